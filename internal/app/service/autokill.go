@@ -0,0 +1,182 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+)
+
+// KillRecord 一次自动关闭连接的记录
+type KillRecord struct {
+	Time    time.Time `json:"time"`
+	Policy  string    `json:"policy"`
+	Match   string    `json:"match"`
+	ConnID  string    `json:"id"`
+	Host    string    `json:"host,omitempty"`
+	DestIP  string    `json:"destination_ip,omitempty"`
+	Port    string    `json:"destination_port,omitempty"`
+	Network string    `json:"network,omitempty"`
+	Process string    `json:"process,omitempty"`
+	Chain   string    `json:"chain,omitempty"`
+	DryRun  bool      `json:"dry_run,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// Target 连接目标（主机优先，否则为目标 IP）
+func (r KillRecord) Target() string {
+	target := r.Host
+	if target == "" {
+		target = r.DestIP
+	}
+	if r.Port != "" {
+		target += ":" + r.Port
+	}
+	return target
+}
+
+// String 单行描述，供日志/终端输出
+func (r KillRecord) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "[%s] %s", r.Policy, r.Target())
+	if r.Process != "" {
+		fmt.Fprintf(&sb, " 进程=%s", r.Process)
+	}
+	if r.Chain != "" {
+		fmt.Fprintf(&sb, " 链路=%s", r.Chain)
+	}
+	fmt.Fprintf(&sb, "（匹配 %s）", r.Match)
+	switch {
+	case r.Error != "":
+		fmt.Fprintf(&sb, " 关闭失败: %s", r.Error)
+	case r.DryRun:
+		sb.WriteString(" [dry-run]")
+	}
+	return sb.String()
+}
+
+type killPolicy struct {
+	name  string
+	match string
+	query ConnQuery
+}
+
+// maxKillAttempts 关闭失败时对同一连接的最多尝试次数
+const maxKillAttempts = 3
+
+// AutoKiller 按策略自动关闭连接，每次收到连接快照时调用 Evaluate
+type AutoKiller struct {
+	mu       sync.Mutex
+	policies []killPolicy
+	closer   func(id string) error
+	dryRun   bool
+	handled  map[string]bool // 已处理过的连接 ID，避免对同一连接重复关闭
+	failures map[string]int  // 关闭失败的次数，达到上限后不再重试
+}
+
+// NewAutoKiller 编译配置中的策略；closer 为 nil 或 dryRun 时只记录不关闭
+func NewAutoKiller(policies []config.KillPolicy, closer func(id string) error, dryRun bool) (*AutoKiller, error) {
+	k := &AutoKiller{closer: closer, dryRun: dryRun || closer == nil, handled: make(map[string]bool), failures: make(map[string]int)}
+	for i, p := range policies {
+		if p.Disabled {
+			continue
+		}
+		name := strings.TrimSpace(p.Name)
+		if name == "" {
+			name = fmt.Sprintf("policy-%d", i+1)
+		}
+		q, err := ParseConnQuery(p.Match)
+		if err != nil {
+			return nil, fmt.Errorf("策略 %s 的匹配表达式无效: %w", name, err)
+		}
+		if q.Empty() {
+			return nil, fmt.Errorf("策略 %s 的匹配表达式为空", name)
+		}
+		k.policies = append(k.policies, killPolicy{name: name, match: p.Match, query: q})
+	}
+	return k, nil
+}
+
+// Empty 是否没有启用的策略
+func (k *AutoKiller) Empty() bool {
+	return k == nil || len(k.policies) == 0
+}
+
+// PolicyNames 已启用的策略名称
+func (k *AutoKiller) PolicyNames() []string {
+	if k == nil {
+		return nil
+	}
+	names := make([]string, 0, len(k.policies))
+	for _, p := range k.policies {
+		names = append(names, p.name)
+	}
+	return names
+}
+
+// Evaluate 对一次连接快照逐个匹配策略（命中第一条即止），关闭命中的连接并返回记录
+func (k *AutoKiller) Evaluate(conns []model.Connection, now time.Time) []KillRecord {
+	if k.Empty() {
+		return nil
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	alive := make(map[string]bool, len(conns))
+	var records []KillRecord
+	for _, conn := range conns {
+		alive[conn.ID] = true
+		if k.handled[conn.ID] {
+			continue
+		}
+		for _, p := range k.policies {
+			if !p.query.MatchAt(conn, now) {
+				continue
+			}
+			record := newKillRecord(p, conn, now)
+			record.DryRun = k.dryRun
+			k.handled[conn.ID] = true
+			if !k.dryRun {
+				if err := k.closer(conn.ID); err != nil {
+					// 临时错误（如 API 超时）在下次快照时重试
+					record.Error = err.Error()
+					k.failures[conn.ID]++
+					k.handled[conn.ID] = k.failures[conn.ID] >= maxKillAttempts
+				}
+			}
+			records = append(records, record)
+			break
+		}
+	}
+
+	// 已消失的连接不再需要去重
+	for id := range k.handled {
+		if !alive[id] {
+			delete(k.handled, id)
+		}
+	}
+	for id := range k.failures {
+		if !alive[id] {
+			delete(k.failures, id)
+		}
+	}
+	return records
+}
+
+func newKillRecord(p killPolicy, conn model.Connection, now time.Time) KillRecord {
+	return KillRecord{
+		Time:    now,
+		Policy:  p.name,
+		Match:   p.match,
+		ConnID:  conn.ID,
+		Host:    conn.Metadata.Host,
+		DestIP:  conn.Metadata.DestinationIP,
+		Port:    conn.Metadata.DestinationPort,
+		Network: conn.Metadata.Network,
+		Process: conn.Metadata.Process,
+		Chain:   ConnectionChain(conn),
+	}
+}
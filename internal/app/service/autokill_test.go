@@ -0,0 +1,107 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func autoKillConns(now time.Time) []model.Connection {
+	return []model.Connection{
+		{ID: "a", Chains: []string{"DIRECT"}, Start: now.Add(-time.Minute).Format(time.RFC3339),
+			Metadata: model.Metadata{Network: "tcp", Host: "example.com", DestinationPort: "443", Process: "foo"}},
+		{ID: "b", Chains: []string{"HK 01", "Proxy"}, Start: now.Add(-20 * time.Minute).Format(time.RFC3339),
+			Metadata: model.Metadata{Network: "udp", DestinationIP: "1.2.3.4", DestinationPort: "443", Process: "chrome"}},
+		{ID: "c", Chains: []string{"HK 01", "Proxy"}, Start: now.Add(-time.Minute).Format(time.RFC3339),
+			Metadata: model.Metadata{Network: "udp", DestinationIP: "1.2.3.5", DestinationPort: "443", Process: "chrome"}},
+	}
+}
+
+func TestAutoKillerEvaluate(t *testing.T) {
+	var closed []string
+	closer := func(id string) error {
+		closed = append(closed, id)
+		return nil
+	}
+	killer, err := NewAutoKiller([]config.KillPolicy{
+		{Name: "foo-direct", Match: "process:foo chain:DIRECT"},
+		{Name: "stale-quic", Match: "net:udp dport:443 age>10m"},
+		{Name: "off", Match: "process:chrome", Disabled: true},
+	}, closer, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo-direct", "stale-quic"}, killer.PolicyNames())
+
+	now := time.Now()
+	records := killer.Evaluate(autoKillConns(now), now)
+	require.Len(t, records, 2)
+	assert.Equal(t, []string{"a", "b"}, closed)
+	assert.Equal(t, "foo-direct", records[0].Policy)
+	assert.Equal(t, "stale-quic", records[1].Policy)
+	assert.Equal(t, "1.2.3.4:443", records[1].Target())
+	assert.Equal(t, "Proxy", records[1].Chain)
+	assert.Contains(t, records[1].String(), "net:udp dport:443 age>10m")
+
+	// 同一连接在下一次快照中不会被重复关闭
+	assert.Empty(t, killer.Evaluate(autoKillConns(now), now))
+	assert.Len(t, closed, 2)
+}
+
+func TestAutoKillerForgetsVanishedConnections(t *testing.T) {
+	closes := 0
+	killer, err := NewAutoKiller([]config.KillPolicy{{Name: "foo", Match: "process:foo"}},
+		func(string) error { closes++; return nil }, false)
+	require.NoError(t, err)
+
+	now := time.Now()
+	conns := autoKillConns(now)
+	killer.Evaluate(conns, now)
+	killer.Evaluate(conns[1:], now)
+	// 相同 ID 的连接重新出现时（关闭失败或 ID 复用）会再次处理
+	killer.Evaluate(conns, now)
+	assert.Equal(t, 2, closes)
+}
+
+func TestAutoKillerDryRunAndErrors(t *testing.T) {
+	now := time.Now()
+
+	dry, err := NewAutoKiller([]config.KillPolicy{{Match: "process:foo"}}, func(string) error {
+		t.Fatal("dry-run 不应关闭连接")
+		return nil
+	}, true)
+	require.NoError(t, err)
+	records := dry.Evaluate(autoKillConns(now), now)
+	require.Len(t, records, 1)
+	assert.True(t, records[0].DryRun)
+	assert.Equal(t, "policy-1", records[0].Policy)
+
+	attempts := 0
+	failing, err := NewAutoKiller([]config.KillPolicy{{Name: "foo", Match: "process:foo"}},
+		func(string) error { attempts++; return errors.New("boom") }, false)
+	require.NoError(t, err)
+	records = failing.Evaluate(autoKillConns(now), now)
+	require.Len(t, records, 1)
+	assert.Equal(t, "boom", records[0].Error)
+
+	// 关闭失败时在之后的快照中重试，达到上限后放弃
+	for range maxKillAttempts + 2 {
+		failing.Evaluate(autoKillConns(now), now)
+	}
+	assert.Equal(t, maxKillAttempts, attempts)
+}
+
+func TestNewAutoKillerRejectsInvalidPolicies(t *testing.T) {
+	_, err := NewAutoKiller([]config.KillPolicy{{Name: "bad", Match: "hots:x"}}, nil, false)
+	assert.ErrorContains(t, err, "bad")
+
+	_, err = NewAutoKiller([]config.KillPolicy{{Name: "empty", Match: "  "}}, nil, false)
+	assert.Error(t, err)
+
+	killer, err := NewAutoKiller(nil, nil, false)
+	require.NoError(t, err)
+	assert.True(t, killer.Empty())
+}
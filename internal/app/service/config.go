@@ -82,9 +82,24 @@ func (s *ConfigService) SetConfigValue(key, value string) error {
 		cfg.Timeout = timeout
 	case "proxy_address", "proxy-address":
 		cfg.ProxyAddress = value
+	case "test_concurrency", "test-concurrency":
+		var concurrency int
+		if _, err := fmt.Sscanf(value, "%d", &concurrency); err != nil || concurrency <= 0 {
+			return fmt.Errorf("test_concurrency 必须是正整数: %s", value)
+		}
+		cfg.TestConcurrency = concurrency
 	default:
-		return fmt.Errorf("未知的配置项: %s (可用: api_address, secret, test_url, timeout, proxy_address)", key)
+		return fmt.Errorf("未知的配置项: %s (可用: api_address, secret, test_url, timeout, proxy_address, test_concurrency)", key)
 	}
 
 	return config.Save(cfg)
 }
+
+// SetConnectionLayout 保存连接表的列布局与排序
+func (s *ConfigService) SetConnectionLayout(columns []string, sort string) error {
+	return config.Update(func(cfg *config.Config) error {
+		cfg.ConnectionColumns = columns
+		cfg.ConnectionSort = sort
+		return nil
+	})
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+)
+
+// ConnExportFormat 连接导出格式
+type ConnExportFormat string
+
+const (
+	ConnExportCSV   ConnExportFormat = "csv"
+	ConnExportJSONL ConnExportFormat = "jsonl"
+)
+
+// ParseConnExportFormat 解析导出格式
+func ParseConnExportFormat(s string) (ConnExportFormat, error) {
+	switch f := ConnExportFormat(strings.ToLower(strings.TrimSpace(s))); f {
+	case ConnExportCSV, ConnExportJSONL:
+		return f, nil
+	case "ndjson":
+		return ConnExportJSONL, nil
+	}
+	return "", fmt.Errorf("不支持的导出格式 %q（可用: csv, jsonl）", s)
+}
+
+// Ext 导出文件扩展名
+func (f ConnExportFormat) Ext() string {
+	return "." + string(f)
+}
+
+// ConnExportRecord 导出的一条连接记录
+type ConnExportRecord struct {
+	ID            string   `json:"id"`
+	State         string   `json:"state"` // active / closed
+	Start         string   `json:"start,omitempty"`
+	ClosedAt      string   `json:"closed_at,omitempty"`
+	DurationSec   float64  `json:"duration_seconds"`
+	Network       string   `json:"network"`
+	Type          string   `json:"type"`
+	Source        string   `json:"source"`
+	Destination   string   `json:"destination"`
+	Host          string   `json:"host,omitempty"`
+	SniffHost     string   `json:"sniff_host,omitempty"`
+	Process       string   `json:"process,omitempty"`
+	ProcessPath   string   `json:"process_path,omitempty"`
+	UID           int      `json:"uid,omitempty"`
+	Inbound       string   `json:"inbound,omitempty"`
+	InboundUser   string   `json:"inbound_user,omitempty"`
+	Rule          string   `json:"rule"`
+	RulePayload   string   `json:"rule_payload,omitempty"`
+	Chains        []string `json:"chains"` // 策略组 → 出口节点
+	Upload        int64    `json:"upload"`
+	Download      int64    `json:"download"`
+	UploadSpeed   int64    `json:"upload_speed"`
+	DownloadSpeed int64    `json:"download_speed"`
+}
+
+// connExportColumns CSV 表头，顺序与 csvRow 一致
+var connExportColumns = []string{
+	"id", "state", "start", "closed_at", "duration_seconds",
+	"network", "type", "source", "destination", "host", "sniff_host",
+	"process", "process_path", "uid", "inbound", "inbound_user",
+	"rule", "rule_payload", "chains",
+	"upload", "download", "upload_speed", "download_speed",
+}
+
+// NewConnExportRecord 由连接生成导出记录，closedAt 为零值表示仍活跃
+func NewConnExportRecord(c model.Connection, closedAt, now time.Time) ConnExportRecord {
+	md := c.Metadata
+	chains := make([]string, len(c.Chains))
+	for i, name := range c.Chains {
+		chains[len(c.Chains)-1-i] = name
+	}
+
+	r := ConnExportRecord{
+		ID:            c.ID,
+		State:         "active",
+		Network:       md.Network,
+		Type:          md.Type,
+		Source:        joinEndpoint(md.SourceIP, md.SourcePort),
+		Destination:   joinEndpoint(md.DestinationIP, md.DestinationPort),
+		Host:          md.Host,
+		SniffHost:     md.SniffHost,
+		Process:       md.Process,
+		ProcessPath:   md.ProcessPath,
+		UID:           md.UID,
+		Inbound:       md.InboundName,
+		InboundUser:   md.InboundUser,
+		Rule:          c.Rule,
+		RulePayload:   c.RulePayload,
+		Chains:        chains,
+		Upload:        c.Upload,
+		Download:      c.Download,
+		UploadSpeed:   c.UploadSpeed,
+		DownloadSpeed: c.DownloadSpeed,
+	}
+
+	end := now
+	if !closedAt.IsZero() {
+		r.State = "closed"
+		r.ClosedAt = closedAt.Format(time.RFC3339)
+		r.UploadSpeed, r.DownloadSpeed = 0, 0
+		end = closedAt
+	}
+	if start, err := time.Parse(time.RFC3339Nano, c.Start); err == nil {
+		r.Start = start.Format(time.RFC3339)
+		r.DurationSec = max(0, end.Sub(start).Round(time.Millisecond).Seconds())
+	} else {
+		r.Start = c.Start
+	}
+	return r
+}
+
+func (r ConnExportRecord) csvRow() []string {
+	uid := ""
+	if r.UID > 0 {
+		uid = strconv.Itoa(r.UID)
+	}
+	return []string{
+		r.ID, r.State, r.Start, r.ClosedAt, strconv.FormatFloat(r.DurationSec, 'f', -1, 64),
+		r.Network, r.Type, r.Source, r.Destination, r.Host, r.SniffHost,
+		r.Process, r.ProcessPath, uid, r.Inbound, r.InboundUser,
+		r.Rule, r.RulePayload, strings.Join(r.Chains, " > "),
+		strconv.FormatInt(r.Upload, 10), strconv.FormatInt(r.Download, 10),
+		strconv.FormatInt(r.UploadSpeed, 10), strconv.FormatInt(r.DownloadSpeed, 10),
+	}
+}
+
+// WriteConnExport 按指定格式写出连接记录
+func WriteConnExport(w io.Writer, records []ConnExportRecord, format ConnExportFormat) error {
+	switch format {
+	case ConnExportCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(connExportColumns); err != nil {
+			return err
+		}
+		for _, r := range records {
+			if err := cw.Write(r.csvRow()); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case ConnExportJSONL:
+		enc := json.NewEncoder(w)
+		for _, r := range records {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("不支持的导出格式: %s", format)
+}
+
+// ConnRecorder 跟踪连接推送，记录期间关闭的连接（CLI 没有 TUI 的历史记录）
+type ConnRecorder struct {
+	active   map[string]model.Connection
+	order    []string
+	closed   []model.Connection
+	closedAt []time.Time
+}
+
+// NewConnRecorder 创建连接记录器
+func NewConnRecorder() *ConnRecorder {
+	return &ConnRecorder{}
+}
+
+// Observe 处理一次连接快照，上一快照中消失的连接记为已关闭
+func (r *ConnRecorder) Observe(conns []model.Connection, now time.Time) {
+	current := make(map[string]model.Connection, len(conns))
+	order := make([]string, 0, len(conns))
+	for _, c := range conns {
+		current[c.ID] = c
+		order = append(order, c.ID)
+	}
+	for _, id := range r.order {
+		if _, ok := current[id]; !ok {
+			r.closed = append(r.closed, r.active[id])
+			r.closedAt = append(r.closedAt, now)
+		}
+	}
+	r.active, r.order = current, order
+}
+
+// Records 最近一次快照中的活跃连接在前，期间关闭的连接按关闭时间在后，均按表达式过滤
+func (r *ConnRecorder) Records(q ConnQuery, now time.Time) []ConnExportRecord {
+	var records []ConnExportRecord
+	for _, id := range r.order {
+		if c := r.active[id]; q.MatchAt(c, now) {
+			records = append(records, NewConnExportRecord(c, time.Time{}, now))
+		}
+	}
+	for i, c := range r.closed {
+		if q.MatchAt(c, now) {
+			records = append(records, NewConnExportRecord(c, r.closedAt[i], now))
+		}
+	}
+	return records
+}
+
+// ClosedCount 记录期间关闭的连接数
+func (r *ConnRecorder) ClosedCount() int {
+	return len(r.closed)
+}
+
+func joinEndpoint(ip, port string) string {
+	if ip == "" {
+		return ""
+	}
+	if port == "" {
+		return ip
+	}
+	return net.JoinHostPort(ip, port)
+}
+
+// DefaultConnExportDir TUI 导出文件的默认目录（~/.mihosh/exports）
+func DefaultConnExportDir() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("获取配置目录失败: %w", err)
+	}
+	return filepath.Join(dir, "exports"), nil
+}
+
+// ExportConnectionsToFile 将记录写入 dir 下以时间命名的文件，返回文件路径
+func ExportConnectionsToFile(dir string, records []ConnExportRecord, format ConnExportFormat, now time.Time) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("创建导出目录失败: %w", err)
+	}
+	path := filepath.Join(dir, "connections-"+now.Format("20060102-150405")+format.Ext())
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("创建导出文件失败: %w", err)
+	}
+	if err := WriteConnExport(f, records, format); err != nil {
+		f.Close()
+		return "", fmt.Errorf("写入导出数据失败: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("写入导出数据失败: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConnExportFormat(t *testing.T) {
+	f, err := ParseConnExportFormat("CSV")
+	require.NoError(t, err)
+	assert.Equal(t, ConnExportCSV, f)
+
+	f, err = ParseConnExportFormat("ndjson")
+	require.NoError(t, err)
+	assert.Equal(t, ConnExportJSONL, f)
+
+	_, err = ParseConnExportFormat("xml")
+	assert.Error(t, err)
+}
+
+func TestNewConnExportRecord(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC)
+	conn := model.Connection{
+		ID:          "a",
+		Start:       "2024-01-01T00:00:00.5Z",
+		Chains:      []string{"HK 01", "Proxy"},
+		Rule:        "DomainSuffix",
+		RulePayload: "google.com",
+		UploadSpeed: 10,
+		Metadata:    model.Metadata{SourceIP: "::1", SourcePort: "5000", DestinationIP: "1.1.1.1", DestinationPort: "443", ProcessPath: "/usr/bin/curl"},
+	}
+
+	active := NewConnExportRecord(conn, time.Time{}, now)
+	assert.Equal(t, "active", active.State)
+	assert.Equal(t, []string{"Proxy", "HK 01"}, active.Chains)
+	assert.Equal(t, "[::1]:5000", active.Source)
+	assert.Equal(t, 59.5, active.DurationSec)
+	assert.Equal(t, int64(10), active.UploadSpeed)
+
+	closed := NewConnExportRecord(conn, now.Add(-30*time.Second), now)
+	assert.Equal(t, "closed", closed.State)
+	assert.Equal(t, "2024-01-01T00:00:30Z", closed.ClosedAt)
+	assert.Equal(t, 29.5, closed.DurationSec)
+	assert.Zero(t, closed.UploadSpeed)
+}
+
+func TestWriteConnExport(t *testing.T) {
+	records := []ConnExportRecord{{ID: "a", State: "active", Rule: "MATCH", Chains: []string{"Proxy", "HK, 01"}, Download: 42}}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteConnExport(&buf, records, ConnExportCSV))
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	require.Len(t, rows[1], len(connExportColumns))
+	assert.Equal(t, "Proxy > HK, 01", rows[1][18])
+	assert.Equal(t, "42", rows[1][20])
+
+	buf.Reset()
+	require.NoError(t, WriteConnExport(&buf, append(records, records...), ConnExportJSONL))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var decoded ConnExportRecord
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &decoded))
+	assert.Equal(t, records[0], decoded)
+}
+
+func TestConnRecorder(t *testing.T) {
+	t0 := time.Now()
+	r := NewConnRecorder()
+	r.Observe([]model.Connection{{ID: "a", Metadata: model.Metadata{Host: "a.com"}}, {ID: "b", Metadata: model.Metadata{Host: "b.com"}}}, t0)
+	r.Observe([]model.Connection{{ID: "b", Metadata: model.Metadata{Host: "b.com"}}, {ID: "c", Metadata: model.Metadata{Host: "c.org"}}}, t0.Add(time.Second))
+	assert.Equal(t, 1, r.ClosedCount())
+
+	records := r.Records(ConnQuery{}, t0.Add(2*time.Second))
+	require.Len(t, records, 3)
+	assert.Equal(t, []string{"b", "c", "a"}, []string{records[0].ID, records[1].ID, records[2].ID})
+	assert.Equal(t, "closed", records[2].State)
+
+	q, err := ParseConnQuery("host:.com")
+	require.NoError(t, err)
+	assert.Len(t, r.Records(q, t0), 2)
+}
+
+func TestExportConnectionsToFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "exports")
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.Local)
+
+	path, err := ExportConnectionsToFile(dir, []ConnExportRecord{{ID: "a"}}, ConnExportJSONL, now)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "connections-20240506-070809.jsonl"), path)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"id":"a"`)
+}
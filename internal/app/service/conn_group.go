@@ -0,0 +1,178 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+)
+
+// ConnGroupKey 连接聚合维度
+type ConnGroupKey string
+
+const (
+	GroupByProcess ConnGroupKey = "process"
+	GroupByHost    ConnGroupKey = "host"
+	GroupByDomain  ConnGroupKey = "domain"
+	GroupByNode    ConnGroupKey = "node"
+	GroupByRule    ConnGroupKey = "rule"
+	GroupBySource  ConnGroupKey = "src"
+)
+
+// ConnGroupKeys 全部聚合维度（按切换顺序）
+var ConnGroupKeys = []ConnGroupKey{GroupByProcess, GroupByHost, GroupByDomain, GroupByNode, GroupByRule, GroupBySource}
+
+// Label 聚合维度的中文名称
+func (k ConnGroupKey) Label() string {
+	switch k {
+	case GroupByProcess:
+		return "进程"
+	case GroupByHost:
+		return "目标主机"
+	case GroupByDomain:
+		return "主域名"
+	case GroupByNode:
+		return "出口节点"
+	case GroupByRule:
+		return "规则"
+	case GroupBySource:
+		return "源 IP"
+	}
+	return string(k)
+}
+
+// ParseConnGroupKey 解析聚合维度
+func ParseConnGroupKey(s string) (ConnGroupKey, error) {
+	key := ConnGroupKey(strings.ToLower(strings.TrimSpace(s)))
+	for _, k := range ConnGroupKeys {
+		if k == key {
+			return k, nil
+		}
+	}
+	names := make([]string, 0, len(ConnGroupKeys))
+	for _, k := range ConnGroupKeys {
+		names = append(names, string(k))
+	}
+	return "", fmt.Errorf("未知的聚合维度 %q（可用: %s）", s, strings.Join(names, ", "))
+}
+
+// Value 连接在该维度下的分组值，缺失时返回占位文本
+func (k ConnGroupKey) Value(c model.Connection) string {
+	var v string
+	switch k {
+	case GroupByProcess:
+		v = firstNonEmptyString(c.Metadata.Process, c.Metadata.ProcessPath)
+	case GroupByHost:
+		v = firstNonEmptyString(c.Metadata.Host, c.Metadata.SniffHost, c.Metadata.DestinationIP)
+	case GroupByDomain:
+		v = RegistrableDomain(firstNonEmptyString(c.Metadata.Host, c.Metadata.SniffHost, c.Metadata.DestinationIP))
+	case GroupByNode:
+		v = "DIRECT"
+		if len(c.Chains) > 0 {
+			v = c.Chains[0]
+		}
+	case GroupByRule:
+		v = c.Rule
+		if c.RulePayload != "" {
+			v += ":" + c.RulePayload
+		}
+	case GroupBySource:
+		v = c.Metadata.SourceIP
+	}
+	if v == "" {
+		return "(未知)"
+	}
+	return v
+}
+
+// ConnGroup 按同一分组值聚合的连接
+type ConnGroup struct {
+	Key           string
+	Connections   []model.Connection
+	Upload        int64
+	Download      int64
+	UploadSpeed   int64
+	DownloadSpeed int64
+}
+
+// Count 连接数
+func (g ConnGroup) Count() int {
+	return len(g.Connections)
+}
+
+// TotalBytes 上下行总流量
+func (g ConnGroup) TotalBytes() int64 {
+	return g.Upload + g.Download
+}
+
+// GroupConnections 按维度聚合连接，组内保持原顺序，组间按总流量从大到小、再按名称排序
+func GroupConnections(conns []model.Connection, by ConnGroupKey) []ConnGroup {
+	index := make(map[string]int)
+	var groups []ConnGroup
+	for _, c := range conns {
+		key := by.Value(c)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, ConnGroup{Key: key})
+		}
+		g := &groups[i]
+		g.Connections = append(g.Connections, c)
+		g.Upload += c.Upload
+		g.Download += c.Download
+		g.UploadSpeed += c.UploadSpeed
+		g.DownloadSpeed += c.DownloadSpeed
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].TotalBytes() != groups[j].TotalBytes() {
+			return groups[i].TotalBytes() > groups[j].TotalBytes()
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups
+}
+
+// multiLabelSuffixes 常见的二级公共后缀（未引入完整的公共后缀列表，按需补充）
+var multiLabelSuffixes = map[string]bool{
+	"co.uk": true, "org.uk": true, "ac.uk": true, "gov.uk": true,
+	"com.cn": true, "net.cn": true, "org.cn": true, "gov.cn": true, "edu.cn": true,
+	"com.hk": true, "net.hk": true, "org.hk": true,
+	"com.tw": true, "net.tw": true, "org.tw": true,
+	"co.jp": true, "ne.jp": true, "or.jp": true, "ac.jp": true,
+	"co.kr": true, "or.kr": true,
+	"com.au": true, "net.au": true, "org.au": true,
+	"com.sg": true, "com.br": true, "com.mx": true, "co.in": true, "co.nz": true,
+	"github.io": true, "cloudfront.net": true, "amazonaws.com": true, "herokuapp.com": true,
+}
+
+// RegistrableDomain 返回主机名的可注册域名（eTLD+1），IP 地址原样返回
+func RegistrableDomain(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	if host == "" || net.ParseIP(host) != nil {
+		return host
+	}
+	labels := strings.Split(host, ".")
+	if len(labels) <= 2 {
+		return host
+	}
+	n := 2
+	if multiLabelSuffixes[strings.Join(labels[len(labels)-2:], ".")] {
+		n = 3
+	}
+	if len(labels) <= n {
+		return host
+	}
+	return strings.Join(labels[len(labels)-n:], ".")
+}
+
+func firstNonEmptyString(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupConnections(t *testing.T) {
+	conns := []model.Connection{
+		{ID: "1", Download: 100, DownloadSpeed: 10, Chains: []string{"HK 01", "Proxy"}, Metadata: model.Metadata{Process: "chrome", Host: "www.google.com"}},
+		{ID: "2", Download: 500, Upload: 50, UploadSpeed: 5, Chains: []string{"DIRECT"}, Metadata: model.Metadata{Process: "curl", Host: "mail.google.com"}},
+		{ID: "3", Download: 300, DownloadSpeed: 20, Chains: []string{"HK 01", "Proxy"}, Metadata: model.Metadata{Process: "chrome", Host: "github.com"}},
+		{ID: "4", Metadata: model.Metadata{DestinationIP: "1.1.1.1"}},
+	}
+
+	groups := GroupConnections(conns, GroupByProcess)
+	require.Len(t, groups, 3)
+	assert.Equal(t, "curl", groups[0].Key) // 550 字节排第一
+	assert.Equal(t, "chrome", groups[1].Key)
+	assert.Equal(t, 2, groups[1].Count())
+	assert.Equal(t, int64(400), groups[1].Download)
+	assert.Equal(t, int64(30), groups[1].DownloadSpeed)
+	assert.Equal(t, []string{"1", "3"}, []string{groups[1].Connections[0].ID, groups[1].Connections[1].ID})
+	assert.Equal(t, "(未知)", groups[2].Key)
+
+	domains := GroupConnections(conns, GroupByDomain)
+	require.Len(t, domains, 3)
+	assert.Equal(t, "google.com", domains[0].Key)
+	assert.Equal(t, 2, domains[0].Count())
+
+	nodes := GroupConnections(conns, GroupByNode)
+	assert.Equal(t, "DIRECT", nodes[0].Key)
+	assert.Equal(t, 2, nodes[1].Count())
+}
+
+func TestRegistrableDomain(t *testing.T) {
+	tests := map[string]string{
+		"www.google.com":        "google.com",
+		"a.b.c.example.org.":    "example.org",
+		"news.bbc.co.uk":        "bbc.co.uk",
+		"co.uk":                 "co.uk",
+		"user.github.io":        "user.github.io",
+		"localhost":             "localhost",
+		"142.250.1.1":           "142.250.1.1",
+		"2001:db8::1":           "2001:db8::1",
+		"static.Example.COM.cn": "example.com.cn",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, RegistrableDomain(in), in)
+	}
+}
+
+func TestParseConnGroupKey(t *testing.T) {
+	key, err := ParseConnGroupKey(" Domain ")
+	require.NoError(t, err)
+	assert.Equal(t, GroupByDomain, key)
+	assert.Equal(t, "主域名", key.Label())
+
+	_, err = ParseConnGroupKey("country")
+	assert.ErrorContains(t, err, "未知的聚合维度")
+}
@@ -0,0 +1,663 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+)
+
+// ConnQuery 连接过滤表达式（TUI 过滤栏与 mihosh connections --filter 共用）
+//
+// 语法示例：
+//
+//	host:google net:udp dport:443 -chain:DIRECT
+//	process:chrome OR process:firefox
+//	(rule:GeoIP OR rule:Match) AND down>10MB age>5m
+//	host:/^api\./  host~"(a|b)\.com"
+//
+// 以空格分隔的条件之间为 AND，可用 AND/OR/NOT（或 && || !）与括号组合，
+// 在条件前加 - 或 ! 表示取反。未带字段的词按主机/规则/目标 IP/代理链子串匹配，
+// /.../ 包裹的值按正则（不区分大小写）匹配，含空格或括号的值需加引号。
+type ConnQuery struct {
+	raw  string
+	expr connExpr
+}
+
+type connExpr interface {
+	match(c model.Connection, now time.Time) bool
+}
+
+// connFieldKind 字段值类型
+type connFieldKind int
+
+const (
+	connFieldText connFieldKind = iota
+	connFieldInt
+	connFieldBytes
+	connFieldAge
+)
+
+type connField struct {
+	kind connFieldKind
+	text func(c model.Connection) []string
+	num  func(c model.Connection, now time.Time) (float64, bool)
+	geo  bool // 依赖目标 IP 的地理位置补全
+}
+
+// connFields 规范字段名 -> 取值方式
+var connFields = map[string]connField{
+	"host":    {kind: connFieldText, text: func(c model.Connection) []string { return []string{c.Metadata.Host, c.Metadata.SniffHost} }},
+	"process": {kind: connFieldText, text: func(c model.Connection) []string { return []string{c.Metadata.Process, c.Metadata.ProcessPath} }},
+	"chain":   {kind: connFieldText, text: func(c model.Connection) []string { return c.Chains }},
+	"rule":    {kind: connFieldText, text: func(c model.Connection) []string { return []string{c.Rule, c.RulePayload} }},
+	"net":     {kind: connFieldText, text: func(c model.Connection) []string { return []string{c.Metadata.Network} }},
+	"type":    {kind: connFieldText, text: func(c model.Connection) []string { return []string{c.Metadata.Type} }},
+	"src":     {kind: connFieldText, text: func(c model.Connection) []string { return []string{c.Metadata.SourceIP} }},
+	"dst":     {kind: connFieldText, text: connDestinations},
+	"inbound": {kind: connFieldText, text: func(c model.Connection) []string { return []string{c.Metadata.InboundName} }},
+	"user":    {kind: connFieldText, text: func(c model.Connection) []string { return []string{c.Metadata.InboundUser} }},
+	"sniff":   {kind: connFieldText, text: func(c model.Connection) []string { return []string{c.Metadata.SniffHost} }},
+	"country": {kind: connFieldText, text: connCountry, geo: true},
+	"asn":     {kind: connFieldText, text: connASN, geo: true},
+	"dport":   {kind: connFieldInt, num: portValue(func(c model.Connection) string { return c.Metadata.DestinationPort })},
+	"sport":   {kind: connFieldInt, num: portValue(func(c model.Connection) string { return c.Metadata.SourcePort })},
+	"uid":     {kind: connFieldInt, num: func(c model.Connection, _ time.Time) (float64, bool) { return float64(c.Metadata.UID), true }},
+	"up":      {kind: connFieldBytes, num: func(c model.Connection, _ time.Time) (float64, bool) { return float64(c.Upload), true }},
+	"down":    {kind: connFieldBytes, num: func(c model.Connection, _ time.Time) (float64, bool) { return float64(c.Download), true }},
+	"total":   {kind: connFieldBytes, num: func(c model.Connection, _ time.Time) (float64, bool) { return float64(c.Upload + c.Download), true }},
+	"age":     {kind: connFieldAge, num: connAge},
+}
+
+// connFieldAliases 字段别名 -> 规范字段名
+var connFieldAliases = map[string]string{
+	"domain":  "host",
+	"proc":    "process",
+	"network": "net",
+	"source":  "src",
+	"dest":    "dst",
+	"ip":      "dst",
+	"port":    "dport",
+	"geoip":   "country",
+}
+
+// connDefaultFields 未指定字段时匹配的字段
+var connDefaultFields = []func(c model.Connection) []string{
+	func(c model.Connection) []string { return []string{c.Metadata.Host} },
+	func(c model.Connection) []string { return []string{c.Rule} },
+	func(c model.Connection) []string { return []string{c.Metadata.DestinationIP} },
+	func(c model.Connection) []string { return c.Chains },
+}
+
+// connOperators 按长度优先排列，避免 ">=" 被识别为 ">"
+var connOperators = []string{"!=", ">=", "<=", ":", "=", ">", "<", "~"}
+
+// ParseConnQuery 解析连接过滤表达式
+func ParseConnQuery(raw string) (ConnQuery, error) {
+	tokens, err := tokenizeConnQuery(raw)
+	if err != nil {
+		return ConnQuery{}, err
+	}
+	if len(tokens) == 0 {
+		return ConnQuery{raw: raw}, nil
+	}
+
+	p := &connParser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return ConnQuery{}, err
+	}
+	if p.pos < len(p.tokens) {
+		return ConnQuery{}, fmt.Errorf("多余的 %q", p.tokens[p.pos].text)
+	}
+	return ConnQuery{raw: raw, expr: expr}, nil
+}
+
+// LiteralConnQuery 将整段文本作为子串匹配（表达式无法解析时的回退）
+func LiteralConnQuery(text string) ConnQuery {
+	if strings.TrimSpace(text) == "" {
+		return ConnQuery{raw: text}
+	}
+	return ConnQuery{raw: text, expr: connBare{substringMatcher(strings.ToLower(text))}}
+}
+
+// String 返回原始表达式
+func (q ConnQuery) String() string {
+	return q.raw
+}
+
+// Empty 是否没有任何过滤条件
+func (q ConnQuery) Empty() bool {
+	return q.expr == nil
+}
+
+// UsesGeo 是否包含 country:/asn: 条件（需要先补全目标 IP 的地理位置）
+func (q ConnQuery) UsesGeo() bool {
+	return exprUsesGeo(q.expr)
+}
+
+func exprUsesGeo(e connExpr) bool {
+	switch x := e.(type) {
+	case connAnd:
+		for _, item := range x {
+			if exprUsesGeo(item) {
+				return true
+			}
+		}
+	case connOr:
+		for _, item := range x {
+			if exprUsesGeo(item) {
+				return true
+			}
+		}
+	case connNot:
+		return exprUsesGeo(x.inner)
+	case connTextTerm:
+		return x.geo
+	}
+	return false
+}
+
+// Match 判断连接是否满足表达式
+func (q ConnQuery) Match(c model.Connection) bool {
+	return q.MatchAt(c, time.Now())
+}
+
+// MatchAt 以指定时间计算 age 判断连接是否满足表达式
+func (q ConnQuery) MatchAt(c model.Connection, now time.Time) bool {
+	if q.expr == nil {
+		return true
+	}
+	return q.expr.match(c, now)
+}
+
+// FilterConnections 返回满足表达式的连接（表达式为空时原样返回）
+func FilterConnections(conns []model.Connection, q ConnQuery) []model.Connection {
+	if q.Empty() {
+		return conns
+	}
+	now := time.Now()
+	var filtered []model.Connection
+	for _, c := range conns {
+		if q.MatchAt(c, now) {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+// ---- 词法 ----
+
+type connToken struct {
+	text   string
+	quoted bool // 整个词以引号开头（不再识别为运算符或字段）
+}
+
+func tokenizeConnQuery(raw string) ([]connToken, error) {
+	var (
+		tokens  []connToken
+		sb      strings.Builder
+		inQuote bool
+		quoted  bool
+		started bool
+	)
+	flush := func() {
+		if started {
+			tokens = append(tokens, connToken{text: sb.String(), quoted: quoted})
+		}
+		sb.Reset()
+		quoted, started = false, false
+	}
+
+	runes := []rune(raw)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case inQuote:
+			if r == '"' {
+				inQuote = false
+			} else {
+				sb.WriteRune(r)
+			}
+		case r == '"':
+			if !started {
+				quoted = true
+			}
+			inQuote, started = true, true
+		case r == ' ' || r == '\t':
+			flush()
+		case r == '(' && !started:
+			tokens = append(tokens, connToken{text: "("})
+		case r == ')':
+			flush()
+			tokens = append(tokens, connToken{text: ")"})
+		case (r == '!' || r == '-') && !started && i+1 < len(runes) && runes[i+1] == '(':
+			tokens = append(tokens, connToken{text: "NOT"})
+		default:
+			sb.WriteRune(r)
+			started = true
+		}
+	}
+	if inQuote {
+		return nil, fmt.Errorf("引号未闭合")
+	}
+	flush()
+	return tokens, nil
+}
+
+// ---- 语法 ----
+
+type connParser struct {
+	tokens []connToken
+	pos    int
+}
+
+func (p *connParser) peek() (connToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return connToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *connParser) isKeyword(tok connToken, keywords ...string) bool {
+	if tok.quoted {
+		return false
+	}
+	for _, k := range keywords {
+		if strings.EqualFold(tok.text, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *connParser) parseOr() (connExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	items := []connExpr{left}
+	for {
+		tok, ok := p.peek()
+		if !ok || !p.isKeyword(tok, "OR", "||") {
+			break
+		}
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, right)
+	}
+	if len(items) == 1 {
+		return left, nil
+	}
+	return connOr(items), nil
+}
+
+func (p *connParser) parseAnd() (connExpr, error) {
+	var items []connExpr
+	for {
+		tok, ok := p.peek()
+		if !ok || p.isKeyword(tok, "OR", "||") || (tok.text == ")" && !tok.quoted) {
+			break
+		}
+		if p.isKeyword(tok, "AND", "&&") {
+			p.pos++
+			continue
+		}
+		item, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	switch len(items) {
+	case 0:
+		return nil, fmt.Errorf("缺少过滤条件")
+	case 1:
+		return items[0], nil
+	}
+	return connAnd(items), nil
+}
+
+func (p *connParser) parseUnary() (connExpr, error) {
+	tok, _ := p.peek()
+	if p.isKeyword(tok, "NOT", "!") {
+		p.pos++
+		if _, ok := p.peek(); !ok {
+			return nil, fmt.Errorf("NOT 后缺少条件")
+		}
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return connNot{inner}, nil
+	}
+	if tok.text == "(" && !tok.quoted {
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing, ok := p.peek(); !ok || closing.text != ")" {
+			return nil, fmt.Errorf("缺少 )")
+		}
+		p.pos++
+		return inner, nil
+	}
+	p.pos++
+	return parseConnTerm(tok)
+}
+
+// parseConnTerm 解析单个条件：[-!]field<op>value 或裸词
+func parseConnTerm(tok connToken) (connExpr, error) {
+	text := tok.text
+	if !tok.quoted && len(text) > 1 && (text[0] == '-' || text[0] == '!') {
+		inner, err := parseConnTerm(connToken{text: text[1:]})
+		if err != nil {
+			return nil, err
+		}
+		return connNot{inner}, nil
+	}
+
+	if !tok.quoted {
+		if name, op, value, ok := splitConnTerm(text); ok {
+			canonical := strings.ToLower(name)
+			if alias, isAlias := connFieldAliases[canonical]; isAlias {
+				canonical = alias
+			}
+			field, known := connFields[canonical]
+			if !known {
+				return nil, fmt.Errorf("未知字段 %q", name)
+			}
+			return newConnFieldTerm(canonical, field, op, value)
+		}
+	}
+
+	matcher, err := newTextMatcher(text, tok.quoted)
+	if err != nil {
+		return nil, err
+	}
+	return connBare{matcher}, nil
+}
+
+// splitConnTerm 拆分 "字段 运算符 值"，字段须为纯字母
+func splitConnTerm(text string) (name, op, value string, ok bool) {
+	i := 0
+	for i < len(text) && (text[i] >= 'a' && text[i] <= 'z' || text[i] >= 'A' && text[i] <= 'Z') {
+		i++
+	}
+	if i == 0 || i == len(text) {
+		return "", "", "", false
+	}
+	for _, candidate := range connOperators {
+		if strings.HasPrefix(text[i:], candidate) {
+			return text[:i], candidate, text[i+len(candidate):], true
+		}
+	}
+	return "", "", "", false
+}
+
+func newConnFieldTerm(name string, field connField, op, value string) (connExpr, error) {
+	if value == "" {
+		return nil, fmt.Errorf("%s 缺少值", name)
+	}
+
+	if field.kind == connFieldText {
+		switch op {
+		case ":":
+			if _, cidr, err := net.ParseCIDR(value); err == nil && (name == "src" || name == "dst") {
+				return connTextTerm{get: field.text, m: cidrMatcher{cidr}}, nil
+			}
+			m, err := newTextMatcher(value, false)
+			if err != nil {
+				return nil, err
+			}
+			return connTextTerm{get: field.text, m: m, geo: field.geo}, nil
+		case "=", "!=":
+			var term connExpr = connTextTerm{get: field.text, m: exactMatcher(strings.ToLower(value)), geo: field.geo}
+			if op == "!=" {
+				term = connNot{term}
+			}
+			return term, nil
+		case "~":
+			re, err := regexp.Compile("(?i)" + value)
+			if err != nil {
+				return nil, fmt.Errorf("%s 的正则无效: %w", name, err)
+			}
+			return connTextTerm{get: field.text, m: regexMatcher{re}, geo: field.geo}, nil
+		}
+		return nil, fmt.Errorf("%s 不支持 %s 比较", name, op)
+	}
+
+	if op == "~" {
+		return nil, fmt.Errorf("%s 不支持正则匹配", name)
+	}
+	num, err := parseConnNumber(field.kind, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s 的值无效: %w", name, err)
+	}
+	if op == ":" {
+		op = "="
+	}
+	return connNumTerm{get: field.num, op: op, num: num}, nil
+}
+
+// parseConnNumber 按字段类型解析数值（端口/UID 等整数、10MB 等字节数、5m/2h/1d 等时长）
+func parseConnNumber(kind connFieldKind, value string) (float64, error) {
+	switch kind {
+	case connFieldBytes:
+		return parseByteSize(value)
+	case connFieldAge:
+		if days, ok := strings.CutSuffix(value, "d"); ok {
+			n, err := strconv.ParseFloat(days, 64)
+			if err != nil {
+				return 0, err
+			}
+			return n * float64(24*time.Hour), nil
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, err
+		}
+		return float64(d), nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	return float64(n), nil
+}
+
+// parseByteSize 解析 512、1.5K、10MB、2GiB 等（按 1024 进制）
+func parseByteSize(value string) (float64, error) {
+	upper := strings.ToUpper(value)
+	upper = strings.TrimSuffix(strings.TrimSuffix(upper, "IB"), "B")
+	multiplier := 1.0
+	if upper != "" {
+		switch upper[len(upper)-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		case 'T':
+			multiplier = 1 << 40
+		}
+		if multiplier > 1 {
+			upper = upper[:len(upper)-1]
+		}
+	}
+	n, err := strconv.ParseFloat(upper, 64)
+	if err != nil {
+		return 0, fmt.Errorf("无法识别的大小 %q", value)
+	}
+	return n * multiplier, nil
+}
+
+func portValue(get func(c model.Connection) string) func(c model.Connection, now time.Time) (float64, bool) {
+	return func(c model.Connection, _ time.Time) (float64, bool) {
+		n, err := strconv.Atoi(get(c))
+		return float64(n), err == nil
+	}
+}
+
+func connDestinations(c model.Connection) []string {
+	return []string{c.Metadata.DestinationIP, c.Metadata.RemoteDestination}
+}
+
+// connCountry 目标 IP 的国家代码（只匹配代码，避免 "us" 命中 "Russia"）
+func connCountry(c model.Connection) []string {
+	return []string{c.DestinationGeo().CountryCode}
+}
+
+// connASN 目标 IP 的 ASN：asn:13335、asn=AS13335、asn:cloudflare 均可
+func connASN(c model.Connection) []string {
+	tag := c.DestinationGeo()
+	if tag.ASN == 0 {
+		return []string{tag.ASNOrg}
+	}
+	return []string{strconv.Itoa(tag.ASN), "AS" + strconv.Itoa(tag.ASN), tag.ASNOrg}
+}
+
+func connAge(c model.Connection, now time.Time) (float64, bool) {
+	start, err := time.Parse(time.RFC3339, c.Start)
+	if err != nil {
+		return 0, false
+	}
+	return float64(now.Sub(start)), true
+}
+
+// ---- 求值 ----
+
+type connAnd []connExpr
+
+func (e connAnd) match(c model.Connection, now time.Time) bool {
+	for _, item := range e {
+		if !item.match(c, now) {
+			return false
+		}
+	}
+	return true
+}
+
+type connOr []connExpr
+
+func (e connOr) match(c model.Connection, now time.Time) bool {
+	for _, item := range e {
+		if item.match(c, now) {
+			return true
+		}
+	}
+	return false
+}
+
+type connNot struct{ inner connExpr }
+
+func (e connNot) match(c model.Connection, now time.Time) bool {
+	return !e.inner.match(c, now)
+}
+
+type connBare struct{ m textMatcher }
+
+func (e connBare) match(c model.Connection, _ time.Time) bool {
+	for _, get := range connDefaultFields {
+		if matchAny(get(c), e.m) {
+			return true
+		}
+	}
+	return false
+}
+
+type connTextTerm struct {
+	get func(c model.Connection) []string
+	m   textMatcher
+	geo bool
+}
+
+func (e connTextTerm) match(c model.Connection, _ time.Time) bool {
+	return matchAny(e.get(c), e.m)
+}
+
+type connNumTerm struct {
+	get func(c model.Connection, now time.Time) (float64, bool)
+	op  string
+	num float64
+}
+
+func (e connNumTerm) match(c model.Connection, now time.Time) bool {
+	v, ok := e.get(c, now)
+	if !ok {
+		return false
+	}
+	switch e.op {
+	case ">":
+		return v > e.num
+	case ">=":
+		return v >= e.num
+	case "<":
+		return v < e.num
+	case "<=":
+		return v <= e.num
+	case "!=":
+		return v != e.num
+	}
+	return v == e.num
+}
+
+// textMatcher 文本值匹配方式
+type textMatcher interface {
+	matchText(s string) bool
+}
+
+// newTextMatcher /.../ 为正则，否则为不区分大小写的子串匹配
+func newTextMatcher(value string, quoted bool) (textMatcher, error) {
+	if !quoted && len(value) >= 2 && strings.HasPrefix(value, "/") && strings.HasSuffix(value, "/") {
+		re, err := regexp.Compile("(?i)" + value[1:len(value)-1])
+		if err != nil {
+			return nil, fmt.Errorf("正则无效: %w", err)
+		}
+		return regexMatcher{re}, nil
+	}
+	return substringMatcher(strings.ToLower(value)), nil
+}
+
+type substringMatcher string
+
+func (m substringMatcher) matchText(s string) bool {
+	return strings.Contains(strings.ToLower(s), string(m))
+}
+
+type exactMatcher string
+
+func (m exactMatcher) matchText(s string) bool {
+	return strings.ToLower(s) == string(m)
+}
+
+type regexMatcher struct{ re *regexp.Regexp }
+
+func (m regexMatcher) matchText(s string) bool {
+	return m.re.MatchString(s)
+}
+
+type cidrMatcher struct{ cidr *net.IPNet }
+
+func (m cidrMatcher) matchText(s string) bool {
+	ip := net.ParseIP(s)
+	return ip != nil && m.cidr.Contains(ip)
+}
+
+func matchAny(values []string, m textMatcher) bool {
+	for _, v := range values {
+		if v != "" && m.matchText(v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func queryTestConns(now time.Time) []model.Connection {
+	return []model.Connection{
+		{
+			ID: "1", Upload: 1024, Download: 20 << 20, Start: now.Add(-10 * time.Minute).Format(time.RFC3339),
+			Chains: []string{"JP 01", "Proxy"}, Rule: "DomainSuffix", RulePayload: "google.com",
+			Metadata: model.Metadata{Network: "tcp", Type: "HTTPS", Host: "www.google.com", SourceIP: "192.168.1.10",
+				SourcePort: "51000", DestinationIP: "142.250.1.1", DestinationPort: "443", Process: "chrome"},
+		},
+		{
+			ID: "2", Upload: 10, Download: 2048, Start: now.Add(-30 * time.Second).Format(time.RFC3339),
+			Chains: []string{"DIRECT"}, Rule: "GeoIP", RulePayload: "CN",
+			Metadata: model.Metadata{Network: "udp", Type: "Socks5", Host: "", SourceIP: "192.168.1.20",
+				SourcePort: "53000", DestinationIP: "114.114.114.114", DestinationPort: "53", Process: "systemd-resolved"},
+		},
+		{
+			ID: "3", Upload: 0, Download: 5 << 20, Start: now.Add(-2 * time.Hour).Format(time.RFC3339),
+			Chains: []string{"HK 02", "Proxy"}, Rule: "Match",
+			Metadata: model.Metadata{Network: "tcp", Host: "api.github.com", SourceIP: "10.0.0.5",
+				SourcePort: "40000", DestinationIP: "20.205.243.168", DestinationPort: "443", Process: "firefox"},
+		},
+	}
+}
+
+func matchedIDs(t *testing.T, raw string) []string {
+	t.Helper()
+	now := time.Now()
+	q, err := ParseConnQuery(raw)
+	require.NoError(t, err, raw)
+	var ids []string
+	for _, c := range queryTestConns(now) {
+		if q.MatchAt(c, now) {
+			ids = append(ids, c.ID)
+		}
+	}
+	return ids
+}
+
+func TestConnQueryMatch(t *testing.T) {
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"1", "2", "3"}},
+		{"google", []string{"1"}},
+		{"proxy", []string{"1", "3"}},
+		{"host:github", []string{"3"}},
+		{"process:chrome OR process:firefox", []string{"1", "3"}},
+		{"net:udp", []string{"2"}},
+		{"dport:443", []string{"1", "3"}},
+		{"dport:443 -chain:HK", []string{"1"}},
+		{"!net:udp", []string{"1", "3"}},
+		{"NOT (net:udp OR host:google)", []string{"3"}},
+		{"-(net:udp)", []string{"1", "3"}},
+		{"rule:geoip || rule:match", []string{"2", "3"}},
+		{"chain:proxy AND down>10MB", []string{"1"}},
+		{"down>=5MB down<10MB", []string{"3"}},
+		{"up<1K", []string{"2", "3"}},
+		{"age>5m", []string{"1", "3"}},
+		{"age<1m", []string{"2"}},
+		{"age>1d", nil},
+		{"host:/^api\\./", []string{"3"}},
+		{`host~"(www|api)\.g"`, []string{"1", "3"}},
+		{"rule=match", []string{"3"}},
+		{"rule!=match", []string{"1", "2"}},
+		{"src:192.168.1.0/24", []string{"1", "2"}},
+		{"dst:114.114", []string{"2"}},
+		{"sport>52000", []string{"2"}},
+		{"process:systemd-resolved", []string{"2"}},
+		{`"JP 01"`, []string{"1"}},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, matchedIDs(t, tc.query), tc.query)
+	}
+}
+
+func TestConnQueryParseErrors(t *testing.T) {
+	for _, raw := range []string{
+		"hots:google",
+		"host:",
+		"(net:udp",
+		"net:udp)",
+		`host:"abc`,
+		"host~[",
+		"dport:https",
+		"down>lots",
+		"age>soon",
+		"host>3",
+		"dport~44",
+		"net:udp OR",
+		"NOT",
+	} {
+		_, err := ParseConnQuery(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	for raw, want := range map[string]float64{
+		"512":   512,
+		"1.5K":  1536,
+		"10MB":  10 << 20,
+		"2GiB":  2 << 30,
+		"100kb": 100 << 10,
+	} {
+		got, err := parseByteSize(raw)
+		assert.NoError(t, err, raw)
+		assert.Equal(t, want, got, raw)
+	}
+}
+
+func TestFilterConnections(t *testing.T) {
+	conns := queryTestConns(time.Now())
+
+	q, err := ParseConnQuery("net:tcp")
+	require.NoError(t, err)
+	assert.Len(t, FilterConnections(conns, q), 2)
+
+	empty, err := ParseConnQuery("   ")
+	require.NoError(t, err)
+	assert.True(t, empty.Empty())
+	assert.Len(t, FilterConnections(conns, empty), 3)
+}
+
+func TestConnQueryInboundFields(t *testing.T) {
+	conns := []model.Connection{
+		{ID: "1", Metadata: model.Metadata{InboundName: "DEFAULT-MIXED", InboundUser: "alice", UID: 1000, SniffHost: "video.example.com"}},
+		{ID: "2", Metadata: model.Metadata{InboundName: "tun", UID: 0, Host: "api.example.com"}},
+	}
+	for query, want := range map[string]string{
+		"inbound:mixed":  "1",
+		"user=alice":     "1",
+		"uid:1000":       "1",
+		"uid<1000":       "2",
+		"sniff:video":    "1",
+		"host:video":     "1",
+		"-inbound:mixed": "2",
+	} {
+		q, err := ParseConnQuery(query)
+		require.NoError(t, err, query)
+		filtered := FilterConnections(conns, q)
+		require.Len(t, filtered, 1, query)
+		assert.Equal(t, want, filtered[0].ID, query)
+	}
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/pkg/utils"
+)
+
+// 连接事件类型
+const (
+	ConnEventOpen    = "open"
+	ConnEventClose   = "close"
+	ConnEventSummary = "summary"
+)
+
+// ConnEvent 连接监听事件
+type ConnEvent struct {
+	Event      string            `json:"event"`
+	Time       time.Time         `json:"time"`
+	Connection *ConnExportRecord `json:"connection,omitempty"`
+	Summary    *ConnWatchSummary `json:"summary,omitempty"`
+}
+
+// ConnWatchSummary 周期汇总：当前满足过滤条件的连接与上次汇总以来的新建/关闭数
+type ConnWatchSummary struct {
+	Active        int   `json:"active"`
+	Opened        int   `json:"opened"`
+	Closed        int   `json:"closed"`
+	UploadSpeed   int64 `json:"upload_speed"`
+	DownloadSpeed int64 `json:"download_speed"`
+	UploadTotal   int64 `json:"upload_total"`
+	DownloadTotal int64 `json:"download_total"`
+}
+
+// String 人类可读的事件描述
+func (e ConnEvent) String() string {
+	ts := e.Time.Format("15:04:05")
+	if e.Summary != nil {
+		s := e.Summary
+		return fmt.Sprintf("%s = 活跃 %d | 新建 %d | 关闭 %d | ↑%s/s ↓%s/s | 总计 ↑%s ↓%s",
+			ts, s.Active, s.Opened, s.Closed,
+			utils.FormatBytes(s.UploadSpeed), utils.FormatBytes(s.DownloadSpeed),
+			utils.FormatBytes(s.UploadTotal), utils.FormatBytes(s.DownloadTotal))
+	}
+
+	c := e.Connection
+	target := c.Destination
+	if host := firstNonEmptyString(c.Host, c.SniffHost); host != "" {
+		target = host
+		if _, port, err := net.SplitHostPort(c.Destination); err == nil {
+			target = net.JoinHostPort(host, port)
+		}
+	}
+	chain := "DIRECT"
+	if len(c.Chains) > 0 {
+		chain = strings.Join(c.Chains, " > ")
+	}
+	line := fmt.Sprintf("%s %s %s %s %s [%s] %s", ts, eventMark(e.Event), firstNonEmptyString(c.Process, "-"), c.Network, target, chain, c.Rule)
+	if e.Event == ConnEventClose {
+		line += fmt.Sprintf(" ↑%s ↓%s %.1fs", utils.FormatBytes(c.Upload), utils.FormatBytes(c.Download), c.DurationSec)
+	}
+	return line
+}
+
+func eventMark(event string) string {
+	switch event {
+	case ConnEventOpen:
+		return "+"
+	case ConnEventClose:
+		return "-"
+	}
+	return "="
+}
+
+// ConnWatcher 比较连续的连接快照，产生新建/关闭事件与周期汇总
+type ConnWatcher struct {
+	query   ConnQuery
+	prev    map[string]model.Connection
+	order   []string
+	started bool
+	opened  int
+	closed  int
+	last    model.ConnectionsResponse
+}
+
+// NewConnWatcher 创建连接监听器，只对满足表达式的连接产生事件
+func NewConnWatcher(q ConnQuery) *ConnWatcher {
+	return &ConnWatcher{query: q}
+}
+
+// Observe 处理一次连接快照；首次快照只作为基线，不产生事件
+func (w *ConnWatcher) Observe(data model.ConnectionsResponse, now time.Time) []ConnEvent {
+	current := make(map[string]model.Connection, len(data.Connections))
+	order := make([]string, 0, len(data.Connections))
+	var events []ConnEvent
+	for _, c := range data.Connections {
+		current[c.ID] = c
+		order = append(order, c.ID)
+		if _, seen := w.prev[c.ID]; !seen && w.started && w.query.MatchAt(c, now) {
+			record := NewConnExportRecord(c, time.Time{}, now)
+			events = append(events, ConnEvent{Event: ConnEventOpen, Time: now, Connection: &record})
+			w.opened++
+		}
+	}
+	for _, id := range w.order {
+		c := w.prev[id]
+		if _, alive := current[id]; !alive && w.query.MatchAt(c, now) {
+			record := NewConnExportRecord(c, now, now)
+			events = append(events, ConnEvent{Event: ConnEventClose, Time: now, Connection: &record})
+			w.closed++
+		}
+	}
+
+	w.prev, w.order = current, order
+	w.started = true
+	w.last = data
+	return events
+}
+
+// Summary 生成汇总事件并重置新建/关闭计数
+func (w *ConnWatcher) Summary(now time.Time) ConnEvent {
+	s := ConnWatchSummary{
+		Opened:        w.opened,
+		Closed:        w.closed,
+		UploadTotal:   w.last.UploadTotal,
+		DownloadTotal: w.last.DownloadTotal,
+	}
+	for _, c := range w.last.Connections {
+		if w.query.MatchAt(c, now) {
+			s.Active++
+			s.UploadSpeed += c.UploadSpeed
+			s.DownloadSpeed += c.DownloadSpeed
+		}
+	}
+	w.opened, w.closed = 0, 0
+	return ConnEvent{Event: ConnEventSummary, Time: now, Summary: &s}
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnWatcher(t *testing.T) {
+	q, err := ParseConnQuery("net:tcp")
+	require.NoError(t, err)
+	w := NewConnWatcher(q)
+	t0 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tcp := func(id string) model.Connection {
+		return model.Connection{ID: id, DownloadSpeed: 100, Metadata: model.Metadata{Network: "tcp", Host: id + ".com", DestinationIP: "1.1.1.1", DestinationPort: "443"}}
+	}
+	udp := model.Connection{ID: "u", Metadata: model.Metadata{Network: "udp"}}
+
+	// 首次快照只作为基线
+	assert.Empty(t, w.Observe(model.ConnectionsResponse{Connections: []model.Connection{tcp("a"), udp}}, t0))
+
+	events := w.Observe(model.ConnectionsResponse{Connections: []model.Connection{tcp("b"), tcp("c")}, DownloadTotal: 4096}, t0.Add(time.Second))
+	require.Len(t, events, 3)
+	assert.Equal(t, ConnEventOpen, events[0].Event)
+	assert.Equal(t, "b", events[0].Connection.ID)
+	assert.Equal(t, ConnEventOpen, events[1].Event)
+	assert.Equal(t, ConnEventClose, events[2].Event)
+	assert.Equal(t, "a", events[2].Connection.ID)
+	assert.Equal(t, "closed", events[2].Connection.State)
+	assert.Contains(t, events[0].String(), "+ - tcp b.com:443 [DIRECT]")
+
+	summary := w.Summary(t0.Add(2 * time.Second))
+	require.NotNil(t, summary.Summary)
+	assert.Equal(t, ConnWatchSummary{Active: 2, Opened: 2, Closed: 1, DownloadSpeed: 200, DownloadTotal: 4096}, *summary.Summary)
+	assert.Contains(t, summary.String(), "活跃 2 | 新建 2 | 关闭 1")
+
+	// 汇总后计数归零
+	assert.Zero(t, w.Summary(t0).Summary.Opened)
+}
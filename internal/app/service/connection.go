@@ -1,6 +1,9 @@
 package service
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/aimony/mihosh/internal/domain/model"
 	"github.com/aimony/mihosh/internal/infrastructure/api"
 )
@@ -8,6 +11,7 @@ import (
 // ConnectionService 连接监控服务
 type ConnectionService struct {
 	client *api.Client
+	geo    *GeoCache
 }
 
 // NewConnectionService 创建连接服务
@@ -17,7 +21,81 @@ func NewConnectionService(client *api.Client) *ConnectionService {
 	}
 }
 
+// SetGeoCache 设置地理位置查询，表达式含 country:/asn: 时用于补全连接
+func (s *ConnectionService) SetGeoCache(geo *GeoCache) {
+	s.geo = geo
+}
+
+// ConnectionChain 连接命中的策略组（代理链起点），无代理链时为 DIRECT
+func ConnectionChain(conn model.Connection) string {
+	if len(conn.Chains) > 0 {
+		return conn.Chains[len(conn.Chains)-1]
+	}
+	return "DIRECT"
+}
+
 // GetConnections 获取连接信息
 func (s *ConnectionService) GetConnections() (*model.ConnectionsResponse, error) {
 	return s.client.GetConnections()
 }
+
+// CloseConnection 关闭单个连接
+func (s *ConnectionService) CloseConnection(id string) error {
+	return s.client.CloseConnection(id)
+}
+
+// CloseResult 批量关闭连接的结果
+type CloseResult struct {
+	Matched []model.Connection // 满足条件的连接
+	Closed  int                // 成功关闭的数量
+	Failed  map[string]string  // 关闭失败的连接 ID -> 错误
+}
+
+// CloseMatching 关闭满足表达式的活跃连接，dryRun 时只返回将被关闭的连接
+func (s *ConnectionService) CloseMatching(q ConnQuery, dryRun bool) (CloseResult, error) {
+	if q.Empty() {
+		return CloseResult{}, fmt.Errorf("过滤条件不能为空")
+	}
+	conns, err := s.client.GetConnections()
+	if err != nil {
+		return CloseResult{}, fmt.Errorf("获取连接失败: %w", err)
+	}
+
+	if s.geo != nil && q.UsesGeo() {
+		ctx, cancel := context.WithTimeout(context.Background(), geoAnnotateTimeout)
+		conns.Connections = AnnotateGeo(ctx, s.geo, conns.Connections)
+		cancel()
+	}
+
+	result := CloseResult{Matched: FilterConnections(conns.Connections, q)}
+	if dryRun {
+		return result, nil
+	}
+	result.Closed, result.Failed = s.CloseConnections(connectionIDs(result.Matched))
+	return result, nil
+}
+
+// CloseConnections 逐个关闭指定连接，返回成功数量与失败详情
+func (s *ConnectionService) CloseConnections(ids []string) (int, map[string]string) {
+	closed := 0
+	var failed map[string]string
+	for _, id := range ids {
+		if err := s.client.CloseConnection(id); err != nil {
+			if failed == nil {
+				failed = make(map[string]string)
+			}
+			failed[id] = err.Error()
+			continue
+		}
+		closed++
+	}
+	return closed, failed
+}
+
+func connectionIDs(conns []model.Connection) []string {
+	ids := make([]string, 0, len(conns))
+	for _, c := range conns {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/infrastructure/api"
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const closeTestConnections = `{"connections":[
+	{"id":"a","chains":["HK 01","Proxy"],"metadata":{"network":"tcp","host":"www.google.com","process":"chrome"}},
+	{"id":"b","chains":["DIRECT"],"metadata":{"network":"udp","host":"dns.google","process":"chrome"}},
+	{"id":"c","chains":["JP 01","Proxy"],"metadata":{"network":"tcp","host":"github.com","process":"git"}}
+]}`
+
+func newTestConnectionService(t *testing.T, failID string) (*ConnectionService, *[]string) {
+	t.Helper()
+	var (
+		mu      sync.Mutex
+		deleted []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/connections":
+			w.Write([]byte(closeTestConnections))
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/connections/"):
+			id := strings.TrimPrefix(r.URL.Path, "/connections/")
+			if id == failID {
+				http.Error(w, "boom", http.StatusInternalServerError)
+				return
+			}
+			mu.Lock()
+			deleted = append(deleted, id)
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	client := api.NewClient(&config.Config{APIAddress: srv.URL, Timeout: 5000})
+	return NewConnectionService(client), &deleted
+}
+
+func TestCloseMatching(t *testing.T) {
+	svc, deleted := newTestConnectionService(t, "")
+	q, err := ParseConnQuery("chain:Proxy")
+	require.NoError(t, err)
+
+	result, err := svc.CloseMatching(q, false)
+	require.NoError(t, err)
+	assert.Len(t, result.Matched, 2)
+	assert.Equal(t, 2, result.Closed)
+	assert.Empty(t, result.Failed)
+	assert.ElementsMatch(t, []string{"a", "c"}, *deleted)
+}
+
+func TestCloseMatchingDryRun(t *testing.T) {
+	svc, deleted := newTestConnectionService(t, "")
+	q, err := ParseConnQuery("process:chrome")
+	require.NoError(t, err)
+
+	result, err := svc.CloseMatching(q, true)
+	require.NoError(t, err)
+	assert.Len(t, result.Matched, 2)
+	assert.Zero(t, result.Closed)
+	assert.Empty(t, *deleted, "dry-run 不应关闭连接")
+}
+
+func TestCloseMatchingReportsFailures(t *testing.T) {
+	svc, deleted := newTestConnectionService(t, "b")
+	q, err := ParseConnQuery("process:chrome")
+	require.NoError(t, err)
+
+	result, err := svc.CloseMatching(q, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Closed)
+	assert.Contains(t, result.Failed, "b")
+	assert.Equal(t, []string{"a"}, *deleted)
+}
+
+func TestCloseMatchingRejectsEmptyQuery(t *testing.T) {
+	svc, _ := newTestConnectionService(t, "")
+	_, err := svc.CloseMatching(ConnQuery{}, false)
+	assert.Error(t, err)
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+)
+
+// GlobalGroup mihomo 内置的全局选择器，global 模式下所有流量经由它
+const GlobalGroup = "GLOBAL"
+
+// 泄漏类型
+const (
+	LeakRouting = "routing"
+	LeakDNS     = "dns"
+)
+
+// DNSResolver 远端看到的 DNS 解析器
+type DNSResolver struct {
+	IP          string `json:"ip"`
+	Geo         string `json:"geo,omitempty"`
+	CountryCode string `json:"country_code,omitempty"`
+	ASN         int    `json:"asn,omitempty"`
+	ASNOrg      string `json:"asn_organization,omitempty"`
+}
+
+// EgressLeak 代理出口与直连出口对比发现的问题
+type EgressLeak struct {
+	Kind   string `json:"kind"`
+	Detail string `json:"detail"`
+}
+
+// GetDNSResolver 通过代理访问随机子域名，返回实际为其做解析的 DNS 服务器
+func (s *ProxyService) GetDNSResolver(ctx context.Context, proxyAddr string) (*DNSResolver, error) {
+	httpClient, err := s.client.NewHTTPClientWithProxy(proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+	label := make([]byte, 8)
+	if _, err := rand.Read(label); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(model.DNSEchoURL, hex.EncodeToString(label)), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var echo struct {
+		DNS struct {
+			IP  string `json:"ip"`
+			Geo string `json:"geo"`
+		} `json:"dns"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&echo); err != nil {
+		return nil, err
+	}
+	if echo.DNS.IP == "" {
+		return nil, errors.New("未返回 DNS 解析器信息")
+	}
+
+	resolver := &DNSResolver{IP: echo.DNS.IP, Geo: echo.DNS.Geo}
+	if s.geo != nil {
+		if info, err := s.geo.Lookup(ctx, resolver.IP); err == nil {
+			resolver.CountryCode = info.CountryCode
+			resolver.ASN = info.ASN
+			resolver.ASNOrg = info.ASNOrganization
+		}
+	}
+	return resolver, nil
+}
+
+// WithNode 临时把 GLOBAL 切到指定节点并切换到 global 模式执行 fn，结束后恢复原选择与模式。
+// ctx 取消后不再执行 fn，已做的切换仍会恢复
+func (s *ProxyService) WithNode(ctx context.Context, node string, fn func() error) (err error) {
+	configs, err := s.client.GetConfigs()
+	if err != nil {
+		return fmt.Errorf("获取代理模式失败: %w", err)
+	}
+	global, err := s.client.GetProxy(GlobalGroup)
+	if err != nil {
+		return fmt.Errorf("获取 %s 失败: %w", GlobalGroup, err)
+	}
+	if !slices.Contains(global.All, node) {
+		return fmt.Errorf("节点不在 %s 中: %s", GlobalGroup, node)
+	}
+
+	if global.Now != node {
+		if err := s.client.SelectProxy(GlobalGroup, node); err != nil {
+			return fmt.Errorf("切换到节点 %s 失败: %w", node, err)
+		}
+		defer func() {
+			if global.Now == "" {
+				return
+			}
+			if rerr := s.client.SelectProxy(GlobalGroup, global.Now); rerr != nil {
+				err = errors.Join(err, fmt.Errorf("恢复 %s 的选择 %s 失败: %w", GlobalGroup, global.Now, rerr))
+			}
+		}()
+	}
+	if !strings.EqualFold(configs.Mode, "global") {
+		if err := s.client.UpdateConfig(model.UpdateConfigRequest{Mode: "global"}); err != nil {
+			return fmt.Errorf("切换到 global 模式失败: %w", err)
+		}
+		defer func() {
+			if rerr := s.client.UpdateConfig(model.UpdateConfigRequest{Mode: configs.Mode}); rerr != nil {
+				err = errors.Join(err, fmt.Errorf("恢复 %s 模式失败: %w", configs.Mode, rerr))
+			}
+		}()
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return fn()
+}
+
+// CompareEgress 对比代理与直连出口：出口 IP 相同视为路由泄漏，
+// DNS 解析器与直连同属一个 ASN（而代理出口不是）视为 DNS 泄漏
+func CompareEgress(proxied, direct *model.IPInfo, resolver *DNSResolver) []EgressLeak {
+	var leaks []EgressLeak
+	if proxied == nil || direct == nil {
+		return leaks
+	}
+	if proxied.IP != "" && proxied.IP == direct.IP {
+		leaks = append(leaks, EgressLeak{Kind: LeakRouting, Detail: fmt.Sprintf("代理出口与直连出口相同 (%s)", direct.IP)})
+	}
+	if resolver == nil {
+		return leaks
+	}
+	switch {
+	case resolver.IP == direct.IP:
+		leaks = append(leaks, EgressLeak{Kind: LeakDNS, Detail: fmt.Sprintf("DNS 查询从直连出口发出 (%s)", resolver.IP)})
+	case resolver.ASN != 0 && resolver.ASN == direct.ASN && resolver.ASN != proxied.ASN:
+		leaks = append(leaks, EgressLeak{Kind: LeakDNS, Detail: fmt.Sprintf("DNS 解析器 %s 与直连出口同属 AS%d", resolver.IP, resolver.ASN)})
+	}
+	return leaks
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetIPInfoAndDNSResolverThroughProxy(t *testing.T) {
+	// 充当 HTTP 代理：按请求的目标主机返回对应接口的响应
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Host, ".edns.ip-api.com"):
+			fmt.Fprint(w, `{"dns":{"geo":"Japan - KDDI","ip":"203.0.113.53"}}`)
+		case r.URL.Host == "ip-api.com":
+			fmt.Fprint(w, `{"status":"success","query":"203.0.113.7","countryCode":"JP","as":"AS2516 KDDI CORPORATION","isp":"KDDI"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer proxy.Close()
+
+	svc := newTestProxyService(t, func(w http.ResponseWriter, r *http.Request) {})
+	info, err := svc.GetIPInfo(proxy.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.7", info.IP)
+	assert.Equal(t, 2516, info.ASN)
+	assert.Equal(t, "KDDI CORPORATION", info.ASNOrganization)
+
+	svc.SetGeoProvider(&stubGeoProvider{name: "mmdb", info: &model.IPInfo{CountryCode: "JP", ASN: 2516, ASNOrganization: "KDDI"}})
+	resolver, err := svc.GetDNSResolver(context.Background(), proxy.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.53", resolver.IP)
+	assert.Equal(t, "Japan - KDDI", resolver.Geo)
+	assert.Equal(t, 2516, resolver.ASN)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = svc.GetIPInfoContext(ctx, proxy.URL)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestWithNodeRestoresSelectionAndMode(t *testing.T) {
+	mode, now := "rule", "Auto"
+	var calls []string
+	svc := newTestProxyService(t, func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		switch {
+		case r.URL.Path == "/configs" && r.Method == http.MethodGet:
+			fmt.Fprintf(w, `{"mode":%q}`, mode)
+		case r.URL.Path == "/configs" && r.Method == http.MethodPatch:
+			var req model.UpdateConfigRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			mode = req.Mode
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Path == "/proxies/GLOBAL" && r.Method == http.MethodGet:
+			fmt.Fprintf(w, `{"name":"GLOBAL","type":"Selector","now":%q,"all":["Auto","HK","JP"]}`, now)
+		case r.URL.Path == "/proxies/GLOBAL" && r.Method == http.MethodPut:
+			var req model.SelectProxyRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			now = req.Name
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	err := svc.WithNode(context.Background(), "JP", func() error {
+		assert.Equal(t, "global", mode)
+		assert.Equal(t, "JP", now)
+		return errors.New("probe failed")
+	})
+	assert.EqualError(t, err, "probe failed")
+	assert.Equal(t, "rule", mode)
+	assert.Equal(t, "Auto", now)
+
+	calls = nil
+	err = svc.WithNode(context.Background(), "US", func() error { return nil })
+	assert.ErrorContains(t, err, "节点不在 GLOBAL 中")
+	assert.NotContains(t, calls, "PUT /proxies/GLOBAL")
+
+	// 已取消时不再检测，但切换仍会恢复
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	err = svc.WithNode(ctx, "JP", func() error { called = true; return nil })
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, called)
+	assert.Equal(t, "rule", mode)
+	assert.Equal(t, "Auto", now)
+}
+
+func TestCompareEgress(t *testing.T) {
+	proxied := &model.IPInfo{IP: "203.0.113.7", ASN: 2516}
+	direct := &model.IPInfo{IP: "198.51.100.2", ASN: 4134}
+
+	assert.Empty(t, CompareEgress(proxied, direct, &DNSResolver{IP: "203.0.113.53", ASN: 2516}))
+
+	leaks := CompareEgress(direct, direct, &DNSResolver{IP: "198.51.100.2"})
+	require.Len(t, leaks, 2)
+	assert.Equal(t, LeakRouting, leaks[0].Kind)
+	assert.Equal(t, LeakDNS, leaks[1].Kind)
+
+	leaks = CompareEgress(proxied, direct, &DNSResolver{IP: "198.51.100.53", ASN: 4134})
+	require.Len(t, leaks, 1)
+	assert.Contains(t, leaks[0].Detail, "AS4134")
+}
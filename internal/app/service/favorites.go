@@ -0,0 +1,158 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+)
+
+// AliasRegexPrefix 别名目标的正则前缀（如 "re:香港.*IEPL"）
+const AliasRegexPrefix = "re:"
+
+// LookupProxyAlias 查找别名对应的目标（别名不区分大小写）
+func LookupProxyAlias(aliases map[string]string, alias string) (string, bool) {
+	if len(aliases) == 0 {
+		return "", false
+	}
+	// viper 会将 map 键转为小写
+	target, ok := aliases[strings.ToLower(strings.TrimSpace(alias))]
+	return target, ok
+}
+
+// ResolveProxyAlias 将别名解析为候选列表中的节点名
+//
+// 候选列表中存在同名节点时优先使用原名；正则别名取候选列表中第一个匹配项；
+// 既不是节点名也不是别名时原样返回，交由核心报错。
+func ResolveProxyAlias(name string, aliases map[string]string, candidates []string) (string, error) {
+	for _, candidate := range candidates {
+		if candidate == name {
+			return name, nil
+		}
+	}
+
+	target, ok := LookupProxyAlias(aliases, name)
+	if !ok {
+		return name, nil
+	}
+
+	if pattern, isRegex := strings.CutPrefix(target, AliasRegexPrefix); isRegex {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return "", fmt.Errorf("别名 %s 的正则无效: %w", name, err)
+		}
+		for _, candidate := range candidates {
+			if re.MatchString(candidate) {
+				return candidate, nil
+			}
+		}
+		return "", fmt.Errorf("别名 %s 未匹配到任何节点: %s", name, target)
+	}
+
+	for _, candidate := range candidates {
+		if candidate == target {
+			return target, nil
+		}
+	}
+	return "", fmt.Errorf("别名 %s 指向的节点不存在: %s", name, target)
+}
+
+// SortedAliasNames 返回排序后的别名列表
+func SortedAliasNames(aliases map[string]string) []string {
+	names := make([]string, 0, len(aliases))
+	for alias := range aliases {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PinFavorites 将收藏项稳定地移到列表前部，返回新切片与置顶数量
+func PinFavorites(names []string, favorites map[string]bool) ([]string, int) {
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		if favorites[name] {
+			result = append(result, name)
+		}
+	}
+	pinned := len(result)
+	for _, name := range names {
+		if !favorites[name] {
+			result = append(result, name)
+		}
+	}
+	return result, pinned
+}
+
+// ToggleFavorite 切换收藏状态，返回新列表与切换后是否已收藏
+func ToggleFavorite(list []string, name string) ([]string, bool) {
+	result := make([]string, 0, len(list)+1)
+	removed := false
+	for _, item := range list {
+		if item == name {
+			removed = true
+			continue
+		}
+		result = append(result, item)
+	}
+	if removed {
+		return result, false
+	}
+	return append(result, name), true
+}
+
+// SetFavorites 保存收藏的节点与策略组
+func (s *ConfigService) SetFavorites(proxies, groups []string) error {
+	return config.Update(func(cfg *config.Config) error {
+		cfg.FavoriteProxies = proxies
+		cfg.FavoriteGroups = groups
+		return nil
+	})
+}
+
+// SetProxyAlias 新增或修改节点别名
+func (s *ConfigService) SetProxyAlias(alias, target string) error {
+	alias = strings.ToLower(strings.TrimSpace(alias))
+	if alias == "" || target == "" {
+		return fmt.Errorf("别名和目标节点不能为空")
+	}
+	// viper 按 "." 拆分配置键，含 "." 的别名写入后无法再读取
+	if strings.Contains(alias, ".") {
+		return fmt.Errorf("别名不能包含 \".\": %s", alias)
+	}
+	if pattern, isRegex := strings.CutPrefix(target, AliasRegexPrefix); isRegex {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("别名 %s 的正则无效: %w", alias, err)
+		}
+	}
+
+	return config.Update(func(cfg *config.Config) error {
+		aliases := make(map[string]string, len(cfg.ProxyAliases)+1)
+		for k, v := range cfg.ProxyAliases {
+			aliases[k] = v
+		}
+		aliases[alias] = target
+		cfg.ProxyAliases = aliases
+		return nil
+	})
+}
+
+// RemoveProxyAlias 删除节点别名
+func (s *ConfigService) RemoveProxyAlias(alias string) error {
+	alias = strings.ToLower(strings.TrimSpace(alias))
+	return config.Update(func(cfg *config.Config) error {
+		if _, ok := cfg.ProxyAliases[alias]; !ok {
+			return fmt.Errorf("别名不存在: %s", alias)
+		}
+		aliases := make(map[string]string, len(cfg.ProxyAliases))
+		for k, v := range cfg.ProxyAliases {
+			if k != alias {
+				aliases[k] = v
+			}
+		}
+		cfg.ProxyAliases = aliases
+		return nil
+	})
+}
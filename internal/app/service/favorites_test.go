@@ -0,0 +1,108 @@
+package service
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveProxyAlias(t *testing.T) {
+	candidates := []string{"🇭🇰 香港 IEPL 01 | x1.5", "🇭🇰 香港 IEPL 02 | x1.5", "🇯🇵 日本 01", "hk"}
+	aliases := map[string]string{
+		"hk":   "🇯🇵 日本 01",
+		"iepl": "re:香港 IEPL",
+		"jp":   "🇯🇵 日本 01",
+		"sg":   "🇸🇬 新加坡 01",
+		"bad":  "re:(",
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "exact node name wins over alias", input: "hk", want: "hk"},
+		{name: "plain alias", input: "JP", want: "🇯🇵 日本 01"},
+		{name: "regex alias picks first match", input: "iepl", want: "🇭🇰 香港 IEPL 01 | x1.5"},
+		{name: "unknown name passes through", input: "US 01", want: "US 01"},
+		{name: "alias target missing", input: "sg", wantErr: true},
+		{name: "invalid regex", input: "bad", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveProxyAlias(tt.input, aliases, candidates)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestResolveProxyAliasSurvivesRename(t *testing.T) {
+	aliases := map[string]string{"hk": `re:香港.*IEPL`}
+
+	got, err := ResolveProxyAlias("hk", aliases, []string{"JP", "香港 IEPL 01 | 新"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "香港 IEPL 01 | 新", got)
+}
+
+func TestPinFavorites(t *testing.T) {
+	got, pinned := PinFavorites([]string{"a", "b", "c", "d"}, map[string]bool{"c": true, "a": true})
+
+	assert.Equal(t, []string{"a", "c", "b", "d"}, got)
+	assert.Equal(t, 2, pinned)
+}
+
+func TestToggleFavorite(t *testing.T) {
+	list, on := ToggleFavorite([]string{"a"}, "b")
+	assert.True(t, on)
+	assert.Equal(t, []string{"a", "b"}, list)
+
+	list, on = ToggleFavorite(list, "a")
+	assert.False(t, on)
+	assert.Equal(t, []string{"b"}, list)
+}
+
+func TestSetProxyAliasRejectsDot(t *testing.T) {
+	err := NewConfigService().SetProxyAlias("jp.1", "🇯🇵 日本 01")
+	assert.ErrorContains(t, err, "不能包含")
+}
+
+func TestConcurrentFavoriteAndAliasSaves(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	viper.Reset()
+	tempHome := t.TempDir()
+	t.Setenv("HOME", tempHome)
+	t.Setenv("USERPROFILE", tempHome)
+	cfg := config.DefaultConfig
+	require.NoError(t, config.Save(&cfg))
+
+	// TUI 中的收藏与别名切换各自在 goroutine 中保存，不应互相覆盖
+	svc := NewConfigService()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, svc.SetFavorites([]string{"HK"}, []string{"Proxy"}))
+	}()
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, svc.SetProxyAlias("hk", "HK"))
+	}()
+	wg.Wait()
+
+	loaded, err := config.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"HK"}, loaded.FavoriteProxies)
+	assert.Equal(t, map[string]string{"hk": "HK"}, loaded.ProxyAliases)
+}
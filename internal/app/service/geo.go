@@ -0,0 +1,358 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+	"github.com/aimony/mihosh/pkg/mmdb"
+)
+
+// 地理位置数据源
+const (
+	GeoProviderAuto = "auto" // 本地 MMDB 优先，未收录时回退 HTTP
+	GeoProviderMMDB = "mmdb" // 仅本地 MMDB，完全离线
+	GeoProviderHTTP = "http" // 仅在线接口
+)
+
+// DefaultGeoHTTPURL 在线查询接口，{ip} 替换为目标 IP
+const DefaultGeoHTTPURL = "https://api.ip.sb/geoip/{ip}"
+
+// ErrGeoNotFound 数据源未收录该 IP
+var ErrGeoNotFound = errors.New("未收录该 IP")
+
+// GeoProvider IP 地理位置/ASN 查询
+type GeoProvider interface {
+	Name() string
+	Lookup(ctx context.Context, ip string) (*model.IPInfo, error)
+}
+
+// MMDBGeoProvider 基于本地 MMDB 文件查询，多个文件的结果合并（国家/城市库 + ASN 库）
+type MMDBGeoProvider struct {
+	files   []string
+	readers []*mmdb.Reader
+}
+
+// NewMMDBGeoProvider 打开 MMDB 文件（MaxMind、DB-IP 以及 mihomo 的 Country.mmdb/geoip.metadb）
+func NewMMDBGeoProvider(files ...string) (*MMDBGeoProvider, error) {
+	if len(files) == 0 {
+		return nil, errors.New("未指定 MMDB 文件")
+	}
+	p := &MMDBGeoProvider{}
+	for _, file := range files {
+		r, err := mmdb.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("打开 MMDB 文件失败: %w", err)
+		}
+		p.files = append(p.files, file)
+		p.readers = append(p.readers, r)
+	}
+	return p, nil
+}
+
+// Name 数据源名称
+func (p *MMDBGeoProvider) Name() string {
+	return GeoProviderMMDB
+}
+
+// Files 已加载的数据库文件
+func (p *MMDBGeoProvider) Files() []string {
+	return p.files
+}
+
+// Lookup 依次查询各数据库，先加载的文件优先
+func (p *MMDBGeoProvider) Lookup(_ context.Context, ip string) (*model.IPInfo, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return nil, fmt.Errorf("无效的 IP 地址: %s", ip)
+	}
+	info := &model.IPInfo{IP: parsed.String()}
+	found := false
+	for i, r := range p.readers {
+		record, ok, err := r.Lookup(parsed)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(p.files[i]), err)
+		}
+		if ok {
+			mergeMMDBRecord(info, record)
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("%s: %w", ip, ErrGeoNotFound)
+	}
+	NormalizeIPInfo(info)
+	return info, nil
+}
+
+// mergeMMDBRecord 将记录中的字段填入 info，已有值不覆盖
+func mergeMMDBRecord(info *model.IPInfo, record any) {
+	switch rec := record.(type) {
+	case string:
+		// mihomo geoip.metadb（sing-geoip）只存国家代码
+		setIfEmpty(&info.CountryCode, strings.ToUpper(rec))
+		return
+	case []any:
+		// Meta-geoip0 存多个代码，取第一个
+		for _, code := range rec {
+			if s, ok := code.(string); ok && s != "" {
+				setIfEmpty(&info.CountryCode, strings.ToUpper(s))
+				return
+			}
+		}
+		return
+	case map[string]any:
+		country := mmdbMap(rec, "country")
+		if country == nil {
+			country = mmdbMap(rec, "registered_country")
+		}
+		setIfEmpty(&info.CountryCode, mmdbString(country, "iso_code"))
+		setIfEmpty(&info.Country, mmdbName(country))
+		setIfEmpty(&info.ContinentCode, mmdbString(mmdbMap(rec, "continent"), "code"))
+		setIfEmpty(&info.City, mmdbName(mmdbMap(rec, "city")))
+		if subs, ok := rec["subdivisions"].([]any); ok && len(subs) > 0 {
+			if sub, ok := subs[0].(map[string]any); ok {
+				setIfEmpty(&info.RegionName, mmdbName(sub))
+			}
+		}
+		if loc := mmdbMap(rec, "location"); loc != nil && info.Latitude == 0 && info.Longitude == 0 {
+			info.Latitude, _ = loc["latitude"].(float64)
+			info.Longitude, _ = loc["longitude"].(float64)
+			setIfEmpty(&info.Timezone, mmdbString(loc, "time_zone"))
+		}
+		if asn, ok := rec["autonomous_system_number"].(uint64); ok && info.ASN == 0 {
+			info.ASN = int(asn)
+		}
+		setIfEmpty(&info.ASNOrganization, mmdbString(rec, "autonomous_system_organization"))
+		setIfEmpty(&info.ISP, mmdbString(rec, "isp"))
+		setIfEmpty(&info.Organization, mmdbString(rec, "organization"))
+	}
+}
+
+func mmdbMap(m map[string]any, key string) map[string]any {
+	v, _ := m[key].(map[string]any)
+	return v
+}
+
+func mmdbString(m map[string]any, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+// mmdbName 取 names 中的英文名（没有时取任意一个）
+func mmdbName(m map[string]any) string {
+	names := mmdbMap(m, "names")
+	if name := mmdbString(names, "en"); name != "" {
+		return name
+	}
+	for _, v := range names {
+		if s, ok := v.(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+func setIfEmpty(dst *string, v string) {
+	if *dst == "" {
+		*dst = v
+	}
+}
+
+// NormalizeIPInfo 对齐 ip.sb、ip-api.com 与 MMDB 的字段，使两套字段名都有值
+func NormalizeIPInfo(info *model.IPInfo) {
+	setIfEmpty(&info.IP, info.Query)
+	setIfEmpty(&info.Query, info.IP)
+	setIfEmpty(&info.CountryCode, info.CountryCodeApi)
+	setIfEmpty(&info.CountryCodeApi, info.CountryCode)
+	setIfEmpty(&info.Organization, info.Org)
+	setIfEmpty(&info.Org, firstNonEmptyString(info.Organization, info.ASNOrganization))
+	if info.ASN == 0 && info.AS != "" {
+		asn, org := model.ParseASN(info.AS)
+		info.ASN = asn
+		setIfEmpty(&info.ASNOrganization, org)
+	}
+	if info.AS == "" && info.ASN > 0 {
+		info.AS = strings.TrimSpace(fmt.Sprintf("AS%d %s", info.ASN, info.ASNOrganization))
+	}
+	if info.Latitude == 0 && info.Longitude == 0 {
+		info.Latitude, info.Longitude = info.Lat, info.Lon
+	}
+	if info.Lat == 0 && info.Lon == 0 {
+		info.Lat, info.Lon = info.Latitude, info.Longitude
+	}
+}
+
+// HTTPGeoProvider 通过在线接口查询（响应需为 ip.sb 或 ip-api.com 格式的 JSON）
+type HTTPGeoProvider struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPGeoProvider 创建在线查询数据源，url 中的 {ip} 替换为目标 IP，为空时使用 ip.sb
+func NewHTTPGeoProvider(url string) *HTTPGeoProvider {
+	if url == "" {
+		url = DefaultGeoHTTPURL
+	}
+	return &HTTPGeoProvider{url: url, client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+// Name 数据源名称
+func (p *HTTPGeoProvider) Name() string {
+	return GeoProviderHTTP
+}
+
+// Lookup 请求在线接口
+func (p *HTTPGeoProvider) Lookup(ctx context.Context, ip string) (*model.IPInfo, error) {
+	url := strings.ReplaceAll(p.url, "{ip}", ip)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	req.Header.Set("Accept", "*/*")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("查询 %s 失败: HTTP %d", ip, resp.StatusCode)
+	}
+
+	var info model.IPInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	if info.Status == "fail" {
+		return nil, fmt.Errorf("%s: %w", ip, ErrGeoNotFound)
+	}
+	NormalizeIPInfo(&info)
+	return &info, nil
+}
+
+// GeoChain 依次尝试多个数据源，返回第一个成功的结果
+type GeoChain []GeoProvider
+
+// Name 数据源名称，如 "mmdb+http"
+func (c GeoChain) Name() string {
+	names := make([]string, len(c))
+	for i, p := range c {
+		names[i] = p.Name()
+	}
+	return strings.Join(names, "+")
+}
+
+// Lookup 依次查询，全部失败时返回最后一个错误
+func (c GeoChain) Lookup(ctx context.Context, ip string) (*model.IPInfo, error) {
+	err := ErrGeoNotFound
+	for _, p := range c {
+		var info *model.IPInfo
+		if info, err = p.Lookup(ctx, ip); err == nil {
+			return info, nil
+		}
+	}
+	return nil, err
+}
+
+// mmdbFileNames 自动发现时查找的文件名，国家/城市库在前，ASN 库在后
+var mmdbFileNames = []string{
+	"GeoLite2-City.mmdb", "GeoIP2-City.mmdb", "dbip-city-lite.mmdb",
+	"Country.mmdb", "GeoLite2-Country.mmdb", "dbip-country-lite.mmdb", "geoip.metadb",
+	"GeoLite2-ASN.mmdb", "ASN.mmdb", "dbip-asn-lite.mmdb",
+}
+
+// geoSearchDirs 自动发现 MMDB 文件的目录（mihosh 配置目录与 mihomo 常见目录）
+var geoSearchDirs = func() []string {
+	var dirs []string
+	if dir, err := config.GetConfigDir(); err == nil {
+		dirs = append(dirs, dir)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs,
+			filepath.Join(home, ".config", "mihomo"),
+			filepath.Join(home, ".config", "clash"),
+			filepath.Join(home, ".mihomo"),
+			filepath.Join(home, ".clash"),
+		)
+	}
+	return append(dirs, "/etc/mihomo", "/usr/local/etc/mihomo")
+}
+
+// DiscoverMMDBFiles 在常见目录中查找 MMDB 文件，同名文件只取第一个目录中的
+func DiscoverMMDBFiles() []string {
+	var files []string
+	for _, name := range mmdbFileNames {
+		for _, dir := range geoSearchDirs() {
+			path := filepath.Join(dir, name)
+			if st, err := os.Stat(path); err == nil && !st.IsDir() {
+				files = append(files, path)
+				break
+			}
+		}
+	}
+	return files
+}
+
+// expandHomePaths 展开路径开头的 ~
+func expandHomePaths(paths []string) []string {
+	home, err := os.UserHomeDir()
+	out := make([]string, len(paths))
+	for i, p := range paths {
+		if err == nil && (p == "~" || strings.HasPrefix(p, "~/")) {
+			p = filepath.Join(home, p[1:])
+		}
+		out[i] = p
+	}
+	return out
+}
+
+// NewGeoProviderFromConfig 按配置创建数据源；auto 模式下无可用 MMDB 时只使用 HTTP
+func NewGeoProviderFromConfig(cfg *config.Config) (GeoProvider, error) {
+	mode := strings.ToLower(strings.TrimSpace(cfg.GeoProvider))
+	// 在线接口限速，批量补全连接表时不会对每一行都立即发请求
+	rate := cfg.GeoRateLimit
+	if rate <= 0 {
+		rate = DefaultGeoRateLimit
+	}
+	httpProvider := NewRateLimitedGeoProvider(NewHTTPGeoProvider(cfg.GeoHTTPURL), rate, int(math.Ceil(rate)))
+	files := expandHomePaths(cfg.GeoMMDBFiles)
+	if len(files) == 0 && mode != GeoProviderHTTP {
+		files = DiscoverMMDBFiles()
+	}
+
+	switch mode {
+	case GeoProviderHTTP:
+		return httpProvider, nil
+	case GeoProviderMMDB:
+		if len(files) == 0 {
+			return nil, errors.New("未找到 MMDB 文件，请在 geo_mmdb_files 中指定")
+		}
+		return NewMMDBGeoProvider(files...)
+	case "", GeoProviderAuto:
+		// 自动模式跳过无法打开的文件
+		local := &MMDBGeoProvider{}
+		for _, file := range files {
+			if r, err := mmdb.Open(file); err == nil {
+				local.files = append(local.files, file)
+				local.readers = append(local.readers, r)
+			}
+		}
+		if len(local.readers) == 0 {
+			return httpProvider, nil
+		}
+		return GeoChain{local, httpProvider}, nil
+	}
+	return nil, fmt.Errorf("不支持的地理位置数据源 %q（可用: auto, mmdb, http）", cfg.GeoProvider)
+}
@@ -0,0 +1,396 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+)
+
+// 地理位置缓存默认参数
+const (
+	DefaultGeoCacheSize = 4096
+	DefaultGeoCacheTTL  = 24 * time.Hour
+	// geoNegativeTTL 未收录结果的缓存时间，避免反复查询
+	geoNegativeTTL = 30 * time.Minute
+	// DefaultGeoRateLimit 在线查询默认每秒请求数
+	DefaultGeoRateLimit = 2.0
+	// geoBatchConcurrency 批量补全时的并发查询数
+	geoBatchConcurrency = 4
+	// geoAnnotateTimeout 命令行按 country:/asn: 过滤时补全的最长等待时间
+	geoAnnotateTimeout = 15 * time.Second
+)
+
+// ErrGeoRateLimited 在线查询超过速率限制
+var ErrGeoRateLimited = errors.New("查询过于频繁")
+
+// GeoCache 带 LRU+TTL 缓存与并发合并的地理位置查询，本身也是 GeoProvider
+type GeoCache struct {
+	provider GeoProvider
+	capacity int
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu       sync.Mutex
+	ll       *list.List // 最近使用的在前
+	items    map[string]*list.Element
+	inflight map[string]*geoCall
+	dirty    bool
+}
+
+type geoCacheEntry struct {
+	IP      string        `json:"ip"`
+	Info    *model.IPInfo `json:"info,omitempty"` // nil 表示未收录
+	Expires time.Time     `json:"expires"`
+}
+
+// geoCall 进行中的查询，同一 IP 的并发请求共享结果
+type geoCall struct {
+	done chan struct{}
+	info *model.IPInfo
+	err  error
+}
+
+// NewGeoCache 创建地理位置缓存，capacity/ttl 不大于 0 时使用默认值
+func NewGeoCache(provider GeoProvider, capacity int, ttl time.Duration) *GeoCache {
+	if capacity <= 0 {
+		capacity = DefaultGeoCacheSize
+	}
+	if ttl <= 0 {
+		ttl = DefaultGeoCacheTTL
+	}
+	return &GeoCache{
+		provider: provider,
+		capacity: capacity,
+		ttl:      ttl,
+		now:      time.Now,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+		inflight: make(map[string]*geoCall),
+	}
+}
+
+// Name 底层数据源名称
+func (c *GeoCache) Name() string {
+	return c.provider.Name()
+}
+
+// Peek 只读取缓存，不发起查询
+func (c *GeoCache) Peek(ip string) (info *model.IPInfo, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.get(ip)
+	if !ok {
+		return nil, false
+	}
+	return entry.Info, true
+}
+
+// Lookup 优先返回缓存；同一 IP 同时只有一个查询在进行
+func (c *GeoCache) Lookup(ctx context.Context, ip string) (*model.IPInfo, error) {
+	c.mu.Lock()
+	if entry, ok := c.get(ip); ok {
+		c.mu.Unlock()
+		if entry.Info == nil {
+			return nil, fmt.Errorf("%s: %w", ip, ErrGeoNotFound)
+		}
+		return entry.Info, nil
+	}
+	if call, ok := c.inflight[ip]; ok {
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.info, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	call := &geoCall{done: make(chan struct{})}
+	c.inflight[ip] = call
+	c.mu.Unlock()
+
+	call.info, call.err = c.provider.Lookup(ctx, ip)
+
+	c.mu.Lock()
+	delete(c.inflight, ip)
+	switch {
+	case call.err == nil:
+		c.put(ip, call.info, c.ttl)
+	case errors.Is(call.err, ErrGeoNotFound):
+		c.put(ip, nil, geoNegativeTTL)
+	}
+	c.mu.Unlock()
+	close(call.done)
+	return call.info, call.err
+}
+
+// LookupMany 并发查询一批 IP；未收录的 IP 对应 nil，查询失败（超时、限流等）的 IP 不在结果中
+func (c *GeoCache) LookupMany(ctx context.Context, ips []string) map[string]*model.IPInfo {
+	result := make(map[string]*model.IPInfo, len(ips))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, geoBatchConcurrency)
+	for _, ip := range ips {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(ip string) {
+			defer func() { <-sem; wg.Done() }()
+			info, err := c.Lookup(ctx, ip)
+			if err != nil && !errors.Is(err, ErrGeoNotFound) {
+				return
+			}
+			mu.Lock()
+			result[ip] = info
+			mu.Unlock()
+		}(ip)
+	}
+	wg.Wait()
+	return result
+}
+
+// get 读取未过期的缓存项并移到最前（调用方持有锁）
+func (c *GeoCache) get(ip string) (*geoCacheEntry, bool) {
+	el, ok := c.items[ip]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*geoCacheEntry)
+	if !c.now().Before(entry.Expires) {
+		c.ll.Remove(el)
+		delete(c.items, ip)
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return entry, true
+}
+
+// put 写入缓存，超出容量时淘汰最久未使用的项（调用方持有锁）
+func (c *GeoCache) put(ip string, info *model.IPInfo, ttl time.Duration) {
+	c.putEntry(&geoCacheEntry{IP: ip, Info: info, Expires: c.now().Add(ttl)})
+}
+
+func (c *GeoCache) putEntry(entry *geoCacheEntry) {
+	c.dirty = true
+	if el, ok := c.items[entry.IP]; ok {
+		el.Value = entry
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[entry.IP] = c.ll.PushFront(entry)
+	for c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*geoCacheEntry).IP)
+	}
+}
+
+// Len 缓存项数量（含已过期但尚未淘汰的项）
+func (c *GeoCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// DefaultGeoCachePath 持久化缓存的默认路径（~/.mihosh/geo-cache.json）
+func DefaultGeoCachePath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("获取配置目录失败: %w", err)
+	}
+	return filepath.Join(dir, "geo-cache.json"), nil
+}
+
+// Load 从文件恢复未过期的缓存项，文件不存在时不报错
+func (c *GeoCache) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取地理位置缓存失败: %w", err)
+	}
+	var entries []geoCacheEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("解析地理位置缓存失败: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	// 文件中最近使用的在前，倒序写入以保持顺序
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entry := entries[i]; now.Before(entry.Expires) {
+			c.putEntry(&entry)
+		}
+	}
+	c.dirty = false
+	return nil
+}
+
+// Save 将未过期的缓存项写入文件，没有变化时跳过
+func (c *GeoCache) Save(path string) error {
+	c.mu.Lock()
+	if !c.dirty {
+		c.mu.Unlock()
+		return nil
+	}
+	now := c.now()
+	entries := make([]geoCacheEntry, 0, c.ll.Len())
+	for el := c.ll.Front(); el != nil; el = el.Next() {
+		if entry := el.Value.(*geoCacheEntry); now.Before(entry.Expires) {
+			entries = append(entries, *entry)
+		}
+	}
+	c.dirty = false
+	c.mu.Unlock()
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("创建缓存目录失败: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("写入地理位置缓存失败: %w", err)
+	}
+	return nil
+}
+
+// RateLimitedGeoProvider 令牌桶限速的数据源（用于在线接口），令牌不足时等待
+type RateLimitedGeoProvider struct {
+	provider GeoProvider
+	rate     float64 // 每秒补充的令牌数
+	burst    float64
+	now      func() time.Time
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimitedGeoProvider 创建限速数据源，perSecond 不大于 0 时使用默认值
+func NewRateLimitedGeoProvider(provider GeoProvider, perSecond float64, burst int) *RateLimitedGeoProvider {
+	if perSecond <= 0 {
+		perSecond = DefaultGeoRateLimit
+	}
+	if burst <= 0 {
+		burst = 1
+	}
+	return &RateLimitedGeoProvider{
+		provider: provider,
+		rate:     perSecond,
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		now:      time.Now,
+	}
+}
+
+// Name 底层数据源名称
+func (p *RateLimitedGeoProvider) Name() string {
+	return p.provider.Name()
+}
+
+// Lookup 取得令牌后查询；ctx 在令牌可用前结束时返回 ErrGeoRateLimited
+func (p *RateLimitedGeoProvider) Lookup(ctx context.Context, ip string) (*model.IPInfo, error) {
+	if wait := p.reserve(); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			p.cancel()
+			return nil, fmt.Errorf("%s: %w", ip, ErrGeoRateLimited)
+		}
+	}
+	return p.provider.Lookup(ctx, ip)
+}
+
+// reserve 预占一个令牌，返回需要等待的时间
+func (p *RateLimitedGeoProvider) reserve() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	if !p.last.IsZero() {
+		p.tokens = min(p.burst, p.tokens+now.Sub(p.last).Seconds()*p.rate)
+	}
+	p.last = now
+	p.tokens--
+	if p.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-p.tokens / p.rate * float64(time.Second))
+}
+
+// cancel 归还放弃等待的令牌
+func (p *RateLimitedGeoProvider) cancel() {
+	p.mu.Lock()
+	p.tokens = min(p.burst, p.tokens+1)
+	p.mu.Unlock()
+}
+
+// IsPublicIP 是否为值得查询地理位置的公网地址（排除内网、回环、链路本地与 fake-ip 网段）
+func IsPublicIP(s string) bool {
+	ip := net.ParseIP(s)
+	if ip == nil || ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() ||
+		ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	if v4 := ip.To4(); v4 != nil {
+		// 198.18.0.0/15 为 mihomo 默认 fake-ip 网段，100.64.0.0/10 为 CGNAT
+		if v4[0] == 198 && v4[1]&0xFE == 18 || v4[0] == 100 && v4[1]&0xC0 == 64 {
+			return false
+		}
+	}
+	return true
+}
+
+// AnnotateGeo 为公网目标 IP 补全国家与 ASN，返回新切片（mihomo 已提供两者的连接不查询）
+func AnnotateGeo(ctx context.Context, cache *GeoCache, conns []model.Connection) []model.Connection {
+	seen := make(map[string]bool)
+	var ips []string
+	for _, c := range conns {
+		ip := c.Metadata.DestinationIP
+		if tag := c.DestinationGeo(); seen[ip] || !IsPublicIP(ip) || tag.CountryCode != "" && tag.ASN != 0 {
+			continue
+		}
+		seen[ip] = true
+		ips = append(ips, ip)
+	}
+	infos := cache.LookupMany(ctx, ips)
+
+	out := make([]model.Connection, len(conns))
+	copy(out, conns)
+	for i := range out {
+		if info, ok := infos[out[i].Metadata.DestinationIP]; ok && info != nil {
+			tag := GeoTagFromInfo(info)
+			out[i].Geo = &tag
+		}
+	}
+	return out
+}
+
+// GeoTagFromInfo 从查询结果提取国家与 ASN
+func GeoTagFromInfo(info *model.IPInfo) model.GeoTag {
+	if info == nil {
+		return model.GeoTag{}
+	}
+	tag := model.GeoTag{
+		CountryCode: info.CountryCode,
+		ASN:         info.ASN,
+		ASNOrg:      firstNonEmptyString(info.ASNOrganization, info.Organization, info.Org),
+	}
+	if tag.ASN == 0 && info.AS != "" {
+		tag.ASN, tag.ASNOrg = model.ParseASN(info.AS)
+	}
+	return tag
+}
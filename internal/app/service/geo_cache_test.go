@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingGeoProvider 记录每个 IP 的查询次数，可选阻塞直到 release 关闭
+type countingGeoProvider struct {
+	mu      sync.Mutex
+	hits    map[string]int
+	known   map[string]model.IPInfo
+	release chan struct{}
+}
+
+func (p *countingGeoProvider) Name() string { return "counting" }
+
+func (p *countingGeoProvider) Lookup(_ context.Context, ip string) (*model.IPInfo, error) {
+	if p.release != nil {
+		<-p.release
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.hits == nil {
+		p.hits = make(map[string]int)
+	}
+	p.hits[ip]++
+	info, ok := p.known[ip]
+	if !ok {
+		return nil, ErrGeoNotFound
+	}
+	return &info, nil
+}
+
+func (p *countingGeoProvider) count(ip string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.hits[ip]
+}
+
+func TestGeoCacheCachesResultsAndMisses(t *testing.T) {
+	p := &countingGeoProvider{known: map[string]model.IPInfo{"1.1.1.1": {IP: "1.1.1.1", CountryCode: "AU"}}}
+	c := NewGeoCache(p, 2, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		info, err := c.Lookup(context.Background(), "1.1.1.1")
+		require.NoError(t, err)
+		assert.Equal(t, "AU", info.CountryCode)
+		_, err = c.Lookup(context.Background(), "9.9.9.9")
+		assert.ErrorIs(t, err, ErrGeoNotFound)
+	}
+	assert.Equal(t, 1, p.count("1.1.1.1"))
+	assert.Equal(t, 1, p.count("9.9.9.9"))
+
+	// 容量为 2：再查一个 IP 会淘汰最久未使用的项
+	_, _ = c.Lookup(context.Background(), "8.8.8.8")
+	assert.Equal(t, 2, c.Len())
+	_, ok := c.Peek("1.1.1.1")
+	assert.False(t, ok)
+	_, ok = c.Peek("9.9.9.9")
+	assert.True(t, ok)
+}
+
+func TestGeoCacheCoalescesConcurrentLookups(t *testing.T) {
+	p := &countingGeoProvider{known: map[string]model.IPInfo{"1.1.1.1": {CountryCode: "AU"}}, release: make(chan struct{})}
+	c := NewGeoCache(p, 0, 0)
+
+	var wg sync.WaitGroup
+	var ok int32
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if info, err := c.Lookup(context.Background(), "1.1.1.1"); err == nil && info.CountryCode == "AU" {
+				atomic.AddInt32(&ok, 1)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(p.release)
+	wg.Wait()
+	assert.Equal(t, int32(8), ok)
+	assert.Equal(t, 1, p.count("1.1.1.1"))
+}
+
+func TestGeoCacheSaveAndLoad(t *testing.T) {
+	p := &countingGeoProvider{known: map[string]model.IPInfo{"1.1.1.1": {IP: "1.1.1.1", CountryCode: "AU", ASN: 13335}}}
+	c := NewGeoCache(p, 0, 0)
+	got := c.LookupMany(context.Background(), []string{"1.1.1.1", "9.9.9.9"})
+	require.Len(t, got, 2)
+	assert.Nil(t, got["9.9.9.9"])
+
+	path := filepath.Join(t.TempDir(), "geo-cache.json")
+	require.NoError(t, c.Save(path))
+
+	restored := NewGeoCache(p, 0, 0)
+	require.NoError(t, restored.Load(path))
+	info, err := restored.Lookup(context.Background(), "1.1.1.1")
+	require.NoError(t, err)
+	assert.Equal(t, 13335, info.ASN)
+	assert.Equal(t, 1, p.count("1.1.1.1"))
+
+	assert.NoError(t, NewGeoCache(p, 0, 0).Load(filepath.Join(t.TempDir(), "missing.json")))
+}
+
+func TestRateLimitedGeoProvider(t *testing.T) {
+	p := NewRateLimitedGeoProvider(&countingGeoProvider{}, 1, 1)
+	_, err := p.Lookup(context.Background(), "1.1.1.1")
+	assert.ErrorIs(t, err, ErrGeoNotFound)
+
+	// 令牌已用完，等待期间 ctx 结束
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = p.Lookup(ctx, "1.1.1.1")
+	assert.ErrorIs(t, err, ErrGeoRateLimited)
+}
+
+func TestIsPublicIP(t *testing.T) {
+	for ip, want := range map[string]bool{
+		"1.1.1.1":     true,
+		"2606:4700::": true,
+		"10.0.0.1":    false,
+		"192.168.1.1": false,
+		"127.0.0.1":   false,
+		"198.18.0.5":  false,
+		"100.64.1.1":  false,
+		"fe80::1":     false,
+		"":            false,
+		"example.com": false,
+	} {
+		assert.Equal(t, want, IsPublicIP(ip), ip)
+	}
+}
+
+func TestAnnotateGeo(t *testing.T) {
+	p := &countingGeoProvider{known: map[string]model.IPInfo{"1.1.1.1": {CountryCode: "AU", ASN: 13335, ASNOrganization: "CLOUDFLARENET"}}}
+	conns := []model.Connection{
+		{ID: "a", Metadata: model.Metadata{DestinationIP: "1.1.1.1"}},
+		{ID: "b", Metadata: model.Metadata{DestinationIP: "10.0.0.1"}},
+		{ID: "c", Metadata: model.Metadata{DestinationIP: "8.8.8.8", DestinationGeoIP: []interface{}{"us"}, DestinationIPASN: "AS15169 Google LLC"}},
+	}
+	out := AnnotateGeo(context.Background(), NewGeoCache(p, 0, 0), conns)
+
+	require.NotNil(t, out[0].Geo)
+	assert.Equal(t, model.GeoTag{CountryCode: "AU", ASN: 13335, ASNOrg: "CLOUDFLARENET"}, *out[0].Geo)
+	assert.Nil(t, conns[0].Geo, "原切片不应被修改")
+	assert.Nil(t, out[1].Geo)
+	assert.Equal(t, 0, p.count("8.8.8.8"), "mihomo 已提供国家与 ASN 时不查询")
+	assert.Equal(t, model.GeoTag{CountryCode: "US", ASN: 15169, ASNOrg: "Google LLC"}, out[2].DestinationGeo())
+}
+
+func TestConnQueryGeoFields(t *testing.T) {
+	conns := []model.Connection{
+		{ID: "a", Geo: &model.GeoTag{CountryCode: "AU", ASN: 13335, ASNOrg: "CLOUDFLARENET"}},
+		{ID: "b", Metadata: model.Metadata{DestinationGeoIP: "us", DestinationIPASN: "AS15169 Google LLC"}},
+		{ID: "c"},
+	}
+	for expr, want := range map[string][]string{
+		"country:au":         {"a"},
+		"geoip:US":           {"b"},
+		"asn:13335":          {"a"},
+		"asn:AS15169":        {"b"},
+		"asn:google":         {"b"},
+		"!country:au asn:as": {"b"},
+	} {
+		q, err := ParseConnQuery(expr)
+		require.NoError(t, err, expr)
+		assert.True(t, q.UsesGeo(), expr)
+		var ids []string
+		for _, c := range FilterConnections(conns, q) {
+			ids = append(ids, c.ID)
+		}
+		assert.ElementsMatch(t, want, ids, expr)
+	}
+
+	q, err := ParseConnQuery("host:example.com")
+	require.NoError(t, err)
+	assert.False(t, q.UsesGeo())
+}
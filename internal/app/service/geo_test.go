@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+	"github.com/aimony/mihosh/internal/testutil/mmdbtest"
+	"github.com/aimony/mihosh/pkg/mmdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestMMDB 生成只含给定网段的 MMDB 文件
+func writeTestMMDB(t *testing.T, dir, name, dbType string, records map[string]any) string {
+	t.Helper()
+	b := mmdbtest.NewBuilder(dbType, 6)
+	for cidr, rec := range records {
+		require.NoError(t, b.Insert(cidr, rec))
+	}
+	buf, err := b.Bytes()
+	require.NoError(t, err)
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, buf, 0o644))
+	return path
+}
+
+func writeCountryAndASN(t *testing.T, dir string) (string, string) {
+	country := writeTestMMDB(t, dir, "Country.mmdb", "GeoLite2-Country", map[string]any{
+		"1.1.1.0/24": map[string]any{
+			"continent": map[string]any{"code": "OC"},
+			"country": map[string]any{
+				"iso_code": "AU",
+				"names":    map[string]any{"en": "Australia", "zh-CN": "澳大利亚"},
+			},
+			"location": map[string]any{"latitude": -33.494, "longitude": 143.2104, "time_zone": "Australia/Sydney"},
+		},
+	})
+	asn := writeTestMMDB(t, dir, "GeoLite2-ASN.mmdb", "GeoLite2-ASN", map[string]any{
+		"1.1.1.0/24": map[string]any{
+			"autonomous_system_number":       uint32(13335),
+			"autonomous_system_organization": "CLOUDFLARENET",
+		},
+		"2606:4700::/32": map[string]any{
+			"autonomous_system_number":       uint32(13335),
+			"autonomous_system_organization": "CLOUDFLARENET",
+		},
+	})
+	return country, asn
+}
+
+func TestMMDBGeoProviderMergesDatabases(t *testing.T) {
+	country, asn := writeCountryAndASN(t, t.TempDir())
+	p, err := NewMMDBGeoProvider(country, asn)
+	require.NoError(t, err)
+
+	info, err := p.Lookup(context.Background(), "1.1.1.1")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.1.1", info.IP)
+	assert.Equal(t, "AU", info.CountryCode)
+	assert.Equal(t, "Australia", info.Country)
+	assert.Equal(t, "OC", info.ContinentCode)
+	assert.Equal(t, "Australia/Sydney", info.Timezone)
+	assert.InDelta(t, -33.494, info.Latitude, 1e-9)
+	assert.Equal(t, 13335, info.ASN)
+	assert.Equal(t, "CLOUDFLARENET", info.ASNOrganization)
+	assert.Equal(t, "AS13335 CLOUDFLARENET", info.AS)
+
+	// 只有 ASN 库收录的地址
+	info, err = p.Lookup(context.Background(), "2606:4700::1111")
+	require.NoError(t, err)
+	assert.Empty(t, info.CountryCode)
+	assert.Equal(t, 13335, info.ASN)
+
+	_, err = p.Lookup(context.Background(), "9.9.9.9")
+	assert.ErrorIs(t, err, ErrGeoNotFound)
+	_, err = p.Lookup(context.Background(), "not-an-ip")
+	assert.Error(t, err)
+}
+
+func TestMMDBGeoProviderMetaDB(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestMMDB(t, dir, "geoip.metadb", "Meta-geoip0", map[string]any{
+		"10.0.0.0/8":     "private",
+		"223.5.5.0/24":   []any{"cn", "alibaba"},
+		"203.0.113.0/24": []any{},
+	})
+	p, err := NewMMDBGeoProvider(path)
+	require.NoError(t, err)
+
+	info, err := p.Lookup(context.Background(), "223.5.5.5")
+	require.NoError(t, err)
+	assert.Equal(t, "CN", info.CountryCode)
+	info, err = p.Lookup(context.Background(), "10.1.2.3")
+	require.NoError(t, err)
+	assert.Equal(t, "PRIVATE", info.CountryCode)
+}
+
+type stubGeoProvider struct {
+	name string
+	info *model.IPInfo
+	err  error
+	hits int
+}
+
+func (p *stubGeoProvider) Name() string { return p.name }
+
+func (p *stubGeoProvider) Lookup(_ context.Context, ip string) (*model.IPInfo, error) {
+	p.hits++
+	return p.info, p.err
+}
+
+func TestGeoChainFallsBack(t *testing.T) {
+	local := &stubGeoProvider{name: "mmdb", err: ErrGeoNotFound}
+	remote := &stubGeoProvider{name: "http", info: &model.IPInfo{IP: "9.9.9.9", CountryCode: "US"}}
+	chain := GeoChain{local, remote}
+
+	assert.Equal(t, "mmdb+http", chain.Name())
+	info, err := chain.Lookup(context.Background(), "9.9.9.9")
+	require.NoError(t, err)
+	assert.Equal(t, "US", info.CountryCode)
+	assert.Equal(t, 1, local.hits)
+
+	remote.info, remote.err = nil, errors.New("timeout")
+	_, err = chain.Lookup(context.Background(), "9.9.9.9")
+	assert.EqualError(t, err, "timeout")
+}
+
+func TestHTTPGeoProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/json/8.8.8.8":
+			fmt.Fprint(w, `{"status":"success","query":"8.8.8.8","countryCode":"US","as":"AS15169 Google LLC","org":"Google Public DNS","lat":39.03,"lon":-77.5}`)
+		case "/json/0.0.0.1":
+			fmt.Fprint(w, `{"status":"fail","message":"reserved range","query":"0.0.0.1"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	p := NewHTTPGeoProvider(srv.URL + "/json/{ip}")
+	info, err := p.Lookup(context.Background(), "8.8.8.8")
+	require.NoError(t, err)
+	assert.Equal(t, "8.8.8.8", info.IP)
+	assert.Equal(t, "US", info.CountryCode)
+	assert.Equal(t, "Google Public DNS", info.Organization)
+	assert.InDelta(t, 39.03, info.Latitude, 1e-9)
+
+	_, err = p.Lookup(context.Background(), "0.0.0.1")
+	assert.ErrorIs(t, err, ErrGeoNotFound)
+	_, err = p.Lookup(context.Background(), "1.2.3.4")
+	assert.Error(t, err)
+}
+
+func TestNewGeoProviderFromConfig(t *testing.T) {
+	dir := t.TempDir()
+	orig := geoSearchDirs
+	geoSearchDirs = func() []string { return []string{dir} }
+	t.Cleanup(func() { geoSearchDirs = orig })
+
+	// 没有本地数据库：auto 只用 HTTP，mmdb 报错
+	p, err := NewGeoProviderFromConfig(&config.Config{})
+	require.NoError(t, err)
+	assert.Equal(t, "http", p.Name())
+	_, err = NewGeoProviderFromConfig(&config.Config{GeoProvider: "mmdb"})
+	assert.Error(t, err)
+
+	country, asn := writeCountryAndASN(t, dir)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "GeoLite2-City.mmdb"), []byte("broken"), 0o644))
+	assert.Equal(t, []string{filepath.Join(dir, "GeoLite2-City.mmdb"), country, asn}, DiscoverMMDBFiles())
+
+	// auto 跳过损坏的文件，mmdb 模式则报错
+	p, err = NewGeoProviderFromConfig(&config.Config{GeoProvider: "auto"})
+	require.NoError(t, err)
+	assert.Equal(t, "mmdb+http", p.Name())
+	_, err = NewGeoProviderFromConfig(&config.Config{GeoProvider: "mmdb"})
+	assert.ErrorIs(t, err, mmdb.ErrInvalidDatabase)
+
+	p, err = NewGeoProviderFromConfig(&config.Config{GeoProvider: "MMDB", GeoMMDBFiles: []string{asn}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{asn}, p.(*MMDBGeoProvider).Files())
+
+	p, err = NewGeoProviderFromConfig(&config.Config{GeoProvider: "http"})
+	require.NoError(t, err)
+	assert.Equal(t, "http", p.Name())
+
+	_, err = NewGeoProviderFromConfig(&config.Config{GeoProvider: "maxmind"})
+	assert.Error(t, err)
+}
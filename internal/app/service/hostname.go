@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aimony/mihosh/internal/infrastructure/api"
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+	"github.com/aimony/mihosh/pkg/mdns"
+)
+
+// 设备名反查的缓存与超时
+const (
+	hostnameTTL           = time.Hour
+	hostnameNegativeTTL   = 10 * time.Minute
+	hostnameLookupTimeout = 1500 * time.Millisecond
+)
+
+// 设备名来源
+const (
+	HostnameSourceDNS    = "dns"
+	HostnameSourceMDNS   = "mdns"
+	HostnameSourceMihomo = "mihomo"
+	HostnameSourceDHCP   = "dhcp"
+)
+
+// PTRLookup 反向解析方式
+type PTRLookup interface {
+	Name() string
+	LookupPTR(ctx context.Context, ip string) (string, error)
+}
+
+// SystemPTRLookup 通过系统 DNS 反向解析（家用路由器的 DNS 通常能解析 DHCP 设备名）
+type SystemPTRLookup struct{}
+
+// Name 来源名称
+func (SystemPTRLookup) Name() string { return HostnameSourceDNS }
+
+// LookupPTR 反向解析
+func (SystemPTRLookup) LookupPTR(ctx context.Context, ip string) (string, error) {
+	names, err := net.DefaultResolver.LookupAddr(ctx, ip)
+	if err != nil {
+		return "", err
+	}
+	if len(names) == 0 {
+		return "", errors.New("无 PTR 记录")
+	}
+	return strings.TrimSuffix(names[0], "."), nil
+}
+
+// MDNSPTRLookup 通过组播 DNS 反查（苹果设备、打印机、NAS 等通常会应答）
+type MDNSPTRLookup struct{}
+
+// Name 来源名称
+func (MDNSPTRLookup) Name() string { return HostnameSourceMDNS }
+
+// LookupPTR 反向解析
+func (MDNSPTRLookup) LookupPTR(ctx context.Context, ip string) (string, error) {
+	return mdns.LookupAddr(ctx, ip)
+}
+
+// MihomoPTRLookup 通过 mihomo 的 /dns/query 反向解析
+type MihomoPTRLookup struct {
+	Client *api.Client
+}
+
+// Name 来源名称
+func (MihomoPTRLookup) Name() string { return HostnameSourceMihomo }
+
+// LookupPTR 反向解析
+func (l MihomoPTRLookup) LookupPTR(ctx context.Context, ip string) (string, error) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return "", fmt.Errorf("无效的 IP: %s", ip)
+	}
+	resp, err := l.Client.QueryDNS(ctx, mdns.ReverseName(addr), "PTR")
+	if err != nil {
+		return "", err
+	}
+	for _, ans := range resp.Answer {
+		if ans.Type == 12 && ans.Data != "" {
+			return strings.TrimSuffix(ans.Data, "."), nil
+		}
+	}
+	return "", errors.New("无 PTR 记录")
+}
+
+// NewPTRLookupsFromConfig 按 lan_name_lookup 创建反查方式，为空时依次使用系统 DNS 与 mDNS，"off" 关闭
+func NewPTRLookupsFromConfig(cfg *config.Config, client *api.Client) ([]PTRLookup, error) {
+	names := cfg.LANNameLookup
+	if len(names) == 0 {
+		names = []string{HostnameSourceDNS, HostnameSourceMDNS}
+	}
+	var lookups []PTRLookup
+	for _, name := range names {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "off", "none":
+			return nil, nil
+		case HostnameSourceDNS, "system":
+			lookups = append(lookups, SystemPTRLookup{})
+		case HostnameSourceMDNS:
+			lookups = append(lookups, MDNSPTRLookup{})
+		case HostnameSourceMihomo:
+			if client == nil {
+				continue
+			}
+			lookups = append(lookups, MihomoPTRLookup{Client: client})
+		default:
+			return nil, fmt.Errorf("未知的设备名反查方式: %s (可用: dns, mdns, mihomo, off)", name)
+		}
+	}
+	return lookups, nil
+}
+
+// HostnameResolver 带缓存的局域网设备名反查，同时记住从 DHCP 租约等被动学到的名称
+type HostnameResolver struct {
+	lookups []PTRLookup
+
+	mu    sync.Mutex
+	cache map[string]hostnameEntry
+	now   func() time.Time
+}
+
+type hostnameEntry struct {
+	name    string
+	source  string
+	expires time.Time
+}
+
+// NewHostnameResolver 创建设备名反查，lookups 按顺序尝试
+func NewHostnameResolver(lookups ...PTRLookup) *HostnameResolver {
+	return &HostnameResolver{
+		lookups: lookups,
+		cache:   make(map[string]hostnameEntry),
+		now:     time.Now,
+	}
+}
+
+// Peek 只读取缓存
+func (h *HostnameResolver) Peek(ip string) (name, source string, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	e, ok := h.cache[ip]
+	if !ok || h.now().After(e.expires) {
+		return "", "", false
+	}
+	return e.name, e.source, true
+}
+
+// Learn 记录被动获得的设备名（如 DHCP 租约），覆盖反查结果
+func (h *HostnameResolver) Learn(ip, name, source string) {
+	if ip == "" || name == "" {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cache[ip] = hostnameEntry{name: name, source: source, expires: h.now().Add(hostnameTTL)}
+}
+
+// Lookup 返回设备名与来源，未命中缓存时依次反查；查不到时返回空字符串（短时间内不再重试）
+func (h *HostnameResolver) Lookup(ctx context.Context, ip string) (name, source string) {
+	if name, source, ok := h.Peek(ip); ok {
+		return name, source
+	}
+	for _, l := range h.lookups {
+		lookupCtx, cancel := context.WithTimeout(ctx, hostnameLookupTimeout)
+		name, err := l.LookupPTR(lookupCtx, ip)
+		cancel()
+		if err == nil && name != "" {
+			h.Learn(ip, name, l.Name())
+			return name, l.Name()
+		}
+		if ctx.Err() != nil {
+			// 调用方放弃时不记录为查不到
+			return "", ""
+		}
+	}
+	h.mu.Lock()
+	h.cache[ip] = hostnameEntry{expires: h.now().Add(hostnameNegativeTTL)}
+	h.mu.Unlock()
+	return "", ""
+}
+
+// HostnameSourceLabel 设备名来源的显示文字
+func HostnameSourceLabel(source string) string {
+	switch source {
+	case HostnameSourceDNS:
+		return "反向 DNS"
+	case HostnameSourceMDNS:
+		return "mDNS"
+	case HostnameSourceMihomo:
+		return "mihomo DNS"
+	case HostnameSourceDHCP:
+		return "DHCP 租约"
+	}
+	return source
+}
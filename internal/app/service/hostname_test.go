@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aimony/mihosh/internal/infrastructure/api"
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubPTRLookup struct {
+	name  string
+	names map[string]string
+	calls int
+}
+
+func (l *stubPTRLookup) Name() string { return l.name }
+
+func (l *stubPTRLookup) LookupPTR(_ context.Context, ip string) (string, error) {
+	l.calls++
+	if name, ok := l.names[ip]; ok {
+		return name, nil
+	}
+	return "", errors.New("no such host")
+}
+
+func TestHostnameResolverCachesAndFallsBack(t *testing.T) {
+	dns := &stubPTRLookup{name: HostnameSourceDNS, names: map[string]string{"192.168.1.20": "pixel-8.lan"}}
+	mdns := &stubPTRLookup{name: HostnameSourceMDNS, names: map[string]string{"192.168.1.30": "Alices-MacBook.local"}}
+	h := NewHostnameResolver(dns, mdns)
+	now := time.Now()
+	h.now = func() time.Time { return now }
+
+	name, source := h.Lookup(context.Background(), "192.168.1.20")
+	assert.Equal(t, "pixel-8.lan", name)
+	assert.Equal(t, HostnameSourceDNS, source)
+	assert.Equal(t, 0, mdns.calls)
+
+	name, source = h.Lookup(context.Background(), "192.168.1.30")
+	assert.Equal(t, "Alices-MacBook.local", name)
+	assert.Equal(t, HostnameSourceMDNS, source)
+
+	// 查不到的结果也缓存，短时间内不再反查
+	name, _ = h.Lookup(context.Background(), "192.168.1.40")
+	assert.Empty(t, name)
+	calls := dns.calls
+	h.Lookup(context.Background(), "192.168.1.40")
+	h.Lookup(context.Background(), "192.168.1.20")
+	assert.Equal(t, calls, dns.calls)
+
+	now = now.Add(hostnameNegativeTTL + time.Second)
+	h.Lookup(context.Background(), "192.168.1.40")
+	assert.Equal(t, calls+1, dns.calls)
+
+	h.Learn("192.168.1.40", "printer", HostnameSourceDHCP)
+	name, source, ok := h.Peek("192.168.1.40")
+	assert.True(t, ok)
+	assert.Equal(t, "printer", name)
+	assert.Equal(t, "DHCP 租约", HostnameSourceLabel(source))
+}
+
+func TestIPResolverUsesHostnames(t *testing.T) {
+	env := fakeIPSourceEnv(nil, map[string]string{
+		"/leases": "1700000000 aa:bb:cc:dd:ee:01 192.168.1.50 kindle *\n",
+	})
+	r := NewIPResolverWithSources(env, []IPSource{DHCPLeaseIPSource{Files: []string{"/leases"}}})
+	dns := &stubPTRLookup{name: HostnameSourceDNS, names: map[string]string{"192.168.1.20": "pixel-8.lan", "172.20.0.9": "nas.lan"}}
+	names := NewHostnameResolver(dns)
+	r.SetHostnameResolver(names)
+
+	phone := r.Resolve("192.168.1.20")
+	assert.Equal(t, "lan", phone.NetworkType)
+	assert.Equal(t, "pixel-8.lan", phone.AppName)
+	assert.Equal(t, "反向 DNS", phone.AppDetail)
+	assert.False(t, phone.Guessed)
+
+	// 172.16/12 按网段会被猜成 Docker，反查到名称后改为局域网设备
+	assert.Equal(t, "lan", r.Resolve("172.20.0.9").NetworkType)
+
+	unknown := r.Resolve("192.168.1.99")
+	assert.True(t, unknown.Guessed)
+	assert.Equal(t, "", r.SourceName("192.168.1.99"))
+	assert.Equal(t, "", r.SourceName("8.8.8.8"))
+	assert.Equal(t, "本机", r.SourceName("127.0.0.1"))
+
+	// 租约中的名称被动记录下来
+	assert.Equal(t, "kindle", r.SourceName("192.168.1.50"))
+	name, source, ok := names.Peek("192.168.1.50")
+	require.True(t, ok)
+	assert.Equal(t, "kindle", name)
+	assert.Equal(t, HostnameSourceDHCP, source)
+}
+
+func TestMihomoPTRLookup(t *testing.T) {
+	svc := newTestProxyService(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/dns/query" || r.URL.Query().Get("type") != "PTR" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.URL.Query().Get("name") {
+		case "20.1.168.192.in-addr.arpa.":
+			fmt.Fprint(w, `{"Status":0,"Answer":[{"name":"20.1.168.192.in-addr.arpa.","type":12,"TTL":60,"data":"pixel-8.lan."}]}`)
+		default:
+			fmt.Fprint(w, `{"Status":3}`)
+		}
+	})
+
+	l := MihomoPTRLookup{Client: svc.client}
+	name, err := l.LookupPTR(context.Background(), "192.168.1.20")
+	require.NoError(t, err)
+	assert.Equal(t, "pixel-8.lan", name)
+	_, err = l.LookupPTR(context.Background(), "192.168.1.21")
+	assert.Error(t, err)
+}
+
+func TestNewPTRLookupsFromConfig(t *testing.T) {
+	client := api.NewClient(&config.Config{APIAddress: "http://127.0.0.1:9090"})
+
+	lookups, err := NewPTRLookupsFromConfig(&config.Config{}, client)
+	require.NoError(t, err)
+	require.Len(t, lookups, 2)
+	assert.Equal(t, HostnameSourceDNS, lookups[0].Name())
+	assert.Equal(t, HostnameSourceMDNS, lookups[1].Name())
+
+	lookups, err = NewPTRLookupsFromConfig(&config.Config{LANNameLookup: []string{"mihomo", "dns"}}, client)
+	require.NoError(t, err)
+	assert.Equal(t, HostnameSourceMihomo, lookups[0].Name())
+
+	lookups, err = NewPTRLookupsFromConfig(&config.Config{LANNameLookup: []string{"off"}}, client)
+	require.NoError(t, err)
+	assert.Empty(t, lookups)
+
+	_, err = NewPTRLookupsFromConfig(&config.Config{LANNameLookup: []string{"netbios"}}, client)
+	assert.Error(t, err)
+}
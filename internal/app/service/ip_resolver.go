@@ -1,10 +1,9 @@
 package service
 
 import (
-	"encoding/json"
+	"context"
 	"net"
-	"os/exec"
-	"strings"
+	"sort"
 	"sync"
 	"time"
 
@@ -13,33 +12,53 @@ import (
 
 // IPResolver 内网IP解析器（带缓存）
 type IPResolver struct {
-	mu            sync.Mutex
-	cache         map[string]*model.ResolvedIP
-	cacheTime     time.Time
-	cacheTTL      time.Duration
-	dockerChecked bool
-	dockerOK      bool
-	tsChecked     bool
-	tsOK          bool
+	mu        sync.Mutex
+	cache     map[string]*model.ResolvedIP
+	cacheTime time.Time
+	cacheTTL  time.Duration
+	sources   []IPSource
+	env       IPSourceEnv
+	available map[string]bool // 各解析源是否可用，首次刷新时检查
+	names     *HostnameResolver
+
+	proc     *ProcSource
+	procSnap *ProcSnapshot
+	procTime time.Time
 }
 
-// NewIPResolver 创建IP解析器
+// procTTL /proc 索引的刷新间隔
+const procTTL = 5 * time.Second
+
+// NewIPResolver 创建IP解析器（启用全部内置解析源）
 func NewIPResolver() *IPResolver {
+	return NewIPResolverWithSources(DefaultIPSourceEnv(), DefaultIPSources())
+}
+
+// NewIPResolverWithSources 使用指定的解析源创建IP解析器，sources 按优先级从高到低排列
+func NewIPResolverWithSources(env IPSourceEnv, sources []IPSource) *IPResolver {
 	return &IPResolver{
 		cache:    make(map[string]*model.ResolvedIP),
 		cacheTTL: 60 * time.Second,
+		sources:  sources,
+		env:      env,
+		proc:     NewProcSource("/proc"),
 	}
 }
 
-// Resolve 解析IP地址，返回解析结果
+// SetHostnameResolver 设置局域网设备名反查（nil 时只按网段推测）
+func (r *IPResolver) SetHostnameResolver(h *HostnameResolver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.names = h
+}
+
+// Resolve 解析IP地址，返回解析结果；解析源都未收录时尝试反查设备名（可能阻塞数秒）
 func (r *IPResolver) Resolve(ip string) *model.ResolvedIP {
 	if !IsPrivateIP(ip) {
 		return &model.ResolvedIP{IP: ip, IsPrivate: false}
 	}
 
 	r.mu.Lock()
-	defer r.mu.Unlock()
-
 	// 缓存过期则刷新
 	if time.Since(r.cacheTime) >= r.cacheTTL {
 		r.refreshCache()
@@ -47,11 +66,88 @@ func (r *IPResolver) Resolve(ip string) *model.ResolvedIP {
 
 	// 查缓存
 	if resolved, ok := r.cache[ip]; ok {
+		r.mu.Unlock()
 		return resolved
 	}
 
 	// 缓存中未找到，按网段推测
-	return r.guessBySubnet(ip)
+	result := r.guessBySubnet(ip)
+	names := r.names
+	r.mu.Unlock()
+
+	if names != nil && result.Guessed {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*hostnameLookupTimeout)
+		defer cancel()
+		if name, source := names.Lookup(ctx, ip); name != "" {
+			result.AppName = name
+			result.AppDetail = HostnameSourceLabel(source)
+			result.Guessed = false
+			if result.NetworkType == "docker" {
+				// 172.16/12 只是按网段猜测的 Docker，能反查到名称说明是局域网设备
+				result.NetworkType = "lan"
+			}
+		}
+	}
+	return result
+}
+
+// SourceName 连接来源的设备名（容器名、主机名等），只有网段推测结果时返回空
+func (r *IPResolver) SourceName(ip string) string {
+	resolved := r.Resolve(ip)
+	if !resolved.IsPrivate || resolved.Guessed {
+		return ""
+	}
+	return resolved.AppName
+}
+
+// ResolveProcess 通过 /proc 查找本机连接所属的进程，非 Linux 或找不到时返回 nil
+func (r *IPResolver) ResolveProcess(c model.Connection) *model.ProcessInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.resolveProcessLocked(c)
+}
+
+func (r *IPResolver) resolveProcessLocked(c model.Connection) *model.ProcessInfo {
+	if r.proc == nil {
+		return nil
+	}
+	if time.Since(r.procTime) >= procTTL {
+		r.procSnap, _ = r.proc.Snapshot()
+		r.procTime = time.Now()
+	}
+	if r.procSnap == nil {
+		return nil
+	}
+	return r.procSnap.Lookup(c)
+}
+
+// GroupProcesses 按进程聚合连接，并补充 PID、用户、systemd 单元与容器信息
+func (r *IPResolver) GroupProcesses(conns []model.Connection) []ProcessGroup {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	groups := GroupConnections(conns, GroupByProcess)
+	result := make([]ProcessGroup, len(groups))
+	for i, g := range groups {
+		pg := ProcessGroup{ConnGroup: g}
+		seen := make(map[int]bool)
+		for _, c := range g.Connections {
+			info := r.resolveProcessLocked(c)
+			if info == nil {
+				continue
+			}
+			if pg.Info == nil {
+				pg.Info = info
+			}
+			if info.PID > 0 && !seen[info.PID] {
+				seen[info.PID] = true
+				pg.PIDs = append(pg.PIDs, info.PID)
+			}
+		}
+		sort.Ints(pg.PIDs)
+		result[i] = pg
+	}
+	return result
 }
 
 // IsPrivateIP 判断是否为内网IP
@@ -71,139 +167,51 @@ func isCGNAT(ip net.IP) bool {
 func (r *IPResolver) refreshCache() {
 	r.cache = make(map[string]*model.ResolvedIP)
 	r.cacheTime = time.Now()
-	r.refreshDocker()
-	r.refreshTailscale()
-}
-
-// ── Docker 解析 ──
-
-func (r *IPResolver) refreshDocker() {
-	if !r.dockerChecked {
-		_, err := exec.LookPath("docker")
-		r.dockerOK = err == nil
-		r.dockerChecked = true
-	}
-	if !r.dockerOK {
-		return
-	}
-
-	out, err := exec.Command("docker", "ps", "-q").Output()
-	if err != nil {
-		return
-	}
-
-	ids := strings.Fields(strings.TrimSpace(string(out)))
-	if len(ids) == 0 {
-		return
-	}
-
-	// 一次 inspect 所有容器：Name|Image|IP1,IP2,...
-	args := append([]string{"inspect", "--format",
-		"{{.Name}}|{{.Config.Image}}|{{range .NetworkSettings.Networks}}{{.IPAddress}},{{end}}"}, ids...)
-	out, err = exec.Command("docker", args...).Output()
-	if err != nil {
-		return
+	if r.available == nil {
+		r.available = make(map[string]bool, len(r.sources))
+		for _, src := range r.sources {
+			r.available[src.Name()] = src.Available(r.env)
+		}
 	}
-
-	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
-		line = strings.TrimSpace(line)
-		if line == "" {
+	// 排在前面的解析源优先，后面的不覆盖已有结果
+	for _, src := range r.sources {
+		if !r.available[src.Name()] {
 			continue
 		}
-		parts := strings.SplitN(line, "|", 3)
-		if len(parts) < 3 {
+		entries, err := src.Collect(r.env)
+		if err != nil {
 			continue
 		}
-		name := strings.TrimPrefix(parts[0], "/")
-		image := parts[1]
-		ips := strings.Split(strings.TrimRight(parts[2], ","), ",")
-		for _, ip := range ips {
-			ip = strings.TrimSpace(ip)
-			if ip != "" {
-				r.cache[ip] = &model.ResolvedIP{
-					IP:          ip,
-					IsPrivate:   true,
-					NetworkType: "docker",
-					AppName:     name,
-					AppDetail:   image,
-				}
+		for _, e := range entries {
+			if _, ok := r.cache[e.IP]; ok || e.IP == "" {
+				continue
 			}
-		}
-	}
-}
-
-// ── Tailscale 解析 ──
-
-func (r *IPResolver) refreshTailscale() {
-	if !r.tsChecked {
-		_, err := exec.LookPath("tailscale")
-		r.tsOK = err == nil
-		r.tsChecked = true
-	}
-	if !r.tsOK {
-		return
-	}
-
-	out, err := exec.Command("tailscale", "status", "--json").Output()
-	if err != nil {
-		return
-	}
-
-	var status tailscaleStatus
-	if err := json.Unmarshal(out, &status); err != nil {
-		return
-	}
-
-	// 添加自身
-	for _, ip := range status.Self.TailscaleIPs {
-		r.cache[ip] = &model.ResolvedIP{
-			IP:          ip,
-			IsPrivate:   true,
-			NetworkType: "tailscale",
-			AppName:     status.Self.HostName,
-			AppDetail:   status.Self.OS,
-		}
-	}
-
-	// 添加 peers
-	for _, peer := range status.Peer {
-		for _, ip := range peer.TailscaleIPs {
-			r.cache[ip] = &model.ResolvedIP{
-				IP:          ip,
-				IsPrivate:   true,
-				NetworkType: "tailscale",
-				AppName:     peer.HostName,
-				AppDetail:   peer.OS,
+			e.IsPrivate = true
+			if e.NetworkType == "" {
+				e.NetworkType = src.Name()
+			}
+			resolved := e
+			r.cache[e.IP] = &resolved
+			// 记住租约中的设备名，租约过期后仍可识别
+			if r.names != nil && e.NetworkType == HostnameSourceDHCP && e.AppName != unnamedLeaseHost {
+				r.names.Learn(e.IP, e.AppName, HostnameSourceDHCP)
 			}
 		}
 	}
 }
 
-// tailscaleStatus tailscale status --json 的部分结构
-type tailscaleStatus struct {
-	Self struct {
-		HostName     string   `json:"HostName"`
-		TailscaleIPs []string `json:"TailscaleIPs"`
-		OS           string   `json:"OS"`
-	} `json:"Self"`
-	Peer map[string]struct {
-		HostName     string   `json:"HostName"`
-		TailscaleIPs []string `json:"TailscaleIPs"`
-		OS           string   `json:"OS"`
-	} `json:"Peer"`
-}
-
 // ── 网段推测（降级） ──
 
 func (r *IPResolver) guessBySubnet(ip string) *model.ResolvedIP {
 	netIP := net.ParseIP(ip)
 	if netIP == nil {
-		return &model.ResolvedIP{IP: ip, IsPrivate: true, NetworkType: "unknown", AppName: "(未知来源)"}
+		return &model.ResolvedIP{IP: ip, IsPrivate: true, NetworkType: "unknown", AppName: "(未知来源)", Guessed: true}
 	}
 
-	result := &model.ResolvedIP{IP: ip, IsPrivate: true}
+	result := &model.ResolvedIP{IP: ip, IsPrivate: true, Guessed: true}
 
 	if netIP.IsLoopback() {
+		result.Guessed = false
 		result.NetworkType = "local"
 		result.AppName = "本机"
 		return result
@@ -213,7 +221,7 @@ func (r *IPResolver) guessBySubnet(ip string) *model.ResolvedIP {
 	_, docker172, _ := net.ParseCIDR("172.16.0.0/12")
 	if docker172 != nil && docker172.Contains(netIP) {
 		result.NetworkType = "docker"
-		if !r.dockerOK {
+		if !r.available["docker"] && !r.available["podman"] {
 			result.AppName = "(CLI未安装)"
 		} else {
 			result.AppName = "(未知容器)"
@@ -224,7 +232,7 @@ func (r *IPResolver) guessBySubnet(ip string) *model.ResolvedIP {
 	// Tailscale CGNAT: 100.64.0.0/10
 	if isCGNAT(netIP) {
 		result.NetworkType = "tailscale"
-		if !r.tsOK {
+		if !r.available["tailscale"] {
 			result.AppName = "(CLI未安装)"
 		} else {
 			result.AppName = "(未知设备)"
@@ -0,0 +1,597 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+)
+
+// ipSourceCommandTimeout 解析源执行外部命令的超时
+const ipSourceCommandTimeout = 5 * time.Second
+
+// IPSourceEnv 解析源访问外部环境的方式，测试时可替换为假的命令输出与文件内容
+type IPSourceEnv struct {
+	// Run 执行命令并返回标准输出
+	Run func(name string, args ...string) ([]byte, error)
+	// LookPath 查找可执行文件
+	LookPath func(file string) (string, error)
+	// ReadFile 读取文件
+	ReadFile func(path string) ([]byte, error)
+}
+
+// DefaultIPSourceEnv 使用真实命令与文件系统的环境
+func DefaultIPSourceEnv() IPSourceEnv {
+	return IPSourceEnv{
+		Run: func(name string, args ...string) ([]byte, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), ipSourceCommandTimeout)
+			defer cancel()
+			return exec.CommandContext(ctx, name, args...).Output()
+		},
+		LookPath: exec.LookPath,
+		ReadFile: os.ReadFile,
+	}
+}
+
+func (env IPSourceEnv) hasCommand(name string) bool {
+	if env.LookPath == nil {
+		return false
+	}
+	_, err := env.LookPath(name)
+	return err == nil
+}
+
+func (env IPSourceEnv) hasFile(path string) bool {
+	if env.ReadFile == nil {
+		return false
+	}
+	_, err := env.ReadFile(path)
+	return err == nil
+}
+
+// IPSource 内网 IP 解析源
+type IPSource interface {
+	// Name 解析源名称，也是结果默认的 NetworkType
+	Name() string
+	// Available 所需的命令或文件是否存在（每个解析器只检查一次）
+	Available(env IPSourceEnv) bool
+	// Collect 列出当前已知的 IP 及其归属
+	Collect(env IPSourceEnv) ([]model.ResolvedIP, error)
+}
+
+// IPSourceFactory 按配置创建解析源
+type IPSourceFactory func(cfg *config.Config) IPSource
+
+type ipSourceEntry struct {
+	name    string
+	factory IPSourceFactory
+}
+
+// ipSourceRegistry 已注册的解析源，按优先级从高到低排列
+var ipSourceRegistry = []ipSourceEntry{
+	{"static", func(cfg *config.Config) IPSource { return StaticIPSource(cfg.IPNames) }},
+	{"docker", func(*config.Config) IPSource { return dockerIPSource() }},
+	{"podman", func(*config.Config) IPSource { return podmanIPSource() }},
+	{"libvirt", func(*config.Config) IPSource { return LibvirtIPSource{} }},
+	{"incus", func(*config.Config) IPSource { return InstanceIPSource{Command: "incus"} }},
+	{"lxd", func(*config.Config) IPSource { return InstanceIPSource{Command: "lxc"} }},
+	{"kubernetes", func(*config.Config) IPSource { return KubernetesIPSource{} }},
+	{"tailscale", func(*config.Config) IPSource { return TailscaleIPSource{} }},
+	{"dhcp", func(cfg *config.Config) IPSource { return DHCPLeaseIPSource{Files: cfg.DHCPLeaseFiles} }},
+	{"hosts", func(*config.Config) IPSource { return HostsIPSource{} }},
+}
+
+// RegisterIPSource 注册新的解析源（名称重复时替换原有实现，否则追加到末尾）
+func RegisterIPSource(name string, factory IPSourceFactory) {
+	for i, e := range ipSourceRegistry {
+		if e.name == name {
+			ipSourceRegistry[i].factory = factory
+			return
+		}
+	}
+	ipSourceRegistry = append(ipSourceRegistry, ipSourceEntry{name: name, factory: factory})
+}
+
+// IPSourceNames 已注册的解析源名称（按优先级）
+func IPSourceNames() []string {
+	names := make([]string, len(ipSourceRegistry))
+	for i, e := range ipSourceRegistry {
+		names[i] = e.name
+	}
+	return names
+}
+
+// DefaultIPSources 使用默认配置创建全部解析源
+func DefaultIPSources() []IPSource {
+	sources, _ := NewIPSourcesFromConfig(&config.Config{})
+	return sources
+}
+
+// NewIPSourcesFromConfig 按 ip_sources 创建解析源，为空时启用全部；
+// 指定的顺序即优先级，未知名称返回错误
+func NewIPSourcesFromConfig(cfg *config.Config) ([]IPSource, error) {
+	if len(cfg.IPSources) == 0 {
+		sources := make([]IPSource, len(ipSourceRegistry))
+		for i, e := range ipSourceRegistry {
+			sources[i] = e.factory(cfg)
+		}
+		return sources, nil
+	}
+	sources := make([]IPSource, 0, len(cfg.IPSources))
+	for _, name := range cfg.IPSources {
+		name = strings.ToLower(strings.TrimSpace(name))
+		found := false
+		for _, e := range ipSourceRegistry {
+			if e.name == name {
+				sources = append(sources, e.factory(cfg))
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("未知的 IP 解析源: %s (可用: %s)", name, strings.Join(IPSourceNames(), ", "))
+		}
+	}
+	return sources, nil
+}
+
+// NewIPResolverFromConfig 按配置创建IP解析器
+func NewIPResolverFromConfig(cfg *config.Config) (*IPResolver, error) {
+	sources, err := NewIPSourcesFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewIPResolverWithSources(DefaultIPSourceEnv(), sources), nil
+}
+
+// ── 用户静态映射 ──
+
+// StaticIPSource 配置中手工维护的 IP 名称（ip_names）
+type StaticIPSource []config.IPName
+
+// Name 解析源名称
+func (StaticIPSource) Name() string { return "static" }
+
+// Available 有映射时可用
+func (s StaticIPSource) Available(IPSourceEnv) bool { return len(s) > 0 }
+
+// Collect 返回全部填写了 IP 的条目
+func (s StaticIPSource) Collect(IPSourceEnv) ([]model.ResolvedIP, error) {
+	out := make([]model.ResolvedIP, 0, len(s))
+	for _, entry := range s {
+		ip := strings.TrimSpace(entry.IP)
+		if ip == "" {
+			continue
+		}
+		out = append(out, model.ResolvedIP{IP: ip, AppName: strings.TrimSpace(entry.Name), AppDetail: strings.TrimSpace(entry.Detail)})
+	}
+	return out, nil
+}
+
+// ── Docker / Podman ──
+
+// ContainerIPSource 通过 ps + inspect 列出容器 IP（Docker 与 Podman 命令兼容）
+type ContainerIPSource struct {
+	Command string
+	// Format inspect 的 Go 模板，输出 名称|镜像|IP1,IP2,...
+	Format string
+}
+
+func dockerIPSource() ContainerIPSource {
+	return ContainerIPSource{Command: "docker", Format: "{{.Name}}|{{.Config.Image}}|{{range .NetworkSettings.Networks}}{{.IPAddress}},{{end}}"}
+}
+
+func podmanIPSource() ContainerIPSource {
+	return ContainerIPSource{Command: "podman", Format: "{{.Name}}|{{.ImageName}}|{{range .NetworkSettings.Networks}}{{.IPAddress}},{{end}}"}
+}
+
+// Name 解析源名称
+func (s ContainerIPSource) Name() string { return s.Command }
+
+// Available 命令存在时可用
+func (s ContainerIPSource) Available(env IPSourceEnv) bool { return env.hasCommand(s.Command) }
+
+// Collect 一次 inspect 所有运行中的容器
+func (s ContainerIPSource) Collect(env IPSourceEnv) ([]model.ResolvedIP, error) {
+	out, err := env.Run(s.Command, "ps", "-q")
+	if err != nil {
+		return nil, err
+	}
+	ids := strings.Fields(string(out))
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	out, err = env.Run(s.Command, append([]string{"inspect", "--format", s.Format}, ids...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []model.ResolvedIP
+	for _, line := range nonEmptyLines(out) {
+		parts := strings.SplitN(line, "|", 3)
+		if len(parts) < 3 {
+			continue
+		}
+		name := strings.TrimPrefix(parts[0], "/")
+		for _, ip := range strings.Split(parts[2], ",") {
+			if ip = strings.TrimSpace(ip); ip != "" {
+				result = append(result, model.ResolvedIP{IP: ip, AppName: name, AppDetail: parts[1]})
+			}
+		}
+	}
+	return result, nil
+}
+
+// ── libvirt ──
+
+// LibvirtIPSource 通过 virsh domifaddr 列出运行中虚拟机的地址
+type LibvirtIPSource struct{}
+
+// Name 解析源名称
+func (LibvirtIPSource) Name() string { return "libvirt" }
+
+// Available virsh 存在时可用
+func (LibvirtIPSource) Available(env IPSourceEnv) bool { return env.hasCommand("virsh") }
+
+// Collect 逐个虚拟机查询网卡地址
+func (LibvirtIPSource) Collect(env IPSourceEnv) ([]model.ResolvedIP, error) {
+	out, err := env.Run("virsh", "list", "--name")
+	if err != nil {
+		return nil, err
+	}
+	var result []model.ResolvedIP
+	for _, domain := range nonEmptyLines(out) {
+		addrs, err := env.Run("virsh", "domifaddr", domain)
+		if err != nil {
+			continue
+		}
+		// 表格：Name  MAC address  Protocol  Address
+		for _, line := range nonEmptyLines(addrs) {
+			fields := strings.Fields(line)
+			if len(fields) < 4 {
+				continue
+			}
+			ip, _, err := net.ParseCIDR(fields[3])
+			if err != nil {
+				continue
+			}
+			result = append(result, model.ResolvedIP{IP: ip.String(), AppName: domain, AppDetail: fields[0] + " " + fields[1]})
+		}
+	}
+	return result, nil
+}
+
+// ── LXC / Incus ──
+
+// InstanceIPSource 通过 incus list 或 lxc list（LXD）的 JSON 输出列出实例地址
+type InstanceIPSource struct {
+	Command string
+}
+
+// Name 解析源名称
+func (s InstanceIPSource) Name() string {
+	if s.Command == "lxc" {
+		return "lxd"
+	}
+	return s.Command
+}
+
+// Available 命令存在时可用
+func (s InstanceIPSource) Available(env IPSourceEnv) bool { return env.hasCommand(s.Command) }
+
+// Collect 列出全部实例的全局地址
+func (s InstanceIPSource) Collect(env IPSourceEnv) ([]model.ResolvedIP, error) {
+	out, err := env.Run(s.Command, "list", "--format", "json")
+	if err != nil {
+		return nil, err
+	}
+	var instances []struct {
+		Name  string `json:"name"`
+		Type  string `json:"type"`
+		State *struct {
+			Network map[string]struct {
+				Addresses []struct {
+					Address string `json:"address"`
+					Scope   string `json:"scope"`
+				} `json:"addresses"`
+			} `json:"network"`
+		} `json:"state"`
+	}
+	if err := json.Unmarshal(out, &instances); err != nil {
+		return nil, err
+	}
+
+	var result []model.ResolvedIP
+	for _, inst := range instances {
+		if inst.State == nil {
+			continue
+		}
+		ifaces := make([]string, 0, len(inst.State.Network))
+		for name := range inst.State.Network {
+			ifaces = append(ifaces, name)
+		}
+		sort.Strings(ifaces)
+		for _, iface := range ifaces {
+			if iface == "lo" {
+				continue
+			}
+			for _, addr := range inst.State.Network[iface].Addresses {
+				if addr.Scope != "" && addr.Scope != "global" {
+					continue
+				}
+				result = append(result, model.ResolvedIP{IP: addr.Address, AppName: inst.Name, AppDetail: inst.Type})
+			}
+		}
+	}
+	return result, nil
+}
+
+// ── Kubernetes ──
+
+// KubernetesIPSource 通过 kubectl 列出所有命名空间的 Pod IP（跳过 hostNetwork Pod）
+type KubernetesIPSource struct{}
+
+// Name 解析源名称
+func (KubernetesIPSource) Name() string { return "kubernetes" }
+
+// Available kubectl 存在时可用
+func (KubernetesIPSource) Available(env IPSourceEnv) bool { return env.hasCommand("kubectl") }
+
+// Collect 列出 Pod IP
+func (KubernetesIPSource) Collect(env IPSourceEnv) ([]model.ResolvedIP, error) {
+	out, err := env.Run("kubectl", "get", "pods", "--all-namespaces", "--output", "json", "--request-timeout", "5s")
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+			Spec struct {
+				NodeName    string `json:"nodeName"`
+				HostNetwork bool   `json:"hostNetwork"`
+			} `json:"spec"`
+			Status struct {
+				PodIP  string `json:"podIP"`
+				PodIPs []struct {
+					IP string `json:"ip"`
+				} `json:"podIPs"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(out, &list); err != nil {
+		return nil, err
+	}
+
+	var result []model.ResolvedIP
+	for _, pod := range list.Items {
+		if pod.Spec.HostNetwork {
+			continue
+		}
+		ips := []string{pod.Status.PodIP}
+		for _, p := range pod.Status.PodIPs {
+			ips = append(ips, p.IP)
+		}
+		seen := make(map[string]bool)
+		for _, ip := range ips {
+			if ip == "" || seen[ip] {
+				continue
+			}
+			seen[ip] = true
+			result = append(result, model.ResolvedIP{
+				IP:        ip,
+				AppName:   pod.Metadata.Namespace + "/" + pod.Metadata.Name,
+				AppDetail: pod.Spec.NodeName,
+			})
+		}
+	}
+	return result, nil
+}
+
+// ── Tailscale ──
+
+// TailscaleIPSource 通过 tailscale status --json 列出本机与对端设备
+type TailscaleIPSource struct{}
+
+// Name 解析源名称
+func (TailscaleIPSource) Name() string { return "tailscale" }
+
+// Available tailscale 存在时可用
+func (TailscaleIPSource) Available(env IPSourceEnv) bool { return env.hasCommand("tailscale") }
+
+// Collect 列出本机与对端的 Tailscale IP
+func (TailscaleIPSource) Collect(env IPSourceEnv) ([]model.ResolvedIP, error) {
+	out, err := env.Run("tailscale", "status", "--json")
+	if err != nil {
+		return nil, err
+	}
+	var status tailscaleStatus
+	if err := json.Unmarshal(out, &status); err != nil {
+		return nil, err
+	}
+
+	var result []model.ResolvedIP
+	add := func(host, os string, ips []string) {
+		for _, ip := range ips {
+			result = append(result, model.ResolvedIP{IP: ip, AppName: host, AppDetail: os})
+		}
+	}
+	add(status.Self.HostName, status.Self.OS, status.Self.TailscaleIPs)
+	peers := make([]string, 0, len(status.Peer))
+	for key := range status.Peer {
+		peers = append(peers, key)
+	}
+	sort.Strings(peers)
+	for _, key := range peers {
+		peer := status.Peer[key]
+		add(peer.HostName, peer.OS, peer.TailscaleIPs)
+	}
+	return result, nil
+}
+
+// tailscaleStatus tailscale status --json 的部分结构
+type tailscaleStatus struct {
+	Self struct {
+		HostName     string   `json:"HostName"`
+		TailscaleIPs []string `json:"TailscaleIPs"`
+		OS           string   `json:"OS"`
+	} `json:"Self"`
+	Peer map[string]struct {
+		HostName     string   `json:"HostName"`
+		TailscaleIPs []string `json:"TailscaleIPs"`
+		OS           string   `json:"OS"`
+	} `json:"Peer"`
+}
+
+// ── DHCP 租约 ──
+
+// DefaultDHCPLeaseFiles dnsmasq 与 odhcpd（OpenWrt）租约文件的常见位置
+var DefaultDHCPLeaseFiles = []string{
+	"/var/lib/misc/dnsmasq.leases",
+	"/var/lib/dnsmasq/dnsmasq.leases",
+	"/tmp/dhcp.leases",
+	"/tmp/hosts/odhcpd",
+}
+
+// DHCPLeaseIPSource 读取 dnsmasq / odhcpd 租约文件，Files 为空时使用 DefaultDHCPLeaseFiles
+type DHCPLeaseIPSource struct {
+	Files []string
+}
+
+// Name 解析源名称
+func (DHCPLeaseIPSource) Name() string { return "dhcp" }
+
+func (s DHCPLeaseIPSource) files() []string {
+	if len(s.Files) > 0 {
+		return s.Files
+	}
+	return DefaultDHCPLeaseFiles
+}
+
+// Available 任一租约文件可读时可用
+func (s DHCPLeaseIPSource) Available(env IPSourceEnv) bool {
+	for _, f := range s.files() {
+		if env.hasFile(f) {
+			return true
+		}
+	}
+	return false
+}
+
+// Collect 解析全部可读的租约文件
+func (s DHCPLeaseIPSource) Collect(env IPSourceEnv) ([]model.ResolvedIP, error) {
+	var result []model.ResolvedIP
+	for _, f := range s.files() {
+		data, err := env.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		result = append(result, ParseDHCPLeases(data)...)
+	}
+	return result, nil
+}
+
+// ParseDHCPLeases 解析租约文件，支持以下格式：
+//
+//	dnsmasq: <过期时间> <MAC> <IP> <主机名|*> <客户端 ID>
+//	odhcpd:  # <接口> <DUID> <IAID> <主机名> <过期时间> <ID> <前缀长度> <地址/长度>...
+//	hosts:   <IP> <主机名>（/tmp/hosts/odhcpd）
+func ParseDHCPLeases(data []byte) []model.ResolvedIP {
+	var result []model.ResolvedIP
+	for _, line := range nonEmptyLines(data) {
+		fields := strings.Fields(line)
+		switch {
+		case fields[0] == "#" && len(fields) >= 9:
+			host := leaseHostname(fields[4])
+			for _, addr := range fields[8:] {
+				ip, _, _ := strings.Cut(addr, "/")
+				if net.ParseIP(ip) != nil {
+					result = append(result, model.ResolvedIP{IP: ip, AppName: host, AppDetail: fields[1]})
+				}
+			}
+		case strings.HasPrefix(fields[0], "#"):
+		case len(fields) >= 4 && net.ParseIP(fields[2]) != nil:
+			result = append(result, model.ResolvedIP{IP: fields[2], AppName: leaseHostname(fields[3]), AppDetail: fields[1]})
+		case len(fields) >= 2 && net.ParseIP(fields[0]) != nil:
+			result = append(result, model.ResolvedIP{IP: fields[0], AppName: fields[1]})
+		}
+	}
+	return result
+}
+
+// unnamedLeaseHost 租约中没有主机名时的显示文字
+const unnamedLeaseHost = "(未命名设备)"
+
+func leaseHostname(name string) string {
+	if name == "*" || name == "-" || name == "" {
+		return unnamedLeaseHost
+	}
+	return name
+}
+
+// ── /etc/hosts ──
+
+// HostsIPSource 读取 hosts 文件，Path 为空时使用 /etc/hosts
+type HostsIPSource struct {
+	Path string
+}
+
+// Name 解析源名称
+func (HostsIPSource) Name() string { return "hosts" }
+
+func (s HostsIPSource) path() string {
+	if s.Path != "" {
+		return s.Path
+	}
+	return "/etc/hosts"
+}
+
+// Available 文件可读时可用
+func (s HostsIPSource) Available(env IPSourceEnv) bool { return env.hasFile(s.path()) }
+
+// Collect 列出 hosts 中的内网地址（回环地址交给网段推测处理）
+func (s HostsIPSource) Collect(env IPSourceEnv) ([]model.ResolvedIP, error) {
+	data, err := env.ReadFile(s.path())
+	if err != nil {
+		return nil, err
+	}
+	var result []model.ResolvedIP
+	for _, line := range nonEmptyLines(data) {
+		line, _, _ = strings.Cut(line, "#")
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		ip := net.ParseIP(fields[0])
+		if ip == nil || ip.IsLoopback() || !IsPrivateIP(fields[0]) {
+			continue
+		}
+		result = append(result, model.ResolvedIP{IP: fields[0], AppName: fields[1], AppDetail: strings.Join(fields[2:], " ")})
+	}
+	return result, nil
+}
+
+// nonEmptyLines 按行拆分并去掉首尾空白与空行
+func nonEmptyLines(data []byte) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeIPSourceEnv 按 "命令 参数..." 返回预置输出，按路径返回预置文件
+func fakeIPSourceEnv(commands map[string]string, files map[string]string) IPSourceEnv {
+	return IPSourceEnv{
+		Run: func(name string, args ...string) ([]byte, error) {
+			key := strings.Join(append([]string{name}, args...), " ")
+			out, ok := commands[key]
+			if !ok {
+				return nil, errors.New("unexpected command: " + key)
+			}
+			return []byte(out), nil
+		},
+		LookPath: func(file string) (string, error) {
+			for key := range commands {
+				if strings.HasPrefix(key, file+" ") {
+					return "/usr/bin/" + file, nil
+				}
+			}
+			return "", errors.New("not found")
+		},
+		ReadFile: func(path string) ([]byte, error) {
+			data, ok := files[path]
+			if !ok {
+				return nil, os.ErrNotExist
+			}
+			return []byte(data), nil
+		},
+	}
+}
+
+func TestContainerIPSources(t *testing.T) {
+	docker := dockerIPSource()
+	podman := podmanIPSource()
+	env := fakeIPSourceEnv(map[string]string{
+		"docker ps -q": "abc\ndef\n",
+		"docker inspect --format " + docker.Format + " abc def": "/web|nginx:latest|172.17.0.2,\n/db|postgres:16|172.18.0.3,172.19.0.3,\n",
+		"podman ps -q": "123\n",
+		"podman inspect --format " + podman.Format + " 123": "cache|docker.io/library/redis:7|10.88.0.5,\n",
+	}, nil)
+
+	got, err := docker.Collect(env)
+	require.NoError(t, err)
+	assert.Equal(t, []model.ResolvedIP{
+		{IP: "172.17.0.2", AppName: "web", AppDetail: "nginx:latest"},
+		{IP: "172.18.0.3", AppName: "db", AppDetail: "postgres:16"},
+		{IP: "172.19.0.3", AppName: "db", AppDetail: "postgres:16"},
+	}, got)
+
+	assert.True(t, podman.Available(env))
+	got, err = podman.Collect(env)
+	require.NoError(t, err)
+	assert.Equal(t, []model.ResolvedIP{{IP: "10.88.0.5", AppName: "cache", AppDetail: "docker.io/library/redis:7"}}, got)
+}
+
+func TestLibvirtIPSource(t *testing.T) {
+	env := fakeIPSourceEnv(map[string]string{
+		"virsh list --name": "win11\nubuntu\n\n",
+		"virsh domifaddr win11": ` Name       MAC address          Protocol     Address
+-------------------------------------------------------------------------------
+ vnet0      52:54:00:aa:bb:cc    ipv4         192.168.122.50/24
+ vnet0      52:54:00:aa:bb:cc    ipv6         fd00::50/64
+`,
+		"virsh domifaddr ubuntu": " Name       MAC address          Protocol     Address\n" + strings.Repeat("-", 20) + "\n",
+	}, nil)
+
+	got, err := LibvirtIPSource{}.Collect(env)
+	require.NoError(t, err)
+	assert.Equal(t, []model.ResolvedIP{
+		{IP: "192.168.122.50", AppName: "win11", AppDetail: "vnet0 52:54:00:aa:bb:cc"},
+		{IP: "fd00::50", AppName: "win11", AppDetail: "vnet0 52:54:00:aa:bb:cc"},
+	}, got)
+}
+
+func TestInstanceIPSource(t *testing.T) {
+	env := fakeIPSourceEnv(map[string]string{
+		"incus list --format json": `[
+  {"name":"dev","type":"container","state":{"network":{
+    "lo":{"addresses":[{"family":"inet","address":"127.0.0.1","scope":"local"}]},
+    "eth0":{"addresses":[
+      {"family":"inet","address":"10.10.0.20","scope":"global"},
+      {"family":"inet6","address":"fe80::1","scope":"link"}]}}}},
+  {"name":"stopped","type":"virtual-machine","state":null}
+]`,
+	}, nil)
+
+	src := InstanceIPSource{Command: "incus"}
+	assert.Equal(t, "incus", src.Name())
+	assert.Equal(t, "lxd", InstanceIPSource{Command: "lxc"}.Name())
+	got, err := src.Collect(env)
+	require.NoError(t, err)
+	assert.Equal(t, []model.ResolvedIP{{IP: "10.10.0.20", AppName: "dev", AppDetail: "container"}}, got)
+}
+
+func TestKubernetesIPSource(t *testing.T) {
+	env := fakeIPSourceEnv(map[string]string{
+		"kubectl get pods --all-namespaces --output json --request-timeout 5s": `{"items":[
+  {"metadata":{"name":"api-7d9","namespace":"prod"},"spec":{"nodeName":"node-1"},
+   "status":{"podIP":"10.244.1.7","podIPs":[{"ip":"10.244.1.7"},{"ip":"fd00:10:244::7"}]}},
+  {"metadata":{"name":"kube-proxy-x","namespace":"kube-system"},"spec":{"nodeName":"node-1","hostNetwork":true},
+   "status":{"podIP":"192.168.1.10"}}
+]}`,
+	}, nil)
+
+	got, err := KubernetesIPSource{}.Collect(env)
+	require.NoError(t, err)
+	assert.Equal(t, []model.ResolvedIP{
+		{IP: "10.244.1.7", AppName: "prod/api-7d9", AppDetail: "node-1"},
+		{IP: "fd00:10:244::7", AppName: "prod/api-7d9", AppDetail: "node-1"},
+	}, got)
+}
+
+func TestTailscaleIPSource(t *testing.T) {
+	env := fakeIPSourceEnv(map[string]string{
+		"tailscale status --json": `{"Self":{"HostName":"laptop","TailscaleIPs":["100.64.0.1"],"OS":"linux"},
+"Peer":{"k1":{"HostName":"nas","TailscaleIPs":["100.64.0.2"],"OS":"linux"}}}`,
+	}, nil)
+
+	got, err := TailscaleIPSource{}.Collect(env)
+	require.NoError(t, err)
+	assert.Equal(t, []model.ResolvedIP{
+		{IP: "100.64.0.1", AppName: "laptop", AppDetail: "linux"},
+		{IP: "100.64.0.2", AppName: "nas", AppDetail: "linux"},
+	}, got)
+}
+
+func TestParseDHCPLeases(t *testing.T) {
+	got := ParseDHCPLeases([]byte(`1700000000 aa:bb:cc:dd:ee:01 192.168.1.20 phone 01:aa:bb:cc:dd:ee:01
+1700000000 aa:bb:cc:dd:ee:02 192.168.1.21 * *
+# br-lan 00010001aabbccdd 1a2b3c4d printer 1700000000 a1 128 fd00::a1/128 fd00::a2/128
+# comment line
+192.168.1.30 tv
+`))
+	assert.Equal(t, []model.ResolvedIP{
+		{IP: "192.168.1.20", AppName: "phone", AppDetail: "aa:bb:cc:dd:ee:01"},
+		{IP: "192.168.1.21", AppName: "(未命名设备)", AppDetail: "aa:bb:cc:dd:ee:02"},
+		{IP: "fd00::a1", AppName: "printer", AppDetail: "br-lan"},
+		{IP: "fd00::a2", AppName: "printer", AppDetail: "br-lan"},
+		{IP: "192.168.1.30", AppName: "tv"},
+	}, got)
+
+	src := DHCPLeaseIPSource{Files: []string{"/missing", "/leases"}}
+	env := fakeIPSourceEnv(nil, map[string]string{"/leases": "1700000000 aa:bb:cc:dd:ee:01 192.168.1.20 phone *\n"})
+	assert.True(t, src.Available(env))
+	entries, err := src.Collect(env)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestHostsIPSource(t *testing.T) {
+	env := fakeIPSourceEnv(nil, map[string]string{"/etc/hosts": `127.0.0.1 localhost
+::1 localhost ip6-localhost
+192.168.1.5 nas nas.lan # storage
+93.184.216.34 example.com
+`})
+	got, err := HostsIPSource{}.Collect(env)
+	require.NoError(t, err)
+	assert.Equal(t, []model.ResolvedIP{{IP: "192.168.1.5", AppName: "nas", AppDetail: "nas.lan"}}, got)
+}
+
+func TestIPResolverSourcePriority(t *testing.T) {
+	env := fakeIPSourceEnv(map[string]string{
+		"docker ps -q": "abc\n",
+		"docker inspect --format " + dockerIPSource().Format + " abc": "/web|nginx|172.17.0.2,\n",
+	}, map[string]string{"/etc/hosts": "172.17.0.2 web-from-hosts\n192.168.1.5 nas\n"})
+	static := StaticIPSource{{IP: "192.168.1.5", Name: "NAS", Detail: "群晖 DS920+"}}
+
+	r := NewIPResolverWithSources(env, []IPSource{static, dockerIPSource(), TailscaleIPSource{}, HostsIPSource{}})
+
+	nas := r.Resolve("192.168.1.5")
+	assert.Equal(t, "static", nas.NetworkType)
+	assert.Equal(t, "NAS", nas.AppName)
+	assert.Equal(t, "群晖 DS920+", nas.AppDetail)
+
+	web := r.Resolve("172.17.0.2")
+	assert.Equal(t, "docker", web.NetworkType)
+	assert.Equal(t, "web", web.AppName)
+	assert.True(t, web.IsPrivate)
+
+	// tailscale 不可用时按网段推测并提示未安装
+	ts := r.Resolve("100.64.0.9")
+	assert.Equal(t, "tailscale", ts.NetworkType)
+	assert.Equal(t, "(CLI未安装)", ts.AppName)
+
+	assert.False(t, r.Resolve("8.8.8.8").IsPrivate)
+}
+
+func TestNewIPSourcesFromConfig(t *testing.T) {
+	sources, err := NewIPSourcesFromConfig(&config.Config{})
+	require.NoError(t, err)
+	assert.Len(t, sources, len(IPSourceNames()))
+
+	sources, err = NewIPSourcesFromConfig(&config.Config{IPSources: []string{"hosts", " Docker "}, DHCPLeaseFiles: []string{"/x"}})
+	require.NoError(t, err)
+	require.Len(t, sources, 2)
+	assert.Equal(t, "hosts", sources[0].Name())
+	assert.Equal(t, "docker", sources[1].Name())
+
+	_, err = NewIPSourcesFromConfig(&config.Config{IPSources: []string{"vmware"}})
+	assert.ErrorContains(t, err, "未知的 IP 解析源: vmware")
+}
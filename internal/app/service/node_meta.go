@@ -0,0 +1,304 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+)
+
+// codePattern 匹配前后不紧邻字母的大写地区代码（"HK01"、"🇭🇰 HK" 算，"THK"、"HKT" 不算）
+func codePattern(code string) string {
+	return `(?:^|[^A-Za-z])` + code + `(?:[^A-Za-z]|$)`
+}
+
+// DefaultNodeRegionPatterns 内置地区识别规则（地区代码 -> 正则）
+var DefaultNodeRegionPatterns = map[string]string{
+	"HK": `香港|深港|沪港|🇭🇰|(?i:hong\s*kong)|` + codePattern("HK"),
+	"TW": `台湾|臺灣|台北|🇹🇼|(?i:taiwan)|` + codePattern("TW"),
+	"JP": `日本|东京|東京|大阪|🇯🇵|(?i:japan|tokyo|osaka)|` + codePattern("JP"),
+	"SG": `新加坡|狮城|🇸🇬|(?i:singapore)|` + codePattern("SG"),
+	"US": `美国|美國|洛杉矶|硅谷|西雅图|🇺🇸|(?i:united\s*states|america|los\s*angeles|seattle)|` + codePattern("US"),
+	"KR": `韩国|韓國|首尔|🇰🇷|(?i:korea|seoul)|` + codePattern("KR"),
+	"GB": `英国|伦敦|🇬🇧|(?i:united\s*kingdom|london)|` + codePattern("UK") + `|` + codePattern("GB"),
+	"DE": `德国|法兰克福|🇩🇪|(?i:germany|frankfurt)|` + codePattern("DE"),
+}
+
+// DefaultNodeMultiplierPattern 内置倍率识别规则：匹配 "0.5x"、"2倍"、"x2"、"×1.5" 等
+const DefaultNodeMultiplierPattern = `(?i)(?:^|[^A-Za-z0-9.])(\d+(?:\.\d+)?)\s*(?:x|×|倍)(?:[^A-Za-z]|$)|(?:^|[^A-Za-z])(?:x|×)\s*(\d+(?:\.\d+)?)`
+
+// NodeClassifier 基于可配置正则推导节点地区与倍率
+type NodeClassifier struct {
+	regions    []regionRule
+	multiplier *regexp.Regexp
+
+	mu    sync.Mutex
+	cache map[string]nodeNameMeta
+}
+
+type regionRule struct {
+	code string
+	re   *regexp.Regexp
+}
+
+type nodeNameMeta struct {
+	region     string
+	multiplier float64
+}
+
+// NewNodeClassifier 编译地区与倍率规则（为空时使用内置规则）
+func NewNodeClassifier(regionPatterns map[string]string, multiplierPattern string) (*NodeClassifier, error) {
+	if len(regionPatterns) == 0 {
+		regionPatterns = DefaultNodeRegionPatterns
+	}
+	if multiplierPattern == "" {
+		multiplierPattern = DefaultNodeMultiplierPattern
+	}
+
+	codes := make([]string, 0, len(regionPatterns))
+	for code := range regionPatterns {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	c := &NodeClassifier{cache: make(map[string]nodeNameMeta)}
+	for _, code := range codes {
+		re, err := regexp.Compile(regionPatterns[code])
+		if err != nil {
+			return nil, fmt.Errorf("地区规则 %s 无效: %w", code, err)
+		}
+		// viper 会将 map 键转为小写，统一使用大写地区代码
+		c.regions = append(c.regions, regionRule{code: strings.ToUpper(code), re: re})
+	}
+
+	re, err := regexp.Compile(multiplierPattern)
+	if err != nil {
+		return nil, fmt.Errorf("倍率规则无效: %w", err)
+	}
+	c.multiplier = re
+	return c, nil
+}
+
+// NewNodeClassifierFromConfig 根据配置创建节点分类器
+func NewNodeClassifierFromConfig(cfg *config.Config) (*NodeClassifier, error) {
+	if cfg == nil {
+		return DefaultNodeClassifier(), nil
+	}
+	return NewNodeClassifier(cfg.NodeRegionPatterns, cfg.NodeMultiplierPattern)
+}
+
+// DefaultNodeClassifier 使用内置规则的节点分类器
+func DefaultNodeClassifier() *NodeClassifier {
+	c, err := NewNodeClassifier(nil, "")
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Classify 推导节点元数据（名称相关的推导结果按名称缓存）
+func (c *NodeClassifier) Classify(p model.Proxy, name string) model.NodeMeta {
+	if name == "" {
+		name = p.Name
+	}
+	derived := c.classifyName(name)
+	return model.NodeMeta{
+		Region:     derived.region,
+		Protocol:   p.Type,
+		UDP:        p.UDP,
+		Multiplier: derived.multiplier,
+		Provider:   p.ProviderName,
+	}
+}
+
+func (c *NodeClassifier) classifyName(name string) nodeNameMeta {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if m, ok := c.cache[name]; ok {
+		return m
+	}
+
+	m := nodeNameMeta{multiplier: 1}
+	for _, rule := range c.regions {
+		if rule.re.MatchString(name) {
+			m.region = rule.code
+			break
+		}
+	}
+	if sub := c.multiplier.FindStringSubmatch(name); sub != nil {
+		for _, g := range sub[1:] {
+			if g == "" {
+				continue
+			}
+			if v, err := strconv.ParseFloat(g, 64); err == nil && v > 0 {
+				m.multiplier = v
+			}
+			break
+		}
+	}
+
+	c.cache[name] = m
+	return m
+}
+
+// FormatMultiplier 格式化倍率（如 1x、0.5x）
+func FormatMultiplier(m float64) string {
+	return strconv.FormatFloat(m, 'f', -1, 64) + "x"
+}
+
+// NodeSortKey 节点排序字段
+type NodeSortKey string
+
+const (
+	NodeSortOriginal   NodeSortKey = ""
+	NodeSortName       NodeSortKey = "name"
+	NodeSortDelay      NodeSortKey = "delay"
+	NodeSortRegion     NodeSortKey = "region"
+	NodeSortProtocol   NodeSortKey = "protocol"
+	NodeSortUDP        NodeSortKey = "udp"
+	NodeSortMultiplier NodeSortKey = "multiplier"
+)
+
+// ParseNodeSortKey 解析排序字段
+func ParseNodeSortKey(raw string) (NodeSortKey, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", "original", "default":
+		return NodeSortOriginal, nil
+	case "name":
+		return NodeSortName, nil
+	case "delay":
+		return NodeSortDelay, nil
+	case "region":
+		return NodeSortRegion, nil
+	case "protocol", "type":
+		return NodeSortProtocol, nil
+	case "udp":
+		return NodeSortUDP, nil
+	case "multiplier", "mult":
+		return NodeSortMultiplier, nil
+	}
+	return "", fmt.Errorf("不支持的排序字段: %q (可选: name|delay|region|protocol|udp|multiplier)", raw)
+}
+
+// NodeGroupKey 节点分组字段
+type NodeGroupKey string
+
+const (
+	NodeGroupNone       NodeGroupKey = ""
+	NodeGroupRegion     NodeGroupKey = "region"
+	NodeGroupProtocol   NodeGroupKey = "protocol"
+	NodeGroupUDP        NodeGroupKey = "udp"
+	NodeGroupMultiplier NodeGroupKey = "multiplier"
+)
+
+// ParseNodeGroupKey 解析分组字段
+func ParseNodeGroupKey(raw string) (NodeGroupKey, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", "none":
+		return NodeGroupNone, nil
+	case "region":
+		return NodeGroupRegion, nil
+	case "protocol", "type":
+		return NodeGroupProtocol, nil
+	case "udp":
+		return NodeGroupUDP, nil
+	case "multiplier", "mult":
+		return NodeGroupMultiplier, nil
+	}
+	return "", fmt.Errorf("不支持的分组字段: %q (可选: region|protocol|udp|multiplier)", raw)
+}
+
+// NodeGroupLabel 返回节点在指定分组字段下的分组名
+func NodeGroupLabel(meta model.NodeMeta, key NodeGroupKey) string {
+	switch key {
+	case NodeGroupRegion:
+		if meta.Region == "" {
+			return "其他"
+		}
+		return meta.Region
+	case NodeGroupProtocol:
+		if meta.Protocol == "" {
+			return "未知"
+		}
+		return meta.Protocol
+	case NodeGroupUDP:
+		if meta.UDP {
+			return "UDP"
+		}
+		return "无 UDP"
+	case NodeGroupMultiplier:
+		return FormatMultiplier(meta.Multiplier)
+	}
+	return ""
+}
+
+// SortNodes 按字段对节点名稳定排序（原地修改）
+func (c *NodeClassifier) SortNodes(names []string, proxies map[string]model.Proxy, key NodeSortKey) {
+	less := c.nodeLess(proxies, key)
+	if less == nil {
+		return
+	}
+	sort.SliceStable(names, func(i, j int) bool { return less(names[i], names[j]) })
+}
+
+// GroupNodes 按分组字段稳定排序，使同组节点相邻（保留组内原有顺序）
+func (c *NodeClassifier) GroupNodes(names []string, proxies map[string]model.Proxy, key NodeGroupKey) {
+	if key == NodeGroupNone {
+		return
+	}
+	c.SortNodes(names, proxies, NodeSortKey(key))
+}
+
+// ArrangeNodes 过滤、排序并分组节点列表，返回新切片
+func (c *NodeClassifier) ArrangeNodes(names []string, proxies map[string]model.Proxy, query NodeQuery, sortKey NodeSortKey, groupKey NodeGroupKey) []string {
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		if query.Match(name, c.Classify(proxies[name], name)) {
+			result = append(result, name)
+		}
+	}
+	c.SortNodes(result, proxies, sortKey)
+	c.GroupNodes(result, proxies, groupKey)
+	return result
+}
+
+func (c *NodeClassifier) nodeLess(proxies map[string]model.Proxy, key NodeSortKey) func(a, b string) bool {
+	meta := func(name string) model.NodeMeta { return c.Classify(proxies[name], name) }
+
+	switch key {
+	case NodeSortName:
+		return func(a, b string) bool { return strings.ToLower(a) < strings.ToLower(b) }
+	case NodeSortDelay:
+		// 未测速或失败的节点排在最后
+		rank := func(name string) int {
+			d := proxies[name].LastDelay()
+			if d <= 0 {
+				return 9999999 - d
+			}
+			return d
+		}
+		return func(a, b string) bool { return rank(a) < rank(b) }
+	case NodeSortRegion:
+		return func(a, b string) bool {
+			ra, rb := meta(a).Region, meta(b).Region
+			if (ra == "") != (rb == "") {
+				return rb == ""
+			}
+			return ra < rb
+		}
+	case NodeSortProtocol:
+		return func(a, b string) bool {
+			return strings.ToLower(meta(a).Protocol) < strings.ToLower(meta(b).Protocol)
+		}
+	case NodeSortUDP:
+		return func(a, b string) bool { return meta(a).UDP && !meta(b).UDP }
+	case NodeSortMultiplier:
+		return func(a, b string) bool { return meta(a).Multiplier < meta(b).Multiplier }
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeClassifierDefaults(t *testing.T) {
+	c := DefaultNodeClassifier()
+
+	tests := []struct {
+		name       string
+		region     string
+		multiplier float64
+	}{
+		{"🇭🇰 香港 01", "HK", 1},
+		{"HK-02 0.5x", "HK", 0.5},
+		{"HK01", "HK", 1},
+		{"THK Premium", "", 1},
+		{"日本 东京 x2", "JP", 2},
+		{"US 洛杉矶 1.5倍", "US", 1.5},
+		{"Netflix 2", "", 1},
+		{"Status Page", "", 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			meta := c.Classify(model.Proxy{Type: "Vmess", UDP: true, ProviderName: "sub"}, tc.name)
+			assert.Equal(t, tc.region, meta.Region)
+			assert.Equal(t, tc.multiplier, meta.Multiplier)
+			assert.Equal(t, "Vmess", meta.Protocol)
+			assert.True(t, meta.UDP)
+			assert.Equal(t, "sub", meta.Provider)
+		})
+	}
+}
+
+func TestNodeClassifierCustomPatterns(t *testing.T) {
+	// viper 读出的键为小写，需要统一成大写代码
+	c, err := NewNodeClassifier(map[string]string{"nl": `荷兰|Amsterdam`}, `\[(\d+(?:\.\d+)?)\]`)
+	assert.NoError(t, err)
+
+	meta := c.Classify(model.Proxy{}, "Amsterdam [3]")
+	assert.Equal(t, "NL", meta.Region)
+	assert.Equal(t, 3.0, meta.Multiplier)
+
+	_, err = NewNodeClassifier(map[string]string{"HK": `(`}, "")
+	assert.Error(t, err)
+}
+
+func TestNodeQueryMatch(t *testing.T) {
+	meta := model.NodeMeta{Region: "HK", Protocol: "Vmess", UDP: true, Multiplier: 0.5, Provider: "airport-a"}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"", true},
+		{"hk", true},
+		{"region:hk,jp", true},
+		{"region:jp", false},
+		{"type:vmess udp:yes", true},
+		{"udp:no", false},
+		{"mult:<1", true},
+		{"mult:>=1", false},
+		{"mult:0.5x", true},
+		{"provider:airport", true},
+		{"region:hk 高级", false},
+		{"foo:bar", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.query, func(t *testing.T) {
+			assert.Equal(t, tc.want, ParseNodeQuery(tc.query).Match("HK 01", meta))
+		})
+	}
+}
+
+func TestArrangeNodes(t *testing.T) {
+	c := DefaultNodeClassifier()
+	proxies := map[string]model.Proxy{
+		"JP 01":      {Type: "Trojan", History: []model.Delay{{Delay: 80}}},
+		"HK 01 2x":   {Type: "Vmess", UDP: true, History: []model.Delay{{Delay: 120}}},
+		"HK 02":      {Type: "Vmess", History: []model.Delay{{Delay: 50}}},
+		"Other 0.5x": {Type: "Vmess", UDP: true},
+	}
+	names := []string{"JP 01", "HK 01 2x", "HK 02", "Other 0.5x"}
+
+	assert.Equal(t, []string{"HK 02", "JP 01", "HK 01 2x", "Other 0.5x"},
+		c.ArrangeNodes(names, proxies, NodeQuery{}, NodeSortDelay, NodeGroupNone))
+	assert.Equal(t, []string{"HK 02", "HK 01 2x", "JP 01", "Other 0.5x"},
+		c.ArrangeNodes(names, proxies, NodeQuery{}, NodeSortDelay, NodeGroupRegion))
+	assert.Equal(t, []string{"Other 0.5x", "HK 01 2x"},
+		c.ArrangeNodes(names, proxies, ParseNodeQuery("udp:yes"), NodeSortMultiplier, NodeGroupNone))
+
+	_, err := ParseNodeSortKey("speed")
+	assert.Error(t, err)
+	_, err = ParseNodeGroupKey("country")
+	assert.Error(t, err)
+}
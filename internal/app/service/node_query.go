@@ -0,0 +1,167 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+)
+
+// NodeQuery 节点过滤条件
+//
+// 以空格分隔的条件之间为 AND，字段值中的逗号表示 OR，例如：
+//
+//	region:hk,jp type:vmess udp:yes mult:<1 provider:sub 高级
+//
+// 未带字段前缀的词按节点名（不区分大小写）子串匹配。
+type NodeQuery struct {
+	terms []nodeTerm
+}
+
+type nodeTerm struct {
+	field  string // name/region/protocol/udp/mult/provider
+	values []string
+	op     string // mult 比较运算符
+	num    float64
+}
+
+// nodeQueryFields 字段别名 -> 规范字段名
+var nodeQueryFields = map[string]string{
+	"region":     "region",
+	"r":          "region",
+	"type":       "protocol",
+	"proto":      "protocol",
+	"protocol":   "protocol",
+	"udp":        "udp",
+	"mult":       "mult",
+	"multiplier": "mult",
+	"x":          "mult",
+	"provider":   "provider",
+	"p":          "provider",
+}
+
+// ParseNodeQuery 解析节点过滤表达式
+func ParseNodeQuery(raw string) NodeQuery {
+	var q NodeQuery
+	for _, word := range strings.Fields(raw) {
+		q.terms = append(q.terms, parseNodeTerm(word))
+	}
+	return q
+}
+
+func parseNodeTerm(word string) nodeTerm {
+	nameTerm := nodeTerm{field: "name", values: []string{strings.ToLower(word)}}
+
+	key, value, ok := strings.Cut(word, ":")
+	if !ok || value == "" {
+		return nameTerm
+	}
+	field, known := nodeQueryFields[strings.ToLower(key)]
+	if !known {
+		return nameTerm
+	}
+
+	if field == "mult" {
+		op, num, ok := parseMultiplierCond(value)
+		if !ok {
+			return nameTerm
+		}
+		return nodeTerm{field: field, op: op, num: num}
+	}
+
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, strings.ToLower(v))
+		}
+	}
+	return nodeTerm{field: field, values: values}
+}
+
+func parseMultiplierCond(value string) (string, float64, bool) {
+	op := "="
+	for _, candidate := range []string{"<=", ">=", "<", ">", "="} {
+		if strings.HasPrefix(value, candidate) {
+			op = candidate
+			value = value[len(candidate):]
+			break
+		}
+	}
+	value = strings.TrimRight(strings.ToLower(value), "x×倍")
+	num, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return op, num, true
+}
+
+// Empty 是否没有任何过滤条件
+func (q NodeQuery) Empty() bool {
+	return len(q.terms) == 0
+}
+
+// Match 判断节点是否满足全部条件
+func (q NodeQuery) Match(name string, meta model.NodeMeta) bool {
+	for _, term := range q.terms {
+		if !term.match(name, meta) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t nodeTerm) match(name string, meta model.NodeMeta) bool {
+	switch t.field {
+	case "mult":
+		switch t.op {
+		case "<":
+			return meta.Multiplier < t.num
+		case "<=":
+			return meta.Multiplier <= t.num
+		case ">":
+			return meta.Multiplier > t.num
+		case ">=":
+			return meta.Multiplier >= t.num
+		default:
+			return meta.Multiplier == t.num
+		}
+	case "udp":
+		for _, v := range t.values {
+			switch v {
+			case "yes", "true", "1", "y":
+				if meta.UDP {
+					return true
+				}
+			case "no", "false", "0", "n":
+				if !meta.UDP {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	var target string
+	switch t.field {
+	case "name":
+		target = name
+	case "region":
+		target = meta.Region
+	case "protocol":
+		target = meta.Protocol
+	case "provider":
+		target = meta.Provider
+	}
+	target = strings.ToLower(target)
+
+	for _, v := range t.values {
+		if t.field == "name" || t.field == "provider" {
+			if strings.Contains(target, v) {
+				return true
+			}
+		} else if target == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+)
+
+// PresetStatus 预设中单个策略组的比对/应用状态
+type PresetStatus string
+
+const (
+	PresetSame         PresetStatus = "same"          // 当前选择与预设一致
+	PresetChanged      PresetStatus = "changed"       // 当前选择与预设不同
+	PresetApplied      PresetStatus = "applied"       // 已切换到预设节点
+	PresetFailed       PresetStatus = "failed"        // 切换失败
+	PresetGroupMissing PresetStatus = "group_missing" // 策略组已不存在
+	PresetProxyMissing PresetStatus = "proxy_missing" // 预设节点已不在策略组中
+)
+
+// PresetEntry 预设中单个策略组的比对/应用结果
+type PresetEntry struct {
+	Group   string       `json:"group"`
+	Current string       `json:"current"`
+	Target  string       `json:"target"`
+	Status  PresetStatus `json:"status"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// PresetService 策略组选择预设服务
+type PresetService struct {
+	proxySvc *ProxyService
+}
+
+// NewPresetService 创建预设服务
+func NewPresetService(proxySvc *ProxyService) *PresetService {
+	return &PresetService{proxySvc: proxySvc}
+}
+
+// List 返回按名称排序的全部预设
+func (s *PresetService) List() ([]model.SelectionPreset, error) {
+	presets, err := config.LoadPresets()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.SelectionPreset, 0, len(presets))
+	for _, p := range presets {
+		result = append(result, p)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// Get 读取指定预设
+func (s *PresetService) Get(name string) (model.SelectionPreset, error) {
+	presets, err := config.LoadPresets()
+	if err != nil {
+		return model.SelectionPreset{}, err
+	}
+	preset, ok := presets[name]
+	if !ok {
+		return model.SelectionPreset{}, fmt.Errorf("预设不存在: %s", name)
+	}
+	return preset, nil
+}
+
+// Save 记录所有 Selector 策略组当前选中的节点，同名预设会被覆盖
+func (s *PresetService) Save(name string) (model.SelectionPreset, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return model.SelectionPreset{}, fmt.Errorf("预设名称不能为空")
+	}
+
+	groups, _, err := s.proxySvc.GetGroups()
+	if err != nil {
+		return model.SelectionPreset{}, fmt.Errorf("获取策略组失败: %w", err)
+	}
+	selections := make(map[string]string)
+	for groupName, group := range groups {
+		if strings.EqualFold(group.Type, "Selector") && group.Now != "" {
+			selections[groupName] = group.Now
+		}
+	}
+	if len(selections) == 0 {
+		return model.SelectionPreset{}, fmt.Errorf("没有可保存的 Selector 策略组")
+	}
+
+	presets, err := config.LoadPresets()
+	if err != nil {
+		return model.SelectionPreset{}, err
+	}
+	preset := model.SelectionPreset{Name: name, CreatedAt: time.Now(), Selections: selections}
+	presets[name] = preset
+	if err := config.SavePresets(presets); err != nil {
+		return model.SelectionPreset{}, err
+	}
+	return preset, nil
+}
+
+// Delete 删除指定预设
+func (s *PresetService) Delete(name string) error {
+	presets, err := config.LoadPresets()
+	if err != nil {
+		return err
+	}
+	if _, ok := presets[name]; !ok {
+		return fmt.Errorf("预设不存在: %s", name)
+	}
+	delete(presets, name)
+	return config.SavePresets(presets)
+}
+
+// Diff 比较预设与当前选择（按策略组配置顺序排列）
+func (s *PresetService) Diff(name string) ([]PresetEntry, error) {
+	preset, err := s.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	groups, orderedNames, err := s.proxySvc.GetGroups()
+	if err != nil {
+		return nil, fmt.Errorf("获取策略组失败: %w", err)
+	}
+	return ComparePreset(preset, groups, orderedNames), nil
+}
+
+// Apply 将预设中与当前不同的选择逐个切换回去，失效的策略组/节点只报告不处理
+func (s *PresetService) Apply(name string) ([]PresetEntry, error) {
+	entries, err := s.Diff(name)
+	if err != nil {
+		return nil, err
+	}
+	for i, entry := range entries {
+		if entry.Status != PresetChanged {
+			continue
+		}
+		if err := s.proxySvc.SelectProxy(entry.Group, entry.Target); err != nil {
+			entries[i].Status = PresetFailed
+			entries[i].Error = err.Error()
+			continue
+		}
+		entries[i].Status = PresetApplied
+	}
+	return entries, nil
+}
+
+// ComparePreset 逐个策略组比较预设与当前状态
+func ComparePreset(preset model.SelectionPreset, groups map[string]model.Group, orderedNames []string) []PresetEntry {
+	names := make([]string, 0, len(preset.Selections))
+	seen := make(map[string]bool, len(preset.Selections))
+	for _, name := range orderedNames {
+		if _, ok := preset.Selections[name]; ok {
+			names = append(names, name)
+			seen[name] = true
+		}
+	}
+	// 配置顺序中找不到的策略组（多为已删除）按名称排在最后
+	var rest []string
+	for name := range preset.Selections {
+		if !seen[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	names = append(names, rest...)
+
+	entries := make([]PresetEntry, 0, len(names))
+	for _, name := range names {
+		target := preset.Selections[name]
+		entry := PresetEntry{Group: name, Target: target}
+		group, ok := groups[name]
+		switch {
+		case !ok:
+			entry.Status = PresetGroupMissing
+		case !slices.Contains(group.All, target):
+			entry.Current = group.Now
+			entry.Status = PresetProxyMissing
+		case group.Now == target:
+			entry.Current = group.Now
+			entry.Status = PresetSame
+		default:
+			entry.Current = group.Now
+			entry.Status = PresetChanged
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// SummarizePreset 统计各状态的策略组数量
+func SummarizePreset(entries []PresetEntry) map[PresetStatus]int {
+	summary := make(map[PresetStatus]int)
+	for _, entry := range entries {
+		summary[entry.Status]++
+	}
+	return summary
+}
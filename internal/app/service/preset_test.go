@@ -0,0 +1,85 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePresetCore 模拟核心 /proxies 接口，记录 PUT 切换
+func fakePresetCore(t *testing.T, now map[string]string) (*PresetService, *[]string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("USERPROFILE", "")
+
+	var selects []string
+	svc := newTestProxyService(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/proxies":
+			json.NewEncoder(w).Encode(map[string]interface{}{"proxies": map[string]model.Proxy{
+				"GLOBAL":    {Name: "GLOBAL", Type: "Selector", Now: "Proxy", All: []string{"Proxy", "Streaming", "Auto"}},
+				"Proxy":     {Name: "Proxy", Type: "Selector", Now: now["Proxy"], All: []string{"HK", "JP"}},
+				"Streaming": {Name: "Streaming", Type: "Selector", Now: now["Streaming"], All: []string{"HK", "US"}},
+				"Auto":      {Name: "Auto", Type: "URLTest", Now: "HK", All: []string{"HK", "JP"}},
+			}})
+		case r.Method == http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			selects = append(selects, strings.TrimPrefix(r.URL.Path, "/proxies/")+"="+string(body))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	return NewPresetService(svc), &selects
+}
+
+func TestPresetSaveCapturesSelectorGroups(t *testing.T) {
+	svc, _ := fakePresetCore(t, map[string]string{"Proxy": "JP", "Streaming": "US"})
+
+	preset, err := svc.Save("work")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"GLOBAL": "Proxy", "Proxy": "JP", "Streaming": "US"}, preset.Selections)
+
+	list, err := svc.List()
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "work", list[0].Name)
+}
+
+func TestPresetApplyReportsMissingNodes(t *testing.T) {
+	svc, selects := fakePresetCore(t, map[string]string{"Proxy": "HK", "Streaming": "HK"})
+	presets := map[string]model.SelectionPreset{"gaming": {Name: "gaming", Selections: map[string]string{
+		"Proxy":     "JP",
+		"Streaming": "SG",
+		"Old":       "HK",
+		"GLOBAL":    "Proxy",
+	}}}
+	require.NoError(t, config.SavePresets(presets))
+
+	entries, err := svc.Apply("gaming")
+	require.NoError(t, err)
+
+	assert.Equal(t, []PresetEntry{
+		{Group: "Proxy", Current: "HK", Target: "JP", Status: PresetApplied},
+		{Group: "Streaming", Current: "HK", Target: "SG", Status: PresetProxyMissing},
+		{Group: "GLOBAL", Current: "Proxy", Target: "Proxy", Status: PresetSame},
+		{Group: "Old", Target: "HK", Status: PresetGroupMissing},
+	}, entries)
+	require.Len(t, *selects, 1)
+	assert.Contains(t, (*selects)[0], `Proxy={"name":"JP"}`)
+}
+
+func TestPresetUnknownName(t *testing.T) {
+	svc, _ := fakePresetCore(t, nil)
+
+	_, err := svc.Diff("nope")
+
+	assert.ErrorContains(t, err, "预设不存在")
+}
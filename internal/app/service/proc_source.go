@@ -0,0 +1,309 @@
+package service
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+)
+
+// ProcSource 基于 /proc 的本机进程解析源
+type ProcSource struct {
+	root       string
+	lookupUser func(uid int) string
+}
+
+// NewProcSource 创建 /proc 解析源，root 通常为 /proc
+func NewProcSource(root string) *ProcSource {
+	return &ProcSource{root: root, lookupUser: lookupUsername}
+}
+
+func lookupUsername(uid int) string {
+	u, err := user.LookupId(strconv.Itoa(uid))
+	if err != nil {
+		return ""
+	}
+	return u.Username
+}
+
+// procSocket /proc/net 中的一个套接字
+type procSocket struct {
+	inode uint64
+	uid   int
+}
+
+// ProcSnapshot 某一时刻的套接字与进程索引
+type ProcSnapshot struct {
+	src       *ProcSource
+	sockets   map[string]procSocket // "tcp|ip|port" -> 套接字
+	inodePID  map[uint64]int
+	pidExe    map[int]string
+	processes map[int]*model.ProcessInfo
+}
+
+// Snapshot 读取 /proc/net 与各进程的 fd，构建一次索引
+func (p *ProcSource) Snapshot() (*ProcSnapshot, error) {
+	snap := &ProcSnapshot{
+		src:       p,
+		sockets:   make(map[string]procSocket),
+		inodePID:  make(map[uint64]int),
+		pidExe:    make(map[int]string),
+		processes: make(map[int]*model.ProcessInfo),
+	}
+
+	found := false
+	for _, name := range []string{"tcp", "tcp6", "udp", "udp6"} {
+		if err := snap.readSockets(filepath.Join(p.root, "net", name), strings.TrimSuffix(name, "6")); err == nil {
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("无法读取 %s/net", p.root)
+	}
+
+	entries, err := os.ReadDir(p.root)
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 失败: %w", p.root, err)
+	}
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		dir := filepath.Join(p.root, e.Name())
+		if exe, err := os.Readlink(filepath.Join(dir, "exe")); err == nil {
+			snap.pidExe[pid] = exe
+		}
+		fds, err := os.ReadDir(filepath.Join(dir, "fd"))
+		if err != nil {
+			continue // 无权限读取其他用户的进程
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(dir, "fd", fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			inode, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]"), 10, 64)
+			if err != nil {
+				continue
+			}
+			if _, ok := snap.inodePID[inode]; !ok {
+				snap.inodePID[inode] = pid
+			}
+		}
+	}
+	return snap, nil
+}
+
+// tcpListenState /proc/net/tcp 中 LISTEN 状态的 st 字段
+const tcpListenState = "0A"
+
+// readSockets 解析 /proc/net/{tcp,udp}[6]
+func (s *ProcSnapshot) readSockets(path, network string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // 表头
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		// 监听套接字不会发起连接，留着会让源端口相同的连接被算到服务端进程上
+		if network == "tcp" && fields[3] == tcpListenState {
+			continue
+		}
+		ip, port, err := parseProcAddr(fields[1])
+		if err != nil {
+			continue
+		}
+		uid, _ := strconv.Atoi(fields[7])
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil || inode == 0 {
+			continue
+		}
+		s.sockets[socketKey(network, ip.String(), port)] = procSocket{inode: inode, uid: uid}
+	}
+	return scanner.Err()
+}
+
+// parseProcAddr 解析 "0100007F:1F90" 形式的地址（IP 按 32 位小端分组）
+func parseProcAddr(s string) (net.IP, int, error) {
+	hexIP, hexPort, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, 0, fmt.Errorf("地址格式错误: %s", s)
+	}
+	raw, err := hex.DecodeString(hexIP)
+	if err != nil || (len(raw) != 4 && len(raw) != 16) {
+		return nil, 0, fmt.Errorf("地址格式错误: %s", s)
+	}
+	port, err := strconv.ParseUint(hexPort, 16, 16)
+	if err != nil {
+		return nil, 0, fmt.Errorf("端口格式错误: %s", s)
+	}
+	ip := make(net.IP, len(raw))
+	for i := 0; i < len(raw); i += 4 {
+		binary.BigEndian.PutUint32(ip[i:], binary.LittleEndian.Uint32(raw[i:]))
+	}
+	return ip, int(port), nil
+}
+
+func socketKey(network, ip string, port int) string {
+	return network + "|" + ip + "|" + strconv.Itoa(port)
+}
+
+// Lookup 按连接的源地址查找本机进程，找不到套接字时按进程路径匹配
+func (s *ProcSnapshot) Lookup(c model.Connection) *model.ProcessInfo {
+	network := strings.ToLower(c.Metadata.Network)
+	port, _ := strconv.Atoi(c.Metadata.SourcePort)
+	if ip := net.ParseIP(c.Metadata.SourceIP); ip != nil && port > 0 {
+		hosts := []string{ip.String()}
+		if network == "udp" {
+			// 只有 UDP 会从绑定在通配地址上的套接字发出
+			hosts = append(hosts, "0.0.0.0", "::")
+		}
+		for _, host := range hosts {
+			sock, ok := s.sockets[socketKey(network, host, port)]
+			if !ok {
+				continue
+			}
+			if pid := s.inodePID[sock.inode]; pid > 0 {
+				return s.process(pid)
+			}
+			// 无权限读取所属进程的 fd，只能给出用户
+			return &model.ProcessInfo{Name: c.Metadata.Process, Path: c.Metadata.ProcessPath, UID: sock.uid, User: s.src.lookupUser(sock.uid)}
+		}
+	}
+
+	if c.Metadata.ProcessPath != "" {
+		pids := make([]int, 0, 1)
+		for pid, exe := range s.pidExe {
+			if exe == c.Metadata.ProcessPath {
+				pids = append(pids, pid)
+			}
+		}
+		if len(pids) > 0 {
+			sort.Ints(pids)
+			return s.process(pids[0])
+		}
+	}
+	return nil
+}
+
+// process 读取进程详情（带缓存）
+func (s *ProcSnapshot) process(pid int) *model.ProcessInfo {
+	if info, ok := s.processes[pid]; ok {
+		return info
+	}
+	dir := filepath.Join(s.src.root, strconv.Itoa(pid))
+	info := &model.ProcessInfo{PID: pid, Path: s.pidExe[pid]}
+	if comm, err := os.ReadFile(filepath.Join(dir, "comm")); err == nil {
+		info.Name = strings.TrimSpace(string(comm))
+	}
+	if status, err := os.ReadFile(filepath.Join(dir, "status")); err == nil {
+		info.UID = parseStatusUID(string(status))
+		info.User = s.src.lookupUser(info.UID)
+	}
+	if cgroup, err := os.ReadFile(filepath.Join(dir, "cgroup")); err == nil {
+		info.Cgroup, info.Unit, info.ContainerID = parseCgroup(string(cgroup))
+	}
+	s.processes[pid] = info
+	return info
+}
+
+// parseStatusUID 取 /proc/<pid>/status 中的真实 UID
+func parseStatusUID(status string) int {
+	for _, line := range strings.Split(status, "\n") {
+		if rest, ok := strings.CutPrefix(line, "Uid:"); ok {
+			if fields := strings.Fields(rest); len(fields) > 0 {
+				uid, _ := strconv.Atoi(fields[0])
+				return uid
+			}
+		}
+	}
+	return 0
+}
+
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// parseCgroup 从 /proc/<pid>/cgroup 提取 cgroup 路径、systemd 单元与容器 ID
+func parseCgroup(content string) (path, unit, containerID string) {
+	for _, line := range strings.Split(strings.TrimSpace(content), "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		// 优先使用 cgroup v2 的统一层级，其次是 v1 的 systemd 层级
+		if path == "" || parts[0] == "0" || strings.Contains(parts[1], "name=systemd") {
+			path = parts[2]
+		}
+		if containerID == "" {
+			containerID = containerIDPattern.FindString(parts[2])
+		}
+	}
+
+	// 取最内层的单元
+	elems := strings.Split(path, "/")
+	for i := len(elems) - 1; i >= 0; i-- {
+		if strings.HasSuffix(elems[i], ".service") || strings.HasSuffix(elems[i], ".scope") {
+			return path, elems[i], containerID
+		}
+	}
+	return path, "", containerID
+}
+
+// ProcessGroup 按进程聚合的连接及其本机进程信息
+type ProcessGroup struct {
+	ConnGroup
+	Info *model.ProcessInfo // 组内首个能解析到的进程
+	PIDs []int              // 组内连接涉及的全部 PID
+}
+
+// Detail 进程信息摘要，如 "pid 1234 +1 · alice · nginx.service · 容器 0123456789ab"
+func (g ProcessGroup) Detail() string {
+	if g.Info == nil {
+		return ""
+	}
+	var parts []string
+	if len(g.PIDs) > 0 {
+		pid := fmt.Sprintf("pid %d", g.PIDs[0])
+		if len(g.PIDs) > 1 {
+			pid += fmt.Sprintf(" +%d", len(g.PIDs)-1)
+		}
+		parts = append(parts, pid)
+	}
+	if g.Info.User != "" {
+		parts = append(parts, g.Info.User)
+	} else {
+		parts = append(parts, fmt.Sprintf("uid %d", g.Info.UID))
+	}
+	if g.Info.Unit != "" {
+		parts = append(parts, g.Info.Unit)
+	}
+	if g.Info.ContainerID != "" {
+		parts = append(parts, "容器 "+ShortContainerID(g.Info.ContainerID))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// ShortContainerID 容器 ID 的前 12 位（与 docker ps 一致）
+func ShortContainerID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fakeContainerID = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+// newFakeProc 构造一个最小的 /proc 目录树
+func newFakeProc(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	write := func(rel, content string) {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	link := func(target, rel string) {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.Symlink(target, path))
+	}
+
+	header := "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+	// 127.0.0.1:8080 -> inode 1001（uid 1000）；0.0.0.0:5353 udp -> inode 1002（uid 0，无权限读取所属进程）
+	write("net/tcp", header+"   0: 0100007F:1F90 0100007F:1BBB 01 00000000:00000000 00:00000000 00000000  1000        0 1001 1 0000000000000000 20 4 30 10 -1\n"+
+		"   1: 00000000:01BB 00000000:0000 0A 00000000:00000000 00:00000000 00000000    33        0 1004 1 0000000000000000 100 0 0 10 0\n")
+	write("net/udp", header+"   0: 00000000:14E9 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 1002 2 0000000000000000 0\n")
+	// ::ffff:198.18.0.1:40000 -> inode 1003
+	write("net/tcp6", header+"   0: 0000000000000000FFFF0000010012C6:9C40 00000000000000000000000000000000:0000 01 00000000:00000000 00:00000000 00000000  1000        0 1003 1 0000000000000000 20 4 30 10 -1\n")
+
+	write("42/comm", "curl\n")
+	write("42/status", "Name:\tcurl\nUid:\t1000\t1000\t1000\t1000\nGid:\t1000\t1000\t1000\t1000\n")
+	write("42/cgroup", "0::/user.slice/user-1000.slice/user@1000.service/app.slice/app-term-7.scope\n")
+	link("/usr/bin/curl", "42/exe")
+	link("socket:[1001]", "42/fd/3")
+	link("/dev/null", "42/fd/0")
+
+	write("77/comm", "nginx\n")
+	write("77/status", "Uid:\t33\t33\t33\t33\n")
+	write("77/cgroup", "12:pids:/docker/"+fakeContainerID+"\n1:name=systemd:/system.slice/docker-"+fakeContainerID+".scope\n")
+	link("/usr/sbin/nginx", "77/exe")
+	link("socket:[1003]", "77/fd/5")
+	link("socket:[1004]", "77/fd/6")
+
+	write("self/comm", "ignored\n")
+	return root
+}
+
+func TestParseProcAddr(t *testing.T) {
+	ip, port, err := parseProcAddr("0100007F:1F90")
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", ip.String())
+	assert.Equal(t, 8080, port)
+
+	ip, _, err = parseProcAddr("000080FE00000000FF57A6705DC771FE:0016")
+	require.NoError(t, err)
+	assert.Equal(t, "fe80::70a6:57ff:fe71:c75d", ip.String())
+
+	_, _, err = parseProcAddr("zz:1")
+	assert.Error(t, err)
+}
+
+func TestParseCgroup(t *testing.T) {
+	path, unit, id := parseCgroup("0::/system.slice/nginx.service\n")
+	assert.Equal(t, "/system.slice/nginx.service", path)
+	assert.Equal(t, "nginx.service", unit)
+	assert.Empty(t, id)
+
+	_, unit, id = parseCgroup("0::/system.slice/docker-" + fakeContainerID + ".scope\n")
+	assert.Equal(t, "docker-"+fakeContainerID+".scope", unit)
+	assert.Equal(t, fakeContainerID, id)
+}
+
+func TestProcSnapshotLookup(t *testing.T) {
+	src := NewProcSource(newFakeProc(t))
+	src.lookupUser = func(uid int) string {
+		return map[int]string{0: "root", 33: "www-data", 1000: "alice"}[uid]
+	}
+	snap, err := src.Snapshot()
+	require.NoError(t, err)
+
+	info := snap.Lookup(model.Connection{Metadata: model.Metadata{Network: "tcp", SourceIP: "127.0.0.1", SourcePort: "8080"}})
+	require.NotNil(t, info)
+	assert.Equal(t, model.ProcessInfo{
+		PID: 42, Name: "curl", Path: "/usr/bin/curl", UID: 1000, User: "alice",
+		Unit: "app-term-7.scope", Cgroup: "/user.slice/user-1000.slice/user@1000.service/app.slice/app-term-7.scope",
+	}, *info)
+
+	// IPv4 映射地址与 cgroup v1 中的容器 ID
+	info = snap.Lookup(model.Connection{Metadata: model.Metadata{Network: "tcp", SourceIP: "198.18.0.1", SourcePort: "40000"}})
+	require.NotNil(t, info)
+	assert.Equal(t, 77, info.PID)
+	assert.Equal(t, "www-data", info.User)
+	assert.Equal(t, fakeContainerID, info.ContainerID)
+
+	// 绑定在通配地址、无权限读取所属进程：只有用户
+	info = snap.Lookup(model.Connection{Metadata: model.Metadata{Network: "udp", SourceIP: "10.0.0.2", SourcePort: "5353", Process: "avahi"}})
+	require.NotNil(t, info)
+	assert.Equal(t, 0, info.PID)
+	assert.Equal(t, "root", info.User)
+	assert.Equal(t, "avahi", info.Name)
+
+	// 源端口与本机监听端口相同的 TCP 连接不算到监听进程上
+	assert.Nil(t, snap.Lookup(model.Connection{Metadata: model.Metadata{Network: "tcp", SourceIP: "10.0.0.2", SourcePort: "443"}}))
+
+	// 套接字已不存在时按进程路径匹配
+	info = snap.Lookup(model.Connection{Metadata: model.Metadata{Network: "tcp", SourceIP: "127.0.0.1", SourcePort: "1", ProcessPath: "/usr/sbin/nginx"}})
+	require.NotNil(t, info)
+	assert.Equal(t, 77, info.PID)
+
+	assert.Nil(t, snap.Lookup(model.Connection{Metadata: model.Metadata{Network: "tcp", SourceIP: "127.0.0.1", SourcePort: "1"}}))
+}
+
+func TestProcSourceMissingRoot(t *testing.T) {
+	_, err := NewProcSource(filepath.Join(t.TempDir(), "none")).Snapshot()
+	assert.Error(t, err)
+}
+
+func TestIPResolverGroupProcesses(t *testing.T) {
+	r := NewIPResolver()
+	r.proc = NewProcSource(newFakeProc(t))
+	r.proc.lookupUser = func(uid int) string { return "" }
+
+	conns := []model.Connection{
+		{ID: "1", Download: 10, Metadata: model.Metadata{Process: "curl", Network: "tcp", SourceIP: "127.0.0.1", SourcePort: "8080"}},
+		{ID: "2", Download: 5, Metadata: model.Metadata{Process: "curl", Network: "tcp", SourceIP: "127.0.0.1", SourcePort: "9"}},
+		{ID: "3", Metadata: model.Metadata{Process: "remote"}},
+	}
+	groups := r.GroupProcesses(conns)
+	require.Len(t, groups, 2)
+	assert.Equal(t, "curl", groups[0].Key)
+	assert.Equal(t, []int{42}, groups[0].PIDs)
+	assert.Equal(t, "pid 42 · uid 1000 · app-term-7.scope", groups[0].Detail())
+	assert.Nil(t, groups[1].Info)
+	assert.Empty(t, groups[1].Detail())
+}
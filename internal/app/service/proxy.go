@@ -1,7 +1,10 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"net/http"
 	"sync"
 
 	"github.com/aimony/mihosh/internal/domain/model"
@@ -10,9 +13,18 @@ import (
 
 // ProxyService 代理管理服务
 type ProxyService struct {
-	client  *api.Client
-	testURL string
-	timeout int
+	client      *api.Client
+	testURL     string
+	timeout     int
+	concurrency int
+	geo         GeoProvider
+}
+
+// DelayResult 单个节点的测速结果
+type DelayResult struct {
+	Name  string
+	Delay int // 失败时为 -1
+	Err   error
 }
 
 // NewProxyService 创建代理服务
@@ -24,6 +36,29 @@ func NewProxyService(client *api.Client, testURL string, timeout int) *ProxyServ
 	}
 }
 
+// SetConcurrency 设置批量测速并发数（<=0 时恢复默认值）
+func (s *ProxyService) SetConcurrency(n int) {
+	s.concurrency = n
+}
+
+// SetGeoProvider 设置出口 IP 的地理位置数据源（nil 时直接使用 ip-api.com 的结果）
+func (s *ProxyService) SetGeoProvider(p GeoProvider) {
+	s.geo = p
+}
+
+// Concurrency 返回实际生效的批量测速并发数
+func (s *ProxyService) Concurrency() int {
+	return ResolveTestConcurrency(s.concurrency)
+}
+
+// ResolveTestConcurrency 将配置的并发数归一化（<=0 时使用默认值）
+func ResolveTestConcurrency(n int) int {
+	if n <= 0 {
+		return model.TestConcurrency
+	}
+	return n
+}
+
 // GetGroups 获取所有策略组
 func (s *ProxyService) GetGroups() (map[string]model.Group, []string, error) {
 	return s.client.GetGroups()
@@ -44,34 +79,75 @@ func (s *ProxyService) TestProxyDelay(name string) (int, error) {
 	return s.client.TestProxyDelay(name, s.testURL, s.timeout)
 }
 
-// TestGroupDelay 测试策略组内所有节点延迟
-func (s *ProxyService) TestGroupDelay(group string) error {
-	return s.client.TestGroupDelay(group, s.testURL, s.timeout)
+// TestGroupDelay 测试策略组内所有节点延迟（返回节点名到延迟的映射，失败记为 -1）
+func (s *ProxyService) TestGroupDelay(group string) (map[string]int, error) {
+	return s.TestGroupDelayContext(context.Background(), group)
+}
+
+// TestGroupDelayContext 通过 /group/{name}/delay 一次请求完成整组测速；
+// 核心只返回成功节点，这里按组成员补齐失败节点（-1）
+func (s *ProxyService) TestGroupDelayContext(ctx context.Context, group string) (map[string]int, error) {
+	results, err := s.client.TestGroupDelayContext(ctx, group, s.testURL, s.timeout)
+	if err != nil {
+		// 全部节点超时时核心返回 504，视为整组失败而非请求错误
+		var statusErr *api.StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusGatewayTimeout {
+			return nil, err
+		}
+		results = make(map[string]int)
+	}
+
+	if proxy, err := s.client.GetProxy(group); err == nil {
+		for _, name := range proxy.All {
+			if _, ok := results[name]; !ok {
+				results[name] = -1
+			}
+		}
+	}
+	return results, nil
 }
 
 // TestAllProxies 批量测试代理延迟（返回每个代理的测试结果）
 func (s *ProxyService) TestAllProxies(proxies []string) map[string]int {
-	results := make(map[string]int)
+	return s.TestProxiesContext(context.Background(), proxies, nil)
+}
+
+// TestProxiesContext 按并发上限批量测速，每完成一个节点回调 onResult；
+// ctx 取消后不再派发排队中的节点，进行中的请求随之中止且不计入结果
+func (s *ProxyService) TestProxiesContext(ctx context.Context, proxies []string, onResult func(DelayResult)) map[string]int {
+	results := make(map[string]int, len(proxies))
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 
-	sem := make(chan struct{}, model.TestConcurrency)
+	sem := make(chan struct{}, s.Concurrency())
 
 	for _, p := range proxies {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
 		wg.Add(1)
 		go func(proxy string) {
 			defer wg.Done()
-			sem <- struct{}{}
 			defer func() { <-sem }()
 
-			delay, err := s.TestProxyDelay(proxy)
+			delay, err := s.client.TestProxyDelayContext(ctx, proxy, s.testURL, s.timeout)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				delay = -1 // 失败时记录为 -1
+			}
 
 			mu.Lock()
 			defer mu.Unlock()
-			if err != nil {
-				results[proxy] = -1 // 失败时记录为 -1
-			} else {
-				results[proxy] = delay
+			results[proxy] = delay
+			if onResult != nil {
+				onResult(DelayResult{Name: proxy, Delay: delay, Err: err})
 			}
 		}(p)
 	}
@@ -118,14 +194,44 @@ func (s *ProxyService) GetNodeChain() ([]string, error) {
 
 // GetIPInfo 获取当前出口 IP 信息
 func (s *ProxyService) GetIPInfo(proxyAddr string) (*model.IPInfo, error) {
+	return s.GetIPInfoContext(context.Background(), proxyAddr)
+}
+
+// GetIPInfoContext 获取当前出口 IP 信息，ctx 取消时中止请求
+func (s *ProxyService) GetIPInfoContext(ctx context.Context, proxyAddr string) (*model.IPInfo, error) {
 	httpClient, err := s.client.NewHTTPClientWithProxy(proxyAddr)
 	if err != nil {
 		return nil, err
 	}
+	return s.ipInfo(ctx, httpClient)
+}
+
+// GetDirectIPInfo 不经过任何代理（包括 HTTP_PROXY 等环境变量）获取本机出口 IP 信息
+func (s *ProxyService) GetDirectIPInfo(ctx context.Context) (*model.IPInfo, error) {
+	return s.ipInfo(ctx, s.client.NewDirectHTTPClient())
+}
+
+func (s *ProxyService) ipInfo(ctx context.Context, httpClient *http.Client) (*model.IPInfo, error) {
+	// 配置了本地数据源时只通过代理获取出口 IP，地理信息在本地查询
+	if s.geo != nil && s.geo.Name() != GeoProviderHTTP {
+		if echo, err := fetchIPInfo(ctx, httpClient, model.IPEchoURL); err == nil && echo.IP != "" {
+			if info, err := s.geo.Lookup(ctx, echo.IP); err == nil {
+				return info, nil
+			}
+		}
+	}
 
 	// 使用 ip-api.com 获取详细信息
 	// fields=61439 包含：status, message, country, countryCode, region, regionName, city, zip, lat, lon, timezone, isp, org, as, query
-	resp, err := httpClient.Get(model.IPApiURL)
+	return fetchIPInfo(ctx, httpClient, model.IPApiURL)
+}
+
+func fetchIPInfo(ctx context.Context, httpClient *http.Client, url string) (*model.IPInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -137,21 +243,6 @@ func (s *ProxyService) GetIPInfo(proxyAddr string) (*model.IPInfo, error) {
 	}
 
 	// 字段校准：将 ip-api.com 的字段映射到通用字段名
-	if info.IP == "" && info.Query != "" {
-		info.IP = info.Query
-	}
-	if info.CountryCode == "" && info.CountryCodeApi != "" {
-		info.CountryCode = info.CountryCodeApi
-	}
-	if info.Organization == "" && info.Org != "" {
-		info.Organization = info.Org
-	}
-	if info.Latitude == 0 && info.Lat != 0 {
-		info.Latitude = info.Lat
-	}
-	if info.Longitude == 0 && info.Lon != 0 {
-		info.Longitude = info.Lon
-	}
-
+	NormalizeIPInfo(&info)
 	return &info, nil
 }
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aimony/mihosh/internal/infrastructure/api"
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestProxyService(t *testing.T, handler http.HandlerFunc) *ProxyService {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	client := api.NewClient(&config.Config{APIAddress: srv.URL, Timeout: 5000})
+	return NewProxyService(client, "http://example.com", 1000)
+}
+
+func TestTestProxiesContextRespectsConcurrency(t *testing.T) {
+	var inFlight, peak int32
+	svc := newTestProxyService(t, func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			old := atomic.LoadInt32(&peak)
+			if n <= old || atomic.CompareAndSwapInt32(&peak, old, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte(`{"delay":42}`))
+	})
+	svc.SetConcurrency(2)
+
+	var mu sync.Mutex
+	var reported []string
+	results := svc.TestProxiesContext(context.Background(), []string{"a", "b", "c", "d", "e"}, func(r DelayResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, r.Name)
+	})
+
+	assert.Len(t, results, 5)
+	assert.Equal(t, 42, results["c"])
+	assert.Len(t, reported, 5)
+	assert.LessOrEqual(t, atomic.LoadInt32(&peak), int32(2))
+}
+
+func TestTestProxiesContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int32
+	svc := newTestProxyService(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			cancel()
+		}
+		<-r.Context().Done()
+	})
+	svc.SetConcurrency(1)
+
+	results := svc.TestProxiesContext(ctx, []string{"a", "b", "c"}, nil)
+
+	assert.Empty(t, results)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestResolveTestConcurrency(t *testing.T) {
+	assert.Equal(t, 20, ResolveTestConcurrency(0))
+	assert.Equal(t, 20, ResolveTestConcurrency(-3))
+	assert.Equal(t, 64, ResolveTestConcurrency(64))
+}
+
+func TestTestGroupDelayFillsFailedMembers(t *testing.T) {
+	svc := newTestProxyService(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/group/Auto/delay":
+			w.Write([]byte(`{"HK":42}`))
+		case "/proxies/Auto":
+			w.Write([]byte(`{"name":"Auto","all":["HK","US"]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	results, err := svc.TestGroupDelay("Auto")
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"HK": 42, "US": -1}, results)
+}
+
+func TestTestGroupDelayAllTimeout(t *testing.T) {
+	svc := newTestProxyService(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/group/Auto/delay":
+			w.WriteHeader(http.StatusGatewayTimeout)
+		case "/proxies/Auto":
+			w.Write([]byte(`{"name":"Auto","all":["HK","US"]}`))
+		}
+	})
+
+	results, err := svc.TestGroupDelay("Auto")
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"HK": -1, "US": -1}, results)
+}
+
+func TestTestGroupDelayUnsupportedCore(t *testing.T) {
+	svc := newTestProxyService(t, http.NotFound)
+
+	_, err := svc.TestGroupDelay("Auto")
+
+	assert.True(t, api.IsNotFound(err))
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 新规则插入位置
+const (
+	RuleInsertManaged     = "managed"      // mihosh 管理的规则块（位于规则列表顶部）
+	RuleInsertTop         = "top"          // 规则列表顶部
+	RuleInsertBeforeMatch = "before-match" // MATCH 兜底规则之前
+)
+
+// RuleInsertPositions 可选的插入位置
+var RuleInsertPositions = []string{RuleInsertManaged, RuleInsertTop, RuleInsertBeforeMatch}
+
+// mihosh 管理规则块的起止标记
+const (
+	managedRulesBegin = "# mihosh:rules:begin"
+	managedRulesEnd   = "# mihosh:rules:end"
+)
+
+// RuleDraft 待添加的规则
+type RuleDraft struct {
+	Type    string `json:"type"` // DOMAIN、DOMAIN-SUFFIX、IP-CIDR、IP-CIDR6
+	Payload string `json:"payload"`
+	Target  string `json:"target"` // 策略组、节点或 DIRECT/REJECT
+}
+
+// String 配置文件中的规则写法
+func (d RuleDraft) String() string {
+	return d.Type + "," + d.Payload + "," + d.Target
+}
+
+// SuggestRuleDrafts 根据连接的主机与目标 IP 给出候选规则（未设置目标策略）
+func SuggestRuleDrafts(host, ip string) []RuleDraft {
+	var drafts []RuleDraft
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	if addr, err := netip.ParseAddr(host); err == nil {
+		ip, host = addr.String(), ""
+	}
+	if host != "" {
+		if root := RegistrableDomain(host); root != host {
+			drafts = append(drafts, RuleDraft{Type: "DOMAIN-SUFFIX", Payload: root})
+		}
+		drafts = append(drafts, RuleDraft{Type: "DOMAIN", Payload: host}, RuleDraft{Type: "DOMAIN-SUFFIX", Payload: host})
+	}
+	if addr, err := netip.ParseAddr(strings.TrimSpace(ip)); err == nil {
+		addr = addr.Unmap()
+		if addr.Is4() {
+			drafts = append(drafts, RuleDraft{Type: "IP-CIDR", Payload: addr.String() + "/32"})
+		} else {
+			drafts = append(drafts, RuleDraft{Type: "IP-CIDR6", Payload: addr.String() + "/128"})
+		}
+	}
+	return drafts
+}
+
+// RuleEdit 向 mihomo 配置文件添加规则的预览
+type RuleEdit struct {
+	Path     string    `json:"path"`
+	Rule     RuleDraft `json:"rule"`
+	Position string    `json:"position"`
+	Line     int       `json:"line"` // 新规则所在行（从 1 开始）
+	Diff     string    `json:"diff"`
+
+	original []byte
+	updated  []byte
+}
+
+// PlanRuleInsert 在配置内容的 rules 列表中插入规则，只增加行，保留原有注释与格式
+func PlanRuleInsert(path string, content []byte, draft RuleDraft, position string) (*RuleEdit, error) {
+	if draft.Type == "" || draft.Payload == "" || draft.Target == "" {
+		return nil, errors.New("规则类型、内容与目标策略均不能为空")
+	}
+	if strings.ContainsAny(draft.String(), "\n#") {
+		return nil, fmt.Errorf("规则包含非法字符: %s", draft)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %w", err)
+	}
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	at, added, err := ruleInsertion(&doc, lines, draft, position)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) > 0 && at == len(lines) && !strings.HasSuffix(lines[len(lines)-1], "\n") {
+		lines[len(lines)-1] += "\n"
+	}
+	if bytes.Contains(content, []byte("\r\n")) {
+		for i := range added {
+			added[i] = strings.TrimSuffix(added[i], "\n") + "\r\n"
+		}
+	}
+
+	updated := make([]string, 0, len(lines)+len(added))
+	updated = append(updated, lines[:at]...)
+	updated = append(updated, added...)
+	updated = append(updated, lines[at:]...)
+
+	ruleLine := at + 1
+	for i, l := range added {
+		if strings.Contains(l, draft.String()) {
+			ruleLine = at + i + 1
+		}
+	}
+	return &RuleEdit{
+		Path:     path,
+		Rule:     draft,
+		Position: position,
+		Line:     ruleLine,
+		Diff:     insertionDiff(path, lines, at, added),
+		original: content,
+		updated:  []byte(strings.Join(updated, "")),
+	}, nil
+}
+
+// ruleInsertion 计算插入的行号（从 0 开始，插在该行之前）与新增的行
+func ruleInsertion(doc *yaml.Node, lines []string, draft RuleDraft, position string) (int, []string, error) {
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return 0, nil, errors.New("配置文件顶层不是映射，无法定位 rules")
+	}
+	root := doc.Content[0]
+	var keyNode, rulesNode *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "rules" {
+			keyNode, rulesNode = root.Content[i], root.Content[i+1]
+		}
+	}
+
+	rule := draft.String()
+	if keyNode == nil {
+		// 没有 rules 段时追加到文件末尾
+		return len(lines), []string{"rules:\n", "  - " + rule + "\n"}, nil
+	}
+	if rulesNode.Kind == yaml.ScalarNode && rulesNode.Tag == "!!null" {
+		return keyNode.Line, []string{"  - " + rule + "\n"}, nil
+	}
+	if rulesNode.Kind != yaml.SequenceNode || rulesNode.Style&yaml.FlowStyle != 0 {
+		return 0, nil, errors.New("rules 不是多行列表写法，请手动添加规则")
+	}
+	if len(rulesNode.Content) == 0 {
+		return keyNode.Line, []string{"  - " + rule + "\n"}, nil
+	}
+
+	newKey := NewRuleKey(draft.Type, draft.Payload)
+	matchIdx := -1
+	for i, item := range rulesNode.Content {
+		parts := strings.Split(item.Value, ",")
+		if len(parts) >= 2 && NewRuleKey(parts[0], strings.TrimSpace(parts[1])) == newKey {
+			return 0, nil, fmt.Errorf("规则已存在（第 %d 行）: %s", item.Line, strings.TrimSpace(item.Value))
+		}
+		if normalizeRuleType(strings.TrimSpace(parts[0])) == "MATCH" && matchIdx < 0 {
+			matchIdx = i
+		}
+	}
+
+	first := rulesNode.Content[0].Line - 1
+	indent := leadingSpace(lines[first])
+	item := indent + "- " + rule + "\n"
+
+	switch position {
+	case RuleInsertTop:
+		return first, []string{item}, nil
+	case RuleInsertBeforeMatch:
+		if matchIdx < 0 {
+			last := rulesNode.Content[len(rulesNode.Content)-1].Line
+			return last, []string{item}, nil
+		}
+		at := rulesNode.Content[matchIdx].Line - 1
+		// 紧贴 MATCH 的注释属于 MATCH，新规则放在注释之前
+		for at > keyNode.Line && strings.HasPrefix(strings.TrimSpace(lines[at-1]), "#") {
+			at--
+		}
+		return at, []string{item}, nil
+	case RuleInsertManaged, "":
+		for i := keyNode.Line; i < len(lines); i++ {
+			if strings.TrimSpace(lines[i]) == managedRulesEnd {
+				return i, []string{indent + "- " + rule + "\n"}, nil
+			}
+		}
+		return first, []string{indent + managedRulesBegin + "\n", item, indent + managedRulesEnd + "\n"}, nil
+	}
+	return 0, nil, fmt.Errorf("无效的插入位置: %s（可选 %s）", position, strings.Join(RuleInsertPositions, "/"))
+}
+
+// leadingSpace 行首的空白
+func leadingSpace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// insertionDiff 生成插入若干行的 unified diff（上下各保留 3 行）
+func insertionDiff(path string, lines []string, at int, added []string) string {
+	const context = 3
+	start := max(at-context, 0)
+	end := min(at+context, len(lines))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", path, path)
+	oldCount := end - start
+	oldStart := start + 1
+	if oldCount == 0 {
+		oldStart = start
+	}
+	fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, start+1, oldCount+len(added))
+	for _, l := range lines[start:at] {
+		b.WriteString(" " + strings.TrimRight(l, "\r\n") + "\n")
+	}
+	for _, l := range added {
+		b.WriteString("+" + strings.TrimRight(l, "\r\n") + "\n")
+	}
+	for _, l := range lines[at:end] {
+		b.WriteString(" " + strings.TrimRight(l, "\r\n") + "\n")
+	}
+	return b.String()
+}
+
+// ApplyRuleEdit 备份原配置后写入修改并调用 reload 生效，重载失败时恢复原配置；返回备份路径
+func ApplyRuleEdit(edit *RuleEdit, reload func(path string) error) (string, error) {
+	current, err := os.ReadFile(edit.Path)
+	if err != nil {
+		return "", fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	if !bytes.Equal(current, edit.original) {
+		return "", errors.New("配置文件在预览后已被修改，请重新生成修改")
+	}
+	info, err := os.Stat(edit.Path)
+	if err != nil {
+		return "", fmt.Errorf("读取配置文件信息失败: %w", err)
+	}
+	// 配置文件是软链接时写入其指向的文件，避免替换掉链接本身
+	path, err := filepath.EvalSymlinks(edit.Path)
+	if err != nil {
+		return "", fmt.Errorf("解析配置文件路径失败: %w", err)
+	}
+
+	backup, err := writeBackup(edit.Path, current, info.Mode().Perm())
+	if err != nil {
+		return "", fmt.Errorf("备份配置文件失败: %w", err)
+	}
+	if err := writeFileAtomic(path, edit.updated, info.Mode().Perm()); err != nil {
+		return backup, fmt.Errorf("写入配置文件失败: %w", err)
+	}
+	if reload == nil {
+		return backup, nil
+	}
+	if err := reload(edit.Path); err != nil {
+		if restoreErr := writeFileAtomic(path, current, info.Mode().Perm()); restoreErr != nil {
+			return backup, fmt.Errorf("重载失败: %v；恢复原配置也失败，请从 %s 手动恢复: %w", err, backup, restoreErr)
+		}
+		return backup, fmt.Errorf("重载失败，已恢复原配置: %w", err)
+	}
+	return backup, nil
+}
+
+// writeBackup 写入带时间戳的备份文件，同一秒内重复备份时追加序号，不覆盖已有备份
+func writeBackup(path string, data []byte, perm os.FileMode) (string, error) {
+	stamp := fmt.Sprintf("%s.%s", path, time.Now().Format("20060102-150405"))
+	for i := 0; ; i++ {
+		name := stamp + ".bak"
+		if i > 0 {
+			name = fmt.Sprintf("%s-%d.bak", stamp, i)
+		}
+		f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if _, err := f.Write(data); err != nil {
+			f.Close()
+			os.Remove(name)
+			return "", err
+		}
+		if err := f.Close(); err != nil {
+			os.Remove(name)
+			return "", err
+		}
+		return name, nil
+	}
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleMihomoConfig = `mixed-port: 7890
+# 分流规则
+rules:
+  - DOMAIN-SUFFIX,google.com,Proxy # 谷歌
+  - 'DOMAIN-KEYWORD,ads,REJECT'
+  # 兜底
+  - MATCH,Final
+dns:
+  enable: true
+`
+
+func TestSuggestRuleDrafts(t *testing.T) {
+	drafts := SuggestRuleDrafts("API.GitHub.com.", "140.82.112.6")
+	assert.Equal(t, []RuleDraft{
+		{Type: "DOMAIN-SUFFIX", Payload: "github.com"},
+		{Type: "DOMAIN", Payload: "api.github.com"},
+		{Type: "DOMAIN-SUFFIX", Payload: "api.github.com"},
+		{Type: "IP-CIDR", Payload: "140.82.112.6/32"},
+	}, drafts)
+
+	assert.Equal(t, []RuleDraft{{Type: "IP-CIDR6", Payload: "2001:db8::1/128"}}, SuggestRuleDrafts("2001:db8::1", ""))
+	assert.Empty(t, SuggestRuleDrafts("", "not-an-ip"))
+}
+
+func TestPlanRuleInsertPositions(t *testing.T) {
+	draft := RuleDraft{Type: "DOMAIN-SUFFIX", Payload: "github.com", Target: "Dev"}
+
+	edit, err := PlanRuleInsert("config.yaml", []byte(sampleMihomoConfig), draft, RuleInsertBeforeMatch)
+	require.NoError(t, err)
+	assert.Equal(t, `mixed-port: 7890
+# 分流规则
+rules:
+  - DOMAIN-SUFFIX,google.com,Proxy # 谷歌
+  - 'DOMAIN-KEYWORD,ads,REJECT'
+  - DOMAIN-SUFFIX,github.com,Dev
+  # 兜底
+  - MATCH,Final
+dns:
+  enable: true
+`, string(edit.updated))
+	assert.Equal(t, 6, edit.Line)
+	assert.Equal(t, `--- config.yaml
++++ config.yaml
+@@ -3,6 +3,7 @@
+ rules:
+   - DOMAIN-SUFFIX,google.com,Proxy # 谷歌
+   - 'DOMAIN-KEYWORD,ads,REJECT'
++  - DOMAIN-SUFFIX,github.com,Dev
+   # 兜底
+   - MATCH,Final
+ dns:
+`, edit.Diff)
+
+	edit, err = PlanRuleInsert("config.yaml", []byte(sampleMihomoConfig), draft, RuleInsertTop)
+	require.NoError(t, err)
+	assert.Equal(t, 4, edit.Line)
+	assert.Contains(t, string(edit.updated), "rules:\n  - DOMAIN-SUFFIX,github.com,Dev\n  - DOMAIN-SUFFIX,google.com")
+
+	// 管理块首次创建在列表顶部，之后追加到块内
+	edit, err = PlanRuleInsert("config.yaml", []byte(sampleMihomoConfig), draft, RuleInsertManaged)
+	require.NoError(t, err)
+	assert.Contains(t, string(edit.updated), "rules:\n  # mihosh:rules:begin\n  - DOMAIN-SUFFIX,github.com,Dev\n  # mihosh:rules:end\n  - DOMAIN-SUFFIX,google.com")
+	edit, err = PlanRuleInsert("config.yaml", edit.updated, RuleDraft{Type: "DOMAIN", Payload: "x.com", Target: "DIRECT"}, RuleInsertManaged)
+	require.NoError(t, err)
+	assert.Contains(t, string(edit.updated), "  - DOMAIN-SUFFIX,github.com,Dev\n  - DOMAIN,x.com,DIRECT\n  # mihosh:rules:end\n")
+}
+
+func TestPlanRuleInsertErrors(t *testing.T) {
+	_, err := PlanRuleInsert("c.yaml", []byte(sampleMihomoConfig), RuleDraft{Type: "DomainSuffix", Payload: "google.com", Target: "X"}, RuleInsertTop)
+	assert.ErrorContains(t, err, "规则已存在（第 4 行）")
+
+	_, err = PlanRuleInsert("c.yaml", []byte("rules: [MATCH,DIRECT]\n"), RuleDraft{Type: "DOMAIN", Payload: "a.com", Target: "X"}, RuleInsertTop)
+	assert.ErrorContains(t, err, "多行列表")
+
+	_, err = PlanRuleInsert("c.yaml", []byte(sampleMihomoConfig), RuleDraft{Type: "DOMAIN", Payload: "a.com", Target: "X"}, "middle")
+	assert.ErrorContains(t, err, "无效的插入位置")
+
+	_, err = PlanRuleInsert("c.yaml", []byte(sampleMihomoConfig), RuleDraft{Type: "DOMAIN", Payload: "a.com"}, RuleInsertTop)
+	assert.Error(t, err)
+
+	// 没有 rules 段时追加到末尾（原文件末尾没有换行）
+	edit, err := PlanRuleInsert("c.yaml", []byte("mode: rule"), RuleDraft{Type: "DOMAIN", Payload: "a.com", Target: "X"}, RuleInsertTop)
+	require.NoError(t, err)
+	assert.Equal(t, "mode: rule\nrules:\n  - DOMAIN,a.com,X\n", string(edit.updated))
+}
+
+func TestApplyRuleEdit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(sampleMihomoConfig), 0o600))
+	edit, err := PlanRuleInsert(path, []byte(sampleMihomoConfig), RuleDraft{Type: "DOMAIN", Payload: "a.com", Target: "X"}, RuleInsertTop)
+	require.NoError(t, err)
+
+	// 重载失败时恢复原配置，但保留备份
+	backup, err := ApplyRuleEdit(edit, func(string) error { return errors.New("bad config") })
+	assert.ErrorContains(t, err, "已恢复原配置")
+	data, _ := os.ReadFile(path)
+	assert.Equal(t, sampleMihomoConfig, string(data))
+	saved, _ := os.ReadFile(backup)
+	assert.Equal(t, sampleMihomoConfig, string(saved))
+
+	var reloaded string
+	second, err := ApplyRuleEdit(edit, func(p string) error { reloaded = p; return nil })
+	require.NoError(t, err)
+	assert.Equal(t, path, reloaded)
+	data, _ = os.ReadFile(path)
+	assert.Equal(t, string(edit.updated), string(data))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// 每次写入都有独立的备份，不覆盖之前的备份
+	assert.NotEqual(t, backup, second)
+	saved, _ = os.ReadFile(second)
+	assert.Equal(t, sampleMihomoConfig, string(saved))
+
+	// 预览之后文件被改动则拒绝写入
+	_, err = ApplyRuleEdit(edit, nil)
+	assert.ErrorContains(t, err, "已被修改")
+}
+
+func TestWriteBackupUnique(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	first, err := writeBackup(path, []byte("a"), 0o600)
+	require.NoError(t, err)
+	second, err := writeBackup(path, []byte("b"), 0o600)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+
+	data, _ := os.ReadFile(first)
+	assert.Equal(t, "a", string(data))
+	data, _ = os.ReadFile(second)
+	assert.Equal(t, "b", string(data))
+}
+
+func TestApplyRuleEditKeepsSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "real.yaml")
+	link := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(target, []byte(sampleMihomoConfig), 0o644))
+	require.NoError(t, os.Symlink(target, link))
+	edit, err := PlanRuleInsert(link, []byte(sampleMihomoConfig), RuleDraft{Type: "DOMAIN", Payload: "a.com", Target: "X"}, RuleInsertTop)
+	require.NoError(t, err)
+
+	_, err = ApplyRuleEdit(edit, nil)
+	require.NoError(t, err)
+	info, err := os.Lstat(link)
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&os.ModeSymlink)
+	data, _ := os.ReadFile(target)
+	assert.Equal(t, string(edit.updated), string(data))
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+)
+
+// RuleTarget 规则模拟的目标
+type RuleTarget struct {
+	Host    string `json:"host,omitempty"`
+	IP      string `json:"ip,omitempty"`
+	Port    int    `json:"port,omitempty"`
+	Process string `json:"process,omitempty"`
+}
+
+// RuleVerdict 单条规则的模拟结果
+type RuleVerdict string
+
+const (
+	RuleNoMatch RuleVerdict = "no_match"
+	RuleMatched RuleVerdict = "matched"
+	RuleUnknown RuleVerdict = "unknown" // 缺少数据，无法判断
+)
+
+// RuleStep 参与模拟的一条规则
+type RuleStep struct {
+	Index  int        `json:"index"` // 从 1 开始，与规则页序号一致
+	Rule   model.Rule `json:"rule"`
+	Reason string     `json:"reason,omitempty"`
+}
+
+// RuleExplanation 规则模拟结果
+type RuleExplanation struct {
+	Target    RuleTarget `json:"target"`
+	Mode      string     `json:"mode,omitempty"`
+	Matched   *RuleStep  `json:"matched,omitempty"`
+	Undecided []RuleStep `json:"undecided"` // 命中规则之前无法判断的规则，实际可能先命中
+	Chain     []string   `json:"chain,omitempty"`
+}
+
+// ParseRuleTarget 解析 host、host:port、IP 或 [IPv6]:port
+func ParseRuleTarget(s string) (RuleTarget, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return RuleTarget{}, errors.New("目标不能为空")
+	}
+	var t RuleTarget
+	host := s
+	if h, p, err := net.SplitHostPort(s); err == nil {
+		port, err := strconv.Atoi(p)
+		if err != nil || port <= 0 || port > 65535 {
+			return RuleTarget{}, fmt.Errorf("无效的端口: %s", p)
+		}
+		host, t.Port = h, port
+	}
+	host = strings.TrimSuffix(strings.Trim(host, "[]"), ".")
+	if addr, err := netip.ParseAddr(host); err == nil {
+		t.IP = addr.Unmap().String()
+	} else {
+		t.Host = strings.ToLower(host)
+	}
+	return t, nil
+}
+
+// normalizeRuleType 统一 DOMAIN-SUFFIX 与 DomainSuffix 两种写法
+func normalizeRuleType(t string) string {
+	return strings.NewReplacer("-", "", "_", "").Replace(strings.ToUpper(t))
+}
+
+// MatchRule 判断单条规则能否命中目标，无法判断时返回原因
+func MatchRule(rule model.Rule, t RuleTarget) (RuleVerdict, string) {
+	payload := strings.TrimSpace(rule.Payload)
+	switch normalizeRuleType(rule.Type) {
+	case "DOMAIN":
+		return verdict(t.Host != "" && strings.EqualFold(t.Host, payload)), ""
+	case "DOMAINSUFFIX":
+		suffix := strings.ToLower(strings.TrimPrefix(payload, "."))
+		return verdict(t.Host != "" && (t.Host == suffix || strings.HasSuffix(t.Host, "."+suffix))), ""
+	case "DOMAINKEYWORD":
+		return verdict(t.Host != "" && strings.Contains(t.Host, strings.ToLower(payload))), ""
+	case "IPCIDR", "IPCIDR6":
+		if t.IP == "" {
+			return RuleUnknown, "需要目标 IP（域名需经 DNS 解析）"
+		}
+		prefix, err := netip.ParsePrefix(strings.Split(payload, ",")[0])
+		if err != nil {
+			return RuleUnknown, "无法解析网段: " + payload
+		}
+		addr, err := netip.ParseAddr(t.IP)
+		return verdict(err == nil && prefix.Contains(addr.Unmap())), ""
+	case "DSTPORT":
+		if t.Port == 0 {
+			return RuleUnknown, "需要目标端口"
+		}
+		ok, err := portMatches(payload, t.Port)
+		if err != nil {
+			return RuleUnknown, err.Error()
+		}
+		return verdict(ok), ""
+	case "PROCESSNAME":
+		if t.Process == "" {
+			return RuleUnknown, "需要进程名"
+		}
+		return verdict(strings.EqualFold(t.Process, payload)), ""
+	case "MATCH":
+		return RuleMatched, ""
+	case "RULESET":
+		return RuleUnknown, "需要规则集数据"
+	case "GEOIP":
+		return RuleUnknown, "需要 GeoIP 数据"
+	case "GEOSITE":
+		return RuleUnknown, "需要 GeoSite 数据"
+	}
+	return RuleUnknown, "暂不支持模拟该规则类型"
+}
+
+func verdict(matched bool) RuleVerdict {
+	if matched {
+		return RuleMatched
+	}
+	return RuleNoMatch
+}
+
+// portMatches 支持 443、80/443 与 1000-2000 写法
+func portMatches(payload string, port int) (bool, error) {
+	for _, part := range strings.FieldsFunc(payload, func(r rune) bool { return r == '/' || r == ',' }) {
+		lo, hi, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		start, err := strconv.Atoi(lo)
+		if err != nil {
+			return false, fmt.Errorf("无法解析端口: %s", payload)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(hi); err != nil {
+				return false, fmt.Errorf("无法解析端口: %s", payload)
+			}
+		}
+		if port >= start && port <= end {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ExplainRules 按顺序模拟规则匹配，返回第一条确定命中的规则
+func ExplainRules(rules []model.Rule, t RuleTarget) RuleExplanation {
+	exp := RuleExplanation{Target: t, Undecided: []RuleStep{}}
+	for i, rule := range rules {
+		switch v, reason := MatchRule(rule, t); v {
+		case RuleMatched:
+			exp.Matched = &RuleStep{Index: i + 1, Rule: rule}
+			return exp
+		case RuleUnknown:
+			exp.Undecided = append(exp.Undecided, RuleStep{Index: i + 1, Rule: rule, Reason: reason})
+		}
+	}
+	return exp
+}
+
+// ResolveProxyChain 从规则指向的策略沿各策略组当前选择追踪到最终节点
+func ResolveProxyChain(proxies map[string]model.Proxy, name string) []string {
+	var chain []string
+	seen := make(map[string]bool)
+	for current := name; current != "" && !seen[current]; {
+		seen[current] = true
+		chain = append(chain, current)
+		proxy, ok := proxies[current]
+		if !ok {
+			break
+		}
+		current = proxy.Now
+	}
+	return chain
+}
+
+// ExplainRule 获取当前规则与策略组选择，模拟目标会命中的规则与最终节点
+func (s *ProxyService) ExplainRule(t RuleTarget) (*RuleExplanation, error) {
+	rules, err := s.client.GetRules()
+	if err != nil {
+		return nil, fmt.Errorf("获取规则失败: %w", err)
+	}
+	proxies, err := s.client.GetProxies()
+	if err != nil {
+		return nil, fmt.Errorf("获取代理列表失败: %w", err)
+	}
+	exp := ExplainRules(rules.Rules, t)
+	if configs, err := s.client.GetConfigs(); err == nil {
+		exp.Mode = configs.Mode
+	}
+	target := "DIRECT" // 没有规则命中时 mihomo 直连
+	if exp.Matched != nil {
+		target = exp.Matched.Rule.Proxy
+	}
+	exp.Chain = ResolveProxyChain(proxies, target)
+	return &exp, nil
+}
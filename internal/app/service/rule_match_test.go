@@ -0,0 +1,127 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRuleTarget(t *testing.T) {
+	cases := map[string]RuleTarget{
+		"Example.COM":        {Host: "example.com"},
+		"example.com:443":    {Host: "example.com", Port: 443},
+		"1.1.1.1":            {IP: "1.1.1.1"},
+		"1.1.1.1:53":         {IP: "1.1.1.1", Port: 53},
+		"[2001:db8::1]:8443": {IP: "2001:db8::1", Port: 8443},
+		"2001:db8::1":        {IP: "2001:db8::1"},
+	}
+	for in, want := range cases {
+		got, err := ParseRuleTarget(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	_, err := ParseRuleTarget("example.com:0")
+	assert.ErrorContains(t, err, "无效的端口")
+	_, err = ParseRuleTarget(" ")
+	assert.Error(t, err)
+}
+
+func TestMatchRule(t *testing.T) {
+	target := RuleTarget{Host: "api.github.com", IP: "140.82.112.6", Port: 443, Process: "curl"}
+	cases := []struct {
+		rule model.Rule
+		want RuleVerdict
+	}{
+		{model.Rule{Type: "DOMAIN", Payload: "API.github.com"}, RuleMatched},
+		{model.Rule{Type: "Domain", Payload: "github.com"}, RuleNoMatch},
+		{model.Rule{Type: "DomainSuffix", Payload: "github.com"}, RuleMatched},
+		{model.Rule{Type: "DOMAIN-SUFFIX", Payload: "hub.com"}, RuleNoMatch},
+		{model.Rule{Type: "DomainKeyword", Payload: "git"}, RuleMatched},
+		{model.Rule{Type: "IPCIDR", Payload: "140.82.112.0/20"}, RuleMatched},
+		{model.Rule{Type: "IP-CIDR6", Payload: "2001:db8::/32"}, RuleNoMatch},
+		{model.Rule{Type: "DstPort", Payload: "80/443"}, RuleMatched},
+		{model.Rule{Type: "DST-PORT", Payload: "1000-2000"}, RuleNoMatch},
+		{model.Rule{Type: "ProcessName", Payload: "CURL"}, RuleMatched},
+		{model.Rule{Type: "Match"}, RuleMatched},
+		{model.Rule{Type: "RuleSet", Payload: "proxy"}, RuleUnknown},
+		{model.Rule{Type: "GeoIP", Payload: "CN"}, RuleUnknown},
+		{model.Rule{Type: "SrcIPCIDR", Payload: "10.0.0.0/8"}, RuleUnknown},
+	}
+	for _, c := range cases {
+		got, _ := MatchRule(c.rule, target)
+		assert.Equal(t, c.want, got, "%s,%s", c.rule.Type, c.rule.Payload)
+	}
+
+	// 缺少数据时无法判断
+	v, reason := MatchRule(model.Rule{Type: "IPCIDR", Payload: "10.0.0.0/8"}, RuleTarget{Host: "example.com"})
+	assert.Equal(t, RuleUnknown, v)
+	assert.Contains(t, reason, "IP")
+	v, _ = MatchRule(model.Rule{Type: "DstPort", Payload: "443"}, RuleTarget{Host: "example.com"})
+	assert.Equal(t, RuleUnknown, v)
+	v, _ = MatchRule(model.Rule{Type: "Domain", Payload: "example.com"}, RuleTarget{IP: "1.1.1.1"})
+	assert.Equal(t, RuleNoMatch, v)
+}
+
+func TestExplainRules(t *testing.T) {
+	rules := []model.Rule{
+		{Type: "DomainSuffix", Payload: "google.com", Proxy: "Proxy"},
+		{Type: "RuleSet", Payload: "reject", Proxy: "REJECT"},
+		{Type: "IPCIDR", Payload: "192.168.0.0/16", Proxy: "DIRECT"},
+		{Type: "DomainKeyword", Payload: "github", Proxy: "Dev"},
+		{Type: "Match", Proxy: "Final"},
+	}
+
+	exp := ExplainRules(rules, RuleTarget{Host: "github.com"})
+	require.NotNil(t, exp.Matched)
+	assert.Equal(t, 4, exp.Matched.Index)
+	assert.Equal(t, "Dev", exp.Matched.Rule.Proxy)
+	require.Len(t, exp.Undecided, 2)
+	assert.Equal(t, []int{2, 3}, []int{exp.Undecided[0].Index, exp.Undecided[1].Index})
+
+	exp = ExplainRules(rules[:3], RuleTarget{IP: "8.8.8.8"})
+	assert.Nil(t, exp.Matched)
+	assert.Len(t, exp.Undecided, 1)
+}
+
+func TestResolveProxyChain(t *testing.T) {
+	proxies := map[string]model.Proxy{
+		"Proxy": {Name: "Proxy", Type: "Selector", Now: "Auto"},
+		"Auto":  {Name: "Auto", Type: "URLTest", Now: "HK-01"},
+		"HK-01": {Name: "HK-01", Type: "Shadowsocks"},
+		"Loop":  {Name: "Loop", Type: "Selector", Now: "Loop"},
+	}
+	assert.Equal(t, []string{"Proxy", "Auto", "HK-01"}, ResolveProxyChain(proxies, "Proxy"))
+	assert.Equal(t, []string{"Loop"}, ResolveProxyChain(proxies, "Loop"))
+	assert.Equal(t, []string{"DIRECT"}, ResolveProxyChain(proxies, "DIRECT"))
+}
+
+func TestProxyServiceExplainRule(t *testing.T) {
+	svc := newTestProxyService(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rules":
+			fmt.Fprint(w, `{"rules":[{"type":"DomainSuffix","payload":"github.com","proxy":"Dev"},{"type":"Match","payload":"","proxy":"DIRECT"}]}`)
+		case "/proxies":
+			fmt.Fprint(w, `{"proxies":{"Dev":{"name":"Dev","type":"Selector","now":"JP"},"JP":{"name":"JP","type":"Trojan"}}}`)
+		case "/configs":
+			fmt.Fprint(w, `{"mode":"rule"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	exp, err := svc.ExplainRule(RuleTarget{Host: "api.github.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "rule", exp.Mode)
+	require.NotNil(t, exp.Matched)
+	assert.Equal(t, []string{"Dev", "JP"}, exp.Chain)
+
+	exp, err = svc.ExplainRule(RuleTarget{Host: "example.org"})
+	require.NoError(t, err)
+	assert.Equal(t, "Match", exp.Matched.Rule.Type)
+	assert.Equal(t, []string{"DIRECT"}, exp.Chain)
+}
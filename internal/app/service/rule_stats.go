@@ -0,0 +1,456 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+)
+
+const (
+	// DefaultRuleStatsWindow 判定规则无命中的默认时间窗口
+	DefaultRuleStatsWindow = 24 * time.Hour
+	// ruleHitsRetention 命中记录保留时长
+	ruleHitsRetention = 30 * 24 * time.Hour
+)
+
+// RuleKey 规则标识（类型统一为 DOMAINSUFFIX 这类写法，与连接的 rule/rulePayload 对应）
+type RuleKey struct {
+	Type    string
+	Payload string
+}
+
+// NewRuleKey 由规则类型与内容生成标识
+func NewRuleKey(ruleType, payload string) RuleKey {
+	return RuleKey{Type: normalizeRuleType(ruleType), Payload: payload}
+}
+
+// RuleHitStats 单条规则在时间窗口内的命中次数与流量
+type RuleHitStats struct {
+	Hits    int       `json:"hits"`
+	Bytes   int64     `json:"bytes"`
+	LastHit time.Time `json:"last_hit,omitempty"`
+}
+
+// ruleHitBucket 一小时内的命中统计
+type ruleHitBucket struct {
+	Hour  int64 `json:"hour"` // 小时起点的 Unix 秒
+	Hits  int   `json:"hits"`
+	Bytes int64 `json:"bytes"`
+}
+
+type ruleHitRecord struct {
+	Type    string          `json:"type"`
+	Payload string          `json:"payload"`
+	LastHit time.Time       `json:"last_hit"`
+	Buckets []ruleHitBucket `json:"buckets"`
+}
+
+type ruleHitFile struct {
+	Since   time.Time        `json:"since"`
+	Records []ruleHitRecord  `json:"records"`
+	Conns   map[string]int64 `json:"conns"`
+}
+
+// RuleHitCounter 从实时连接累计各规则的命中次数（新连接数）与流量，按小时分桶以支持时间窗口
+type RuleHitCounter struct {
+	mu      sync.Mutex
+	records map[RuleKey]*ruleHitRecord
+	pending map[string]*pendingConn // 上次载入/保存后各连接新增的统计，保存时与文件中的记录合并
+	conns   map[string]int64        // 仍在活跃的连接已计入的流量，用于计算增量
+	since   time.Time               // 开始统计的时间
+	dirty   bool
+	now     func() time.Time
+}
+
+// pendingConn 一条连接尚未写入文件的统计
+type pendingConn struct {
+	ruleHitRecord
+	base int64 // 开始记录时已计入的流量
+	hit  bool  // 本进程首次看到该连接，需要计一次命中
+}
+
+// NewRuleHitCounter 创建规则命中计数器
+func NewRuleHitCounter() *RuleHitCounter {
+	return &RuleHitCounter{
+		records: make(map[RuleKey]*ruleHitRecord),
+		pending: make(map[string]*pendingConn),
+		conns:   make(map[string]int64),
+		now:     time.Now,
+	}
+}
+
+// Observe 记录一次连接快照：新出现的连接计一次命中，已有连接累加流量增量
+func (c *RuleHitCounter) Observe(conns []model.Connection) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if c.since.IsZero() {
+		c.since = now
+	}
+	hour := now.Truncate(time.Hour).Unix()
+	active := make(map[string]bool, len(conns))
+	for _, conn := range conns {
+		active[conn.ID] = true
+		total := conn.Upload + conn.Download
+		prev, seen := c.conns[conn.ID]
+		key := NewRuleKey(conn.Rule, conn.RulePayload)
+		delta := total - prev
+		if !seen {
+			delta = total
+		}
+		if seen && delta <= 0 {
+			continue
+		}
+		c.conns[conn.ID] = total
+		hits := 0
+		if !seen {
+			hits = 1
+		}
+		bucket := ruleHitBucket{Hour: hour, Hits: hits, Bytes: delta}
+		rec := c.records[key]
+		if rec == nil {
+			rec = &ruleHitRecord{Type: key.Type, Payload: key.Payload}
+			c.records[key] = rec
+		}
+		p := c.pending[conn.ID]
+		if p == nil {
+			p = &pendingConn{ruleHitRecord: ruleHitRecord{Type: key.Type, Payload: key.Payload}, base: prev, hit: !seen}
+			c.pending[conn.ID] = p
+		}
+		for _, r := range []*ruleHitRecord{rec, &p.ruleHitRecord} {
+			r.addBucket(bucket)
+			if !seen {
+				r.LastHit = now
+			}
+		}
+		c.dirty = true
+	}
+	for id := range c.conns {
+		if !active[id] {
+			delete(c.conns, id)
+			c.dirty = true
+		}
+	}
+}
+
+// Stats 返回 since 之后（按小时对齐）各规则的统计
+func (c *RuleHitCounter) Stats(since time.Time) map[RuleKey]RuleHitStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	from := since.Truncate(time.Hour).Unix()
+	out := make(map[RuleKey]RuleHitStats, len(c.records))
+	for key, rec := range c.records {
+		var st RuleHitStats
+		for _, b := range rec.Buckets {
+			if b.Hour >= from {
+				st.Hits += b.Hits
+				st.Bytes += b.Bytes
+			}
+		}
+		if st.Hits > 0 || st.Bytes > 0 {
+			st.LastHit = rec.LastHit
+			out[key] = st
+		}
+	}
+	return out
+}
+
+// Since 开始统计的时间（从未观察到连接时为零值）
+func (c *RuleHitCounter) Since() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.since
+}
+
+// DefaultRuleHitsPath 命中统计的默认保存路径（~/.mihosh/rule-hits.json）
+func DefaultRuleHitsPath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("获取配置目录失败: %w", err)
+	}
+	return filepath.Join(dir, "rule-hits.json"), nil
+}
+
+// Load 从文件恢复统计，文件不存在时不报错
+func (c *RuleHitCounter) Load(path string) error {
+	file, err := readRuleHitFile(path)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.since = file.Since
+	c.records = make(map[RuleKey]*ruleHitRecord, len(file.Records))
+	for i := range file.Records {
+		rec := file.Records[i]
+		c.records[RuleKey{Type: rec.Type, Payload: rec.Payload}] = &rec
+	}
+	c.pending = make(map[string]*pendingConn)
+	c.conns = file.Conns
+	if c.conns == nil {
+		c.conns = make(map[string]int64)
+	}
+	c.dirty = false
+	return nil
+}
+
+// Save 将新增的统计合并进文件中的记录（其它 mihosh 进程可能同时在写），
+// 丢弃超过保留期的记录后原子替换文件，没有变化时跳过。
+// 文件中的 conns 记录各连接已计入的流量，其它进程已计入的命中与流量不会重复累加
+func (c *RuleHitCounter) Save(path string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+
+	unlock, err := lockFile(path)
+	if err != nil {
+		return fmt.Errorf("锁定规则命中统计失败: %w", err)
+	}
+	defer unlock()
+
+	file, err := readRuleHitFile(path)
+	if err != nil {
+		return err
+	}
+	records := make(map[RuleKey]*ruleHitRecord, len(file.Records)+len(c.pending))
+	for i := range file.Records {
+		rec := file.Records[i]
+		records[RuleKey{Type: rec.Type, Payload: rec.Payload}] = &rec
+	}
+	for id, p := range c.pending {
+		counted, known := file.Conns[id]
+		hit := p.hit && !known
+		// 跳过其它进程已计入的流量（分桶按时间排序，从最早的开始扣除）
+		skip := max(counted-p.base, 0)
+		key := RuleKey{Type: p.Type, Payload: p.Payload}
+		for _, b := range p.Buckets {
+			n := min(skip, b.Bytes)
+			b.Bytes -= n
+			skip -= n
+			if !hit {
+				b.Hits = 0
+			}
+			if b.Hits == 0 && b.Bytes == 0 {
+				continue
+			}
+			rec := records[key]
+			if rec == nil {
+				rec = &ruleHitRecord{Type: key.Type, Payload: key.Payload}
+				records[key] = rec
+			}
+			rec.addBucket(b)
+		}
+		if rec := records[key]; hit && rec != nil && p.LastHit.After(rec.LastHit) {
+			rec.LastHit = p.LastHit
+		}
+	}
+	since := c.since
+	if !file.Since.IsZero() && (since.IsZero() || file.Since.Before(since)) {
+		since = file.Since
+	}
+	// 只保留仍在活跃的连接，避免文件无限增长
+	conns := make(map[string]int64, len(c.conns))
+	for id, total := range c.conns {
+		conns[id] = max(total, file.Conns[id])
+	}
+
+	cutoff := c.now().Add(-ruleHitsRetention).Unix()
+	out := ruleHitFile{Since: since, Records: make([]ruleHitRecord, 0, len(records)), Conns: conns}
+	for key, rec := range records {
+		i := sort.Search(len(rec.Buckets), func(i int) bool { return rec.Buckets[i].Hour >= cutoff })
+		if rec.Buckets = rec.Buckets[i:]; len(rec.Buckets) == 0 {
+			delete(records, key)
+			continue
+		}
+		out.Records = append(out.Records, *rec)
+	}
+	if cutoffTime := time.Unix(cutoff, 0); since.Before(cutoffTime) {
+		out.Since = cutoffTime
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, data, 0o644); err != nil {
+		return fmt.Errorf("写入规则命中统计失败: %w", err)
+	}
+
+	// 写入成功后才清空待合并的统计，失败时下次保存重试
+	c.records = records
+	c.pending = make(map[string]*pendingConn)
+	c.conns = conns
+	c.since = out.Since
+	c.dirty = false
+	return nil
+}
+
+// addBucket 将一小时的统计累加到记录中，保持分桶按时间排序
+func (r *ruleHitRecord) addBucket(b ruleHitBucket) {
+	i := sort.Search(len(r.Buckets), func(i int) bool { return r.Buckets[i].Hour >= b.Hour })
+	if i < len(r.Buckets) && r.Buckets[i].Hour == b.Hour {
+		r.Buckets[i].Hits += b.Hits
+		r.Buckets[i].Bytes += b.Bytes
+		return
+	}
+	r.Buckets = slices.Insert(r.Buckets, i, b)
+}
+
+// readRuleHitFile 读取命中统计文件，文件不存在时返回空记录
+func readRuleHitFile(path string) (ruleHitFile, error) {
+	var file ruleHitFile
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return file, nil
+	}
+	if err != nil {
+		return file, fmt.Errorf("读取规则命中统计失败: %w", err)
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return file, fmt.Errorf("解析规则命中统计失败: %w", err)
+	}
+	return file, nil
+}
+
+// writeFileAtomic 先写同目录下的临时文件再替换，避免写入中断导致文件损坏；
+// 临时文件名随机，多个进程同时保存时互不覆盖
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+const (
+	// fileLockTimeout 等待其它进程释放文件锁的最长时间
+	fileLockTimeout = 5 * time.Second
+	// fileLockStale 锁文件超过该时长视为持有进程已退出
+	fileLockStale = 30 * time.Second
+)
+
+// lockFile 通过独占创建 <path>.lock 在进程间互斥，返回释放函数
+func lockFile(path string) (func(), error) {
+	lock := path + ".lock"
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(fileLockTimeout)
+	for {
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lock) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(lock); err == nil && time.Since(info.ModTime()) > fileLockStale {
+			os.Remove(lock)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("等待 %s 超时", lock)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// ParseStatsWindow 解析时间窗口，在 time.ParseDuration 基础上支持 d（天），空字符串返回默认值
+func ParseStatsWindow(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return DefaultRuleStatsWindow, nil
+	}
+	var d time.Duration
+	var err error
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		var n float64
+		n, err = strconv.ParseFloat(days, 64)
+		d = time.Duration(n * float64(24*time.Hour))
+	} else {
+		d, err = time.ParseDuration(s)
+	}
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("无效的时间窗口: %s (示例: 90m, 24h, 7d)", s)
+	}
+	return d, nil
+}
+
+// RuleHitRow 规则列表中一条规则的命中统计
+type RuleHitRow struct {
+	Index   int        `json:"index"` // 从 1 开始
+	Rule    model.Rule `json:"rule"`
+	Hits    int        `json:"hits"`
+	Bytes   int64      `json:"bytes"`
+	LastHit *time.Time `json:"last_hit,omitempty"`
+	Dead    bool       `json:"dead"` // 时间窗口内既没有新连接也没有流量
+}
+
+// BuildRuleHitRows 将统计对应到规则列表；重复的规则只有第一条能被命中
+func BuildRuleHitRows(rules []model.Rule, stats map[RuleKey]RuleHitStats) []RuleHitRow {
+	rows := make([]RuleHitRow, len(rules))
+	claimed := make(map[RuleKey]bool, len(rules))
+	for i, rule := range rules {
+		rows[i] = RuleHitRow{Index: i + 1, Rule: rule}
+		key := NewRuleKey(rule.Type, rule.Payload)
+		st, ok := stats[key]
+		if ok && !claimed[key] {
+			rows[i].Hits, rows[i].Bytes = st.Hits, st.Bytes
+			lastHit := st.LastHit
+			rows[i].LastHit = &lastHit
+		}
+		claimed[key] = true
+		rows[i].Dead = rows[i].Hits == 0 && rows[i].Bytes == 0
+	}
+	return rows
+}
+
+// 命中统计排序方式
+const (
+	RuleSortIndex = "index"
+	RuleSortHits  = "hits"
+	RuleSortBytes = "bytes"
+)
+
+// SortRuleHitRows 按命中次数或流量降序排序（相同时保持规则顺序），index 保持原顺序
+func SortRuleHitRows(rows []RuleHitRow, by string) error {
+	switch by {
+	case "", RuleSortIndex:
+		sort.SliceStable(rows, func(i, j int) bool { return rows[i].Index < rows[j].Index })
+	case RuleSortHits:
+		sort.SliceStable(rows, func(i, j int) bool { return rows[i].Hits > rows[j].Hits })
+	case RuleSortBytes:
+		sort.SliceStable(rows, func(i, j int) bool { return rows[i].Bytes > rows[j].Bytes })
+	default:
+		return fmt.Errorf("不支持的排序方式: %s (可用: index, hits, bytes)", by)
+	}
+	return nil
+}
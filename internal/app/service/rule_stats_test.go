@@ -0,0 +1,198 @@
+package service
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ruleConn(id, rule, payload string, up, down int64) model.Connection {
+	return model.Connection{ID: id, Rule: rule, RulePayload: payload, Upload: up, Download: down}
+}
+
+func TestRuleHitCounterObserve(t *testing.T) {
+	now := time.Date(2026, 10, 1, 10, 30, 0, 0, time.UTC)
+	c := NewRuleHitCounter()
+	c.now = func() time.Time { return now }
+
+	c.Observe([]model.Connection{
+		ruleConn("1", "DomainSuffix", "google.com", 100, 200),
+		ruleConn("2", "DomainSuffix", "google.com", 0, 50),
+		ruleConn("3", "Match", "", 10, 0),
+	})
+	// 已有连接只累加增量，新连接计一次命中
+	now = now.Add(2 * time.Hour)
+	c.Observe([]model.Connection{
+		ruleConn("1", "DomainSuffix", "google.com", 150, 400),
+		ruleConn("4", "DOMAIN-SUFFIX", "google.com", 5, 5),
+	})
+
+	stats := c.Stats(now.Add(-24 * time.Hour))
+	google := stats[NewRuleKey("DomainSuffix", "google.com")]
+	assert.Equal(t, 3, google.Hits)
+	assert.Equal(t, int64(100+200+50+250+10), google.Bytes)
+	assert.Equal(t, now, google.LastHit)
+	assert.Equal(t, 1, stats[NewRuleKey("MATCH", "")].Hits)
+
+	// 时间窗口按小时对齐
+	recent := c.Stats(now.Add(-time.Hour))
+	assert.Equal(t, 1, recent[NewRuleKey("DomainSuffix", "google.com")].Hits)
+	assert.Equal(t, int64(260), recent[NewRuleKey("DomainSuffix", "google.com")].Bytes)
+	assert.NotContains(t, recent, NewRuleKey("Match", ""))
+	assert.Equal(t, now.Add(-2*time.Hour), c.Since())
+}
+
+func TestRuleHitCounterSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rule-hits.json")
+	now := time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)
+	c := NewRuleHitCounter()
+	c.now = func() time.Time { return now }
+	c.Observe([]model.Connection{ruleConn("1", "Domain", "a.com", 10, 10)})
+	require.NoError(t, c.Save(path))
+
+	restored := NewRuleHitCounter()
+	restored.now = func() time.Time { return now }
+	require.NoError(t, restored.Load(path))
+	// 重启后仍在活跃的连接不会重复计数
+	restored.Observe([]model.Connection{ruleConn("1", "Domain", "a.com", 10, 30)})
+	st := restored.Stats(now.Add(-time.Hour))[NewRuleKey("Domain", "a.com")]
+	assert.Equal(t, 1, st.Hits)
+	assert.Equal(t, int64(40), st.Bytes)
+
+	// 超过保留期的记录在保存时丢弃
+	now = now.Add(ruleHitsRetention + 2*time.Hour)
+	restored.Observe(nil)
+	require.NoError(t, restored.Save(path))
+	again := NewRuleHitCounter()
+	require.NoError(t, again.Load(path))
+	assert.Empty(t, again.Stats(time.Time{}))
+
+	assert.NoError(t, NewRuleHitCounter().Load(filepath.Join(t.TempDir(), "missing.json")))
+}
+
+func TestRuleHitCounterSaveMergesConcurrentWriters(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rule-hits.json")
+	now := time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)
+	newCounter := func() *RuleHitCounter {
+		c := NewRuleHitCounter()
+		c.now = func() time.Time { return now }
+		require.NoError(t, c.Load(path))
+		return c
+	}
+
+	// TUI 与 rules stats --collect 同时运行，各自保存时不应覆盖对方的统计
+	tui, cli := newCounter(), newCounter()
+	tui.Observe([]model.Connection{ruleConn("1", "Domain", "a.com", 10, 10)})
+	cli.Observe([]model.Connection{ruleConn("2", "Domain", "a.com", 5, 5), ruleConn("3", "Match", "", 1, 1)})
+	require.NoError(t, tui.Save(path))
+	require.NoError(t, cli.Save(path))
+	now = now.Add(time.Hour)
+	tui.Observe([]model.Connection{ruleConn("1", "Domain", "a.com", 10, 20), ruleConn("4", "Domain", "a.com", 0, 0)})
+	require.NoError(t, tui.Save(path))
+
+	merged := newCounter()
+	stats := merged.Stats(time.Time{})
+	assert.Equal(t, RuleHitStats{Hits: 3, Bytes: 40, LastHit: now}, stats[NewRuleKey("Domain", "a.com")])
+	assert.Equal(t, 1, stats[NewRuleKey("Match", "")].Hits)
+	// 保存后的计数器也能看到其它进程写入的统计
+	assert.Equal(t, stats, tui.Stats(time.Time{}))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "临时文件应已被替换")
+}
+
+func TestRuleHitCounterSaveSkipsConnectionsCountedElsewhere(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rule-hits.json")
+	now := time.Date(2026, 10, 1, 10, 0, 0, 0, time.UTC)
+	newCounter := func() *RuleHitCounter {
+		c := NewRuleHitCounter()
+		c.now = func() time.Time { return now }
+		require.NoError(t, c.Load(path))
+		return c
+	}
+
+	// 两个进程看到同一条连接，只计一次命中与流量
+	tui, cli := newCounter(), newCounter()
+	conn := ruleConn("1", "Domain", "a.com", 50, 50)
+	tui.Observe([]model.Connection{conn})
+	cli.Observe([]model.Connection{conn})
+	require.NoError(t, tui.Save(path))
+	require.NoError(t, cli.Save(path))
+	st := newCounter().Stats(time.Time{})[NewRuleKey("Domain", "a.com")]
+	assert.Equal(t, 1, st.Hits)
+	assert.Equal(t, int64(100), st.Bytes)
+
+	// 之后的增量也只计一次
+	conn = ruleConn("1", "Domain", "a.com", 100, 50)
+	cli.Observe([]model.Connection{conn})
+	tui.Observe([]model.Connection{conn})
+	require.NoError(t, cli.Save(path))
+	require.NoError(t, tui.Save(path))
+	st = newCounter().Stats(time.Time{})[NewRuleKey("Domain", "a.com")]
+	assert.Equal(t, 1, st.Hits)
+	assert.Equal(t, int64(150), st.Bytes)
+
+	// 连接关闭后从文件中移除，文件不会无限增长
+	tui.Observe(nil)
+	require.NoError(t, tui.Save(path))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var file ruleHitFile
+	require.NoError(t, json.Unmarshal(data, &file))
+	assert.Empty(t, file.Conns)
+}
+
+func TestBuildAndSortRuleHitRows(t *testing.T) {
+	rules := []model.Rule{
+		{Type: "DomainSuffix", Payload: "google.com", Proxy: "Proxy"},
+		{Type: "DomainKeyword", Payload: "ads", Proxy: "REJECT"},
+		{Type: "DomainSuffix", Payload: "google.com", Proxy: "DIRECT"}, // 重复规则不会被命中
+		{Type: "Match", Proxy: "Final"},
+	}
+	stats := map[RuleKey]RuleHitStats{
+		NewRuleKey("DOMAIN-SUFFIX", "google.com"): {Hits: 3, Bytes: 100},
+		NewRuleKey("MATCH", ""):                   {Hits: 1, Bytes: 5000},
+	}
+	rows := BuildRuleHitRows(rules, stats)
+	require.Len(t, rows, 4)
+	assert.Equal(t, 3, rows[0].Hits)
+	assert.False(t, rows[0].Dead)
+	assert.True(t, rows[1].Dead)
+	assert.True(t, rows[2].Dead)
+	assert.Nil(t, rows[2].LastHit)
+
+	require.NoError(t, SortRuleHitRows(rows, RuleSortBytes))
+	assert.Equal(t, []int{4, 1, 2, 3}, []int{rows[0].Index, rows[1].Index, rows[2].Index, rows[3].Index})
+	require.NoError(t, SortRuleHitRows(rows, RuleSortHits))
+	assert.Equal(t, 1, rows[0].Index)
+	require.NoError(t, SortRuleHitRows(rows, RuleSortIndex))
+	assert.Equal(t, 1, rows[0].Index)
+	assert.Error(t, SortRuleHitRows(rows, "name"))
+}
+
+func TestParseStatsWindow(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":     DefaultRuleStatsWindow,
+		"90m":  90 * time.Minute,
+		"24h":  24 * time.Hour,
+		"7d":   7 * 24 * time.Hour,
+		"1.5d": 36 * time.Hour,
+	}
+	for in, want := range cases {
+		got, err := ParseStatsWindow(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	for _, in := range []string{"abc", "-1h", "0d"} {
+		_, err := ParseStatsWindow(in)
+		assert.ErrorContains(t, err, "无效的时间窗口", in)
+	}
+}
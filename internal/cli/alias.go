@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/infrastructure/api"
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+	"github.com/spf13/cobra"
+)
+
+var aliasOutput string
+
+var aliasCmd = &cobra.Command{
+	Use:   "alias [--output json|table|plain]",
+	Short: "管理节点别名",
+	Long: `列出、设置或删除节点别名。
+
+别名保存在 mihosh 配置的 proxy_aliases 中，可在 select、test node 及 shell 补全中代替节点名使用。
+别名不区分大小写；目标以 "re:" 开头时按正则匹配，取策略组中第一个匹配的节点，
+订阅更新导致节点改名时，只要正则仍能匹配，脚本就无需修改。`,
+	Example: `  mihosh alias
+  mihosh alias set hk "🇭🇰 香港 IEPL 01 | x1.5"
+  mihosh alias set hk 're:香港.*IEPL'
+  mihosh alias rm hk
+  mihosh select Proxy hk`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := parseOutputFormat(aliasOutput)
+		if err != nil {
+			return wrapParameterError(err)
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return wrapConfigError(fmt.Errorf("加载配置失败: %w", err))
+		}
+
+		if err := renderAliasList(os.Stdout, cfg.ProxyAliases, format); err != nil {
+			return fmt.Errorf("渲染输出失败: %w", err)
+		}
+		return nil
+	},
+}
+
+var aliasSetCmd = &cobra.Command{
+	Use:   "set <alias> <proxy|re:pattern>",
+	Short: "设置节点别名",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configSvc := service.NewConfigService()
+		if err := configSvc.SetProxyAlias(args[0], args[1]); err != nil {
+			return wrapConfigError(fmt.Errorf("设置别名失败: %w", err))
+		}
+
+		fmt.Printf("✓ 已设置别名 %s -> %s\n", args[0], args[1])
+		return nil
+	},
+}
+
+var aliasRemoveCmd = &cobra.Command{
+	Use:     "rm <alias>",
+	Aliases: []string{"remove", "delete"},
+	Short:   "删除节点别名",
+	Args:    cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		cfg, err := config.Load()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return aliasCompletions(cfg.ProxyAliases), cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configSvc := service.NewConfigService()
+		if err := configSvc.RemoveProxyAlias(args[0]); err != nil {
+			return wrapConfigError(fmt.Errorf("删除别名失败: %w", err))
+		}
+
+		fmt.Printf("✓ 已删除别名 %s\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	aliasCmd.Flags().StringVar(&aliasOutput, "output", string(outputFormatPlain), "输出格式: json|table|plain")
+	aliasCmd.AddCommand(aliasSetCmd)
+	aliasCmd.AddCommand(aliasRemoveCmd)
+}
+
+type aliasOutputItem struct {
+	Alias  string `json:"alias"`
+	Target string `json:"target"`
+	Regex  bool   `json:"regex"`
+}
+
+func renderAliasList(w io.Writer, aliases map[string]string, format outputFormat) error {
+	items := make([]aliasOutputItem, 0, len(aliases))
+	for _, alias := range service.SortedAliasNames(aliases) {
+		target := aliases[alias]
+		items = append(items, aliasOutputItem{
+			Alias:  alias,
+			Target: target,
+			Regex:  strings.HasPrefix(target, service.AliasRegexPrefix),
+		})
+	}
+
+	switch format {
+	case outputFormatJSON:
+		return writeJSON(w, items)
+	case outputFormatTable:
+		tw := newTabWriter(w)
+		fmt.Fprintln(tw, "ALIAS\tTARGET")
+		for _, item := range items {
+			fmt.Fprintf(tw, "%s\t%s\n", item.Alias, item.Target)
+		}
+		return tw.Flush()
+	case outputFormatPlain:
+		if len(items) == 0 {
+			fmt.Fprintln(w, "尚未设置节点别名（使用 mihosh alias set <别名> <节点名> 添加）")
+			return nil
+		}
+		for _, item := range items {
+			fmt.Fprintf(w, "%s -> %s\n", item.Alias, item.Target)
+		}
+		return nil
+	default:
+		return fmt.Errorf("不支持的输出格式: %s", format)
+	}
+}
+
+// resolveProxyArg 将命令行中的别名解析为节点名，仅在命中别名时才调用 loadCandidates 拉取节点列表
+func resolveProxyArg(cfg *config.Config, name string, loadCandidates func() ([]string, error)) (string, error) {
+	if _, ok := service.LookupProxyAlias(cfg.ProxyAliases, name); !ok {
+		return name, nil
+	}
+
+	candidates, err := loadCandidates()
+	if err != nil {
+		return "", wrapNetworkError(fmt.Errorf("获取节点列表失败: %w", err))
+	}
+	resolved, err := service.ResolveProxyAlias(name, cfg.ProxyAliases, candidates)
+	if err != nil {
+		return "", wrapParameterError(err)
+	}
+	return resolved, nil
+}
+
+// groupMembersLoader 返回策略组成员列表的加载函数
+func groupMembersLoader(proxySvc *service.ProxyService, group string) func() ([]string, error) {
+	return func() ([]string, error) {
+		groups, _, err := proxySvc.GetGroups()
+		if err != nil {
+			return nil, err
+		}
+		g, ok := groups[group]
+		if !ok {
+			return nil, fmt.Errorf("策略组不存在: %s", group)
+		}
+		return g.All, nil
+	}
+}
+
+// allProxiesLoader 返回按名称排序的全部节点列表的加载函数
+func allProxiesLoader(proxySvc *service.ProxyService) func() ([]string, error) {
+	return func() ([]string, error) {
+		proxies, err := proxySvc.GetProxies()
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(proxies))
+		for name := range proxies {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names, nil
+	}
+}
+
+// aliasCompletions 生成别名补全项（附带目标说明）
+func aliasCompletions(aliases map[string]string) []string {
+	completions := make([]string, 0, len(aliases))
+	for _, alias := range service.SortedAliasNames(aliases) {
+		completions = append(completions, alias+"\t"+aliases[alias])
+	}
+	return completions
+}
+
+// completeProxyNames 补全节点名与别名；group 非空时只补全该策略组成员
+func completeProxyNames(group string) ([]string, cobra.ShellCompDirective) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	completions := aliasCompletions(cfg.ProxyAliases)
+
+	proxySvc := service.NewProxyService(api.NewClient(cfg), cfg.TestURL, cfg.Timeout)
+	load := allProxiesLoader(proxySvc)
+	if group != "" {
+		load = groupMembersLoader(proxySvc, group)
+	}
+	if names, err := load(); err == nil {
+		completions = append(completions, names...)
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeGroupNames 补全策略组名
+func completeGroupNames() ([]string, cobra.ShellCompDirective) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	proxySvc := service.NewProxyService(api.NewClient(cfg), cfg.TestURL, cfg.Timeout)
+	_, orderedNames, err := proxySvc.GetGroups()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return orderedNames, cobra.ShellCompDirectiveNoFileComp
+}
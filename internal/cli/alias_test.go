@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderAliasList(t *testing.T) {
+	aliases := map[string]string{"jp": "JP 01", "hk": "re:香港.*IEPL"}
+
+	var buf bytes.Buffer
+	err := renderAliasList(&buf, aliases, outputFormatPlain)
+	assert.NoError(t, err)
+	assert.Equal(t, "hk -> re:香港.*IEPL\njp -> JP 01\n", buf.String())
+
+	buf.Reset()
+	err = renderAliasList(&buf, aliases, outputFormatJSON)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `"regex": true`)
+}
+
+func TestResolveProxyArg(t *testing.T) {
+	cfg := &config.Config{ProxyAliases: map[string]string{"hk": "re:香港"}}
+	loads := 0
+	load := func() ([]string, error) {
+		loads++
+		return []string{"JP 01", "香港 02"}, nil
+	}
+
+	got, err := resolveProxyArg(cfg, "JP 01", load)
+	assert.NoError(t, err)
+	assert.Equal(t, "JP 01", got)
+	assert.Equal(t, 0, loads, "非别名不应拉取节点列表")
+
+	got, err = resolveProxyArg(cfg, "HK", load)
+	assert.NoError(t, err)
+	assert.Equal(t, "香港 02", got)
+	assert.Equal(t, 1, loads)
+}
+
+func TestResolveProxyArgErrors(t *testing.T) {
+	cfg := &config.Config{ProxyAliases: map[string]string{"hk": "re:香港"}}
+
+	_, err := resolveProxyArg(cfg, "hk", func() ([]string, error) { return []string{"JP 01"}, nil })
+	assert.Equal(t, exitCodeParameter, exitCodeForError(err))
+
+	_, err = resolveProxyArg(cfg, "hk", func() ([]string, error) { return nil, errors.New("connection refused") })
+	assert.Equal(t, exitCodeNetwork, exitCodeForError(err))
+}
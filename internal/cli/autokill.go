@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/infrastructure/api"
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	autokillDryRun bool
+	autokillOutput string
+)
+
+var autokillCmd = &cobra.Command{
+	Use:   "autokill [--dry-run] [--output json|plain]",
+	Short: "按 kill_policies 持续自动关闭连接（无界面模式）",
+	Long: `订阅 mihomo 的连接推送，每次更新时按配置中的 kill_policies 匹配连接，
+命中的连接会被立即关闭并输出一条记录（策略名、连接目标、进程、链路和匹配表达式）。
+策略的 match 使用与 mihosh connections --filter 相同的过滤表达式，按顺序匹配，命中第一条即止。
+
+配置示例（~/.mihosh/config.yaml）：
+  kill_policies:
+    - name: foo-direct
+      match: process:foo chain:DIRECT
+    - name: stale-quic
+      match: net:udp dport:443 age>10m
+
+--dry-run 只输出将被关闭的连接；--output json 时每条记录为一行 JSON（NDJSON）。
+Ctrl+C 退出。TUI 运行期间同样会执行这些策略，记录显示在日志页。`,
+	Example: `  mihosh autokill --dry-run
+  mihosh autokill
+  mihosh autokill --output json >> autokill.log`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := parseOutputFormat(autokillOutput)
+		if err != nil {
+			return wrapParameterError(err)
+		}
+		if format == outputFormatTable {
+			return wrapParameterError(fmt.Errorf("autokill 仅支持 json 或 plain 输出"))
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return wrapConfigError(fmt.Errorf("加载配置失败: %w", err))
+		}
+		connSvc := service.NewConnectionService(api.NewClient(cfg))
+		killer, err := service.NewAutoKiller(cfg.KillPolicies, connSvc.CloseConnection, autokillDryRun)
+		if err != nil {
+			return wrapConfigError(err)
+		}
+		if killer.Empty() {
+			return wrapConfigError(fmt.Errorf("未配置启用的 kill_policies"))
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		// 只保留最新一次快照：处理较慢时跳过中间的推送
+		updates := make(chan api.ConnectionsData, 1)
+		wsClient := api.NewWSClient(cfg.APIAddress, cfg.Secret)
+		wsClient.SetConnectionsHandler(func(data api.ConnectionsData) {
+			select {
+			case updates <- data:
+			default:
+				select {
+				case <-updates:
+				default:
+				}
+				updates <- data
+			}
+		})
+		wsClient.Start()
+		defer wsClient.Stop()
+
+		if format == outputFormatPlain {
+			mode := ""
+			if autokillDryRun {
+				mode = "（dry-run）"
+			}
+			fmt.Fprintf(os.Stderr, "自动关闭已启动%s，策略: %v，Ctrl+C 退出\n", mode, killer.PolicyNames())
+		}
+		return runAutoKill(ctx, killer, updates, os.Stdout, format)
+	},
+}
+
+func init() {
+	autokillCmd.Flags().BoolVar(&autokillDryRun, "dry-run", false, "只输出将被关闭的连接，不实际关闭")
+	autokillCmd.Flags().StringVar(&autokillOutput, "output", string(outputFormatPlain), "输出格式: json|plain")
+}
+
+// runAutoKill 逐个处理连接快照直到 ctx 结束
+func runAutoKill(ctx context.Context, killer *service.AutoKiller, updates <-chan api.ConnectionsData, w io.Writer, format outputFormat) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case data := <-updates:
+			for _, record := range killer.Evaluate(data.Connections, time.Now()) {
+				if format == outputFormatJSON {
+					if err := writeNDJSON(w, record); err != nil {
+						return fmt.Errorf("渲染输出失败: %w", err)
+					}
+					continue
+				}
+				fmt.Fprintf(w, "%s %s\n", record.Time.Format("15:04:05"), record.String())
+			}
+		}
+	}
+}
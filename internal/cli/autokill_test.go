@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/infrastructure/api"
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunAutoKill(t *testing.T) {
+	killer, err := service.NewAutoKiller([]config.KillPolicy{{Name: "foo-direct", Match: "process:foo chain:DIRECT"}}, nil, true)
+	require.NoError(t, err)
+
+	updates := make(chan api.ConnectionsData, 2)
+	snapshot := api.ConnectionsData{Connections: []api.ConnectionData{
+		{ID: "a", Chains: []string{"DIRECT"}, Metadata: api.ConnectionMeta{Host: "example.com", DestinationPort: "443", Process: "foo"}},
+		{ID: "b", Chains: []string{"Proxy"}, Metadata: api.ConnectionMeta{Host: "example.org", Process: "foo"}},
+	}}
+	updates <- snapshot
+	updates <- snapshot
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var out bytes.Buffer
+	require.NoError(t, runAutoKill(ctx, killer, updates, &out, outputFormatJSON))
+	assert.Equal(t, 1, bytes.Count(out.Bytes(), []byte("\n")), "同一连接只记录一次")
+	assert.Contains(t, out.String(), `"policy":"foo-direct"`)
+	assert.Contains(t, out.String(), `"dry_run":true`)
+}
@@ -82,13 +82,15 @@ var configSetCmd = &cobra.Command{
   test-url     - 测速 URL (例如: http://www.gstatic.com/generate_204)
   timeout      - 超时时间，单位毫秒 (例如: 5000)
   proxy-address - HTTP 代理地址 (例如: http://127.0.0.1:7890)
+  test-concurrency - 批量测速并发数 (例如: 20)
 
 示例:
   mihosh config set api-address http://127.0.0.1:9090
   mihosh config set secret your-secret-here
   mihosh config set test-url http://www.google.com/generate_204
   mihosh config set timeout 3000
-  mihosh config set proxy-address http://127.0.0.1:7890`,
+  mihosh config set proxy-address http://127.0.0.1:7890
+  mihosh config set test-concurrency 50`,
 	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		key := args[0]
@@ -380,7 +382,8 @@ func wrapGeneralError(err error) error {
 
 func isConfigSetValidationError(err error) bool {
 	msg := strings.TrimSpace(err.Error())
-	return strings.Contains(msg, "未知的配置项:") || strings.Contains(msg, "timeout 必须是数字:")
+	return strings.Contains(msg, "未知的配置项:") || strings.Contains(msg, "timeout 必须是数字:") ||
+		strings.Contains(msg, "test_concurrency 必须是正整数:")
 }
 
 func renderConfigShow(w io.Writer, cfg *config.Config, configPath string, format outputFormat) error {
@@ -392,6 +395,7 @@ func renderConfigShow(w io.Writer, cfg *config.Config, configPath string, format
 			TestURL      string `json:"test_url"`
 			TimeoutMS    int    `json:"timeout_ms"`
 			ProxyAddress string `json:"proxy_address"`
+			Concurrency  int    `json:"test_concurrency"`
 			ConfigFile   string `json:"config_file"`
 		}{
 			APIAddress:   cfg.APIAddress,
@@ -399,6 +403,7 @@ func renderConfigShow(w io.Writer, cfg *config.Config, configPath string, format
 			TestURL:      cfg.TestURL,
 			TimeoutMS:    cfg.Timeout,
 			ProxyAddress: cfg.ProxyAddress,
+			Concurrency:  service.ResolveTestConcurrency(cfg.TestConcurrency),
 			ConfigFile:   configPath,
 		}
 		return writeJSON(w, payload)
@@ -410,6 +415,7 @@ func renderConfigShow(w io.Writer, cfg *config.Config, configPath string, format
 		fmt.Fprintf(tw, "TEST_URL\t%s\n", cfg.TestURL)
 		fmt.Fprintf(tw, "TIMEOUT_MS\t%d\n", cfg.Timeout)
 		fmt.Fprintf(tw, "PROXY_ADDRESS\t%s\n", cfg.ProxyAddress)
+		fmt.Fprintf(tw, "TEST_CONCURRENCY\t%d\n", service.ResolveTestConcurrency(cfg.TestConcurrency))
 		fmt.Fprintf(tw, "CONFIG_FILE\t%s\n", configPath)
 		return tw.Flush()
 	case outputFormatPlain:
@@ -419,6 +425,7 @@ func renderConfigShow(w io.Writer, cfg *config.Config, configPath string, format
 		fmt.Fprintf(w, "  测速 URL: %s\n", cfg.TestURL)
 		fmt.Fprintf(w, "  超时:     %dms\n", cfg.Timeout)
 		fmt.Fprintf(w, "  代理地址: %s\n", cfg.ProxyAddress)
+		fmt.Fprintf(w, "  测速并发: %d\n", service.ResolveTestConcurrency(cfg.TestConcurrency))
 		fmt.Fprintf(w, "\n配置文件位置: %s\n", configPath)
 		return nil
 	default:
@@ -1,9 +1,15 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/aimony/mihosh/internal/app/service"
 	"github.com/aimony/mihosh/internal/domain/model"
@@ -13,30 +19,103 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var connectionsOutput string
+var (
+	connectionsOutput string
+	connectionsFilter string
+	connectionsDryRun bool
+
+	connectionsWatch         bool
+	connectionsWatchInterval time.Duration
+
+	processesPID int
+
+	exportFormat        string
+	exportFile          string
+	exportIncludeClosed bool
+	exportDuration      time.Duration
+)
 
 var connectionsCmd = &cobra.Command{
-	Use:   "connections [--output json|table|plain]",
+	Use:   "connections [--output json|table|plain] [--filter <表达式>] [--watch]",
 	Short: "查看当前连接（支持多种输出格式）",
 	Long: `查看当前活跃连接和流量统计。
 
 可通过 --output 选择输出格式：
   plain  人类可读文本（默认）
   table  表格输出
-  json   结构化 JSON 输出`,
+  json   结构化 JSON 输出
+
+--filter 使用与 TUI 连接页过滤栏相同的表达式：
+  字段    host: process: chain: rule: net: type: src: dst: dport: sport:
+          inbound: user: sniff: uid:（入站名称、入站用户、嗅探主机、进程 UID）
+  数值    up/down/total（支持 KB/MB/GB）、age（如 30s、5m、2h、1d），可用 > >= < <= = !=
+  组合    空格或 AND 为与，OR 为或，-/!/NOT 取反，括号分组
+  正则    host:/^api\./ 或 host~"(a|b)\.com"（不区分大小写）
+  未带字段的词按主机/规则/目标 IP/代理链子串匹配
+
+--watch 订阅连接推送，持续输出满足过滤条件的新建（+）与关闭（-）连接，
+并每隔 --interval 输出一行汇总（=，0 为不输出）。--output json 时每个事件为一行 JSON（NDJSON），
+event 字段为 open / close / summary。启动时已存在的连接只作为基线，不输出事件。Ctrl+C 退出。`,
 	Example: `  mihosh connections
   mihosh connections --output table
-  mihosh connections --output json`,
+  mihosh connections --output json
+  mihosh connections --filter "net:udp dport:443"
+  mihosh connections --filter "process:chrome OR process:firefox" --output table
+  mihosh connections --filter "down>10MB age>5m -chain:DIRECT"
+  mihosh connections --watch --filter "process:chrome"
+  mihosh connections --watch --output json | jq 'select(.event == "close")'`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		format, err := parseOutputFormat(connectionsOutput)
 		if err != nil {
 			return wrapParameterError(err)
 		}
+		query, err := service.ParseConnQuery(connectionsFilter)
+		if err != nil {
+			return wrapParameterError(fmt.Errorf("过滤表达式无效: %w", err))
+		}
+
+		if connectionsWatch && format == outputFormatTable {
+			return wrapParameterError(fmt.Errorf("--watch 仅支持 json 或 plain 输出"))
+		}
 
 		cfg, err := config.Load()
 		if err != nil {
 			return wrapConfigError(fmt.Errorf("加载配置失败: %w", err))
 		}
+		geoCache, err := newQueryGeoCache(cfg, query)
+		if err != nil {
+			return err
+		}
+		defer saveGeoCache(cfg, geoCache)
+
+		if connectionsWatch {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			// 保留每一次推送：事件依赖相邻快照的差异
+			updates := make(chan api.ConnectionsData, 16)
+			wsClient := api.NewWSClient(cfg.APIAddress, cfg.Secret)
+			wsClient.SetConnectionsHandler(func(data api.ConnectionsData) {
+				data.Connections = annotateConnGeo(ctx, geoCache, data.Connections)
+				select {
+				case updates <- data:
+				case <-ctx.Done():
+				}
+			})
+			wsClient.Start()
+			defer wsClient.Stop()
+
+			var ticks <-chan time.Time
+			if connectionsWatchInterval > 0 {
+				ticker := time.NewTicker(connectionsWatchInterval)
+				defer ticker.Stop()
+				ticks = ticker.C
+			}
+			if format == outputFormatPlain {
+				fmt.Fprintln(os.Stderr, "正在监听连接，Ctrl+C 退出")
+			}
+			return runConnectionsWatch(ctx, service.NewConnWatcher(query), updates, ticks, os.Stdout, format)
+		}
 
 		client := api.NewClient(cfg)
 		connSvc := service.NewConnectionService(client)
@@ -45,6 +124,7 @@ var connectionsCmd = &cobra.Command{
 		if err != nil {
 			return wrapNetworkError(fmt.Errorf("获取连接失败: %w", err))
 		}
+		conns.Connections = service.FilterConnections(annotateConnGeo(context.Background(), geoCache, conns.Connections), query)
 
 		if err := renderConnections(os.Stdout, *conns, format); err != nil {
 			return fmt.Errorf("渲染输出失败: %w", err)
@@ -53,8 +133,347 @@ var connectionsCmd = &cobra.Command{
 	},
 }
 
+var connectionsCloseCmd = &cobra.Command{
+	Use:   "close --filter <表达式> [--dry-run] [--output json|table|plain]",
+	Short: "关闭满足过滤表达式的连接",
+	Long: `关闭所有满足过滤表达式的活跃连接，常用于切换节点后让指定应用重新建立连接，
+而不影响其他会话。表达式语法与 mihosh connections --filter 相同。
+
+--dry-run 只列出将被关闭的连接，不实际关闭。`,
+	Example: `  mihosh connections close --filter "process:chrome" --dry-run
+  mihosh connections close --filter "chain:Proxy"
+  mihosh connections close --filter "host:youtube OR host:googlevideo" --output json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := parseOutputFormat(connectionsOutput)
+		if err != nil {
+			return wrapParameterError(err)
+		}
+		query, err := service.ParseConnQuery(connectionsFilter)
+		if err != nil {
+			return wrapParameterError(fmt.Errorf("过滤表达式无效: %w", err))
+		}
+		if query.Empty() {
+			return wrapParameterError(fmt.Errorf("请通过 --filter 指定要关闭的连接"))
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return wrapConfigError(fmt.Errorf("加载配置失败: %w", err))
+		}
+
+		geoCache, err := newQueryGeoCache(cfg, query)
+		if err != nil {
+			return err
+		}
+		defer saveGeoCache(cfg, geoCache)
+
+		connSvc := service.NewConnectionService(api.NewClient(cfg))
+		connSvc.SetGeoCache(geoCache)
+		result, err := connSvc.CloseMatching(query, connectionsDryRun)
+		if err != nil {
+			return wrapNetworkError(err)
+		}
+
+		if err := renderCloseResult(os.Stdout, result, connectionsDryRun, format); err != nil {
+			return fmt.Errorf("渲染输出失败: %w", err)
+		}
+		if len(result.Failed) > 0 {
+			return wrapNetworkError(fmt.Errorf("%d 个连接关闭失败", len(result.Failed)))
+		}
+		return nil
+	},
+}
+
+var connectionsExportCmd = &cobra.Command{
+	Use:   "export [--format csv|jsonl] [--filter <表达式>] [--include-closed [--duration 30s]] [--file <路径>]",
+	Short: "导出连接记录为 CSV 或 JSON Lines",
+	Long: `导出连接记录，包含代理链（策略组 > 出口节点）、规则、进程路径、起止时间与时长、流量等字段。
+表达式语法与 mihosh connections --filter 相同。
+
+默认导出当前活跃连接的快照。mihomo 不保存已关闭的连接，--include-closed 会订阅
+连接推送 --duration 时长（Ctrl+C 提前结束），导出结束时仍活跃的连接与期间关闭的连接。
+
+不指定 --file 时写到标准输出。TUI 连接页按 e / E 可将当前过滤后的视图导出为 CSV / JSON Lines。`,
+	Example: `  mihosh connections export > conns.csv
+  mihosh connections export --format jsonl --filter "process:chrome" --file chrome.jsonl
+  mihosh connections export --include-closed --duration 5m --file session.csv`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := service.ParseConnExportFormat(exportFormat)
+		if err != nil {
+			return wrapParameterError(err)
+		}
+		query, err := service.ParseConnQuery(connectionsFilter)
+		if err != nil {
+			return wrapParameterError(fmt.Errorf("过滤表达式无效: %w", err))
+		}
+		if exportIncludeClosed && exportDuration <= 0 {
+			return wrapParameterError(fmt.Errorf("--duration 必须大于 0"))
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return wrapConfigError(fmt.Errorf("加载配置失败: %w", err))
+		}
+
+		geoCache, err := newQueryGeoCache(cfg, query)
+		if err != nil {
+			return err
+		}
+		defer saveGeoCache(cfg, geoCache)
+
+		var records []service.ConnExportRecord
+		if exportIncludeClosed {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			ctx, cancel := context.WithTimeout(ctx, exportDuration)
+			defer cancel()
+
+			updates := make(chan api.ConnectionsData, 16)
+			wsClient := api.NewWSClient(cfg.APIAddress, cfg.Secret)
+			wsClient.SetConnectionsHandler(func(data api.ConnectionsData) {
+				data.Connections = annotateConnGeo(ctx, geoCache, data.Connections)
+				select {
+				case updates <- data:
+				case <-ctx.Done():
+				}
+			})
+			wsClient.Start()
+			defer wsClient.Stop()
+
+			fmt.Fprintf(os.Stderr, "正在记录连接 %s，Ctrl+C 提前结束\n", exportDuration)
+			recorder := recordConnections(ctx, updates)
+			fmt.Fprintf(os.Stderr, "记录期间关闭了 %d 个连接\n", recorder.ClosedCount())
+			records = recorder.Records(query, time.Now())
+		} else {
+			conns, err := service.NewConnectionService(api.NewClient(cfg)).GetConnections()
+			if err != nil {
+				return wrapNetworkError(fmt.Errorf("获取连接失败: %w", err))
+			}
+			recorder := service.NewConnRecorder()
+			recorder.Observe(annotateConnGeo(context.Background(), geoCache, conns.Connections), time.Now())
+			records = recorder.Records(query, time.Now())
+		}
+
+		w := io.Writer(os.Stdout)
+		if exportFile != "" {
+			f, err := os.Create(exportFile)
+			if err != nil {
+				return fmt.Errorf("创建导出文件失败: %w", err)
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := service.WriteConnExport(w, records, format); err != nil {
+			return fmt.Errorf("写入导出数据失败: %w", err)
+		}
+		if exportFile != "" {
+			fmt.Fprintf(os.Stderr, "已导出 %d 条连接到 %s\n", len(records), exportFile)
+		}
+		return nil
+	},
+}
+
+var connectionsProcessesCmd = &cobra.Command{
+	Use:   "processes [--filter <表达式>] [--pid <PID>] [--output json|table|plain]",
+	Short: "按进程聚合连接，显示 PID、用户、systemd 单元与容器",
+	Long: `按进程聚合当前连接，统计连接数与流量。mihosh 与 mihomo 运行在同一台 Linux 机器上时，
+会通过 /proc 按连接的源端口找到所属进程，补充 PID、所属用户、systemd 单元、cgroup 与容器 ID；
+无权限读取其他用户的进程时只显示用户。
+
+--pid 列出该进程的全部连接（输出格式同 mihosh connections）。`,
+	Example: `  mihosh connections processes
+  mihosh connections processes --output table --filter "chain:DIRECT"
+  mihosh connections processes --pid 1234`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := parseOutputFormat(connectionsOutput)
+		if err != nil {
+			return wrapParameterError(err)
+		}
+		query, err := service.ParseConnQuery(connectionsFilter)
+		if err != nil {
+			return wrapParameterError(fmt.Errorf("过滤表达式无效: %w", err))
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return wrapConfigError(fmt.Errorf("加载配置失败: %w", err))
+		}
+		conns, err := service.NewConnectionService(api.NewClient(cfg)).GetConnections()
+		if err != nil {
+			return wrapNetworkError(fmt.Errorf("获取连接失败: %w", err))
+		}
+		geoCache, err := newQueryGeoCache(cfg, query)
+		if err != nil {
+			return err
+		}
+		defer saveGeoCache(cfg, geoCache)
+		filtered := service.FilterConnections(annotateConnGeo(context.Background(), geoCache, conns.Connections), query)
+		resolver := service.NewIPResolver()
+
+		if processesPID > 0 {
+			var matched []model.Connection
+			for _, c := range filtered {
+				if info := resolver.ResolveProcess(c); info != nil && info.PID == processesPID {
+					matched = append(matched, c)
+				}
+			}
+			conns.Connections = matched
+			if err := renderConnections(os.Stdout, *conns, format); err != nil {
+				return fmt.Errorf("渲染输出失败: %w", err)
+			}
+			return nil
+		}
+
+		if err := renderProcessGroups(os.Stdout, resolver.GroupProcesses(filtered), format); err != nil {
+			return fmt.Errorf("渲染输出失败: %w", err)
+		}
+		return nil
+	},
+}
+
+type processOutputItem struct {
+	Process     string `json:"process"`
+	PIDs        []int  `json:"pids,omitempty"`
+	Path        string `json:"path,omitempty"`
+	User        string `json:"user,omitempty"`
+	UID         *int   `json:"uid,omitempty"`
+	Unit        string `json:"unit,omitempty"`
+	Cgroup      string `json:"cgroup,omitempty"`
+	ContainerID string `json:"container_id,omitempty"`
+	Connections int    `json:"connections"`
+	Upload      int64  `json:"upload"`
+	Download    int64  `json:"download"`
+}
+
+func renderProcessGroups(w io.Writer, groups []service.ProcessGroup, format outputFormat) error {
+	switch format {
+	case outputFormatJSON:
+		items := make([]processOutputItem, 0, len(groups))
+		for _, g := range groups {
+			item := processOutputItem{Process: g.Key, PIDs: g.PIDs, Connections: g.Count(), Upload: g.Upload, Download: g.Download}
+			if info := g.Info; info != nil {
+				uid := info.UID
+				item.Path, item.User, item.UID = info.Path, info.User, &uid
+				item.Unit, item.Cgroup, item.ContainerID = info.Unit, info.Cgroup, info.ContainerID
+			}
+			items = append(items, item)
+		}
+		return writeJSON(w, items)
+	case outputFormatTable:
+		tw := newTabWriter(w)
+		fmt.Fprintln(tw, "PROCESS\tPID\tUSER\tUNIT\tCONTAINER\tCONNS\tUPLOAD\tDOWNLOAD")
+		for _, g := range groups {
+			pid, user, unit, container := "-", "-", "-", "-"
+			if len(g.PIDs) > 0 {
+				pid = joinInts(g.PIDs)
+			}
+			if info := g.Info; info != nil {
+				user = info.User
+				if user == "" {
+					user = strconv.Itoa(info.UID)
+				}
+				unit = valueOrDash(info.Unit)
+				container = valueOrDash(service.ShortContainerID(info.ContainerID))
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+				g.Key, pid, user, unit, container, g.Count(), utils.FormatBytes(g.Upload), utils.FormatBytes(g.Download))
+		}
+		return tw.Flush()
+	case outputFormatPlain:
+		if len(groups) == 0 {
+			fmt.Fprintln(w, "没有满足条件的连接")
+			return nil
+		}
+		for _, g := range groups {
+			fmt.Fprintf(w, "  %s  %d 个连接  ↑%s ↓%s", g.Key, g.Count(), utils.FormatBytes(g.Upload), utils.FormatBytes(g.Download))
+			if detail := g.Detail(); detail != "" {
+				fmt.Fprintf(w, "  (%s)", detail)
+			}
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, "\n使用 --pid <PID> 或 mihosh connections --filter \"process:<名称>\" 查看进程的连接")
+		return nil
+	default:
+		return fmt.Errorf("不支持的输出格式: %s", format)
+	}
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ",")
+}
+
+// runConnectionsWatch 逐个处理连接快照并输出事件，ticks 触发汇总，直到 ctx 结束或推送通道关闭
+func runConnectionsWatch(ctx context.Context, watcher *service.ConnWatcher, updates <-chan api.ConnectionsData, ticks <-chan time.Time, w io.Writer, format outputFormat) error {
+	emit := func(event service.ConnEvent) error {
+		if format == outputFormatJSON {
+			if err := writeNDJSON(w, event); err != nil {
+				return fmt.Errorf("渲染输出失败: %w", err)
+			}
+			return nil
+		}
+		fmt.Fprintln(w, event.String())
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case data, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			for _, event := range watcher.Observe(data, time.Now()) {
+				if err := emit(event); err != nil {
+					return err
+				}
+			}
+		case now := <-ticks:
+			if err := emit(watcher.Summary(now)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// recordConnections 持续记录连接快照直到 ctx 结束或推送通道关闭
+func recordConnections(ctx context.Context, updates <-chan api.ConnectionsData) *service.ConnRecorder {
+	recorder := service.NewConnRecorder()
+	for {
+		select {
+		case <-ctx.Done():
+			return recorder
+		case data, ok := <-updates:
+			if !ok {
+				return recorder
+			}
+			recorder.Observe(data.Connections, time.Now())
+		}
+	}
+}
+
 func init() {
-	connectionsCmd.Flags().StringVar(&connectionsOutput, "output", string(outputFormatPlain), "输出格式: json|table|plain")
+	for _, cmd := range []*cobra.Command{connectionsCmd, connectionsCloseCmd, connectionsProcessesCmd} {
+		cmd.Flags().StringVar(&connectionsOutput, "output", string(outputFormatPlain), "输出格式: json|table|plain")
+		cmd.Flags().StringVar(&connectionsFilter, "filter", "", "连接过滤表达式（如 \"net:udp dport:443\"）")
+	}
+	connectionsCmd.Flags().BoolVar(&connectionsWatch, "watch", false, "持续输出新建/关闭连接事件与周期汇总")
+	connectionsCmd.Flags().DurationVar(&connectionsWatchInterval, "interval", 10*time.Second, "--watch 时输出汇总的间隔（0 为不输出）")
+	connectionsCloseCmd.Flags().BoolVar(&connectionsDryRun, "dry-run", false, "只列出将被关闭的连接，不实际关闭")
+	connectionsExportCmd.Flags().StringVar(&connectionsFilter, "filter", "", "连接过滤表达式（如 \"net:udp dport:443\"）")
+	connectionsExportCmd.Flags().StringVar(&exportFormat, "format", string(service.ConnExportCSV), "导出格式: csv|jsonl")
+	connectionsExportCmd.Flags().StringVar(&exportFile, "file", "", "写入的文件路径（默认标准输出）")
+	connectionsExportCmd.Flags().BoolVar(&exportIncludeClosed, "include-closed", false, "订阅连接推送一段时间，同时导出期间关闭的连接")
+	connectionsExportCmd.Flags().DurationVar(&exportDuration, "duration", 30*time.Second, "--include-closed 时的记录时长")
+	connectionsProcessesCmd.Flags().IntVar(&processesPID, "pid", 0, "列出该 PID 的全部连接")
+	connectionsCmd.AddCommand(connectionsCloseCmd, connectionsExportCmd, connectionsProcessesCmd)
 }
 
 func renderConnections(w io.Writer, conns model.ConnectionsResponse, format outputFormat) error {
@@ -72,33 +491,19 @@ func renderConnections(w io.Writer, conns model.ConnectionsResponse, format outp
 }
 
 type connectionOutputItem struct {
+	ID          string `json:"id"`
 	Source      string `json:"source"`
 	Destination string `json:"destination"`
 	SourceIP    string `json:"source_ip"`
 	SourcePort  string `json:"source_port"`
 	DestIP      string `json:"destination_ip"`
 	DestPort    string `json:"destination_port"`
+	Host        string `json:"host,omitempty"`
+	Process     string `json:"process,omitempty"`
 	Chain       string `json:"chain"`
 }
 
 func renderConnectionsJSON(w io.Writer, conns model.ConnectionsResponse) error {
-	items := make([]connectionOutputItem, 0, len(conns.Connections))
-	for _, conn := range conns.Connections {
-		chain := "DIRECT"
-		if len(conn.Chains) > 0 {
-			chain = conn.Chains[len(conn.Chains)-1]
-		}
-		items = append(items, connectionOutputItem{
-			Source:      fmt.Sprintf("%s:%s", conn.Metadata.SourceIP, conn.Metadata.SourcePort),
-			Destination: fmt.Sprintf("%s:%s", conn.Metadata.DestinationIP, conn.Metadata.DestinationPort),
-			SourceIP:    conn.Metadata.SourceIP,
-			SourcePort:  conn.Metadata.SourcePort,
-			DestIP:      conn.Metadata.DestinationIP,
-			DestPort:    conn.Metadata.DestinationPort,
-			Chain:       chain,
-		})
-	}
-
 	payload := struct {
 		ActiveConnections int                    `json:"active_connections"`
 		UploadTotal       int64                  `json:"upload_total"`
@@ -108,12 +513,31 @@ func renderConnectionsJSON(w io.Writer, conns model.ConnectionsResponse) error {
 		ActiveConnections: len(conns.Connections),
 		UploadTotal:       conns.UploadTotal,
 		DownloadTotal:     conns.DownloadTotal,
-		Connections:       items,
+		Connections:       connectionOutputItems(conns.Connections),
 	}
 
 	return writeJSON(w, payload)
 }
 
+func connectionOutputItems(conns []model.Connection) []connectionOutputItem {
+	items := make([]connectionOutputItem, 0, len(conns))
+	for _, conn := range conns {
+		items = append(items, connectionOutputItem{
+			ID:          conn.ID,
+			Source:      fmt.Sprintf("%s:%s", conn.Metadata.SourceIP, conn.Metadata.SourcePort),
+			Destination: fmt.Sprintf("%s:%s", conn.Metadata.DestinationIP, conn.Metadata.DestinationPort),
+			SourceIP:    conn.Metadata.SourceIP,
+			SourcePort:  conn.Metadata.SourcePort,
+			DestIP:      conn.Metadata.DestinationIP,
+			DestPort:    conn.Metadata.DestinationPort,
+			Host:        conn.Metadata.Host,
+			Process:     conn.Metadata.Process,
+			Chain:       service.ConnectionChain(conn),
+		})
+	}
+	return items
+}
+
 func renderConnectionsTable(w io.Writer, conns model.ConnectionsResponse) error {
 	fmt.Fprintf(w, "ACTIVE_CONNECTIONS: %d\n", len(conns.Connections))
 	fmt.Fprintf(w, "UPLOAD_TOTAL: %s\n", utils.FormatBytes(conns.UploadTotal))
@@ -158,3 +582,77 @@ func renderConnectionsPlain(w io.Writer, conns model.ConnectionsResponse) {
 		)
 	}
 }
+
+func renderCloseResult(w io.Writer, result service.CloseResult, dryRun bool, format outputFormat) error {
+	switch format {
+	case outputFormatJSON:
+		return writeJSON(w, struct {
+			DryRun      bool                   `json:"dry_run"`
+			Matched     int                    `json:"matched"`
+			Closed      int                    `json:"closed"`
+			Failed      map[string]string      `json:"failed,omitempty"`
+			Connections []connectionOutputItem `json:"connections"`
+		}{
+			DryRun:      dryRun,
+			Matched:     len(result.Matched),
+			Closed:      result.Closed,
+			Failed:      result.Failed,
+			Connections: connectionOutputItems(result.Matched),
+		})
+	case outputFormatTable:
+		tw := newTabWriter(w)
+		fmt.Fprintln(tw, "ID\tSOURCE\tDESTINATION\tHOST\tPROCESS\tCHAIN\tSTATUS")
+		for _, conn := range result.Matched {
+			fmt.Fprintf(tw, "%s\t%s:%s\t%s:%s\t%s\t%s\t%s\t%s\n",
+				conn.ID,
+				conn.Metadata.SourceIP, conn.Metadata.SourcePort,
+				conn.Metadata.DestinationIP, conn.Metadata.DestinationPort,
+				valueOrDash(conn.Metadata.Host), valueOrDash(conn.Metadata.Process),
+				service.ConnectionChain(conn), closeStatus(result, conn.ID, dryRun),
+			)
+		}
+		return tw.Flush()
+	case outputFormatPlain:
+		if len(result.Matched) == 0 {
+			fmt.Fprintln(w, "没有满足条件的连接")
+			return nil
+		}
+		for _, conn := range result.Matched {
+			mark := "✓"
+			if dryRun {
+				mark = "·"
+			} else if _, failed := result.Failed[conn.ID]; failed {
+				mark = "✗"
+			}
+			target := conn.Metadata.Host
+			if target == "" {
+				target = conn.Metadata.DestinationIP
+			}
+			fmt.Fprintf(w, "  %s %s:%s [%s] %s",
+				mark, target, conn.Metadata.DestinationPort, service.ConnectionChain(conn), valueOrDash(conn.Metadata.Process))
+			if errMsg, failed := result.Failed[conn.ID]; failed {
+				fmt.Fprintf(w, "（%s）", errMsg)
+			}
+			fmt.Fprintln(w)
+		}
+		if dryRun {
+			fmt.Fprintf(w, "将关闭 %d 个连接（dry-run，未实际关闭）\n", len(result.Matched))
+		} else {
+			fmt.Fprintf(w, "已关闭 %d/%d 个连接\n", result.Closed, len(result.Matched))
+		}
+		return nil
+	default:
+		return fmt.Errorf("不支持的输出格式: %s", format)
+	}
+}
+
+// closeStatus 单个连接的关闭状态
+func closeStatus(result service.CloseResult, id string, dryRun bool) string {
+	if dryRun {
+		return "dry-run"
+	}
+	if _, failed := result.Failed[id]; failed {
+		return "failed"
+	}
+	return "closed"
+}
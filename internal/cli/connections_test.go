@@ -2,10 +2,15 @@ package cli
 
 import (
 	"bytes"
+	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/aimony/mihosh/internal/app/service"
 	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/infrastructure/api"
 )
 
 func TestRenderConnections(t *testing.T) {
@@ -63,3 +68,91 @@ func TestRenderConnections(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderCloseResult(t *testing.T) {
+	result := service.CloseResult{
+		Matched: []model.Connection{
+			{ID: "a", Chains: []string{"HK", "Proxy"}, Metadata: model.Metadata{Host: "www.google.com", DestinationPort: "443", Process: "chrome"}},
+			{ID: "b", Metadata: model.Metadata{DestinationIP: "8.8.8.8", DestinationPort: "53"}},
+		},
+		Closed: 1,
+		Failed: map[string]string{"b": "boom"},
+	}
+
+	var out bytes.Buffer
+	assert.NoError(t, renderCloseResult(&out, result, false, outputFormatPlain))
+	assert.Contains(t, out.String(), "✓ www.google.com:443 [Proxy] chrome")
+	assert.Contains(t, out.String(), "✗ 8.8.8.8:53 [DIRECT] -（boom）")
+	assert.Contains(t, out.String(), "已关闭 1/2 个连接")
+
+	out.Reset()
+	assert.NoError(t, renderCloseResult(&out, result, true, outputFormatPlain))
+	assert.Contains(t, out.String(), "将关闭 2 个连接")
+
+	out.Reset()
+	assert.NoError(t, renderCloseResult(&out, result, false, outputFormatJSON))
+	assert.Contains(t, out.String(), `"matched": 2`)
+	assert.Contains(t, out.String(), `"b": "boom"`)
+
+	out.Reset()
+	assert.NoError(t, renderCloseResult(&out, result, false, outputFormatTable))
+	assert.Contains(t, out.String(), "failed")
+}
+
+func TestRecordConnections(t *testing.T) {
+	updates := make(chan api.ConnectionsData, 2)
+	updates <- api.ConnectionsData{Connections: []model.Connection{{ID: "a"}, {ID: "b"}}}
+	updates <- api.ConnectionsData{Connections: []model.Connection{{ID: "b"}}}
+	close(updates)
+
+	recorder := recordConnections(context.Background(), updates)
+	assert.Equal(t, 1, recorder.ClosedCount())
+	assert.Len(t, recorder.Records(service.ConnQuery{}, time.Now()), 2)
+}
+
+func TestRunConnectionsWatch(t *testing.T) {
+	updates := make(chan api.ConnectionsData, 3)
+	updates <- api.ConnectionsData{Connections: []model.Connection{{ID: "a", Metadata: model.Metadata{Network: "tcp"}}}}
+	updates <- api.ConnectionsData{Connections: []model.Connection{{ID: "b", Metadata: model.Metadata{Network: "tcp"}}, {ID: "u", Metadata: model.Metadata{Network: "udp"}}}}
+	close(updates)
+
+	q, err := service.ParseConnQuery("net:tcp")
+	assert.NoError(t, err)
+	var buf bytes.Buffer
+	assert.NoError(t, runConnectionsWatch(context.Background(), service.NewConnWatcher(q), updates, nil, &buf, outputFormatJSON))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.Contains(t, lines[0], `"event":"open"`)
+		assert.Contains(t, lines[0], `"id":"b"`)
+		assert.Contains(t, lines[1], `"event":"close"`)
+		assert.Contains(t, lines[1], `"id":"a"`)
+	}
+}
+
+func TestRenderProcessGroups(t *testing.T) {
+	groups := []service.ProcessGroup{
+		{
+			ConnGroup: service.ConnGroup{Key: "curl", Connections: make([]model.Connection, 2), Download: 2048},
+			Info:      &model.ProcessInfo{PID: 42, UID: 1000, User: "alice", Unit: "app.scope", ContainerID: "0123456789abcdef0123"},
+			PIDs:      []int{42, 43},
+		},
+		{ConnGroup: service.ConnGroup{Key: "remote", Connections: make([]model.Connection, 1)}},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, renderProcessGroups(&buf, groups, outputFormatTable))
+	assert.Contains(t, buf.String(), "42,43")
+	assert.Contains(t, buf.String(), "0123456789ab ")
+	assert.Contains(t, buf.String(), "remote")
+
+	buf.Reset()
+	assert.NoError(t, renderProcessGroups(&buf, groups, outputFormatJSON))
+	assert.Contains(t, buf.String(), `"user": "alice"`)
+	assert.Contains(t, buf.String(), `"connections": 2`)
+
+	buf.Reset()
+	assert.NoError(t, renderProcessGroups(&buf, groups, outputFormatPlain))
+	assert.Contains(t, buf.String(), "curl  2 个连接")
+	assert.Contains(t, buf.String(), "pid 42 +1 · alice · app.scope")
+}
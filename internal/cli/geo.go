@@ -0,0 +1,255 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	geoOutput   string
+	geoProvider string
+	geoMMDB     []string
+)
+
+var geoCmd = &cobra.Command{
+	Use:   "geo <ip|host>... [--provider auto|mmdb|http] [--output json|table|plain]",
+	Short: "查询 IP 的地理位置与 ASN",
+	Long: `查询 IP 的国家/地区、城市与 ASN，主机名会先解析为 IP。
+
+默认（auto）优先使用本地 MMDB 文件离线查询：geo_mmdb_files 中配置的文件，
+或 mihomo 配置目录中的 Country.mmdb、GeoLite2-ASN.mmdb、GeoLite2-City.mmdb 等；
+本地未收录时回退到在线接口（geo_http_url，默认 ip.sb）。--provider mmdb 完全离线。`,
+	Example: `  mihosh geo 1.1.1.1 8.8.8.8
+  mihosh geo example.com --provider mmdb --output table
+  mihosh geo 1.1.1.1 --mmdb ~/.config/mihomo/GeoLite2-ASN.mmdb`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := parseOutputFormat(geoOutput)
+		if err != nil {
+			return wrapParameterError(err)
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return wrapConfigError(fmt.Errorf("加载配置失败: %w", err))
+		}
+		geoCfg := *cfg
+		if geoProvider != "" {
+			geoCfg.GeoProvider = geoProvider
+		}
+		if len(geoMMDB) > 0 {
+			geoCfg.GeoMMDBFiles = geoMMDB
+		}
+		cache, err := newGeoCache(&geoCfg)
+		if err != nil {
+			return wrapConfigError(err)
+		}
+		defer saveGeoCache(&geoCfg, cache)
+
+		results := lookupGeo(cmd.Context(), cache, args)
+		if err := renderGeoResults(os.Stdout, results, format); err != nil {
+			return fmt.Errorf("渲染输出失败: %w", err)
+		}
+		for _, r := range results {
+			if r.Error != "" {
+				return errors.New("部分查询失败")
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	geoCmd.Flags().StringVar(&geoOutput, "output", string(outputFormatPlain), "输出格式: json|table|plain")
+	geoCmd.Flags().StringVar(&geoProvider, "provider", "", "数据源: auto|mmdb|http（默认读取配置 geo_provider）")
+	geoCmd.Flags().StringSliceVar(&geoMMDB, "mmdb", nil, "MMDB 文件路径，可重复指定（覆盖配置 geo_mmdb_files）")
+}
+
+// newGeoCache 按配置创建带缓存的地理位置查询，geo_cache_persist 开启时载入持久化缓存
+func newGeoCache(cfg *config.Config) (*service.GeoCache, error) {
+	provider, err := service.NewGeoProviderFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	cache := service.NewGeoCache(provider, 0, 0)
+	if cfg.GeoCachePersist {
+		if path, err := service.DefaultGeoCachePath(); err == nil {
+			_ = cache.Load(path)
+		}
+	}
+	return cache, nil
+}
+
+// saveGeoCache geo_cache_persist 开启时保存缓存
+func saveGeoCache(cfg *config.Config, cache *service.GeoCache) {
+	if cache == nil || !cfg.GeoCachePersist {
+		return
+	}
+	if path, err := service.DefaultGeoCachePath(); err == nil {
+		_ = cache.Save(path)
+	}
+}
+
+// newQueryGeoCache 过滤表达式含 country:/asn: 时创建地理位置查询，否则返回 nil
+func newQueryGeoCache(cfg *config.Config, q service.ConnQuery) (*service.GeoCache, error) {
+	if !q.UsesGeo() {
+		return nil, nil
+	}
+	cache, err := newGeoCache(cfg)
+	if err != nil {
+		return nil, wrapConfigError(fmt.Errorf("country:/asn: 过滤需要地理位置数据源: %w", err))
+	}
+	return cache, nil
+}
+
+// annotateConnGeo 补全连接的国家与 ASN，cache 为 nil 时原样返回
+func annotateConnGeo(ctx context.Context, cache *service.GeoCache, conns []model.Connection) []model.Connection {
+	if cache == nil {
+		return conns
+	}
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	return service.AnnotateGeo(ctx, cache, conns)
+}
+
+type geoOutputItem struct {
+	Query       string  `json:"query"`
+	IP          string  `json:"ip,omitempty"`
+	CountryCode string  `json:"country_code,omitempty"`
+	Country     string  `json:"country,omitempty"`
+	Region      string  `json:"region,omitempty"`
+	City        string  `json:"city,omitempty"`
+	ASN         int     `json:"asn,omitempty"`
+	ASNOrg      string  `json:"asn_organization,omitempty"`
+	Latitude    float64 `json:"latitude,omitempty"`
+	Longitude   float64 `json:"longitude,omitempty"`
+	Timezone    string  `json:"timezone,omitempty"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// lookupGeo 逐个查询参数，主机名先解析为第一个 IP
+func lookupGeo(ctx context.Context, provider service.GeoProvider, args []string) []geoOutputItem {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	items := make([]geoOutputItem, 0, len(args))
+	for _, arg := range args {
+		item := geoOutputItem{Query: arg}
+		ip := arg
+		if net.ParseIP(arg) == nil {
+			ips, err := net.DefaultResolver.LookupIP(ctx, "ip", arg)
+			if err != nil || len(ips) == 0 {
+				item.Error = fmt.Sprintf("解析主机名失败: %v", err)
+				items = append(items, item)
+				continue
+			}
+			ip = ips[0].String()
+		}
+		item.IP = ip
+
+		lookupCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		info, err := provider.Lookup(lookupCtx, ip)
+		cancel()
+		if err != nil {
+			item.Error = err.Error()
+		} else {
+			fillGeoItem(&item, info)
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func fillGeoItem(item *geoOutputItem, info *model.IPInfo) {
+	item.CountryCode = info.CountryCode
+	item.Country = info.Country
+	item.Region = info.RegionName
+	item.City = info.City
+	item.ASN = info.ASN
+	item.ASNOrg = info.ASNOrganization
+	if item.ASNOrg == "" {
+		item.ASNOrg = info.Organization
+	}
+	item.Latitude = info.Latitude
+	item.Longitude = info.Longitude
+	item.Timezone = info.Timezone
+}
+
+func renderGeoResults(w io.Writer, items []geoOutputItem, format outputFormat) error {
+	switch format {
+	case outputFormatJSON:
+		return writeJSON(w, items)
+	case outputFormatTable:
+		tw := newTabWriter(w)
+		fmt.Fprintln(tw, "QUERY\tIP\tCOUNTRY\tCITY\tASN\tORG")
+		for _, item := range items {
+			if item.Error != "" {
+				fmt.Fprintf(tw, "%s\t%s\t-\t-\t-\t%s\n", item.Query, valueOrDash(item.IP), item.Error)
+				continue
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", item.Query, item.IP, valueOrDash(geoCountry(item)),
+				valueOrDash(item.City), valueOrDash(formatGeoASN(item.ASN)), valueOrDash(item.ASNOrg))
+		}
+		return tw.Flush()
+	case outputFormatPlain:
+		for _, item := range items {
+			if item.Error != "" {
+				fmt.Fprintf(w, "%s: %s\n", item.Query, item.Error)
+				continue
+			}
+			parts := []string{geoCountry(item)}
+			if loc := strings.Join(nonEmpty(item.Region, item.City), ", "); loc != "" {
+				parts = append(parts, loc)
+			}
+			if item.ASN > 0 {
+				parts = append(parts, strings.TrimSpace(formatGeoASN(item.ASN)+" "+item.ASNOrg))
+			}
+			label := item.IP
+			if item.Query != item.IP {
+				label = fmt.Sprintf("%s (%s)", item.Query, item.IP)
+			}
+			fmt.Fprintf(w, "%s: %s\n", label, strings.Join(nonEmpty(parts...), " | "))
+		}
+		return nil
+	default:
+		return fmt.Errorf("不支持的输出格式: %s", format)
+	}
+}
+
+func geoCountry(item geoOutputItem) string {
+	switch {
+	case item.Country != "" && item.CountryCode != "":
+		return fmt.Sprintf("%s (%s)", item.Country, item.CountryCode)
+	case item.Country != "":
+		return item.Country
+	}
+	return item.CountryCode
+}
+
+func formatGeoASN(asn int) string {
+	if asn <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("AS%d", asn)
+}
+
+func nonEmpty(values ...string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeGeoProvider map[string]*model.IPInfo
+
+func (p fakeGeoProvider) Name() string { return "fake" }
+
+func (p fakeGeoProvider) Lookup(_ context.Context, ip string) (*model.IPInfo, error) {
+	if info, ok := p[ip]; ok {
+		return info, nil
+	}
+	return nil, service.ErrGeoNotFound
+}
+
+func TestLookupAndRenderGeo(t *testing.T) {
+	provider := fakeGeoProvider{
+		"1.1.1.1": {IP: "1.1.1.1", Country: "Australia", CountryCode: "AU", City: "Sydney", ASN: 13335, ASNOrganization: "CLOUDFLARENET"},
+		"::1":     {IP: "::1", CountryCode: "ZZ"},
+	}
+	items := lookupGeo(context.Background(), provider, []string{"1.1.1.1", "9.9.9.9", "::1"})
+	require.Len(t, items, 3)
+	assert.Equal(t, "AU", items[0].CountryCode)
+	assert.Equal(t, 13335, items[0].ASN)
+	assert.Contains(t, items[1].Error, "未收录")
+
+	var buf bytes.Buffer
+	require.NoError(t, renderGeoResults(&buf, items, outputFormatPlain))
+	assert.Equal(t, "1.1.1.1: Australia (AU) | Sydney | AS13335 CLOUDFLARENET\n9.9.9.9: 未收录该 IP\n::1: ZZ\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, renderGeoResults(&buf, items, outputFormatTable))
+	assert.Contains(t, buf.String(), "QUERY")
+	assert.Contains(t, buf.String(), "AS13335")
+
+	buf.Reset()
+	require.NoError(t, renderGeoResults(&buf, items, outputFormatJSON))
+	var decoded []map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, "CLOUDFLARENET", decoded[0]["asn_organization"])
+	assert.NotContains(t, decoded[0], "error")
+}
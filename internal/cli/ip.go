@@ -0,0 +1,287 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/infrastructure/api"
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ipOutput  string
+	ipVia     string
+	ipDirect  bool
+	ipCompare bool
+)
+
+var ipCmd = &cobra.Command{
+	Use:   "ip [--via <节点>|--direct] [--compare] [--output json|table|plain]",
+	Short: "查看出口 IP、国家/地区、ASN 与运营商",
+	Long: `通过 mixed 端口（proxy_address）访问 IP 查询接口，显示代理出口 IP、国家/地区、ASN 与运营商。
+
+  --direct   不经过代理，显示本机直连出口
+  --via      临时把 GLOBAL 切到指定节点并切换到 global 模式，检测后恢复原选择与模式
+             （检测期间所有流量都经由该节点）
+  --compare  同时检测直连出口（不经过 HTTP_PROXY 等环境变量）与代理使用的 DNS 解析器，需要配置 proxy_address：
+             代理出口与直连相同视为路由泄漏，DNS 解析器与直连同属一个 ASN 视为 DNS 泄漏，
+             发现泄漏时以非零状态退出`,
+	Example: `  mihosh ip
+  mihosh ip --direct
+  mihosh ip --via HK --output table
+  mihosh ip --compare --output json`,
+	Args: cobra.NoArgs,
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := parseOutputFormat(ipOutput)
+		if err != nil {
+			return wrapParameterError(err)
+		}
+		if ipDirect && (ipVia != "" || ipCompare) {
+			return wrapParameterError(errors.New("--direct 不能与 --via、--compare 同时使用"))
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return wrapConfigError(fmt.Errorf("加载配置失败: %w", err))
+		}
+		// 未配置代理地址时"代理出口"就是直连出口，对比没有意义
+		if ipCompare && cfg.ProxyAddress == "" {
+			return wrapConfigError(errors.New("--compare 需要先配置 proxy_address"))
+		}
+
+		proxySvc := newTestProxyService(api.NewClient(cfg), cfg)
+		via := ipVia
+		if via != "" {
+			if via, err = resolveProxyArg(cfg, via, allProxiesLoader(proxySvc)); err != nil {
+				return err
+			}
+		}
+
+		// 捕获 Ctrl+C 与 SIGTERM，保证 --via 切换的节点与模式能被恢复
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		report, err := checkEgress(ctx, proxySvc, cfg.ProxyAddress, via, ipDirect, ipCompare)
+		if err != nil {
+			return wrapNetworkError(err)
+		}
+		if err := renderIPReport(os.Stdout, report, format); err != nil {
+			return fmt.Errorf("渲染输出失败: %w", err)
+		}
+		for _, item := range report.Results {
+			if item.Error != "" {
+				return wrapNetworkError(fmt.Errorf("%s出口检测失败: %s", ipRouteLabel(item), item.Error))
+			}
+		}
+		if len(report.Leaks) > 0 {
+			return fmt.Errorf("检测到 %d 处泄漏", len(report.Leaks))
+		}
+		return nil
+	},
+}
+
+func init() {
+	ipCmd.Flags().StringVar(&ipOutput, "output", string(outputFormatPlain), "输出格式: json|table|plain")
+	ipCmd.Flags().StringVar(&ipVia, "via", "", "临时经由指定节点检测（支持别名）")
+	ipCmd.Flags().BoolVar(&ipDirect, "direct", false, "检测直连出口")
+	ipCmd.Flags().BoolVar(&ipCompare, "compare", false, "与直连出口对比，检查路由与 DNS 泄漏")
+	_ = ipCmd.RegisterFlagCompletionFunc("via", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeProxyNames("")
+	})
+}
+
+// egressChecker 出口检测所需的代理服务能力
+type egressChecker interface {
+	GetIPInfoContext(ctx context.Context, proxyAddr string) (*model.IPInfo, error)
+	GetDirectIPInfo(ctx context.Context) (*model.IPInfo, error)
+	GetDNSResolver(ctx context.Context, proxyAddr string) (*service.DNSResolver, error)
+	WithNode(ctx context.Context, node string, fn func() error) error
+}
+
+type ipOutputItem struct {
+	Route       string               `json:"route"`
+	Node        string               `json:"node,omitempty"`
+	IP          string               `json:"ip,omitempty"`
+	CountryCode string               `json:"country_code,omitempty"`
+	Country     string               `json:"country,omitempty"`
+	City        string               `json:"city,omitempty"`
+	ASN         int                  `json:"asn,omitempty"`
+	ASNOrg      string               `json:"asn_organization,omitempty"`
+	ISP         string               `json:"isp,omitempty"`
+	Resolver    *service.DNSResolver `json:"dns_resolver,omitempty"`
+	Error       string               `json:"error,omitempty"`
+
+	info *model.IPInfo
+}
+
+type ipReport struct {
+	Results  []ipOutputItem       `json:"results"`
+	Compared bool                 `json:"compared"`
+	Leaks    []service.EgressLeak `json:"leaks"`
+}
+
+// checkEgress 检测代理（或直连）出口，compare 时附带直连出口与 DNS 解析器并判断泄漏。
+// ctx 取消时中止检测并返回 ctx 的错误
+func checkEgress(ctx context.Context, checker egressChecker, proxyAddr, via string, direct, compare bool) (ipReport, error) {
+	report := ipReport{Compared: compare, Leaks: []service.EgressLeak{}}
+	if direct {
+		report.Results = append(report.Results, lookupEgress(ctx, checker, "direct", ""))
+		return report, ctx.Err()
+	}
+
+	var proxied ipOutputItem
+	measure := func() error {
+		proxied = lookupEgress(ctx, checker, "proxy", proxyAddr)
+		if compare && proxied.Error == "" {
+			if resolver, err := checker.GetDNSResolver(ctx, proxyAddr); err == nil {
+				proxied.Resolver = resolver
+			}
+		}
+		return ctx.Err()
+	}
+	if via != "" {
+		if err := checker.WithNode(ctx, via, measure); err != nil {
+			return report, err
+		}
+		proxied.Node = via
+	} else if err := measure(); err != nil {
+		return report, err
+	}
+	report.Results = append(report.Results, proxied)
+
+	if compare {
+		directItem := lookupEgress(ctx, checker, "direct", "")
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		report.Results = append(report.Results, directItem)
+		if proxied.info != nil && directItem.info != nil {
+			report.Leaks = append(report.Leaks, service.CompareEgress(proxied.info, directItem.info, proxied.Resolver)...)
+		}
+	}
+	return report, nil
+}
+
+// lookupEgress 查询出口信息，route 为 direct 时不经过任何代理
+func lookupEgress(ctx context.Context, checker egressChecker, route, proxyAddr string) ipOutputItem {
+	item := ipOutputItem{Route: route}
+	var info *model.IPInfo
+	var err error
+	if route == "direct" {
+		info, err = checker.GetDirectIPInfo(ctx)
+	} else {
+		info, err = checker.GetIPInfoContext(ctx, proxyAddr)
+	}
+	if err != nil {
+		item.Error = err.Error()
+		return item
+	}
+	item.info = info
+	item.IP = info.IP
+	item.CountryCode = info.CountryCode
+	item.Country = info.Country
+	item.City = info.City
+	item.ASN = info.ASN
+	item.ASNOrg = info.ASNOrganization
+	if ispList := nonEmpty(info.ISP, info.Organization, info.ASNOrganization); len(ispList) > 0 {
+		item.ISP = ispList[0]
+	}
+	return item
+}
+
+func ipRouteLabel(item ipOutputItem) string {
+	if item.Route == "direct" {
+		return "直连"
+	}
+	if item.Node != "" {
+		return fmt.Sprintf("代理 (%s) ", item.Node)
+	}
+	return "代理"
+}
+
+func ipCountry(item ipOutputItem) string {
+	return geoCountry(geoOutputItem{Country: item.Country, CountryCode: item.CountryCode})
+}
+
+func renderIPReport(w io.Writer, report ipReport, format outputFormat) error {
+	switch format {
+	case outputFormatJSON:
+		return writeJSON(w, report)
+	case outputFormatTable:
+		tw := newTabWriter(w)
+		fmt.Fprintln(tw, "ROUTE\tNODE\tIP\tCOUNTRY\tCITY\tASN\tISP")
+		for _, item := range report.Results {
+			if item.Error != "" {
+				fmt.Fprintf(tw, "%s\t%s\t-\t-\t-\t-\t%s\n", item.Route, valueOrDash(item.Node), item.Error)
+				continue
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", item.Route, valueOrDash(item.Node), item.IP,
+				valueOrDash(ipCountry(item)), valueOrDash(item.City), valueOrDash(formatGeoASN(item.ASN)), valueOrDash(item.ISP))
+			if r := item.Resolver; r != nil {
+				fmt.Fprintf(tw, "dns\t%s\t%s\t%s\t-\t%s\t%s\n", valueOrDash(item.Node), r.IP,
+					valueOrDash(r.CountryCode), valueOrDash(formatGeoASN(r.ASN)), valueOrDash(strings.Join(nonEmpty(r.ASNOrg, r.Geo), " / ")))
+			}
+		}
+		if report.Compared {
+			fmt.Fprintln(tw)
+			fmt.Fprintln(tw, "LEAK\tDETAIL")
+			if len(report.Leaks) == 0 {
+				fmt.Fprintln(tw, "none\t-")
+			}
+			for _, leak := range report.Leaks {
+				fmt.Fprintf(tw, "%s\t%s\n", leak.Kind, leak.Detail)
+			}
+		}
+		return tw.Flush()
+	case outputFormatPlain:
+		for _, item := range report.Results {
+			label := ipRouteLabel(item) + "出口"
+			if item.Error != "" {
+				fmt.Fprintf(w, "%s: %s\n", label, item.Error)
+				continue
+			}
+			parts := []string{ipCountry(item), item.City}
+			if item.ASN > 0 {
+				parts = append(parts, strings.TrimSpace(formatGeoASN(item.ASN)+" "+item.ASNOrg))
+			}
+			if item.ISP != "" && item.ISP != item.ASNOrg {
+				parts = append(parts, item.ISP)
+			}
+			fmt.Fprintf(w, "%s: %s  %s\n", label, item.IP, strings.Join(nonEmpty(parts...), " | "))
+			if r := item.Resolver; r != nil {
+				desc := nonEmpty(r.Geo)
+				if r.ASN > 0 {
+					desc = append(desc, strings.TrimSpace(formatGeoASN(r.ASN)+" "+r.ASNOrg))
+				}
+				fmt.Fprintf(w, "DNS 解析器: %s  %s\n", r.IP, strings.Join(desc, " | "))
+			}
+		}
+		if report.Compared {
+			if len(report.Leaks) == 0 {
+				fmt.Fprintln(w, "✓ 未发现路由或 DNS 泄漏")
+			}
+			for _, leak := range report.Leaks {
+				kind := "路由泄漏"
+				if leak.Kind == service.LeakDNS {
+					kind = "DNS 泄漏"
+				}
+				fmt.Fprintf(w, "⚠ %s: %s\n", kind, leak.Detail)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("不支持的输出格式: %s", format)
+	}
+}
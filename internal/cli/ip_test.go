@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEgressChecker struct {
+	proxied  *model.IPInfo
+	direct   *model.IPInfo
+	resolver *service.DNSResolver
+	viaErr   error
+	via      []string
+	restored bool
+}
+
+func (f *fakeEgressChecker) GetIPInfoContext(_ context.Context, proxyAddr string) (*model.IPInfo, error) {
+	if f.proxied == nil {
+		return nil, errors.New("connection refused")
+	}
+	return f.proxied, nil
+}
+
+func (f *fakeEgressChecker) GetDirectIPInfo(context.Context) (*model.IPInfo, error) {
+	return f.direct, nil
+}
+
+func (f *fakeEgressChecker) GetDNSResolver(context.Context, string) (*service.DNSResolver, error) {
+	return f.resolver, nil
+}
+
+func (f *fakeEgressChecker) WithNode(_ context.Context, node string, fn func() error) error {
+	f.via = append(f.via, node)
+	if f.viaErr != nil {
+		return f.viaErr
+	}
+	err := fn()
+	f.restored = true
+	return err
+}
+
+func TestCheckEgressCompare(t *testing.T) {
+	checker := &fakeEgressChecker{
+		proxied:  &model.IPInfo{IP: "203.0.113.7", CountryCode: "JP", ASN: 2516, ASNOrganization: "KDDI", ISP: "KDDI Corporation"},
+		direct:   &model.IPInfo{IP: "198.51.100.2", CountryCode: "CN", ASN: 4134, ASNOrganization: "CHINANET"},
+		resolver: &service.DNSResolver{IP: "198.51.100.53", ASN: 4134, ASNOrg: "CHINANET"},
+	}
+	report, err := checkEgress(context.Background(), checker, "127.0.0.1:7890", "JP-1", false, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"JP-1"}, checker.via)
+	require.Len(t, report.Results, 2)
+	assert.Equal(t, "JP-1", report.Results[0].Node)
+	assert.Equal(t, "KDDI Corporation", report.Results[0].ISP)
+	require.Len(t, report.Leaks, 1)
+	assert.Equal(t, service.LeakDNS, report.Leaks[0].Kind)
+
+	var buf bytes.Buffer
+	require.NoError(t, renderIPReport(&buf, report, outputFormatPlain))
+	assert.Contains(t, buf.String(), "代理 (JP-1) 出口: 203.0.113.7  JP | AS2516 KDDI | KDDI Corporation")
+	assert.Contains(t, buf.String(), "DNS 解析器: 198.51.100.53  AS4134 CHINANET")
+	assert.Contains(t, buf.String(), "⚠ DNS 泄漏")
+
+	buf.Reset()
+	require.NoError(t, renderIPReport(&buf, report, outputFormatJSON))
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, true, decoded["compared"])
+	assert.Len(t, decoded["results"], 2)
+
+	buf.Reset()
+	require.NoError(t, renderIPReport(&buf, report, outputFormatTable))
+	assert.Contains(t, buf.String(), "ROUTE")
+	assert.Contains(t, buf.String(), "LEAK")
+}
+
+func TestCheckEgressDirectAndFailures(t *testing.T) {
+	checker := &fakeEgressChecker{direct: &model.IPInfo{IP: "198.51.100.2", CountryCode: "CN"}}
+	report, err := checkEgress(context.Background(), checker, "127.0.0.1:7890", "", true, false)
+	require.NoError(t, err)
+	require.Len(t, report.Results, 1)
+	assert.Equal(t, "direct", report.Results[0].Route)
+
+	// 代理不可用时记录错误而不是中断
+	report, err = checkEgress(context.Background(), checker, "127.0.0.1:7890", "", false, true)
+	require.NoError(t, err)
+	assert.Equal(t, "connection refused", report.Results[0].Error)
+	assert.Empty(t, report.Leaks)
+
+	checker.viaErr = errors.New("节点不在 GLOBAL 中: XX")
+	_, err = checkEgress(context.Background(), checker, "127.0.0.1:7890", "XX", false, false)
+	assert.EqualError(t, err, "节点不在 GLOBAL 中: XX")
+}
+
+func TestCheckEgressCanceled(t *testing.T) {
+	checker := &fakeEgressChecker{
+		proxied: &model.IPInfo{IP: "203.0.113.7"},
+		direct:  &model.IPInfo{IP: "198.51.100.2"},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// 中断后不再继续直连检测，--via 的切换仍由 WithNode 恢复
+	report, err := checkEgress(ctx, checker, "127.0.0.1:7890", "JP-1", false, true)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.True(t, checker.restored)
+	assert.Empty(t, report.Results)
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"sort"
+	"strings"
 
 	"github.com/aimony/mihosh/internal/app/service"
 	"github.com/aimony/mihosh/internal/domain/model"
@@ -13,30 +14,57 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var listOutput string
+var (
+	listOutput  string
+	listFilter  string
+	listSort    string
+	listGroupBy string
+)
 
 var listCmd = &cobra.Command{
-	Use:   "list [--output json|table|plain]",
+	Use:   "list [--output json|table|plain] [--filter <条件>] [--sort <字段>] [--group-by <字段>]",
 	Short: "列出所有策略组和节点（支持多种输出格式）",
 	Long: `列出所有策略组及其节点。
 
 可通过 --output 选择输出格式：
   plain  人类可读文本（默认）
   table  表格输出
-  json   结构化 JSON 输出`,
+  json   结构化 JSON 输出
+
+节点元数据（地区、倍率）由节点名称按配置项 node_region_patterns /
+node_multiplier_pattern 中的正则推导，未配置时使用内置规则。
+
+--filter  过滤节点，空格分隔的条件为 AND，逗号为 OR：
+          region:hk,jp type:vmess udp:yes mult:<1 provider:名称 关键词
+--sort    排序字段: name|delay|region|protocol|udp|multiplier
+--group-by 分组字段: region|protocol|udp|multiplier`,
 	Example: `  mihosh list
   mihosh list --output table
-  mihosh list --output json`,
+  mihosh list --output json
+  mihosh list --filter "region:hk,sg mult:<=1" --sort delay
+  mihosh list --group-by region --output table`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		format, err := parseOutputFormat(listOutput)
 		if err != nil {
 			return wrapParameterError(err)
 		}
+		sortKey, err := service.ParseNodeSortKey(listSort)
+		if err != nil {
+			return wrapParameterError(err)
+		}
+		groupKey, err := service.ParseNodeGroupKey(listGroupBy)
+		if err != nil {
+			return wrapParameterError(err)
+		}
 
 		cfg, err := config.Load()
 		if err != nil {
 			return wrapConfigError(fmt.Errorf("加载配置失败: %w", err))
 		}
+		classifier, err := service.NewNodeClassifierFromConfig(cfg)
+		if err != nil {
+			return wrapConfigError(fmt.Errorf("节点识别规则无效: %w", err))
+		}
 
 		client := api.NewClient(cfg)
 		proxySvc := service.NewProxyService(client, cfg.TestURL, cfg.Timeout)
@@ -51,7 +79,13 @@ var listCmd = &cobra.Command{
 			return wrapNetworkError(fmt.Errorf("获取代理失败: %w", err))
 		}
 
-		if err := renderGroupList(os.Stdout, groups, orderedNames, proxiesMap, format); err != nil {
+		opts := listOptions{
+			classifier: classifier,
+			query:      service.ParseNodeQuery(listFilter),
+			sortKey:    sortKey,
+			groupKey:   groupKey,
+		}
+		if err := renderNodeList(os.Stdout, groups, orderedNames, proxiesMap, format, opts); err != nil {
 			return fmt.Errorf("渲染输出失败: %w", err)
 		}
 		return nil
@@ -60,16 +94,45 @@ var listCmd = &cobra.Command{
 
 func init() {
 	listCmd.Flags().StringVar(&listOutput, "output", string(outputFormatPlain), "输出格式: json|table|plain")
+	listCmd.Flags().StringVar(&listFilter, "filter", "", "节点过滤条件（如 \"region:hk udp:yes mult:<1\"）")
+	listCmd.Flags().StringVar(&listSort, "sort", "", "排序字段: name|delay|region|protocol|udp|multiplier")
+	listCmd.Flags().StringVar(&listGroupBy, "group-by", "", "分组字段: region|protocol|udp|multiplier")
+}
+
+// listOptions 节点列表的过滤/排序/分组选项
+type listOptions struct {
+	classifier *service.NodeClassifier
+	query      service.NodeQuery
+	sortKey    service.NodeSortKey
+	groupKey   service.NodeGroupKey
 }
 
 func renderGroupList(w io.Writer, groups map[string]model.Group, orderedNames []string, proxiesMap map[string]model.Proxy, format outputFormat) error {
+	return renderNodeList(w, groups, orderedNames, proxiesMap, format, listOptions{})
+}
+
+// renderNodeList 按选项整理各策略组的节点后输出；有过滤条件时跳过无匹配节点的策略组
+func renderNodeList(w io.Writer, groups map[string]model.Group, orderedNames []string, proxiesMap map[string]model.Proxy, format outputFormat, opts listOptions) error {
+	if opts.classifier == nil {
+		opts.classifier = service.DefaultNodeClassifier()
+	}
+
+	arranged := make(map[string]model.Group, len(groups))
+	for name, group := range groups {
+		group.All = opts.classifier.ArrangeNodes(group.All, proxiesMap, opts.query, opts.sortKey, opts.groupKey)
+		if len(group.All) == 0 && !opts.query.Empty() {
+			continue
+		}
+		arranged[name] = group
+	}
+
 	switch format {
 	case outputFormatJSON:
-		return renderGroupListJSON(w, groups, orderedNames, proxiesMap)
+		return renderGroupListJSON(w, arranged, orderedNames, proxiesMap, opts)
 	case outputFormatTable:
-		return renderGroupListTable(w, groups, orderedNames, proxiesMap)
+		return renderGroupListTable(w, arranged, orderedNames, proxiesMap, opts)
 	case outputFormatPlain:
-		renderGroupListPlain(w, groups, orderedNames, proxiesMap)
+		renderGroupListPlain(w, arranged, orderedNames, proxiesMap, opts)
 		return nil
 	default:
 		return fmt.Errorf("不支持的输出格式: %s", format)
@@ -77,9 +140,15 @@ func renderGroupList(w io.Writer, groups map[string]model.Group, orderedNames []
 }
 
 type listProxyOutput struct {
-	Name     string `json:"name"`
-	DelayMS  *int   `json:"delay_ms,omitempty"`
-	Selected bool   `json:"selected"`
+	Name       string  `json:"name"`
+	DelayMS    *int    `json:"delay_ms,omitempty"`
+	Selected   bool    `json:"selected"`
+	Protocol   string  `json:"protocol,omitempty"`
+	UDP        bool    `json:"udp"`
+	Region     string  `json:"region,omitempty"`
+	Multiplier float64 `json:"multiplier"`
+	Provider   string  `json:"provider,omitempty"`
+	Section    string  `json:"section,omitempty"`
 }
 
 type listGroupOutput struct {
@@ -89,7 +158,17 @@ type listGroupOutput struct {
 	Proxies []listProxyOutput `json:"proxies"`
 }
 
-func renderGroupListJSON(w io.Writer, groups map[string]model.Group, orderedNames []string, proxiesMap map[string]model.Proxy) error {
+// lastPositiveDelay 返回最近一次有效延迟（无有效记录时 ok 为 false）
+func lastPositiveDelay(proxiesMap map[string]model.Proxy, name string) (int, bool) {
+	if proxy, ok := proxiesMap[name]; ok {
+		if delay := proxy.LastDelay(); delay > 0 {
+			return delay, true
+		}
+	}
+	return 0, false
+}
+
+func renderGroupListJSON(w io.Writer, groups map[string]model.Group, orderedNames []string, proxiesMap map[string]model.Proxy, opts listOptions) error {
 	ordered := resolveGroupOrder(groups, orderedNames)
 	payload := struct {
 		Groups []listGroupOutput `json:"groups"`
@@ -107,16 +186,21 @@ func renderGroupListJSON(w io.Writer, groups map[string]model.Group, orderedName
 		}
 
 		for _, proxyName := range group.All {
+			meta := opts.classifier.Classify(proxiesMap[proxyName], proxyName)
 			proxyOut := listProxyOutput{
-				Name:     proxyName,
-				Selected: proxyName == group.Now,
+				Name:       proxyName,
+				Selected:   proxyName == group.Now,
+				Protocol:   meta.Protocol,
+				UDP:        meta.UDP,
+				Region:     meta.Region,
+				Multiplier: meta.Multiplier,
+				Provider:   meta.Provider,
 			}
-			if proxy, ok := proxiesMap[proxyName]; ok && len(proxy.History) > 0 {
-				lastDelay := proxy.History[len(proxy.History)-1].Delay
-				if lastDelay > 0 {
-					delay := lastDelay
-					proxyOut.DelayMS = &delay
-				}
+			if opts.groupKey != service.NodeGroupNone {
+				proxyOut.Section = service.NodeGroupLabel(meta, opts.groupKey)
+			}
+			if delay, ok := lastPositiveDelay(proxiesMap, proxyName); ok {
+				proxyOut.DelayMS = &delay
 			}
 			groupOut.Proxies = append(groupOut.Proxies, proxyOut)
 		}
@@ -127,59 +211,111 @@ func renderGroupListJSON(w io.Writer, groups map[string]model.Group, orderedName
 	return writeJSON(w, payload)
 }
 
-func renderGroupListTable(w io.Writer, groups map[string]model.Group, orderedNames []string, proxiesMap map[string]model.Proxy) error {
+func renderGroupListTable(w io.Writer, groups map[string]model.Group, orderedNames []string, proxiesMap map[string]model.Proxy, opts listOptions) error {
 	tw := newTabWriter(w)
-	fmt.Fprintln(tw, "GROUP\tTYPE\tCURRENT\tPROXY\tDELAY\tSELECTED")
+	grouped := opts.groupKey != service.NodeGroupNone
+	sectionHeader, emptySection := "", ""
+	if grouped {
+		sectionHeader, emptySection = "SECTION\t", "-\t"
+	}
+	fmt.Fprintf(tw, "GROUP\tTYPE\tCURRENT\t%sPROXY\tREGION\tPROTOCOL\tUDP\tMULT\tDELAY\tSELECTED\n", sectionHeader)
 
 	for _, groupName := range resolveGroupOrder(groups, orderedNames) {
 		group := groups[groupName]
 		if len(group.All) == 0 {
-			fmt.Fprintf(tw, "%s\t%s\t%s\t-\t-\t-\n", group.Name, group.Type, group.Now)
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s-\t-\t-\t-\t-\t-\t-\n", group.Name, group.Type, group.Now, emptySection)
 			continue
 		}
 
 		for _, proxyName := range group.All {
+			meta := opts.classifier.Classify(proxiesMap[proxyName], proxyName)
 			delay := "-"
-			if proxy, ok := proxiesMap[proxyName]; ok && len(proxy.History) > 0 {
-				lastDelay := proxy.History[len(proxy.History)-1].Delay
-				if lastDelay > 0 {
-					delay = fmt.Sprintf("%dms", lastDelay)
-				}
+			if lastDelay, ok := lastPositiveDelay(proxiesMap, proxyName); ok {
+				delay = fmt.Sprintf("%dms", lastDelay)
 			}
 			selected := ""
 			if proxyName == group.Now {
 				selected = "yes"
 			}
-			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", group.Name, group.Type, group.Now, proxyName, delay, selected)
+			section := ""
+			if grouped {
+				section = service.NodeGroupLabel(meta, opts.groupKey) + "\t"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				group.Name, group.Type, group.Now, section, proxyName,
+				valueOrDash(meta.Region), valueOrDash(meta.Protocol), yesNo(meta.UDP),
+				service.FormatMultiplier(meta.Multiplier), delay, selected)
 		}
 	}
 
 	return tw.Flush()
 }
 
-func renderGroupListPlain(w io.Writer, groups map[string]model.Group, orderedNames []string, proxiesMap map[string]model.Proxy) {
+func renderGroupListPlain(w io.Writer, groups map[string]model.Group, orderedNames []string, proxiesMap map[string]model.Proxy, opts listOptions) {
 	fmt.Fprintln(w, "策略组列表:")
 	for _, groupName := range resolveGroupOrder(groups, orderedNames) {
 		group := groups[groupName]
 		fmt.Fprintf(w, "\n[%s] %s (当前: %s)\n", group.Type, group.Name, group.Now)
-		for _, proxyName := range group.All {
-			proxy, ok := proxiesMap[proxyName]
-			delay := ""
-			if ok && len(proxy.History) > 0 {
-				lastDelay := proxy.History[len(proxy.History)-1].Delay
-				if lastDelay > 0 {
-					delay = fmt.Sprintf(" (%dms)", lastDelay)
+		lastSection := ""
+		for i, proxyName := range group.All {
+			meta := opts.classifier.Classify(proxiesMap[proxyName], proxyName)
+			if opts.groupKey != service.NodeGroupNone {
+				if section := service.NodeGroupLabel(meta, opts.groupKey); i == 0 || section != lastSection {
+					fmt.Fprintf(w, "  ── %s ──\n", section)
+					lastSection = section
 				}
 			}
+			delay := ""
+			if lastDelay, ok := lastPositiveDelay(proxiesMap, proxyName); ok {
+				delay = fmt.Sprintf(" (%dms)", lastDelay)
+			}
 			marker := ""
 			if proxyName == group.Now {
 				marker = " ✓"
 			}
-			fmt.Fprintf(w, "  - %s%s%s\n", proxyName, delay, marker)
+			fmt.Fprintf(w, "  - %s%s%s%s\n", proxyName, delay, marker, formatNodeMetaPlain(meta))
 		}
 	}
 }
 
+// formatNodeMetaPlain 生成 plain 输出中的元数据后缀，如 " [HK Vmess UDP 0.5x]"
+func formatNodeMetaPlain(meta model.NodeMeta) string {
+	var parts []string
+	if meta.Region != "" {
+		parts = append(parts, meta.Region)
+	}
+	if meta.Protocol != "" {
+		parts = append(parts, meta.Protocol)
+	}
+	if meta.UDP {
+		parts = append(parts, "UDP")
+	}
+	if meta.Multiplier != 1 {
+		parts = append(parts, service.FormatMultiplier(meta.Multiplier))
+	}
+	if meta.Provider != "" {
+		parts = append(parts, "@"+meta.Provider)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(parts, " ") + "]"
+}
+
+func valueOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
 func resolveGroupOrder(groups map[string]model.Group, orderedNames []string) []string {
 	if len(groups) == 0 {
 		return nil
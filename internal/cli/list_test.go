@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/aimony/mihosh/internal/app/service"
 	"github.com/aimony/mihosh/internal/domain/model"
 )
 
@@ -84,3 +85,40 @@ func TestRenderGroupList(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderNodeListWithOptions(t *testing.T) {
+	groups := map[string]model.Group{
+		"Auto":  {Name: "Auto", Type: "Selector", Now: "HK 01", All: []string{"JP 01", "HK 01", "HK 02 2x"}},
+		"Video": {Name: "Video", Type: "Selector", All: []string{"US 01"}},
+	}
+	proxies := map[string]model.Proxy{
+		"JP 01":    {Type: "Trojan", History: []model.Delay{{Delay: 30}}},
+		"HK 01":    {Type: "Vmess", UDP: true, History: []model.Delay{{Delay: 80}}},
+		"HK 02 2x": {Type: "Vmess", History: []model.Delay{{Delay: 20}}},
+		"US 01":    {Type: "Vmess"},
+	}
+	opts := listOptions{
+		query:    service.ParseNodeQuery("region:hk,jp"),
+		sortKey:  service.NodeSortDelay,
+		groupKey: service.NodeGroupRegion,
+	}
+
+	var plain bytes.Buffer
+	assert.NoError(t, renderNodeList(&plain, groups, []string{"Auto", "Video"}, proxies, outputFormatPlain, opts))
+	output := plain.String()
+	assert.NotContains(t, output, "Video", "group without matches should be skipped")
+	assert.Less(t, strings.Index(output, "── HK ──"), strings.Index(output, "── JP ──"))
+	assert.Less(t, strings.Index(output, "HK 02 2x"), strings.Index(output, "- HK 01"))
+	assert.Contains(t, output, "[HK Vmess 2x]")
+
+	var table bytes.Buffer
+	assert.NoError(t, renderNodeList(&table, groups, nil, proxies, outputFormatTable, opts))
+	assert.Contains(t, table.String(), "SECTION")
+	assert.Contains(t, table.String(), "REGION")
+
+	var js bytes.Buffer
+	assert.NoError(t, renderNodeList(&js, groups, nil, proxies, outputFormatJSON, opts))
+	assert.Contains(t, js.String(), `"region": "HK"`)
+	assert.Contains(t, js.String(), `"multiplier": 2`)
+	assert.Contains(t, js.String(), `"section": "JP"`)
+}
@@ -35,6 +35,11 @@ func writeJSON(w io.Writer, data interface{}) error {
 	return encoder.Encode(data)
 }
 
+// writeNDJSON 以单行 JSON 写出一条事件（流式输出使用）
+func writeNDJSON(w io.Writer, data interface{}) error {
+	return json.NewEncoder(w).Encode(data)
+}
+
 func newTabWriter(w io.Writer) *tabwriter.Writer {
 	return tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
 }
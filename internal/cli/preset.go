@@ -0,0 +1,266 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/infrastructure/api"
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+	"github.com/spf13/cobra"
+)
+
+var presetOutput string
+
+var presetCmd = &cobra.Command{
+	Use:   "preset",
+	Short: "策略组选择预设（保存/恢复所有 Selector 的当前节点）",
+	Long: `将所有 Selector 策略组当前选中的节点保存为命名预设，之后一条命令切换回来。
+
+预设保存在 ~/.mihosh/presets.json。应用预设时只切换与当前不同的策略组，
+已不存在的策略组或节点会被列出但不会中断其余切换。`,
+	Example: `  mihosh preset save work
+  mihosh preset apply streaming
+  mihosh preset diff gaming --output table
+  mihosh preset list
+  mihosh preset rm work`,
+}
+
+var presetListCmd = &cobra.Command{
+	Use:   "list [--output json|table|plain]",
+	Short: "列出已保存的预设",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := parseOutputFormat(presetOutput)
+		if err != nil {
+			return wrapParameterError(err)
+		}
+
+		presets, err := service.NewPresetService(nil).List()
+		if err != nil {
+			return wrapConfigError(fmt.Errorf("读取预设失败: %w", err))
+		}
+		if err := renderPresetList(os.Stdout, presets, format); err != nil {
+			return fmt.Errorf("渲染输出失败: %w", err)
+		}
+		return nil
+	},
+}
+
+var presetSaveCmd = &cobra.Command{
+	Use:   "save <name>",
+	Short: "保存当前所有 Selector 策略组的选择",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		presetSvc, err := newPresetService()
+		if err != nil {
+			return err
+		}
+
+		preset, err := presetSvc.Save(args[0])
+		if err != nil {
+			return wrapNetworkError(fmt.Errorf("保存预设失败: %w", err))
+		}
+
+		fmt.Printf("✓ 已保存预设 '%s'（%d 个策略组）\n", preset.Name, len(preset.Selections))
+		return nil
+	},
+}
+
+var presetApplyCmd = &cobra.Command{
+	Use:               "apply <name> [--output json|table|plain]",
+	Short:             "应用预设，切换回保存时的节点",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completePresetNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := parseOutputFormat(presetOutput)
+		if err != nil {
+			return wrapParameterError(err)
+		}
+		presetSvc, err := newPresetService()
+		if err != nil {
+			return err
+		}
+
+		entries, err := presetSvc.Apply(args[0])
+		if err != nil {
+			return wrapNetworkError(fmt.Errorf("应用预设失败: %w", err))
+		}
+		if err := renderPresetEntries(os.Stdout, args[0], entries, format); err != nil {
+			return fmt.Errorf("渲染输出失败: %w", err)
+		}
+		if failed := service.SummarizePreset(entries)[service.PresetFailed]; failed > 0 {
+			return wrapNetworkError(fmt.Errorf("%d 个策略组切换失败", failed))
+		}
+		return nil
+	},
+}
+
+var presetDiffCmd = &cobra.Command{
+	Use:               "diff <name> [--output json|table|plain]",
+	Short:             "比较预设与当前选择",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completePresetNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := parseOutputFormat(presetOutput)
+		if err != nil {
+			return wrapParameterError(err)
+		}
+		presetSvc, err := newPresetService()
+		if err != nil {
+			return err
+		}
+
+		entries, err := presetSvc.Diff(args[0])
+		if err != nil {
+			return wrapNetworkError(fmt.Errorf("比较预设失败: %w", err))
+		}
+		if err := renderPresetEntries(os.Stdout, args[0], entries, format); err != nil {
+			return fmt.Errorf("渲染输出失败: %w", err)
+		}
+		return nil
+	},
+}
+
+var presetRemoveCmd = &cobra.Command{
+	Use:               "rm <name>",
+	Aliases:           []string{"remove", "delete"},
+	Short:             "删除预设",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completePresetNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := service.NewPresetService(nil).Delete(args[0]); err != nil {
+			return wrapConfigError(fmt.Errorf("删除预设失败: %w", err))
+		}
+
+		fmt.Printf("✓ 已删除预设 '%s'\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{presetListCmd, presetApplyCmd, presetDiffCmd} {
+		cmd.Flags().StringVar(&presetOutput, "output", string(outputFormatPlain), "输出格式: json|table|plain")
+	}
+	presetCmd.AddCommand(presetListCmd)
+	presetCmd.AddCommand(presetSaveCmd)
+	presetCmd.AddCommand(presetApplyCmd)
+	presetCmd.AddCommand(presetDiffCmd)
+	presetCmd.AddCommand(presetRemoveCmd)
+}
+
+func newPresetService() (*service.PresetService, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, wrapConfigError(fmt.Errorf("加载配置失败: %w", err))
+	}
+	proxySvc := service.NewProxyService(api.NewClient(cfg), cfg.TestURL, cfg.Timeout)
+	return service.NewPresetService(proxySvc), nil
+}
+
+func completePresetNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	presets, err := service.NewPresetService(nil).List()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names := make([]string, 0, len(presets))
+	for _, p := range presets {
+		names = append(names, p.Name)
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+type presetListItem struct {
+	Name       string            `json:"name"`
+	CreatedAt  string            `json:"created_at"`
+	Groups     int               `json:"groups"`
+	Selections map[string]string `json:"selections"`
+}
+
+func renderPresetList(w io.Writer, presets []model.SelectionPreset, format outputFormat) error {
+	items := make([]presetListItem, 0, len(presets))
+	for _, p := range presets {
+		items = append(items, presetListItem{
+			Name:       p.Name,
+			CreatedAt:  p.CreatedAt.Format("2006-01-02 15:04:05"),
+			Groups:     len(p.Selections),
+			Selections: p.Selections,
+		})
+	}
+
+	switch format {
+	case outputFormatJSON:
+		return writeJSON(w, items)
+	case outputFormatTable:
+		tw := newTabWriter(w)
+		fmt.Fprintln(tw, "NAME\tGROUPS\tCREATED_AT")
+		for _, item := range items {
+			fmt.Fprintf(tw, "%s\t%d\t%s\n", item.Name, item.Groups, item.CreatedAt)
+		}
+		return tw.Flush()
+	case outputFormatPlain:
+		if len(items) == 0 {
+			fmt.Fprintln(w, "尚未保存预设（使用 mihosh preset save <名称> 创建）")
+			return nil
+		}
+		for _, item := range items {
+			fmt.Fprintf(w, "%s  %d 个策略组  %s\n", item.Name, item.Groups, item.CreatedAt)
+		}
+		return nil
+	default:
+		return fmt.Errorf("不支持的输出格式: %s", format)
+	}
+}
+
+// presetStatusLabels 预设状态的人类可读文本
+var presetStatusLabels = map[service.PresetStatus]string{
+	service.PresetSame:         "一致",
+	service.PresetChanged:      "不同",
+	service.PresetApplied:      "已切换",
+	service.PresetFailed:       "切换失败",
+	service.PresetGroupMissing: "策略组不存在",
+	service.PresetProxyMissing: "节点不存在",
+}
+
+func renderPresetEntries(w io.Writer, name string, entries []service.PresetEntry, format outputFormat) error {
+	switch format {
+	case outputFormatJSON:
+		return writeJSON(w, struct {
+			Preset  string                       `json:"preset"`
+			Entries []service.PresetEntry        `json:"entries"`
+			Summary map[service.PresetStatus]int `json:"summary"`
+		}{Preset: name, Entries: entries, Summary: service.SummarizePreset(entries)})
+	case outputFormatTable:
+		tw := newTabWriter(w)
+		fmt.Fprintln(tw, "GROUP\tCURRENT\tPRESET\tSTATUS")
+		for _, e := range entries {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.Group, valueOrDash(e.Current), e.Target, e.Status)
+		}
+		return tw.Flush()
+	case outputFormatPlain:
+		for _, e := range entries {
+			switch e.Status {
+			case service.PresetSame:
+				fmt.Fprintf(w, "  = %s: %s\n", e.Group, e.Target)
+			case service.PresetChanged, service.PresetApplied:
+				fmt.Fprintf(w, "  ✓ %s: %s -> %s（%s）\n", e.Group, valueOrDash(e.Current), e.Target, presetStatusLabels[e.Status])
+			case service.PresetFailed:
+				fmt.Fprintf(w, "  ✗ %s: %s -> %s（%s: %s）\n", e.Group, valueOrDash(e.Current), e.Target, presetStatusLabels[e.Status], e.Error)
+			default:
+				fmt.Fprintf(w, "  ✗ %s: %s（%s）\n", e.Group, e.Target, presetStatusLabels[e.Status])
+			}
+		}
+		summary := service.SummarizePreset(entries)
+		fmt.Fprintf(w, "预设 '%s'：%d 个一致，%d 个不同，%d 个已切换，%d 个失效，%d 个失败\n", name,
+			summary[service.PresetSame], summary[service.PresetChanged], summary[service.PresetApplied],
+			summary[service.PresetGroupMissing]+summary[service.PresetProxyMissing], summary[service.PresetFailed])
+		return nil
+	default:
+		return fmt.Errorf("不支持的输出格式: %s", format)
+	}
+}
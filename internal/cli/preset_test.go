@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderPresetEntries(t *testing.T) {
+	entries := []service.PresetEntry{
+		{Group: "Proxy", Current: "HK", Target: "JP", Status: service.PresetApplied},
+		{Group: "Streaming", Current: "US", Target: "US", Status: service.PresetSame},
+		{Group: "Old", Target: "SG", Status: service.PresetGroupMissing},
+	}
+
+	tests := []struct {
+		name     string
+		format   outputFormat
+		contains []string
+	}{
+		{name: "plain", format: outputFormatPlain, contains: []string{"✓ Proxy: HK -> JP（已切换）", "✗ Old: SG（策略组不存在）", "1 个一致", "1 个失效"}},
+		{name: "table", format: outputFormatTable, contains: []string{"GROUP", "Old", "-", "group_missing"}},
+		{name: "json", format: outputFormatJSON, contains: []string{`"preset": "work"`, `"status": "applied"`, `"group_missing": 1`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := renderPresetEntries(&buf, "work", entries, tt.format)
+			assert.NoError(t, err)
+			for _, want := range tt.contains {
+				assert.Contains(t, buf.String(), want)
+			}
+		})
+	}
+}
@@ -46,9 +46,15 @@ var rootCmd = &cobra.Command{
 		model := tui.NewModel(client, cfg.TestURL, cfg.Timeout)
 
 		p := tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseCellMotion())
-		if _, err := p.Run(); err != nil {
+		final, err := p.Run()
+		if err != nil {
 			return fmt.Errorf("启动失败: %w", err)
 		}
+		if m, ok := final.(tui.Model); ok {
+			if err := m.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "⚠ %v\n", err)
+			}
+		}
 		return nil
 	},
 }
@@ -60,8 +66,14 @@ func init() {
 	rootCmd.AddCommand(testCmd)
 	rootCmd.AddCommand(testGroupCmd)
 	rootCmd.AddCommand(connectionsCmd)
+	rootCmd.AddCommand(autokillCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(modeCmd)
+	rootCmd.AddCommand(aliasCmd)
+	rootCmd.AddCommand(presetCmd)
+	rootCmd.AddCommand(geoCmd)
+	rootCmd.AddCommand(ipCmd)
+	rootCmd.AddCommand(rulesCmd)
 }
 
 // Execute 执行命令
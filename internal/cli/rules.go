@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/infrastructure/api"
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rulesOutput         string
+	rulesExplainIP      string
+	rulesExplainPort    int
+	rulesExplainProcess string
+	rulesExplainResolve bool
+)
+
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "规则相关工具",
+}
+
+var rulesExplainCmd = &cobra.Command{
+	Use:   "explain <host[:port]|ip> [--ip <IP>] [--port <端口>] [--process <进程名>] [--output json|table|plain]",
+	Short: "模拟规则匹配：目标会命中哪条规则、最终走哪个节点",
+	Long: `按当前规则顺序模拟匹配，显示第一条命中的规则，并沿策略组当前选择追踪到最终节点。
+
+支持模拟 DOMAIN、DOMAIN-SUFFIX、DOMAIN-KEYWORD、IP-CIDR、DST-PORT、PROCESS-NAME 与 MATCH。
+RULE-SET、GEOIP、GEOSITE 需要规则集/数据库内容，无法在本地判断，
+这类规则若排在命中规则之前会被列出，实际连接可能先命中它们。
+IP-CIDR 需要目标 IP：直接输入 IP、用 --ip 指定，或加 --resolve 通过本机 DNS 解析域名
+（mihomo 使用自己的 DNS，结果可能不同）。`,
+	Example: `  mihosh rules explain www.google.com
+  mihosh rules explain api.github.com:443 --process git
+  mihosh rules explain 1.1.1.1:53 --output table
+  mihosh rules explain example.com --resolve --output json`,
+	Args: cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := parseOutputFormat(rulesOutput)
+		if err != nil {
+			return wrapParameterError(err)
+		}
+		target, err := buildRuleTarget(args[0], rulesExplainIP, rulesExplainPort, rulesExplainProcess)
+		if err != nil {
+			return wrapParameterError(err)
+		}
+		if rulesExplainResolve && target.IP == "" {
+			if target.IP, err = resolveTargetIP(target.Host); err != nil {
+				return wrapNetworkError(err)
+			}
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return wrapConfigError(fmt.Errorf("加载配置失败: %w", err))
+		}
+
+		exp, err := newTestProxyService(api.NewClient(cfg), cfg).ExplainRule(target)
+		if err != nil {
+			return wrapNetworkError(err)
+		}
+		if err := renderRuleExplanation(os.Stdout, exp, format); err != nil {
+			return fmt.Errorf("渲染输出失败: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rulesExplainCmd.Flags().StringVar(&rulesOutput, "output", string(outputFormatPlain), "输出格式: json|table|plain")
+	rulesExplainCmd.Flags().StringVar(&rulesExplainIP, "ip", "", "目标 IP（用于 IP-CIDR 规则）")
+	rulesExplainCmd.Flags().IntVar(&rulesExplainPort, "port", 0, "目标端口（用于 DST-PORT 规则）")
+	rulesExplainCmd.Flags().StringVar(&rulesExplainProcess, "process", "", "发起连接的进程名（用于 PROCESS-NAME 规则）")
+	rulesExplainCmd.Flags().BoolVar(&rulesExplainResolve, "resolve", false, "通过本机 DNS 解析域名得到目标 IP")
+	rulesCmd.AddCommand(rulesExplainCmd)
+}
+
+// buildRuleTarget 合并位置参数与 --ip/--port/--process
+func buildRuleTarget(arg, ip string, port int, process string) (service.RuleTarget, error) {
+	target, err := service.ParseRuleTarget(arg)
+	if err != nil {
+		return target, err
+	}
+	if ip != "" {
+		addr := net.ParseIP(ip)
+		if addr == nil {
+			return target, fmt.Errorf("无效的 IP: %s", ip)
+		}
+		target.IP = addr.String()
+	}
+	if port != 0 {
+		if port < 0 || port > 65535 {
+			return target, fmt.Errorf("无效的端口: %d", port)
+		}
+		target.Port = port
+	}
+	target.Process = process
+	return target, nil
+}
+
+// resolveTargetIP 通过本机 DNS 解析域名，优先返回 IPv4
+func resolveTargetIP(host string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return "", fmt.Errorf("解析 %s 失败: %w", host, err)
+	}
+	for _, a := range addrs {
+		if a.IP.To4() != nil {
+			return a.IP.String(), nil
+		}
+	}
+	if len(addrs) == 0 {
+		return "", fmt.Errorf("解析 %s 失败: 无记录", host)
+	}
+	return addrs[0].IP.String(), nil
+}
+
+func formatRuleTarget(t service.RuleTarget) string {
+	host := t.Host
+	if host == "" {
+		host = t.IP
+	} else if t.IP != "" {
+		host += " (" + t.IP + ")"
+	}
+	if t.Port > 0 {
+		host += " 端口 " + strconv.Itoa(t.Port)
+	}
+	if t.Process != "" {
+		host += " 进程 " + t.Process
+	}
+	return host
+}
+
+func formatRuleStep(step service.RuleStep) string {
+	rule := step.Rule.Type
+	if step.Rule.Payload != "" {
+		rule += "," + step.Rule.Payload
+	}
+	return fmt.Sprintf("#%d %s → %s", step.Index, rule, step.Rule.Proxy)
+}
+
+func renderRuleExplanation(w io.Writer, exp *service.RuleExplanation, format outputFormat) error {
+	chain := strings.Join(exp.Chain, " → ")
+	switch format {
+	case outputFormatJSON:
+		return writeJSON(w, exp)
+	case outputFormatTable:
+		tw := newTabWriter(w)
+		fmt.Fprintln(tw, "RESULT\tINDEX\tTYPE\tPAYLOAD\tPROXY\tNOTE")
+		for _, step := range exp.Undecided {
+			fmt.Fprintf(tw, "undecided\t%d\t%s\t%s\t%s\t%s\n", step.Index, step.Rule.Type, valueOrDash(step.Rule.Payload), step.Rule.Proxy, step.Reason)
+		}
+		if m := exp.Matched; m != nil {
+			fmt.Fprintf(tw, "matched\t%d\t%s\t%s\t%s\t%s\n", m.Index, m.Rule.Type, valueOrDash(m.Rule.Payload), m.Rule.Proxy, chain)
+		} else {
+			fmt.Fprintf(tw, "default\t-\t-\t-\tDIRECT\t%s\n", chain)
+		}
+		return tw.Flush()
+	case outputFormatPlain:
+		fmt.Fprintf(w, "目标: %s\n", formatRuleTarget(exp.Target))
+		if exp.Matched != nil {
+			fmt.Fprintf(w, "命中规则: %s\n", formatRuleStep(*exp.Matched))
+		} else {
+			fmt.Fprintln(w, "命中规则: 无（默认直连）")
+		}
+		fmt.Fprintf(w, "节点链路: %s\n", chain)
+		if len(exp.Undecided) > 0 {
+			fmt.Fprintf(w, "⚠ 以下 %d 条规则无法在本地判断，实际可能先命中:\n", len(exp.Undecided))
+			for _, step := range exp.Undecided {
+				fmt.Fprintf(w, "  %s（%s）\n", formatRuleStep(step), step.Reason)
+			}
+		}
+		if exp.Mode != "" && !strings.EqualFold(exp.Mode, "rule") {
+			fmt.Fprintf(w, "注意: 当前为 %s 模式，规则不生效\n", exp.Mode)
+		}
+		return nil
+	default:
+		return fmt.Errorf("不支持的输出格式: %s", format)
+	}
+}
@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/infrastructure/api"
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rulesAddTarget   string
+	rulesAddType     string
+	rulesAddPosition string
+	rulesAddPath     string
+	rulesAddDryRun   bool
+	rulesAddYes      bool
+)
+
+var rulesAddCmd = &cobra.Command{
+	Use:   "add <host|ip> --target <策略组> [--type domain|domain-suffix|ip-cidr] [--position managed|top|before-match]",
+	Short: "向 mihomo 配置添加一条规则并重载",
+	Long: `在 mihomo 配置文件的 rules 列表中插入一条 DOMAIN/DOMAIN-SUFFIX/IP-CIDR 规则，
+先显示 diff，确认后备份原文件（<配置文件>.<时间>.bak）、写入并通过 API 重载。
+重载失败时自动恢复原配置。只插入新行，原有注释与格式保持不变。
+
+插入位置:
+  managed       mihosh 管理的规则块（# mihosh:rules:begin/end，位于列表顶部，默认）
+  top           规则列表顶部
+  before-match  MATCH 兜底规则之前
+
+未指定 --type 时，域名使用 DOMAIN-SUFFIX，IP 使用 IP-CIDR（/32 或 /128）。`,
+	Example: `  mihosh rules add github.com --target Dev
+  mihosh rules add api.example.com --type domain --target DIRECT --position before-match
+  mihosh rules add 1.2.3.4 --target REJECT --dry-run`,
+	Args: cobra.ExactArgs(1),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := parseOutputFormat(rulesOutput)
+		if err != nil {
+			return wrapParameterError(err)
+		}
+		draft, err := buildRuleDraft(args[0], rulesAddType, rulesAddTarget)
+		if err != nil {
+			return wrapParameterError(err)
+		}
+		if !slices.Contains(service.RuleInsertPositions, rulesAddPosition) {
+			return wrapParameterError(fmt.Errorf("无效的插入位置: %s（可选 %s）", rulesAddPosition, strings.Join(service.RuleInsertPositions, "/")))
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return wrapConfigError(fmt.Errorf("加载配置失败: %w", err))
+		}
+		client := api.NewClient(cfg)
+		proxies, err := client.GetProxies()
+		if err != nil {
+			return wrapNetworkError(err)
+		}
+		if _, ok := proxies[draft.Target]; !ok {
+			return wrapParameterError(fmt.Errorf("未找到策略组或节点: %s", draft.Target))
+		}
+
+		path := rulesAddPath
+		if strings.TrimSpace(path) != "" {
+			path, err = resolveMihomoConfigTarget(path)
+		} else {
+			path, err = config.GetMihomoConfigPath()
+		}
+		if err != nil {
+			return wrapConfigError(err)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return wrapConfigError(fmt.Errorf("读取配置文件失败: %w", err))
+		}
+		edit, err := service.PlanRuleInsert(path, content, draft, rulesAddPosition)
+		if err != nil {
+			return wrapConfigError(err)
+		}
+
+		if format == outputFormatJSON {
+			return writeJSON(os.Stdout, edit)
+		}
+		fmt.Print(edit.Diff)
+		if rulesAddDryRun {
+			return nil
+		}
+		if !rulesAddYes && !confirm(os.Stdin, os.Stdout, "写入配置并重载 mihomo? [y/N] ") {
+			fmt.Println("已取消")
+			return nil
+		}
+
+		backup, err := service.ApplyRuleEdit(edit, client.ReloadConfig)
+		if err != nil {
+			return wrapConfigError(err)
+		}
+		fmt.Printf("✓ 已添加 %s（第 %d 行），原配置备份在 %s\n", draft, edit.Line, backup)
+		return nil
+	},
+}
+
+func init() {
+	rulesAddCmd.Flags().StringVar(&rulesOutput, "output", string(outputFormatPlain), "输出格式: json|plain（json 只输出预览，不写入）")
+	rulesAddCmd.Flags().StringVar(&rulesAddTarget, "target", "", "目标策略组、节点或 DIRECT/REJECT")
+	rulesAddCmd.Flags().StringVar(&rulesAddType, "type", "", "规则类型: domain|domain-suffix|ip-cidr")
+	rulesAddCmd.Flags().StringVar(&rulesAddPosition, "position", service.RuleInsertManaged, "插入位置: managed|top|before-match")
+	rulesAddCmd.Flags().StringVar(&rulesAddPath, "path", "", "指定 mihomo 配置文件或目录路径")
+	rulesAddCmd.Flags().BoolVar(&rulesAddDryRun, "dry-run", false, "只显示 diff，不写入")
+	rulesAddCmd.Flags().BoolVarP(&rulesAddYes, "yes", "y", false, "跳过确认直接写入")
+	_ = rulesAddCmd.MarkFlagRequired("target")
+	_ = rulesAddCmd.RegisterFlagCompletionFunc("position", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return service.RuleInsertPositions, cobra.ShellCompDirectiveNoFileComp
+	})
+	rulesCmd.AddCommand(rulesAddCmd)
+}
+
+// buildRuleDraft 由主机、IP 或网段与 --type 生成规则
+func buildRuleDraft(arg, ruleType, target string) (service.RuleDraft, error) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return service.RuleDraft{}, fmt.Errorf("--target 不能为空")
+	}
+	arg = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(arg)), ".")
+	if arg == "" {
+		return service.RuleDraft{}, fmt.Errorf("主机或 IP 不能为空")
+	}
+	want := normalizeFlagRuleType(ruleType)
+
+	prefix, err := netip.ParsePrefix(arg)
+	if err != nil {
+		if addr, addrErr := netip.ParseAddr(arg); addrErr == nil {
+			addr = addr.Unmap()
+			prefix, err = addr.Prefix(addr.BitLen())
+		}
+	}
+	if err == nil {
+		if want != "" && want != "IP-CIDR" {
+			return service.RuleDraft{}, fmt.Errorf("IP 只能使用 ip-cidr 类型: %s", arg)
+		}
+		draft := service.RuleDraft{Type: "IP-CIDR", Payload: prefix.Masked().String(), Target: target}
+		if prefix.Addr().Is6() {
+			draft.Type = "IP-CIDR6"
+		}
+		return draft, nil
+	}
+
+	switch want {
+	case "", "DOMAIN-SUFFIX":
+		return service.RuleDraft{Type: "DOMAIN-SUFFIX", Payload: arg, Target: target}, nil
+	case "DOMAIN":
+		return service.RuleDraft{Type: "DOMAIN", Payload: arg, Target: target}, nil
+	case "IP-CIDR":
+		return service.RuleDraft{}, fmt.Errorf("域名不能使用 ip-cidr 类型: %s", arg)
+	}
+	return service.RuleDraft{}, fmt.Errorf("不支持的规则类型: %s（可选 domain/domain-suffix/ip-cidr）", ruleType)
+}
+
+// normalizeFlagRuleType 将 --type 转换为配置写法
+func normalizeFlagRuleType(t string) string {
+	switch strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(t), "_", "-")) {
+	case "":
+		return ""
+	case "DOMAIN":
+		return "DOMAIN"
+	case "DOMAIN-SUFFIX":
+		return "DOMAIN-SUFFIX"
+	case "IP-CIDR", "IP-CIDR6":
+		return "IP-CIDR"
+	}
+	return strings.ToUpper(t)
+}
+
+// confirm 读取一行确认输入，y/yes 视为同意
+func confirm(in io.Reader, out io.Writer, prompt string) bool {
+	fmt.Fprint(out, prompt)
+	line, _ := bufio.NewReader(in).ReadString('\n')
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes"
+}
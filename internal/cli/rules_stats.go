@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/infrastructure/api"
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+	"github.com/aimony/mihosh/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rulesStatsSince   string
+	rulesStatsSort    string
+	rulesStatsDead    bool
+	rulesStatsCollect time.Duration
+)
+
+var rulesStatsCmd = &cobra.Command{
+	Use:   "stats [--since 24h] [--sort index|hits|bytes] [--dead] [--collect <时长>] [--output json|table|plain]",
+	Short: "规则命中次数与流量统计，找出长期无命中的规则",
+	Long: `按规则汇总连接命中次数（新建连接数）与流量，并标记时间窗口内没有命中的规则。
+
+统计来自实时连接：TUI 运行期间会持续记录到 ~/.mihosh/rule-hits.json（保留 30 天），
+本命令读取该记录并计入当前活跃连接。没有运行 TUI 时可用 --collect 订阅连接推送一段时间再统计。
+统计覆盖的时长短于 --since 时会给出提示，此时"无命中"的判断并不可靠。
+重复的规则只有第一条会被命中，其余的总是标记为无命中。`,
+	Example: `  mihosh rules stats
+  mihosh rules stats --since 7d --dead --output table
+  mihosh rules stats --sort bytes --output json
+  mihosh rules stats --collect 10m --since 1h`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := parseOutputFormat(rulesOutput)
+		if err != nil {
+			return wrapParameterError(err)
+		}
+		window, err := service.ParseStatsWindow(rulesStatsSince)
+		if err != nil {
+			return wrapParameterError(err)
+		}
+		if err := service.SortRuleHitRows(nil, rulesStatsSort); err != nil {
+			return wrapParameterError(err)
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return wrapConfigError(fmt.Errorf("加载配置失败: %w", err))
+		}
+		path, err := service.DefaultRuleHitsPath()
+		if err != nil {
+			return wrapConfigError(err)
+		}
+		counter := service.NewRuleHitCounter()
+		if err := counter.Load(path); err != nil {
+			return wrapConfigError(err)
+		}
+
+		client := api.NewClient(cfg)
+		if rulesStatsCollect > 0 {
+			collectRuleHits(cfg, counter, rulesStatsCollect)
+		}
+		conns, err := client.GetConnections()
+		if err != nil {
+			return wrapNetworkError(fmt.Errorf("获取连接失败: %w", err))
+		}
+		counter.Observe(conns.Connections)
+		if err := counter.Save(path); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ %v\n", err)
+		}
+
+		rules, err := client.GetRules()
+		if err != nil {
+			return wrapNetworkError(fmt.Errorf("获取规则失败: %w", err))
+		}
+		now := time.Now()
+		report := buildRuleStatsReport(rules.Rules, counter, now.Add(-window), window, rulesStatsDead)
+		_ = service.SortRuleHitRows(report.Rules, rulesStatsSort)
+		if format != outputFormatJSON && report.TrackingSince.After(report.Since) {
+			fmt.Fprintf(os.Stderr, "⚠ 统计仅覆盖最近 %s（自 %s 起），无命中的判断可能不准确\n",
+				formatStatsDuration(now.Sub(report.TrackingSince)), report.TrackingSince.Format("01-02 15:04"))
+		}
+		if err := renderRuleStats(os.Stdout, report, format); err != nil {
+			return fmt.Errorf("渲染输出失败: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rulesStatsCmd.Flags().StringVar(&rulesOutput, "output", string(outputFormatPlain), "输出格式: json|table|plain")
+	rulesStatsCmd.Flags().StringVar(&rulesStatsSince, "since", "24h", "统计时间窗口（如 90m、24h、7d）")
+	rulesStatsCmd.Flags().StringVar(&rulesStatsSort, "sort", service.RuleSortIndex, "排序: index|hits|bytes")
+	rulesStatsCmd.Flags().BoolVar(&rulesStatsDead, "dead", false, "只列出时间窗口内无命中的规则")
+	rulesStatsCmd.Flags().DurationVar(&rulesStatsCollect, "collect", 0, "先订阅连接推送指定时长再统计（Ctrl+C 提前结束）")
+	rulesCmd.AddCommand(rulesStatsCmd)
+}
+
+// collectRuleHits 订阅连接推送并计入命中统计，直到超时或 Ctrl+C
+func collectRuleHits(cfg *config.Config, counter *service.RuleHitCounter, d time.Duration) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+
+	wsClient := api.NewWSClient(cfg.APIAddress, cfg.Secret)
+	wsClient.SetConnectionsHandler(func(data api.ConnectionsData) {
+		counter.Observe(data.Connections)
+	})
+	wsClient.Start()
+	defer wsClient.Stop()
+
+	fmt.Fprintf(os.Stderr, "正在记录规则命中 %s，Ctrl+C 提前结束\n", d)
+	<-ctx.Done()
+}
+
+type ruleStatsReport struct {
+	Since         time.Time            `json:"since"`
+	Window        string               `json:"window"`
+	TrackingSince time.Time            `json:"tracking_since"`
+	Total         int                  `json:"total"`
+	Dead          int                  `json:"dead"`
+	Rules         []service.RuleHitRow `json:"rules"`
+}
+
+func buildRuleStatsReport(rules []model.Rule, counter *service.RuleHitCounter, since time.Time, window time.Duration, deadOnly bool) ruleStatsReport {
+	rows := service.BuildRuleHitRows(rules, counter.Stats(since))
+	report := ruleStatsReport{
+		Since:         since,
+		Window:        formatStatsDuration(window),
+		TrackingSince: counter.Since(),
+		Total:         len(rows),
+		Rules:         make([]service.RuleHitRow, 0, len(rows)),
+	}
+	for _, row := range rows {
+		if row.Dead {
+			report.Dead++
+		}
+		if !deadOnly || row.Dead {
+			report.Rules = append(report.Rules, row)
+		}
+	}
+	return report
+}
+
+// formatStatsDuration 以天/小时/分钟显示时长
+func formatStatsDuration(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	default:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+}
+
+func formatLastHit(row service.RuleHitRow) string {
+	if row.LastHit == nil || row.LastHit.IsZero() {
+		return "-"
+	}
+	return row.LastHit.Local().Format("01-02 15:04")
+}
+
+func renderRuleStats(w io.Writer, report ruleStatsReport, format outputFormat) error {
+	switch format {
+	case outputFormatJSON:
+		return writeJSON(w, report)
+	case outputFormatTable:
+		tw := newTabWriter(w)
+		fmt.Fprintln(tw, "INDEX\tTYPE\tPAYLOAD\tPROXY\tHITS\tBYTES\tLAST_HIT")
+		for _, row := range report.Rules {
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\t%s\t%s\n", row.Index, row.Rule.Type, valueOrDash(row.Rule.Payload), row.Rule.Proxy,
+				row.Hits, utils.FormatBytes(row.Bytes), formatLastHit(row))
+		}
+		return tw.Flush()
+	case outputFormatPlain:
+		for _, row := range report.Rules {
+			step := formatRuleStep(service.RuleStep{Index: row.Index, Rule: row.Rule})
+			if row.Dead {
+				fmt.Fprintf(w, "✗ %s  无命中\n", step)
+				continue
+			}
+			fmt.Fprintf(w, "  %s  命中 %d  流量 %s  最近 %s\n", step, row.Hits, utils.FormatBytes(row.Bytes), formatLastHit(row))
+		}
+		fmt.Fprintf(w, "共 %d 条规则，%d 条在最近 %s 内无命中\n", report.Total, report.Dead, report.Window)
+		return nil
+	default:
+		return fmt.Errorf("不支持的输出格式: %s", format)
+	}
+}
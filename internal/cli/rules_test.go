@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildRuleTarget(t *testing.T) {
+	target, err := buildRuleTarget("example.com:80", "93.184.216.34", 443, "curl")
+	require.NoError(t, err)
+	assert.Equal(t, service.RuleTarget{Host: "example.com", IP: "93.184.216.34", Port: 443, Process: "curl"}, target)
+
+	_, err = buildRuleTarget("example.com", "not-an-ip", 0, "")
+	assert.ErrorContains(t, err, "无效的 IP")
+	_, err = buildRuleTarget("example.com", "", 70000, "")
+	assert.ErrorContains(t, err, "无效的端口")
+}
+
+func TestRenderRuleExplanation(t *testing.T) {
+	exp := &service.RuleExplanation{
+		Target:  service.RuleTarget{Host: "api.github.com", Port: 443},
+		Mode:    "global",
+		Matched: &service.RuleStep{Index: 5, Rule: model.Rule{Type: "DomainSuffix", Payload: "github.com", Proxy: "Dev"}},
+		Undecided: []service.RuleStep{
+			{Index: 2, Rule: model.Rule{Type: "RuleSet", Payload: "reject", Proxy: "REJECT"}, Reason: "需要规则集数据"},
+		},
+		Chain: []string{"Dev", "JP"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, renderRuleExplanation(&buf, exp, outputFormatPlain))
+	assert.Equal(t, `目标: api.github.com 端口 443
+命中规则: #5 DomainSuffix,github.com → Dev
+节点链路: Dev → JP
+⚠ 以下 1 条规则无法在本地判断，实际可能先命中:
+  #2 RuleSet,reject → REJECT（需要规则集数据）
+注意: 当前为 global 模式，规则不生效
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, renderRuleExplanation(&buf, exp, outputFormatTable))
+	assert.Contains(t, buf.String(), "undecided")
+	assert.Contains(t, buf.String(), "Dev → JP")
+
+	buf.Reset()
+	require.NoError(t, renderRuleExplanation(&buf, exp, outputFormatJSON))
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, []any{"Dev", "JP"}, decoded["chain"])
+
+	buf.Reset()
+	require.NoError(t, renderRuleExplanation(&buf, &service.RuleExplanation{Target: service.RuleTarget{IP: "10.0.0.1"}, Chain: []string{"DIRECT"}}, outputFormatPlain))
+	assert.Contains(t, buf.String(), "命中规则: 无（默认直连）")
+}
+
+func TestRenderRuleStats(t *testing.T) {
+	counter := service.NewRuleHitCounter()
+	counter.Observe([]model.Connection{{ID: "1", Rule: "DomainSuffix", RulePayload: "github.com", Download: 2048}})
+	rules := []model.Rule{
+		{Type: "DomainSuffix", Payload: "github.com", Proxy: "Dev"},
+		{Type: "Domain", Payload: "old.example.com", Proxy: "DIRECT"},
+	}
+	report := buildRuleStatsReport(rules, counter, time.Now().Add(-24*time.Hour), 24*time.Hour, false)
+	assert.Equal(t, 2, report.Total)
+	assert.Equal(t, 1, report.Dead)
+	assert.Equal(t, "1d", report.Window)
+
+	var buf bytes.Buffer
+	require.NoError(t, renderRuleStats(&buf, report, outputFormatPlain))
+	assert.Contains(t, buf.String(), "#1 DomainSuffix,github.com → Dev  命中 1  流量 2.0 KB")
+	assert.Contains(t, buf.String(), "✗ #2 Domain,old.example.com → DIRECT  无命中")
+	assert.Contains(t, buf.String(), "共 2 条规则，1 条在最近 1d 内无命中")
+
+	deadOnly := buildRuleStatsReport(rules, counter, time.Now().Add(-time.Hour), time.Hour, true)
+	require.Len(t, deadOnly.Rules, 1)
+	assert.Equal(t, 2, deadOnly.Rules[0].Index)
+
+	buf.Reset()
+	require.NoError(t, renderRuleStats(&buf, deadOnly, outputFormatTable))
+	assert.Contains(t, buf.String(), "LAST_HIT")
+	assert.Contains(t, buf.String(), "old.example.com")
+
+	buf.Reset()
+	require.NoError(t, renderRuleStats(&buf, report, outputFormatJSON))
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.EqualValues(t, 1, decoded["dead"])
+}
+
+func TestBuildRuleDraft(t *testing.T) {
+	cases := []struct {
+		arg, ruleType string
+		want          service.RuleDraft
+	}{
+		{"GitHub.com.", "", service.RuleDraft{Type: "DOMAIN-SUFFIX", Payload: "github.com", Target: "Dev"}},
+		{"api.github.com", "domain", service.RuleDraft{Type: "DOMAIN", Payload: "api.github.com", Target: "Dev"}},
+		{"1.2.3.4", "", service.RuleDraft{Type: "IP-CIDR", Payload: "1.2.3.4/32", Target: "Dev"}},
+		{"10.1.2.3/8", "ip-cidr", service.RuleDraft{Type: "IP-CIDR", Payload: "10.0.0.0/8", Target: "Dev"}},
+		{"2001:db8::1", "", service.RuleDraft{Type: "IP-CIDR6", Payload: "2001:db8::1/128", Target: "Dev"}},
+	}
+	for _, c := range cases {
+		got, err := buildRuleDraft(c.arg, c.ruleType, "Dev")
+		require.NoError(t, err, c.arg)
+		assert.Equal(t, c.want, got, c.arg)
+	}
+
+	_, err := buildRuleDraft("example.com", "ip-cidr", "Dev")
+	assert.ErrorContains(t, err, "域名不能使用")
+	_, err = buildRuleDraft("1.2.3.4", "domain", "Dev")
+	assert.ErrorContains(t, err, "IP 只能使用")
+	_, err = buildRuleDraft("example.com", "geoip", "Dev")
+	assert.ErrorContains(t, err, "不支持的规则类型")
+	_, err = buildRuleDraft("example.com", "", " ")
+	assert.ErrorContains(t, err, "--target")
+}
+
+func TestConfirm(t *testing.T) {
+	var out bytes.Buffer
+	assert.True(t, confirm(strings.NewReader("Y\n"), &out, "ok? "))
+	assert.Equal(t, "ok? ", out.String())
+	assert.False(t, confirm(strings.NewReader("\n"), &out, ""))
+	assert.False(t, confirm(strings.NewReader(""), &out, ""))
+}
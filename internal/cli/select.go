@@ -12,10 +12,22 @@ import (
 var selectCmd = &cobra.Command{
 	Use:   "select <group> <proxy>",
 	Short: "切换节点",
-	Long:  `切换指定策略组到目标节点。`,
+	Long: `切换指定策略组到目标节点。
+
+<proxy> 也可以是 mihosh alias 中定义的别名，正则别名取策略组中第一个匹配的节点。`,
 	Example: `  mihosh select Proxy "HK 01"
-  mihosh select Auto SG-BGP`,
+  mihosh select Auto SG-BGP
+  mihosh select Proxy hk`,
 	Args: cobra.ExactArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		switch len(args) {
+		case 0:
+			return completeGroupNames()
+		case 1:
+			return completeProxyNames(args[0])
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cfg, err := config.Load()
 		if err != nil {
@@ -25,11 +37,16 @@ var selectCmd = &cobra.Command{
 		client := api.NewClient(cfg)
 		proxySvc := service.NewProxyService(client, cfg.TestURL, cfg.Timeout)
 
-		if err := proxySvc.SelectProxy(args[0], args[1]); err != nil {
+		proxyName, err := resolveProxyArg(cfg, args[1], groupMembersLoader(proxySvc, args[0]))
+		if err != nil {
+			return err
+		}
+
+		if err := proxySvc.SelectProxy(args[0], proxyName); err != nil {
 			return wrapNetworkError(fmt.Errorf("切换节点失败: %w", err))
 		}
 
-		fmt.Printf("✓ 已将策略组 '%s' 切换到节点 '%s'\n", args[0], args[1])
+		fmt.Printf("✓ 已将策略组 '%s' 切换到节点 '%s'\n", args[0], proxyName)
 		return nil
 	},
 }
@@ -1,9 +1,12 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"strings"
 
 	"github.com/aimony/mihosh/internal/app/service"
@@ -25,6 +28,7 @@ const (
 var (
 	testOutput      string
 	testGroupOutput string
+	testConcurrency int
 )
 
 var testCmd = &cobra.Command{
@@ -35,11 +39,19 @@ var testCmd = &cobra.Command{
 可通过 --output 选择输出格式：
   plain  人类可读文本（默认）
   table  表格输出
-  json   结构化 JSON 输出`,
+  json   结构化 JSON 输出
+
+测试策略组时使用核心的 /group/{name}/delay 接口一次完成整组测速，
+逐个输出节点结果（json 为 NDJSON 事件流），Ctrl+C 可中止。
+测试单个节点时可使用 mihosh alias 中定义的别名。
+旧版核心不支持该接口时退回逐节点并发测速，并发数默认读取配置项
+test_concurrency，可用 --concurrency 临时覆盖。`,
 	Example: `  mihosh test
   mihosh test --output json
   mihosh test node HK --output table
-  mihosh test group Auto --output json`,
+  mihosh test node hk
+  mihosh test group Auto --output json
+  mihosh test group Auto --concurrency 50`,
 	Args: func(cmd *cobra.Command, args []string) error {
 		_, _, err := resolveTestAction(args)
 		if err != nil {
@@ -47,6 +59,17 @@ var testCmd = &cobra.Command{
 		}
 		return nil
 	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		switch {
+		case len(args) == 0:
+			return []string{string(actionNode), string(actionGroup)}, cobra.ShellCompDirectiveNoFileComp
+		case len(args) == 1 && args[0] == string(actionNode):
+			return completeProxyNames("")
+		case len(args) == 1 && args[0] == string(actionGroup):
+			return completeGroupNames()
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		format, err := parseOutputFormat(testOutput)
 		if err != nil {
@@ -59,14 +82,22 @@ var testCmd = &cobra.Command{
 		}
 
 		client := api.NewClient(cfg)
-		proxySvc := service.NewProxyService(client, cfg.TestURL, cfg.Timeout)
+		proxySvc := newTestProxyService(client, cfg)
 
 		action, target, err := resolveTestAction(args)
 		if err != nil {
 			return wrapParameterError(err)
 		}
+		if action == actionNode {
+			if target, err = resolveProxyArg(cfg, target, allProxiesLoader(proxySvc)); err != nil {
+				return err
+			}
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
 
-		if err := runTestAction(os.Stdout, proxySvc, cfg.ProxyAddress, action, target, format); err != nil {
+		if err := runTestAction(ctx, os.Stdout, proxySvc, cfg.ProxyAddress, action, target, format); err != nil {
 			return wrapNetworkError(err)
 		}
 		return nil
@@ -90,9 +121,12 @@ var testGroupCmd = &cobra.Command{
 		}
 
 		client := api.NewClient(cfg)
-		proxySvc := service.NewProxyService(client, cfg.TestURL, cfg.Timeout)
+		proxySvc := newTestProxyService(client, cfg)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
 
-		if err := runTestAction(os.Stdout, proxySvc, cfg.ProxyAddress, actionGroup, args[0], format); err != nil {
+		if err := runTestAction(ctx, os.Stdout, proxySvc, cfg.ProxyAddress, actionGroup, args[0], format); err != nil {
 			return wrapNetworkError(err)
 		}
 		return nil
@@ -102,6 +136,22 @@ var testGroupCmd = &cobra.Command{
 func init() {
 	testCmd.Flags().StringVar(&testOutput, "output", string(outputFormatPlain), "输出格式: json|table|plain")
 	testGroupCmd.Flags().StringVar(&testGroupOutput, "output", string(outputFormatPlain), "输出格式: json|table|plain")
+	for _, cmd := range []*cobra.Command{testCmd, testGroupCmd} {
+		cmd.Flags().IntVar(&testConcurrency, "concurrency", 0, "批量测速并发数（默认读取配置 test_concurrency）")
+	}
+}
+
+// newTestProxyService 创建测速服务，--concurrency 优先于配置项
+func newTestProxyService(client *api.Client, cfg *config.Config) *service.ProxyService {
+	proxySvc := service.NewProxyService(client, cfg.TestURL, cfg.Timeout)
+	proxySvc.SetConcurrency(cfg.TestConcurrency)
+	if testConcurrency > 0 {
+		proxySvc.SetConcurrency(testConcurrency)
+	}
+	if geo, err := service.NewGeoProviderFromConfig(cfg); err == nil {
+		proxySvc.SetGeoProvider(geo)
+	}
+	return proxySvc
 }
 
 func resolveTestAction(args []string) (testAction, string, error) {
@@ -121,7 +171,7 @@ func resolveTestAction(args []string) (testAction, string, error) {
 	return "", "", fmt.Errorf("参数格式错误。请使用：mihosh test | mihosh test node <节点名> | mihosh test group <策略组名>")
 }
 
-func runTestAction(w io.Writer, proxySvc *service.ProxyService, proxyAddress string, action testAction, target string, format outputFormat) error {
+func runTestAction(ctx context.Context, w io.Writer, proxySvc *service.ProxyService, proxyAddress string, action testAction, target string, format outputFormat) error {
 	switch action {
 	case actionCurrent:
 		node, found, err := currentSelectedNode(proxySvc)
@@ -158,10 +208,30 @@ func runTestAction(w io.Writer, proxySvc *service.ProxyService, proxyAddress str
 		return renderNodeTestOutput(w, target, delay, format)
 
 	case actionGroup:
-		if err := proxySvc.TestGroupDelay(target); err != nil {
+		groups, _, err := proxySvc.GetGroups()
+		if err != nil {
+			return fmt.Errorf("获取策略组失败: %w", err)
+		}
+		group, ok := groups[target]
+		if !ok {
+			return fmt.Errorf("策略组不存在: %s", target)
+		}
+
+		reporter := newGroupTestReporter(w, target, group.All, format)
+		reporter.Start()
+		results, err := proxySvc.TestGroupDelayContext(ctx, target)
+		switch {
+		case err == nil:
+			reporter.ReportAll(results)
+		case api.IsNotFound(err):
+			// 旧版核心不支持 /group/{name}/delay，退回逐节点并发测速
+			results = proxySvc.TestProxiesContext(ctx, group.All, reporter.Report)
+		case ctx.Err() != nil:
+			results = nil
+		default:
 			return fmt.Errorf("批量测速失败: %w", err)
 		}
-		return renderGroupTestOutput(w, target, format)
+		return reporter.Finish(results, ctx.Err() != nil)
 	}
 
 	return fmt.Errorf("不支持的测试动作: %s", action)
@@ -256,26 +326,130 @@ func renderNodeTestOutput(w io.Writer, node string, delay int, format outputForm
 	}
 }
 
-func renderGroupTestOutput(w io.Writer, group string, format outputFormat) error {
-	switch format {
+// groupTestReporter 策略组测速的流式输出（plain 逐行进度，json 为 NDJSON 事件，table 结束后汇总）
+// Report 由 ProxyService 串行回调，无需额外加锁
+type groupTestReporter struct {
+	w      io.Writer
+	group  string
+	nodes  []string
+	format outputFormat
+	done   int
+	failed int
+	errs   map[string]string
+}
+
+type groupTestEvent struct {
+	Event     string `json:"event"`
+	Group     string `json:"group"`
+	Node      string `json:"node,omitempty"`
+	DelayMS   *int   `json:"delay_ms,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Done      int    `json:"done"`
+	Total     int    `json:"total"`
+	Success   *int   `json:"success,omitempty"`
+	Failed    *int   `json:"failed,omitempty"`
+	Cancelled bool   `json:"cancelled,omitempty"`
+}
+
+func newGroupTestReporter(w io.Writer, group string, nodes []string, format outputFormat) *groupTestReporter {
+	return &groupTestReporter{
+		w:      w,
+		group:  group,
+		nodes:  nodes,
+		format: format,
+		errs:   make(map[string]string),
+	}
+}
+
+// Start 输出开始提示（仅 plain）
+func (r *groupTestReporter) Start() {
+	if r.format == outputFormatPlain {
+		fmt.Fprintf(r.w, "正在测速策略组 '%s'（%d 个节点）...\n", r.group, len(r.nodes))
+	}
+}
+
+// ReportAll 按组成员顺序输出整组测速结果（-1 表示失败）
+func (r *groupTestReporter) ReportAll(results map[string]int) {
+	for _, node := range r.nodes {
+		delay, ok := results[node]
+		if !ok {
+			continue
+		}
+		res := service.DelayResult{Name: node, Delay: delay}
+		if delay < 0 {
+			res.Err = errors.New("timeout or error")
+		}
+		r.Report(res)
+	}
+}
+
+// Report 输出单个节点的测速结果
+func (r *groupTestReporter) Report(res service.DelayResult) {
+	r.done++
+	if res.Err != nil {
+		r.failed++
+		r.errs[res.Name] = res.Err.Error()
+	}
+
+	switch r.format {
 	case outputFormatJSON:
-		return writeJSON(w, map[string]interface{}{
-			"action": "group",
-			"group":  group,
-			"status": "completed",
+		event := groupTestEvent{Event: "result", Group: r.group, Node: res.Name, Done: r.done, Total: len(r.nodes)}
+		if res.Err != nil {
+			event.Error = res.Err.Error()
+		} else {
+			delay := res.Delay
+			event.DelayMS = &delay
+		}
+		writeNDJSON(r.w, event)
+	case outputFormatPlain:
+		width := len(fmt.Sprint(len(r.nodes)))
+		if res.Err != nil {
+			fmt.Fprintf(r.w, "[%*d/%d] ✗ %s: %s\n", width, r.done, len(r.nodes), res.Name, res.Err.Error())
+		} else {
+			fmt.Fprintf(r.w, "[%*d/%d] ✓ %s: %dms\n", width, r.done, len(r.nodes), res.Name, res.Delay)
+		}
+	}
+}
+
+// Finish 输出汇总信息
+func (r *groupTestReporter) Finish(results map[string]int, cancelled bool) error {
+	success := r.done - r.failed
+	switch r.format {
+	case outputFormatJSON:
+		failed := r.failed
+		return writeNDJSON(r.w, groupTestEvent{
+			Event:     "summary",
+			Group:     r.group,
+			Done:      r.done,
+			Total:     len(r.nodes),
+			Success:   &success,
+			Failed:    &failed,
+			Cancelled: cancelled,
 		})
 	case outputFormatTable:
-		tw := newTabWriter(w)
-		fmt.Fprintln(tw, "KEY\tVALUE")
-		fmt.Fprintf(tw, "ACTION\t%s\n", actionGroup)
-		fmt.Fprintf(tw, "GROUP\t%s\n", group)
-		fmt.Fprintln(tw, "STATUS\tcompleted")
+		tw := newTabWriter(r.w)
+		fmt.Fprintln(tw, "NODE\tDELAY_MS\tSTATUS")
+		for _, node := range r.nodes {
+			delay, ok := results[node]
+			switch {
+			case !ok:
+				fmt.Fprintf(tw, "%s\t-\tcancelled\n", node)
+			case delay < 0:
+				fmt.Fprintf(tw, "%s\t-\tfailed: %s\n", node, r.errs[node])
+			default:
+				fmt.Fprintf(tw, "%s\t%d\tok\n", node, delay)
+			}
+		}
 		return tw.Flush()
 	case outputFormatPlain:
-		fmt.Fprintf(w, "✓ 策略组 '%s' 测速完成\n", group)
+		if cancelled {
+			fmt.Fprintf(r.w, "⚠ 策略组 '%s' 测速已取消: 已完成 %d/%d，成功 %d，失败 %d\n", r.group, r.done, len(r.nodes), success, r.failed)
+			return nil
+		}
+		fmt.Fprintf(r.w, "✓ 策略组 '%s' 测速完成: 成功 %d，失败 %d\n", r.group, success, r.failed)
 		return nil
 	default:
-		return fmt.Errorf("不支持的输出格式: %s", format)
+		return fmt.Errorf("不支持的输出格式: %s", r.format)
 	}
 }
 
@@ -2,9 +2,12 @@ package cli
 
 import (
 	"bytes"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/aimony/mihosh/internal/app/service"
 	"github.com/aimony/mihosh/internal/domain/model"
 )
 
@@ -145,3 +148,47 @@ func TestResolveCurrentSelectedNode(t *testing.T) {
 		})
 	}
 }
+
+func TestGroupTestReporterJSONStreamsEvents(t *testing.T) {
+	var out bytes.Buffer
+	reporter := newGroupTestReporter(&out, "Auto", []string{"HK", "US", "JP"}, outputFormatJSON)
+	reporter.Report(service.DelayResult{Name: "HK", Delay: 42})
+	reporter.Report(service.DelayResult{Name: "US", Delay: -1, Err: errors.New("timeout")})
+	assert.NoError(t, reporter.Finish(map[string]int{"HK": 42, "US": -1}, true))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, `{"event":"result","group":"Auto","node":"HK","delay_ms":42,"done":1,"total":3}`, lines[0])
+	assert.Contains(t, lines[1], `"error":"timeout"`)
+	assert.Contains(t, lines[2], `"event":"summary"`)
+	assert.Contains(t, lines[2], `"success":1`)
+	assert.Contains(t, lines[2], `"cancelled":true`)
+}
+
+func TestGroupTestReporterPlainAndTable(t *testing.T) {
+	var plain bytes.Buffer
+	reporter := newGroupTestReporter(&plain, "Auto", []string{"HK", "US"}, outputFormatPlain)
+	reporter.Report(service.DelayResult{Name: "HK", Delay: 42})
+	assert.NoError(t, reporter.Finish(map[string]int{"HK": 42}, false))
+	assert.Contains(t, plain.String(), "[1/2] ✓ HK: 42ms")
+	assert.Contains(t, plain.String(), "测速完成")
+
+	var table bytes.Buffer
+	reporter = newGroupTestReporter(&table, "Auto", []string{"HK", "US"}, outputFormatTable)
+	reporter.Report(service.DelayResult{Name: "HK", Delay: 42})
+	assert.Empty(t, table.String())
+	assert.NoError(t, reporter.Finish(map[string]int{"HK": 42}, true))
+	assert.Contains(t, table.String(), "NODE")
+	assert.Contains(t, table.String(), "cancelled")
+}
+
+func TestGroupTestReporterReportAllFollowsGroupOrder(t *testing.T) {
+	var out bytes.Buffer
+	reporter := newGroupTestReporter(&out, "Auto", []string{"US", "HK"}, outputFormatPlain)
+	reporter.ReportAll(map[string]int{"HK": 42, "US": -1})
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "✗ US")
+	assert.Contains(t, lines[1], "✓ HK: 42ms")
+}
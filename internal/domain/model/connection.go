@@ -12,6 +12,10 @@ type Connection struct {
 	RulePayload   string   `json:"rulePayload"`
 	DownloadSpeed int64    `json:"downloadSpeed"`
 	UploadSpeed   int64    `json:"uploadSpeed"`
+	// Geo 由 mihosh 本地补全的目标国家与 ASN，不来自 API
+	Geo *GeoTag `json:"-"`
+	// SourceName 由 mihosh 识别的来源设备名（容器、主机名等），不来自 API
+	SourceName string `json:"-"`
 }
 
 // Metadata 连接元数据
@@ -5,6 +5,10 @@ const (
 	// 测速相关
 	TestConcurrency = 20
 	IPApiURL        = "http://ip-api.com/json?fields=61439"
+	// IPEchoURL 只返回出口 IP（地理信息改由本地 MMDB 查询）
+	IPEchoURL = "http://ip-api.com/json?fields=query"
+	// DNSEchoURL 返回解析该域名的 DNS 服务器（%s 为随机前缀，避免命中缓存）
+	DNSEchoURL = "http://%s.edns.ip-api.com/json"
 
 	// 数据容量 (Ring Buffer)
 	ClosedConnCap = 1000
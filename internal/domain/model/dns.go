@@ -0,0 +1,15 @@
+package model
+
+// DNSQueryResponse mihomo /dns/query 的响应
+type DNSQueryResponse struct {
+	Status int         `json:"Status"`
+	Answer []DNSAnswer `json:"Answer"`
+}
+
+// DNSAnswer DNS 应答记录
+type DNSAnswer struct {
+	Name string `json:"name"`
+	Type int    `json:"type"`
+	TTL  int    `json:"TTL"`
+	Data string `json:"data"`
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+)
+
+// GeoTag 目标 IP 的国家与 ASN（连接表列与 country:/asn: 过滤使用）
+type GeoTag struct {
+	CountryCode string `json:"country_code,omitempty"`
+	ASN         int    `json:"asn,omitempty"`
+	ASNOrg      string `json:"asn_organization,omitempty"`
+}
+
+// Empty 是否没有任何信息
+func (t GeoTag) Empty() bool {
+	return t.CountryCode == "" && t.ASN == 0 && t.ASNOrg == ""
+}
+
+// DestinationGeo 目标 IP 的国家与 ASN：mihosh 补全的结果优先，其次为 mihomo 推送的 GeoIP/ASN 字段
+func (c Connection) DestinationGeo() GeoTag {
+	var tag GeoTag
+	if c.Geo != nil {
+		tag = *c.Geo
+	}
+	if tag.CountryCode == "" {
+		tag.CountryCode = geoIPCountry(c.Metadata.DestinationGeoIP)
+	}
+	if tag.ASN == 0 && tag.ASNOrg == "" {
+		tag.ASN, tag.ASNOrg = ParseASN(c.Metadata.DestinationIPASN)
+	}
+	return tag
+}
+
+// geoIPCountry 从 mihomo 的 destinationGeoIP（代码列表）中取第一个国家代码
+func geoIPCountry(v interface{}) string {
+	var codes []string
+	switch x := v.(type) {
+	case string:
+		codes = []string{x}
+	case []string:
+		codes = x
+	case []interface{}:
+		for _, item := range x {
+			if s, ok := item.(string); ok {
+				codes = append(codes, s)
+			}
+		}
+	}
+	for _, code := range codes {
+		if len(code) == 2 {
+			return strings.ToUpper(code)
+		}
+	}
+	return ""
+}
+
+// ParseASN 解析 "AS13335 Cloudflare" 或 "13335 Cloudflare" 形式的 ASN 描述
+func ParseASN(s string) (int, string) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, ""
+	}
+	num, org, _ := strings.Cut(s, " ")
+	n, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(num), "AS"))
+	if err != nil {
+		return 0, s
+	}
+	return n, strings.TrimSpace(org)
+}
@@ -0,0 +1,10 @@
+package model
+
+// NodeMeta 从节点名称与核心字段推导出的节点元数据
+type NodeMeta struct {
+	Region     string  // 地区代码（如 HK、JP），无法识别时为空
+	Protocol   string  // 协议类型（如 Shadowsocks、Vmess）
+	UDP        bool    // 是否支持 UDP
+	Multiplier float64 // 流量倍率，未标注时为 1
+	Provider   string  // 所属 proxy-provider
+}
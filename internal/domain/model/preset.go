@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+// SelectionPreset 策略组选择快照（预设）
+type SelectionPreset struct {
+	Name       string            `json:"name"`
+	CreatedAt  time.Time         `json:"created_at"`
+	Selections map[string]string `json:"selections"` // 策略组 -> 选中节点
+}
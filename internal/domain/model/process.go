@@ -0,0 +1,13 @@
+package model
+
+// ProcessInfo 本机进程信息（来自 /proc）
+type ProcessInfo struct {
+	PID         int    // 进程号，无权限读取时为 0
+	Name        string // 进程名（comm）
+	Path        string // 可执行文件路径
+	UID         int    // 所属用户 UID
+	User        string // 所属用户名
+	Unit        string // systemd 单元（.service / .scope）
+	Cgroup      string // cgroup 路径
+	ContainerID string // 容器 ID（docker / containerd / podman）
+}
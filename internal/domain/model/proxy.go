@@ -2,12 +2,24 @@ package model
 
 // Proxy 代理信息
 type Proxy struct {
-	Name    string   `json:"name"`
-	Type    string   `json:"type"`
-	UDP     bool     `json:"udp"`
-	History []Delay  `json:"history"`
-	All     []string `json:"all,omitempty"`
-	Now     string   `json:"now,omitempty"`
+	Name         string                `json:"name"`
+	Type         string                `json:"type"`
+	UDP          bool                  `json:"udp"`
+	XUDP         bool                  `json:"xudp"`
+	TFO          bool                  `json:"tfo"`
+	MPTCP        bool                  `json:"mptcp"`
+	DialerProxy  string                `json:"dialer-proxy,omitempty"`
+	ProviderName string                `json:"provider-name,omitempty"`
+	History      []Delay               `json:"history"`
+	Extra        map[string]ProxyExtra `json:"extra,omitempty"` // 按测速 URL 区分的额外延迟历史
+	All          []string              `json:"all,omitempty"`
+	Now          string                `json:"now,omitempty"`
+}
+
+// ProxyExtra 指定测速 URL 下的延迟历史
+type ProxyExtra struct {
+	Alive   bool    `json:"alive"`
+	History []Delay `json:"history"`
 }
 
 // Delay 延迟信息
@@ -17,6 +29,14 @@ type Delay struct {
 	Error string `json:"error,omitempty"`
 }
 
+// LastDelay 返回最近一次测速延迟（未测速为 0，失败为 0 或负数）
+func (p Proxy) LastDelay() int {
+	if len(p.History) == 0 {
+		return 0
+	}
+	return p.History[len(p.History)-1].Delay
+}
+
 // ProxiesResponse 代理列表响应
 type ProxiesResponse struct {
 	Proxies map[string]Proxy `json:"proxies"`
@@ -7,4 +7,5 @@ type ResolvedIP struct {
 	NetworkType string // "docker", "tailscale", "local", "lan", "unknown"
 	AppName     string // 容器名 / 机器名 / 降级提示
 	AppDetail   string // 容器镜像 / OS / 额外信息
+	Guessed     bool   // 仅按网段推测，AppName 为提示文字
 }
@@ -2,7 +2,9 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -20,6 +22,22 @@ type Client struct {
 	httpClient *http.Client
 }
 
+// StatusError API 返回非成功状态码
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API 请求失败: %s", e.Status)
+}
+
+// IsNotFound 判断错误是否为 404（如旧版核心不支持的接口）
+func IsNotFound(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
+
 // NewClient 创建新的 API 客户端
 func NewClient(cfg *config.Config) *Client {
 	return &Client{
@@ -33,6 +51,11 @@ func NewClient(cfg *config.Config) *Client {
 
 // DoRequest 执行 HTTP 请求（导出供 endpoints 使用）
 func (c *Client) DoRequest(method, path string, body interface{}) ([]byte, error) {
+	return c.DoRequestContext(context.Background(), method, path, body)
+}
+
+// DoRequestContext 执行可取消的 HTTP 请求
+func (c *Client) DoRequestContext(ctx context.Context, method, path string, body interface{}) ([]byte, error) {
 	url := c.baseURL + path
 
 	var reqBody io.Reader
@@ -44,7 +67,7 @@ func (c *Client) DoRequest(method, path string, body interface{}) ([]byte, error
 		reqBody = bytes.NewBuffer(jsonData)
 	}
 
-	req, err := http.NewRequest(method, url, reqBody)
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
 	if err != nil {
 		return nil, err
 	}
@@ -61,12 +84,19 @@ func (c *Client) DoRequest(method, path string, body interface{}) ([]byte, error
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		return nil, fmt.Errorf("API 请求失败: %s", resp.Status)
+		return nil, &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
 	}
 
 	return io.ReadAll(resp.Body)
 }
 
+// NewDirectHTTPClient 创建一个直连的 HTTP 客户端，忽略 HTTP_PROXY 等环境变量
+func (c *Client) NewDirectHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	return &http.Client{Timeout: c.httpClient.Timeout, Transport: transport}
+}
+
 // NewHTTPClientWithProxy 创建一个带代理的 HTTP 客户端
 func (c *Client) NewHTTPClientWithProxy(proxyAddr string) (*http.Client, error) {
 	if proxyAddr == "" {
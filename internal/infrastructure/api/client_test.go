@@ -0,0 +1,21 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDirectHTTPClientIgnoresEnvironmentProxy(t *testing.T) {
+	t.Setenv("HTTP_PROXY", "http://127.0.0.1:1")
+	t.Setenv("HTTPS_PROXY", "http://127.0.0.1:1")
+
+	client := NewClient(&config.Config{Timeout: 3000}).NewDirectHTTPClient()
+	transport, ok := client.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.Nil(t, transport.Proxy)
+	assert.Equal(t, 3000_000_000, int(client.Timeout))
+}
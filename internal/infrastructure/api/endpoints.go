@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
@@ -47,9 +48,14 @@ func (c *Client) SelectProxy(group, proxy string) error {
 
 // TestProxyDelay 测试单个代理延迟
 func (c *Client) TestProxyDelay(name, testURL string, timeout int) (int, error) {
+	return c.TestProxyDelayContext(context.Background(), name, testURL, timeout)
+}
+
+// TestProxyDelayContext 测试单个代理延迟（可通过 ctx 取消）
+func (c *Client) TestProxyDelayContext(ctx context.Context, name, testURL string, timeout int) (int, error) {
 	path := fmt.Sprintf("/proxies/%s/delay?url=%s&timeout=%d",
 		url.PathEscape(name), url.QueryEscape(testURL), timeout)
-	data, err := c.DoRequest("GET", path, nil)
+	data, err := c.DoRequestContext(ctx, "GET", path, nil)
 	if err != nil {
 		return 0, err
 	}
@@ -62,12 +68,26 @@ func (c *Client) TestProxyDelay(name, testURL string, timeout int) (int, error)
 	return resp.Delay, nil
 }
 
-// TestGroupDelay 测试策略组内所有节点延迟
-func (c *Client) TestGroupDelay(group, testURL string, timeout int) error {
-	path := fmt.Sprintf("/proxies/%s/delay?url=%s&timeout=%d",
+// TestGroupDelay 测试策略组内所有节点延迟，返回节点名到延迟的映射（失败节点不在结果中）
+func (c *Client) TestGroupDelay(group, testURL string, timeout int) (map[string]int, error) {
+	return c.TestGroupDelayContext(context.Background(), group, testURL, timeout)
+}
+
+// TestGroupDelayContext 调用 /group/{name}/delay 一次性测速整个策略组（可通过 ctx 取消）
+func (c *Client) TestGroupDelayContext(ctx context.Context, group, testURL string, timeout int) (map[string]int, error) {
+	path := fmt.Sprintf("/group/%s/delay?url=%s&timeout=%d",
 		url.PathEscape(group), url.QueryEscape(testURL), timeout)
-	_, err := c.DoRequest("GET", path, nil)
-	return err
+	data, err := c.DoRequestContext(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]int)
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
 }
 
 // GetGroups 获取所有策略组，返回策略组map和按配置文件顺序排列的组名列表
@@ -174,6 +194,22 @@ func (c *Client) GetRules() (*model.RulesResponse, error) {
 	return &resp, nil
 }
 
+// QueryDNS 通过 mihomo 的 DNS 模块查询记录（qtype 如 A、AAAA、PTR）
+func (c *Client) QueryDNS(ctx context.Context, name, qtype string) (*model.DNSQueryResponse, error) {
+	path := fmt.Sprintf("/dns/query?name=%s&type=%s", url.QueryEscape(name), url.QueryEscape(qtype))
+	data, err := c.DoRequestContext(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp model.DNSQueryResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
 // ReloadConfig 通知 mihomo 核心重新加载配置文件
 func (c *Client) ReloadConfig(configPath string) error {
 	payload := map[string]string{"path": configPath}
@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/aimony/mihosh/internal/domain/model"
 	"github.com/gorilla/websocket"
 )
 
@@ -28,39 +29,14 @@ type TrafficData struct {
 	Down int64 `json:"down"`
 }
 
-// ConnectionsData 连接数据（与ConnectionsResponse结构相同）
-type ConnectionsData struct {
-	DownloadTotal int64            `json:"downloadTotal"`
-	UploadTotal   int64            `json:"uploadTotal"`
-	Connections   []ConnectionData `json:"connections"`
-}
+// ConnectionsData 连接推送数据，与 REST 接口共用 model 结构，保证全部元数据字段都能透传
+type ConnectionsData = model.ConnectionsResponse
 
 // ConnectionData 单个连接数据
-type ConnectionData struct {
-	ID            string         `json:"id"`
-	Metadata      ConnectionMeta `json:"metadata"`
-	Upload        int64          `json:"upload"`
-	Download      int64          `json:"download"`
-	Start         string         `json:"start"`
-	Chains        []string       `json:"chains"`
-	Rule          string         `json:"rule"`
-	RulePayload   string         `json:"rulePayload"`
-	DownloadSpeed int64          `json:"downloadSpeed"`
-	UploadSpeed   int64          `json:"uploadSpeed"`
-}
+type ConnectionData = model.Connection
 
 // ConnectionMeta 连接元数据
-type ConnectionMeta struct {
-	Network         string `json:"network"`
-	Type            string `json:"type"`
-	SourceIP        string `json:"sourceIP"`
-	DestinationIP   string `json:"destinationIP"`
-	SourcePort      string `json:"sourcePort"`
-	DestinationPort string `json:"destinationPort"`
-	Host            string `json:"host"`
-	Process         string `json:"process"`
-	ProcessPath     string `json:"processPath"`
-}
+type ConnectionMeta = model.Metadata
 
 // LogData 日志数据
 type LogData struct {
@@ -168,26 +144,19 @@ func (c *WSClient) Start() error {
 		level = "debug"
 	}
 
-	go connectStream(c, "memory", func(d MemoryData) {
-		if c.memoryHandler != nil {
-			c.memoryHandler(d)
-		}
-	})
-	go connectStream(c, "traffic", func(d TrafficData) {
-		if c.trafficHandler != nil {
-			c.trafficHandler(d)
-		}
-	})
-	go connectStream(c, "connections", func(d ConnectionsData) {
-		if c.connectionsHandler != nil {
-			c.connectionsHandler(d)
-		}
-	})
-	go connectStream(c, "logs?level="+level, func(d LogData) {
-		if c.logsHandler != nil {
-			c.logsHandler(d)
-		}
-	})
+	// 未设置处理器的流不建立连接（无界面模式只订阅需要的数据）
+	if c.memoryHandler != nil {
+		go connectStream(c, "memory", c.memoryHandler)
+	}
+	if c.trafficHandler != nil {
+		go connectStream(c, "traffic", c.trafficHandler)
+	}
+	if c.connectionsHandler != nil {
+		go connectStream(c, "connections", c.connectionsHandler)
+	}
+	if c.logsHandler != nil {
+		go connectStream(c, "logs?level="+level, c.logsHandler)
+	}
 
 	return nil
 }
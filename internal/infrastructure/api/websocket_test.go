@@ -0,0 +1,33 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectionsDataDecodesFullMetadata(t *testing.T) {
+	payload := `{"downloadTotal":1,"uploadTotal":2,"connections":[{"id":"c1","chains":["DIRECT"],
+		"metadata":{"network":"tcp","type":"HTTPS","sourceIP":"10.0.0.2","destinationIP":"1.1.1.1",
+		"sourceGeoIP":["CN"],"destinationGeoIP":["US"],"sourceIPASN":"AS4134","destinationIPASN":"AS13335",
+		"inboundName":"DEFAULT-MIXED","inboundUser":"alice","dnsMode":"fake-ip","uid":1000,
+		"specialProxy":"HK 01","sniffHost":"one.one.one.one","remoteDestination":"1.1.1.1","dscp":46}}]}`
+
+	var data ConnectionsData
+	require.NoError(t, json.Unmarshal([]byte(payload), &data))
+	require.Len(t, data.Connections, 1)
+
+	meta := data.Connections[0].Metadata
+	assert.Equal(t, "DEFAULT-MIXED", meta.InboundName)
+	assert.Equal(t, "alice", meta.InboundUser)
+	assert.Equal(t, 1000, meta.UID)
+	assert.Equal(t, "fake-ip", meta.DNSMode)
+	assert.Equal(t, "HK 01", meta.SpecialProxy)
+	assert.Equal(t, "one.one.one.one", meta.SniffHost)
+	assert.Equal(t, "1.1.1.1", meta.RemoteDestination)
+	assert.Equal(t, "AS13335", meta.DestinationIPASN)
+	assert.Equal(t, 46, meta.DSCP)
+	assert.NotNil(t, meta.SourceGeoIP)
+}
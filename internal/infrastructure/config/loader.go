@@ -202,6 +202,12 @@ func buildMihomoConfigPathNotFoundError() error {
 
 // Load 加载配置文件
 func Load() (*Config, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	return load()
+}
+
+func load() (*Config, error) {
 	configDir, err := GetConfigDir()
 	if err != nil {
 		return nil, err
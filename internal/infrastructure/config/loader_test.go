@@ -2,8 +2,10 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -73,3 +75,43 @@ func TestGetMihomoConfigPathFromProcessFindsConfigInSystemctlDirectory(t *testin
 	require.NoError(t, err)
 	assert.Equal(t, configPath, path)
 }
+
+func TestLoadIPNamesWithDottedAddresses(t *testing.T) {
+	t.Cleanup(func() {
+		viper.Reset()
+	})
+	viper.Reset()
+
+	tempHome := t.TempDir()
+	t.Setenv("HOME", tempHome)
+	t.Setenv("USERPROFILE", tempHome)
+	t.Setenv("HOMEDRIVE", "")
+	t.Setenv("HOMEPATH", "")
+
+	configDir := filepath.Join(tempHome, ".mihosh")
+	require.NoError(t, os.MkdirAll(configDir, 0755))
+	content := `api_address: http://127.0.0.1:9090
+ip_names:
+  - ip: 192.168.1.5
+    name: NAS
+    detail: 群晖 DS920+
+  - ip: fd00::1
+    name: 路由器
+`
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(content), 0644))
+
+	loaded, err := Load()
+	require.NoError(t, err)
+	want := []IPName{
+		{IP: "192.168.1.5", Name: "NAS", Detail: "群晖 DS920+"},
+		{IP: "fd00::1", Name: "路由器"},
+	}
+	assert.Equal(t, want, loaded.IPNames)
+
+	// 保存后重新读取仍保持不变
+	require.NoError(t, Save(loaded))
+	viper.Reset()
+	reloaded, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, want, reloaded.IPNames)
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+)
+
+// presetsFileName 预设单独存放，避免 viper 将策略组名转为小写
+const presetsFileName = "presets.json"
+
+// GetPresetsPath 获取选择预设文件路径
+func GetPresetsPath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, presetsFileName), nil
+}
+
+// LoadPresets 读取全部选择预设（文件不存在时返回空集合）
+func LoadPresets() (map[string]model.SelectionPreset, error) {
+	path, err := GetPresetsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]model.SelectionPreset{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	presets := map[string]model.SelectionPreset{}
+	if err := json.Unmarshal(data, &presets); err != nil {
+		return nil, fmt.Errorf("解析预设文件失败: %w", err)
+	}
+	return presets, nil
+}
+
+// SavePresets 写入全部选择预设
+func SavePresets(presets map[string]model.SelectionPreset) error {
+	path, err := GetPresetsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(presets, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// 先写临时文件再替换，避免写入中断导致预设丢失
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
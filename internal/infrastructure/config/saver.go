@@ -3,12 +3,36 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/spf13/viper"
 )
 
+// mu 串行化对全局 viper 实例的读写：TUI 中的每次切换都在独立的 goroutine 中保存配置
+var mu sync.Mutex
+
 // Save 保存配置文件
 func Save(cfg *Config) error {
+	mu.Lock()
+	defer mu.Unlock()
+	return save(cfg)
+}
+
+// Update 读取配置交给 fn 修改后保存，期间不会有其它读写插入，避免并发保存互相覆盖
+func Update(fn func(cfg *Config) error) error {
+	mu.Lock()
+	defer mu.Unlock()
+	cfg, err := load()
+	if err != nil {
+		return err
+	}
+	if err := fn(cfg); err != nil {
+		return err
+	}
+	return save(cfg)
+}
+
+func save(cfg *Config) error {
 	configDir, err := GetConfigDir()
 	if err != nil {
 		return err
@@ -26,6 +50,59 @@ func Save(cfg *Config) error {
 	viper.Set("test_url", cfg.TestURL)
 	viper.Set("timeout", cfg.Timeout)
 	viper.Set("proxy_address", cfg.ProxyAddress)
+	viper.Set("test_concurrency", cfg.TestConcurrency)
+	// 已写入过的配置项即使清空也要覆盖，否则删除不会生效
+	if len(cfg.NodeRegionPatterns) > 0 || viper.IsSet("node_region_patterns") {
+		viper.Set("node_region_patterns", cfg.NodeRegionPatterns)
+	}
+	if cfg.NodeMultiplierPattern != "" || viper.IsSet("node_multiplier_pattern") {
+		viper.Set("node_multiplier_pattern", cfg.NodeMultiplierPattern)
+	}
+	if len(cfg.FavoriteProxies) > 0 || viper.IsSet("favorite_proxies") {
+		viper.Set("favorite_proxies", cfg.FavoriteProxies)
+	}
+	if len(cfg.FavoriteGroups) > 0 || viper.IsSet("favorite_groups") {
+		viper.Set("favorite_groups", cfg.FavoriteGroups)
+	}
+	if len(cfg.ProxyAliases) > 0 || viper.IsSet("proxy_aliases") {
+		viper.Set("proxy_aliases", cfg.ProxyAliases)
+	}
+	if len(cfg.ConnectionColumns) > 0 || viper.IsSet("connection_columns") {
+		viper.Set("connection_columns", cfg.ConnectionColumns)
+	}
+	if cfg.ConnectionSort != "" || viper.IsSet("connection_sort") {
+		viper.Set("connection_sort", cfg.ConnectionSort)
+	}
+	if cfg.GeoProvider != "" || viper.IsSet("geo_provider") {
+		viper.Set("geo_provider", cfg.GeoProvider)
+	}
+	if len(cfg.GeoMMDBFiles) > 0 || viper.IsSet("geo_mmdb_files") {
+		viper.Set("geo_mmdb_files", cfg.GeoMMDBFiles)
+	}
+	if cfg.GeoHTTPURL != "" || viper.IsSet("geo_http_url") {
+		viper.Set("geo_http_url", cfg.GeoHTTPURL)
+	}
+	if cfg.GeoRateLimit > 0 {
+		viper.Set("geo_rate_limit", cfg.GeoRateLimit)
+	}
+	if cfg.GeoCachePersist || viper.IsSet("geo_cache_persist") {
+		viper.Set("geo_cache_persist", cfg.GeoCachePersist)
+	}
+	if len(cfg.IPSources) > 0 {
+		viper.Set("ip_sources", cfg.IPSources)
+	}
+	if len(cfg.IPNames) > 0 || viper.IsSet("ip_names") {
+		viper.Set("ip_names", cfg.IPNames)
+	}
+	if len(cfg.DHCPLeaseFiles) > 0 {
+		viper.Set("dhcp_lease_files", cfg.DHCPLeaseFiles)
+	}
+	if len(cfg.LANNameLookup) > 0 {
+		viper.Set("lan_name_lookup", cfg.LANNameLookup)
+	}
+	if cfg.RuleStatsWindow != "" {
+		viper.Set("rule_stats_window", cfg.RuleStatsWindow)
+	}
 
 	return viper.WriteConfigAs(configFile)
 }
@@ -1,7 +1,10 @@
 package config
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 
 	"github.com/spf13/viper"
@@ -43,3 +46,206 @@ func TestSavePersistsProxyAddress(t *testing.T) {
 	got := reader.GetString("proxy_address")
 	assert.Equal(t, cfg.ProxyAddress, got, "proxy_address not persisted")
 }
+
+func TestSaveRemovesDeletedAliasesAndFavorites(t *testing.T) {
+	t.Cleanup(func() {
+		viper.Reset()
+	})
+	viper.Reset()
+
+	tempHome := t.TempDir()
+	t.Setenv("HOME", tempHome)
+	t.Setenv("USERPROFILE", tempHome)
+	t.Setenv("HOMEDRIVE", "")
+	t.Setenv("HOMEPATH", "")
+
+	cfg := DefaultConfig
+	cfg.FavoriteProxies = []string{"HK 01", "JP 01"}
+	cfg.ProxyAliases = map[string]string{"hk": "HK 01", "jp": "re:JP"}
+	require.NoError(t, Save(&cfg))
+
+	loaded, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"HK 01", "JP 01"}, loaded.FavoriteProxies)
+	assert.Equal(t, map[string]string{"hk": "HK 01", "jp": "re:JP"}, loaded.ProxyAliases)
+
+	loaded.FavoriteProxies = nil
+	loaded.ProxyAliases = map[string]string{"jp": "re:JP"}
+	require.NoError(t, Save(loaded))
+
+	viper.Reset()
+	reloaded, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, reloaded.FavoriteProxies)
+	assert.Equal(t, map[string]string{"jp": "re:JP"}, reloaded.ProxyAliases)
+}
+
+func TestSaveKeepsKillPoliciesFromFile(t *testing.T) {
+	t.Cleanup(func() {
+		viper.Reset()
+	})
+	viper.Reset()
+
+	tempHome := t.TempDir()
+	t.Setenv("HOME", tempHome)
+	t.Setenv("USERPROFILE", tempHome)
+	t.Setenv("HOMEDRIVE", "")
+	t.Setenv("HOMEPATH", "")
+
+	configDir := filepath.Join(tempHome, ".mihosh")
+	require.NoError(t, os.MkdirAll(configDir, 0755))
+	content := `api_address: http://127.0.0.1:9090
+kill_policies:
+  - name: foo-direct
+    match: process:foo chain:DIRECT
+  - name: stale-quic
+    match: net:udp dport:443 age>10m
+    disabled: true
+`
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(content), 0644))
+
+	loaded, err := Load()
+	require.NoError(t, err)
+	want := []KillPolicy{
+		{Name: "foo-direct", Match: "process:foo chain:DIRECT"},
+		{Name: "stale-quic", Match: "net:udp dport:443 age>10m", Disabled: true},
+	}
+	assert.Equal(t, want, loaded.KillPolicies)
+
+	// 设置页保存其他字段时不应丢失手写的策略
+	loaded.Timeout = 3000
+	require.NoError(t, Save(loaded))
+	viper.Reset()
+	reloaded, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, want, reloaded.KillPolicies)
+}
+
+func TestSavePersistsConnectionLayout(t *testing.T) {
+	t.Cleanup(func() {
+		viper.Reset()
+	})
+	viper.Reset()
+
+	tempHome := t.TempDir()
+	t.Setenv("HOME", tempHome)
+	t.Setenv("USERPROFILE", tempHome)
+	t.Setenv("HOMEDRIVE", "")
+	t.Setenv("HOMEPATH", "")
+
+	cfg := DefaultConfig
+	cfg.ConnectionColumns = []string{"process", "host", "down_speed"}
+	cfg.ConnectionSort = "-down_speed"
+	require.NoError(t, Save(&cfg))
+
+	viper.Reset()
+	loaded, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"process", "host", "down_speed"}, loaded.ConnectionColumns)
+	assert.Equal(t, "-down_speed", loaded.ConnectionSort)
+
+	// 恢复默认（清空）也要写回
+	loaded.ConnectionSort = ""
+	require.NoError(t, Save(loaded))
+	viper.Reset()
+	reloaded, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, reloaded.ConnectionSort)
+}
+
+func TestSaveClearsNodePatterns(t *testing.T) {
+	t.Cleanup(func() {
+		viper.Reset()
+	})
+	viper.Reset()
+
+	tempHome := t.TempDir()
+	t.Setenv("HOME", tempHome)
+	t.Setenv("USERPROFILE", tempHome)
+	t.Setenv("HOMEDRIVE", "")
+	t.Setenv("HOMEPATH", "")
+
+	cfg := DefaultConfig
+	cfg.NodeRegionPatterns = map[string]string{"hk": "香港|HK"}
+	cfg.NodeMultiplierPattern = `(\d+)x`
+	require.NoError(t, Save(&cfg))
+
+	loaded, err := Load()
+	require.NoError(t, err)
+	loaded.NodeRegionPatterns = nil
+	loaded.NodeMultiplierPattern = ""
+	require.NoError(t, Save(loaded))
+
+	viper.Reset()
+	reloaded, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, reloaded.NodeRegionPatterns)
+	assert.Empty(t, reloaded.NodeMultiplierPattern)
+}
+
+func TestSaveClearsGeoSettings(t *testing.T) {
+	t.Cleanup(func() {
+		viper.Reset()
+	})
+	viper.Reset()
+
+	tempHome := t.TempDir()
+	t.Setenv("HOME", tempHome)
+	t.Setenv("USERPROFILE", tempHome)
+	t.Setenv("HOMEDRIVE", "")
+	t.Setenv("HOMEPATH", "")
+
+	cfg := DefaultConfig
+	cfg.GeoProvider = "mmdb"
+	cfg.GeoMMDBFiles = []string{"/tmp/Country.mmdb"}
+	cfg.GeoHTTPURL = "https://example.com/{ip}"
+	require.NoError(t, Save(&cfg))
+
+	loaded, err := Load()
+	require.NoError(t, err)
+	loaded.GeoProvider = ""
+	loaded.GeoMMDBFiles = nil
+	loaded.GeoHTTPURL = ""
+	require.NoError(t, Save(loaded))
+
+	viper.Reset()
+	reloaded, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, reloaded.GeoProvider)
+	assert.Empty(t, reloaded.GeoMMDBFiles)
+	assert.Empty(t, reloaded.GeoHTTPURL)
+}
+
+func TestUpdateSerializesConcurrentWrites(t *testing.T) {
+	t.Cleanup(func() {
+		viper.Reset()
+	})
+	viper.Reset()
+
+	tempHome := t.TempDir()
+	t.Setenv("HOME", tempHome)
+	t.Setenv("USERPROFILE", tempHome)
+	t.Setenv("HOMEDRIVE", "")
+	t.Setenv("HOMEPATH", "")
+
+	cfg := DefaultConfig
+	require.NoError(t, Save(&cfg))
+
+	// TUI 中连续切换时每次保存都在独立的 goroutine 中执行，不应互相覆盖
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, Update(func(cfg *Config) error {
+				cfg.FavoriteGroups = append(cfg.FavoriteGroups, fmt.Sprintf("group-%d", i))
+				return nil
+			}))
+		}(i)
+	}
+	wg.Wait()
+
+	loaded, err := Load()
+	require.NoError(t, err)
+	assert.Len(t, loaded.FavoriteGroups, 10)
+}
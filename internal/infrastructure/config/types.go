@@ -7,6 +7,59 @@ type Config struct {
 	TestURL      string `mapstructure:"test_url"`
 	Timeout      int    `mapstructure:"timeout"`
 	ProxyAddress string `mapstructure:"proxy_address"`
+	// TestConcurrency 批量测速并发数（<=0 时使用默认值）
+	TestConcurrency int `mapstructure:"test_concurrency"`
+	// NodeRegionPatterns 节点地区识别正则（地区代码 -> 正则），为空时使用内置规则
+	NodeRegionPatterns map[string]string `mapstructure:"node_region_patterns"`
+	// NodeMultiplierPattern 节点倍率识别正则（取第一个非空捕获组），为空时使用内置规则
+	NodeMultiplierPattern string `mapstructure:"node_multiplier_pattern"`
+	// FavoriteProxies 收藏的节点，在节点页置顶显示
+	FavoriteProxies []string `mapstructure:"favorite_proxies"`
+	// FavoriteGroups 收藏的策略组，在策略组列表置顶显示
+	FavoriteGroups []string `mapstructure:"favorite_groups"`
+	// ProxyAliases 节点别名（别名 -> 节点名，"re:" 前缀表示正则）
+	ProxyAliases map[string]string `mapstructure:"proxy_aliases"`
+	// KillPolicies 自动关闭连接策略（TUI 与 mihosh autokill 生效）
+	KillPolicies []KillPolicy `mapstructure:"kill_policies"`
+	// ConnectionColumns 连接表显示的列及顺序，为空时使用默认列
+	ConnectionColumns []string `mapstructure:"connection_columns"`
+	// ConnectionSort 连接表排序列（列 ID，"-" 前缀表示降序），为空时按到达顺序
+	ConnectionSort string `mapstructure:"connection_sort"`
+	// GeoProvider IP 地理位置数据源：auto（本地 MMDB 优先）、mmdb（仅离线）、http（仅在线）
+	GeoProvider string `mapstructure:"geo_provider"`
+	// GeoMMDBFiles MMDB 文件路径，为空时在 mihomo 配置目录中自动查找
+	GeoMMDBFiles []string `mapstructure:"geo_mmdb_files"`
+	// GeoHTTPURL 在线查询接口，{ip} 替换为目标 IP，为空时使用 ip.sb
+	GeoHTTPURL string `mapstructure:"geo_http_url"`
+	// GeoRateLimit 在线查询每秒最多请求数（<=0 时使用默认值）
+	GeoRateLimit float64 `mapstructure:"geo_rate_limit"`
+	// GeoCachePersist 是否将地理位置缓存保存到 ~/.mihosh/geo-cache.json
+	GeoCachePersist bool `mapstructure:"geo_cache_persist"`
+	// IPSources 启用的内网 IP 解析源及优先级，为空时启用全部
+	IPSources []string `mapstructure:"ip_sources"`
+	// IPNames 手工维护的内网 IP 名称，优先于其它解析源
+	IPNames []IPName `mapstructure:"ip_names"`
+	// DHCPLeaseFiles dnsmasq/odhcpd 租约文件，为空时查找常见位置
+	DHCPLeaseFiles []string `mapstructure:"dhcp_lease_files"`
+	// LANNameLookup 局域网设备名反查方式（dns、mdns、mihomo，按顺序尝试），为空时使用 dns+mdns，"off" 关闭
+	LANNameLookup []string `mapstructure:"lan_name_lookup"`
+	// RuleStatsWindow 规则页判定无命中规则的时间窗口（如 24h、7d），为空时为 24h
+	RuleStatsWindow string `mapstructure:"rule_stats_window"`
+}
+
+// KillPolicy 自动关闭连接策略，Match 使用连接过滤表达式
+type KillPolicy struct {
+	Name     string `mapstructure:"name"`
+	Match    string `mapstructure:"match"`
+	Disabled bool   `mapstructure:"disabled"`
+}
+
+// IPName 手工维护的 IP 名称。
+// 以列表而非 IP -> 名称的映射保存，因为 viper 会按 "." 拆分映射的键
+type IPName struct {
+	IP     string `mapstructure:"ip"`
+	Name   string `mapstructure:"name"`
+	Detail string `mapstructure:"detail"`
 }
 
 // DefaultConfig 默认配置
@@ -16,4 +69,6 @@ var DefaultConfig = Config{
 	TestURL:      "http://www.gstatic.com/generate_204",
 	Timeout:      5000,
 	ProxyAddress: "http://127.0.0.1:7890",
+
+	TestConcurrency: 20,
 }
@@ -0,0 +1,239 @@
+// Package mmdbtest 为测试生成 MMDB 数据库
+package mmdbtest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+)
+
+// metadataMarker 元数据段起始标记
+var metadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// 数据段字段类型（与 pkg/mmdb 的解码器一致）
+const (
+	typeString = 2
+	typeDouble = 3
+	typeBytes  = 4
+	typeUint16 = 5
+	typeUint32 = 6
+	typeMap    = 7
+	typeInt32  = 8
+	typeUint64 = 9
+	typeArray  = 11
+	typeBool   = 14
+)
+
+// Builder 生成简单的 MMDB 数据库，记录长度固定为 32 位
+type Builder struct {
+	dbType    string
+	ipVersion int
+	root      *trieNode
+}
+
+type trieNode struct {
+	children [2]*trieNode
+	record   any
+	leaf     bool
+}
+
+// NewBuilder 创建数据库生成器，ipVersion 为 4 或 6
+func NewBuilder(dbType string, ipVersion int) *Builder {
+	return &Builder{dbType: dbType, ipVersion: ipVersion, root: &trieNode{}}
+}
+
+// Insert 为网段写入一条记录；IPv6 数据库中的 IPv4 网段写入 ::/96 子树
+func (b *Builder) Insert(cidr string, record any) error {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return err
+	}
+	ones, _ := network.Mask.Size()
+	ip := network.IP
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+		if b.ipVersion == 6 {
+			ip = append(make(net.IP, 12), v4...)
+			ones += 96
+		}
+	} else if b.ipVersion == 4 {
+		return fmt.Errorf("IPv4 数据库不能写入 IPv6 网段 %s", cidr)
+	}
+
+	node := b.root
+	for i := 0; i < ones; i++ {
+		bit := (ip[i/8] >> (7 - uint(i%8))) & 1
+		if node.children[bit] == nil {
+			node.children[bit] = &trieNode{}
+		}
+		node = node.children[bit]
+	}
+	node.leaf, node.record, node.children = true, record, [2]*trieNode{}
+	return nil
+}
+
+// Bytes 序列化数据库
+func (b *Builder) Bytes() ([]byte, error) {
+	// 为内部节点编号（先序）
+	ids := make(map[*trieNode]int)
+	var order []*trieNode
+	var walk func(n *trieNode)
+	walk = func(n *trieNode) {
+		if n == nil || n.leaf {
+			return
+		}
+		ids[n] = len(order)
+		order = append(order, n)
+		walk(n.children[0])
+		walk(n.children[1])
+	}
+	walk(b.root)
+	nodeCount := len(order)
+
+	var data bytes.Buffer
+	offsets := make(map[*trieNode]int)
+	record := func(n *trieNode) (uint32, error) {
+		switch {
+		case n == nil:
+			return uint32(nodeCount), nil
+		case !n.leaf:
+			return uint32(ids[n]), nil
+		}
+		if off, ok := offsets[n]; ok {
+			return uint32(nodeCount + 16 + off), nil
+		}
+		off := data.Len()
+		if err := encode(&data, n.record); err != nil {
+			return 0, err
+		}
+		offsets[n] = off
+		return uint32(nodeCount + 16 + off), nil
+	}
+
+	var out bytes.Buffer
+	for _, n := range order {
+		for _, child := range n.children {
+			v, err := record(child)
+			if err != nil {
+				return nil, err
+			}
+			_ = binary.Write(&out, binary.BigEndian, v)
+		}
+	}
+	out.Write(make([]byte, 16))
+	out.Write(data.Bytes())
+	out.Write(metadataMarker)
+	err := encode(&out, map[string]any{
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"database_type":               b.dbType,
+		"ip_version":                  uint16(b.ipVersion),
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(32),
+		"languages":                   []any{"en"},
+	})
+	return out.Bytes(), err
+}
+
+// encode 按 MMDB 数据段格式编码值
+func encode(w *bytes.Buffer, v any) error {
+	switch x := v.(type) {
+	case string:
+		writeHeader(w, typeString, len(x))
+		w.WriteString(x)
+	case []byte:
+		writeHeader(w, typeBytes, len(x))
+		w.Write(x)
+	case float64:
+		writeHeader(w, typeDouble, 8)
+		_ = binary.Write(w, binary.BigEndian, math.Float64bits(x))
+	case bool:
+		n := 0
+		if x {
+			n = 1
+		}
+		writeHeader(w, typeBool, n)
+	case uint16:
+		writeUint(w, typeUint16, uint64(x))
+	case uint32:
+		writeUint(w, typeUint32, uint64(x))
+	case uint64:
+		writeUint(w, typeUint64, x)
+	case uint:
+		writeUint(w, typeUint32, uint64(x))
+	case int:
+		writeHeader(w, typeInt32, 4)
+		_ = binary.Write(w, binary.BigEndian, int32(x))
+	case map[string]any:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		writeHeader(w, typeMap, len(x))
+		for _, k := range keys {
+			if err := encode(w, k); err != nil {
+				return err
+			}
+			if err := encode(w, x[k]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		writeHeader(w, typeArray, len(x))
+		for _, item := range x {
+			if err := encode(w, item); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("不支持编码的类型 %T", v)
+	}
+	return nil
+}
+
+func writeUint(w *bytes.Buffer, typ int, v uint64) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	n := 8
+	for n > 0 && buf[8-n] == 0 {
+		n--
+	}
+	writeHeader(w, typ, n)
+	w.Write(buf[8-n:])
+}
+
+func writeHeader(w *bytes.Buffer, typ, size int) {
+	ctrl := byte(0)
+	ext := -1
+	if typ > 7 {
+		ext = typ - 7
+	} else {
+		ctrl = byte(typ << 5)
+	}
+
+	var sizeBytes []byte
+	switch {
+	case size < 29:
+		ctrl |= byte(size)
+	case size < 285:
+		ctrl |= 29
+		sizeBytes = []byte{byte(size - 29)}
+	case size < 65821:
+		ctrl |= 30
+		s := size - 285
+		sizeBytes = []byte{byte(s >> 8), byte(s)}
+	default:
+		ctrl |= 31
+		s := size - 65821
+		sizeBytes = []byte{byte(s >> 16), byte(s >> 8), byte(s)}
+	}
+	w.WriteByte(ctrl)
+	if ext >= 0 {
+		w.WriteByte(byte(ext))
+	}
+	w.Write(sizeBytes)
+}
@@ -0,0 +1,178 @@
+package connections
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/infrastructure/api"
+	"github.com/aimony/mihosh/internal/ui/tui/components/common"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// BulkCloseOption 批量关闭菜单中的一项
+type BulkCloseOption struct {
+	Label string // 展示文本
+	Query string // 对应的过滤表达式
+	Count int    // 打开菜单时匹配的连接数
+}
+
+// openBulkClose 根据当前过滤与选中连接生成批量关闭选项
+func (s State) openBulkClose() State {
+	if s.connViewMode != ConnViewActive || s.Connections == nil {
+		return s
+	}
+	options := buildBulkCloseOptions(s.Connections.Connections, s.connFilter, s.selectedConnection())
+	if len(options) == 0 {
+		s.connNotice = "没有可批量关闭的连接"
+		return s
+	}
+	s.bulkCloseMode = true
+	s.bulkCloseOptions = options
+	s.bulkCloseSelected = 0
+	return s
+}
+
+// handleBulkClose 批量关闭菜单按键处理
+func (s State) handleBulkClose(msg tea.KeyMsg, client *api.Client) (State, tea.Cmd) {
+	switch {
+	case key.Matches(msg, common.Keys.Up), msg.String() == "k":
+		if s.bulkCloseSelected > 0 {
+			s.bulkCloseSelected--
+		}
+	case key.Matches(msg, common.Keys.Down), msg.String() == "j":
+		if s.bulkCloseSelected < len(s.bulkCloseOptions)-1 {
+			s.bulkCloseSelected++
+		}
+	case key.Matches(msg, common.Keys.Enter):
+		option := s.bulkCloseOptions[s.bulkCloseSelected]
+		s.bulkCloseMode = false
+		q, err := service.ParseConnQuery(option.Query)
+		if err != nil || s.Connections == nil {
+			return s, nil
+		}
+		// 以按下 Enter 时的连接列表为准，菜单打开期间新建的匹配连接同样会被关闭
+		var ids []string
+		for _, conn := range service.FilterConnections(s.Connections.Connections, q) {
+			ids = append(ids, conn.ID)
+		}
+		s.connNotice = fmt.Sprintf("正在关闭%s 的 %d 个连接...", option.Label, len(ids))
+		return s, CloseConnections(client, option.Label, ids)
+	case key.Matches(msg, common.Keys.Escape), msg.String() == "c":
+		s.bulkCloseMode = false
+	}
+	return s, nil
+}
+
+// ApplyBulkConnectionsClosed 记录批量关闭结果并重置选中位置
+func (s State) ApplyBulkConnectionsClosed(label string, closed, failed int) State {
+	s.connNotice = fmt.Sprintf("已关闭%s 的 %d 个连接", label, closed)
+	if failed > 0 {
+		s.connNotice += fmt.Sprintf("，%d 个失败", failed)
+	}
+	s.selectedConn = 0
+	s.connScrollTop = 0
+	return s
+}
+
+// ApplyAutoKill 在统计栏提示自动关闭的连接
+func (s State) ApplyAutoKill(records []service.KillRecord) State {
+	if len(records) == 0 {
+		return s
+	}
+	last := records[len(records)-1]
+	s.connNotice = fmt.Sprintf("策略 %s 自动关闭 %s", last.Policy, last.Target())
+	if len(records) > 1 {
+		s.connNotice += fmt.Sprintf(" 等 %d 个连接", len(records))
+	}
+	return s
+}
+
+// buildBulkCloseOptions 当前过滤、选中连接的进程/主机/策略组/节点各生成一项，忽略无匹配的项
+func buildBulkCloseOptions(conns []model.Connection, filter string, selected *model.Connection) []BulkCloseOption {
+	var candidates []BulkCloseOption
+	if strings.TrimSpace(filter) != "" {
+		query := filter
+		if _, err := service.ParseConnQuery(filter); err != nil {
+			// 与列表过滤保持一致：表达式无效时按原文子串匹配
+			query = `"` + strings.ReplaceAll(filter, `"`, "") + `"`
+		}
+		candidates = append(candidates, BulkCloseOption{Label: fmt.Sprintf("当前过滤「%s」", filter), Query: query})
+	}
+	if selected != nil {
+		meta := selected.Metadata
+		if meta.Process != "" {
+			candidates = append(candidates, BulkCloseOption{Label: "进程 " + meta.Process, Query: "process=" + quoteQueryValue(meta.Process)})
+		}
+		if meta.Host != "" {
+			candidates = append(candidates, BulkCloseOption{Label: "主机 " + meta.Host, Query: "host=" + quoteQueryValue(meta.Host)})
+		} else if meta.DestinationIP != "" {
+			candidates = append(candidates, BulkCloseOption{Label: "目标 " + meta.DestinationIP, Query: "dst=" + quoteQueryValue(meta.DestinationIP)})
+		}
+		if n := len(selected.Chains); n > 0 {
+			candidates = append(candidates, BulkCloseOption{Label: "策略组 " + selected.Chains[n-1], Query: "chain=" + quoteQueryValue(selected.Chains[n-1])})
+			if n > 1 {
+				candidates = append(candidates, BulkCloseOption{Label: "节点 " + selected.Chains[0], Query: "chain=" + quoteQueryValue(selected.Chains[0])})
+			}
+		}
+	}
+
+	var options []BulkCloseOption
+	for _, option := range candidates {
+		q, err := service.ParseConnQuery(option.Query)
+		if err != nil {
+			continue
+		}
+		option.Count = len(service.FilterConnections(conns, q))
+		if option.Count > 0 {
+			options = append(options, option)
+		}
+	}
+	return options
+}
+
+// quoteQueryValue 为含空格、括号等的值加引号
+func quoteQueryValue(value string) string {
+	if strings.ContainsAny(value, " \t()") {
+		return `"` + strings.ReplaceAll(value, `"`, "") + `"`
+	}
+	return value
+}
+
+// renderBulkCloseModal 渲染批量关闭菜单
+func renderBulkCloseModal(state PageState) string {
+	modalWidth := state.Width - 10
+	if modalWidth < 40 {
+		modalWidth = 40
+	}
+	if modalWidth > 70 {
+		modalWidth = 70
+	}
+	innerWidth := modalWidth - 4
+
+	var bodyLines []string
+	for i, option := range state.BulkCloseOptions {
+		prefix, label := common.SymbolSelectInactive, option.Label
+		if i == state.BulkCloseSelected {
+			prefix, label = common.SymbolSelectActive, common.SelectedStyle.Render(option.Label)
+		}
+		bodyLines = append(bodyLines, prefix+label+"  "+common.DimStyle.Render(fmt.Sprintf("%d 个连接", option.Count)))
+	}
+	bodyLines = append(bodyLines, "")
+	bodyLines = append(bodyLines, common.MutedStyle.Render("[Enter] 关闭所选范围的连接  [Esc] 取消"))
+
+	title := common.TableHeaderStyle.Render("批量关闭连接")
+	separator := common.DimStyle.Render(strings.Repeat("─", innerWidth))
+	content := lipgloss.JoinVertical(lipgloss.Left, title, separator, strings.Join(bodyLines, "\n"))
+
+	modal := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(common.CDanger).
+		Padding(0, 1).
+		Width(modalWidth).
+		Render(content)
+	return lipgloss.Place(state.Width, state.Height-2, lipgloss.Center, lipgloss.Center, modal)
+}
@@ -0,0 +1,66 @@
+package connections
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func bulkTestConns() []model.Connection {
+	return []model.Connection{
+		{ID: "a", Chains: []string{"HK 01", "Proxy"}, Metadata: model.Metadata{Host: "www.google.com", Process: "chrome"}},
+		{ID: "b", Chains: []string{"JP 01", "Proxy"}, Metadata: model.Metadata{Host: "youtube.com", Process: "chrome"}},
+		{ID: "c", Chains: []string{"DIRECT"}, Metadata: model.Metadata{Host: "www.google.com", Process: "Google Chrome Helper"}},
+	}
+}
+
+func TestBuildBulkCloseOptions(t *testing.T) {
+	conns := bulkTestConns()
+	options := buildBulkCloseOptions(conns, "google", &conns[0])
+
+	got := make([]string, 0, len(options))
+	for _, o := range options {
+		got = append(got, fmt.Sprintf("%s=%d", o.Label, o.Count))
+	}
+	want := "当前过滤「google」=2,进程 chrome=2,主机 www.google.com=2,策略组 Proxy=2,节点 HK 01=1"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("unexpected options:\n got %s\nwant %s", strings.Join(got, ","), want)
+	}
+}
+
+func TestBuildBulkCloseOptions_QuotesValuesWithSpaces(t *testing.T) {
+	conns := bulkTestConns()
+	options := buildBulkCloseOptions(conns, "", &conns[2])
+	if len(options) == 0 || options[0].Query != `process="Google Chrome Helper"` || options[0].Count != 1 {
+		t.Fatalf("unexpected process option: %+v", options)
+	}
+}
+
+func TestBulkClose_EnterClosesMatchingConnections(t *testing.T) {
+	s := State{Connections: &model.ConnectionsResponse{Connections: bulkTestConns()}}
+	s, _ = s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")}, nil, 0)
+	if !s.bulkCloseMode || !s.TextInputActive() {
+		t.Fatalf("expected bulk close menu to open")
+	}
+
+	s, _ = s.Update(tea.KeyMsg{Type: tea.KeyDown}, nil, 0)
+	s, cmd := s.Update(tea.KeyMsg{Type: tea.KeyEnter}, nil, 0)
+	if s.bulkCloseMode || cmd == nil {
+		t.Fatalf("expected menu closed with a close command")
+	}
+	if !strings.Contains(s.connNotice, "主机 www.google.com 的 2 个连接") {
+		t.Fatalf("unexpected notice %q", s.connNotice)
+	}
+}
+
+func TestBulkClose_EscCancels(t *testing.T) {
+	s := State{Connections: &model.ConnectionsResponse{Connections: bulkTestConns()}}
+	s, _ = s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")}, nil, 0)
+	s, cmd := s.Update(tea.KeyMsg{Type: tea.KeyEsc}, nil, 0)
+	if s.bulkCloseMode || cmd != nil {
+		t.Fatalf("expected menu cancelled without command")
+	}
+}
@@ -1,12 +1,13 @@
 package connections
 
 import (
-	"encoding/json"
-	"fmt"
+	"context"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
+	"github.com/aimony/mihosh/internal/app/service"
 	"github.com/aimony/mihosh/internal/domain/model"
 	"github.com/aimony/mihosh/internal/infrastructure/api"
 	"github.com/aimony/mihosh/internal/ui/tui/messages"
@@ -43,35 +44,115 @@ func CloseAllConnections(client *api.Client) tea.Cmd {
 	}
 }
 
-// FetchIPInfo 获取IP地理位置信息
-func FetchIPInfo(ip string) tea.Cmd {
+// CloseConnections 批量关闭指定连接
+func CloseConnections(client *api.Client, label string, ids []string) tea.Cmd {
 	return func() tea.Msg {
-		if ip == "" {
-			return messages.IPInfoMsg{Info: nil, Err: nil}
-		}
+		closed, failed := service.NewConnectionService(client).CloseConnections(ids)
+		return messages.BulkConnectionsClosedMsg{Label: label, Closed: closed, Failed: len(failed)}
+	}
+}
 
-		client := &http.Client{Timeout: 5 * time.Second}
-		url := fmt.Sprintf("https://api.ip.sb/geoip/%s", ip)
+// SaveTableLayout 保存连接表的列布局与排序到配置文件
+func SaveTableLayout(columns []string, sort string) tea.Cmd {
+	columns = append([]string(nil), columns...)
+	return func() tea.Msg {
+		err := service.NewConfigService().SetConnectionLayout(columns, sort)
+		return messages.ConnectionLayoutSavedMsg{Columns: columns, Sort: sort, Err: err}
+	}
+}
 
-		req, err := http.NewRequest("GET", url, nil)
+// ExportConnections 将连接记录导出到 ~/.mihosh/exports 下的文件
+func ExportConnections(records []service.ConnExportRecord, format service.ConnExportFormat) tea.Cmd {
+	return func() tea.Msg {
+		dir, err := service.DefaultConnExportDir()
 		if err != nil {
-			return messages.IPInfoMsg{Info: nil, Err: err}
+			return messages.ConnectionsExportedMsg{Err: err}
 		}
-		req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-		req.Header.Set("Accept", "*/*")
+		path, err := service.ExportConnectionsToFile(dir, records, format, time.Now())
+		return messages.ConnectionsExportedMsg{Path: path, Count: len(records), Err: err}
+	}
+}
 
-		resp, err := client.Do(req)
-		if err != nil {
-			return messages.IPInfoMsg{Info: nil, Err: err}
+// ResolveProcessDetails 通过 /proc 解析各进程的 PID、用户、systemd 单元与容器
+func ResolveProcessDetails(resolver *service.IPResolver, conns []model.Connection) tea.Cmd {
+	return func() tea.Msg {
+		details := make(map[string]string)
+		for _, g := range resolver.GroupProcesses(conns) {
+			if detail := g.Detail(); detail != "" {
+				details[g.Key] = detail
+			}
 		}
-		defer resp.Body.Close()
+		return messages.ProcessDetailsMsg{Details: details}
+	}
+}
+
+// ResolveSourceNames 识别来源 IP 对应的设备名（容器、虚拟机、反向 DNS、mDNS 等），没有名称的记为空字符串
+func ResolveSourceNames(resolver *service.IPResolver, ips []string) tea.Cmd {
+	return func() tea.Msg {
+		names := make(map[string]string, len(ips))
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		// 反查可能要等待数秒，限制并发同时查询多个设备
+		sem := make(chan struct{}, 4)
+		for _, ip := range ips {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(ip string) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				name := resolver.SourceName(ip)
+				mu.Lock()
+				names[ip] = name
+				mu.Unlock()
+			}(ip)
+		}
+		wg.Wait()
+		return messages.SourceNamesMsg{Names: names}
+	}
+}
+
+// ResolveGeoTags 批量查询目标 IP 的国家与 ASN，cachePath 非空时随后保存缓存
+func ResolveGeoTags(cache *service.GeoCache, ips []string, cachePath string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		tags := make(map[string]model.GeoTag)
+		for ip, info := range cache.LookupMany(ctx, ips) {
+			tags[ip] = service.GeoTagFromInfo(info)
+		}
+		if cachePath != "" {
+			_ = cache.Save(cachePath)
+		}
+		return messages.GeoTagsMsg{Tags: tags}
+	}
+}
+
+// AutoKill 按自动关闭策略处理一次连接快照，无命中时不产生消息
+func AutoKill(killer *service.AutoKiller, data api.ConnectionsData) tea.Cmd {
+	return func() tea.Msg {
+		records := killer.Evaluate(data.Connections, time.Now())
+		if len(records) == 0 {
+			return nil
+		}
+		return messages.AutoKillMsg{Records: records}
+	}
+}
 
-		var info model.IPInfo
-		if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
-			return messages.IPInfoMsg{Info: nil, Err: err}
+// FetchIPInfo 获取IP地理位置信息
+func FetchIPInfo(cache *service.GeoCache, ip string) tea.Cmd {
+	return func() tea.Msg {
+		if ip == "" {
+			return messages.IPInfoMsg{Info: nil, Err: nil}
+		}
+		var geo service.GeoProvider = service.NewHTTPGeoProvider("")
+		if cache != nil {
+			geo = cache
 		}
 
-		return messages.IPInfoMsg{Info: &info, Err: nil}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		info, err := geo.Lookup(ctx, ip)
+		return messages.IPInfoMsg{Info: info, Err: err}
 	}
 }
 
@@ -127,36 +208,7 @@ func hasScheme(addr string) bool {
 
 // ConvertToConnectionsResponse 将 api.ConnectionsData 转换为 model.ConnectionsResponse
 func ConvertToConnectionsResponse(data api.ConnectionsData) *model.ConnectionsResponse {
-	connections := make([]model.Connection, len(data.Connections))
-	for i, conn := range data.Connections {
-		connections[i] = model.Connection{
-			ID:            conn.ID,
-			Upload:        conn.Upload,
-			Download:      conn.Download,
-			Start:         conn.Start,
-			Chains:        conn.Chains,
-			Rule:          conn.Rule,
-			RulePayload:   conn.RulePayload,
-			DownloadSpeed: conn.DownloadSpeed,
-			UploadSpeed:   conn.UploadSpeed,
-			Metadata: model.Metadata{
-				Network:         conn.Metadata.Network,
-				Type:            conn.Metadata.Type,
-				SourceIP:        conn.Metadata.SourceIP,
-				DestinationIP:   conn.Metadata.DestinationIP,
-				SourcePort:      conn.Metadata.SourcePort,
-				DestinationPort: conn.Metadata.DestinationPort,
-				Host:            conn.Metadata.Host,
-				Process:         conn.Metadata.Process,
-				ProcessPath:     conn.Metadata.ProcessPath,
-			},
-		}
-	}
-	return &model.ConnectionsResponse{
-		DownloadTotal: data.DownloadTotal,
-		UploadTotal:   data.UploadTotal,
-		Connections:   connections,
-	}
+	return &data
 }
 
 // TestAllSites 批量测试所有网站
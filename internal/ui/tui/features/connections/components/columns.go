@@ -0,0 +1,416 @@
+package components
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/pkg/utils"
+)
+
+type columnAlign int
+
+const (
+	columnAlignLeft columnAlign = iota
+	columnAlignRight
+)
+
+// ConnColumn 连接表中一列的定义
+type ConnColumn struct {
+	ID    string
+	Title string
+	// Width 固定列宽度，为 0 时为弹性列，按 Weight 分配剩余宽度
+	Width    int
+	MinWidth int
+	Weight   float64
+	// DefaultDesc 首次按该列排序时是否降序（数值列默认从大到小）
+	DefaultDesc bool
+
+	align columnAlign
+	value func(conn model.Connection, width int) string
+	less  func(a, b model.Connection) bool
+}
+
+// 连接表列 ID，与过滤表达式的字段名保持一致
+const (
+	ColumnHost      = "host"
+	ColumnProcess   = "process"
+	ColumnSource    = "src"
+	ColumnSrcName   = "src_name"
+	ColumnDest      = "dst"
+	ColumnChain     = "chain"
+	ColumnChainHead = "chain_head"
+	ColumnChainTail = "chain_tail"
+	ColumnRule      = "rule"
+	ColumnType      = "type"
+	ColumnNetwork   = "net"
+	ColumnInbound   = "inbound"
+	ColumnUser      = "user"
+	ColumnUID       = "uid"
+	ColumnSniff     = "sniff"
+	ColumnDownSpeed = "down_speed"
+	ColumnUpSpeed   = "up_speed"
+	ColumnDownload  = "down"
+	ColumnUpload    = "up"
+	ColumnAge       = "age"
+	ColumnCountry   = "country"
+	ColumnASN       = "asn"
+)
+
+// DefaultConnColumns 未配置时显示的列
+var DefaultConnColumns = []string{ColumnHost, ColumnCountry, ColumnType, ColumnRule, ColumnChain, ColumnDownload, ColumnUpload, ColumnAge}
+
+// MetaConnColumns 按 i 切换显示的入站元数据列
+var MetaConnColumns = []string{ColumnInbound, ColumnUser, ColumnUID, ColumnSniff}
+
+// connColumns 全部可选列，顺序即列设置中的默认顺序
+var connColumns = []ConnColumn{
+	{ID: ColumnHost, Title: "主机", MinWidth: 12, Weight: 4, value: textCell(connHost), less: textLess(connHost)},
+	{ID: ColumnProcess, Title: "进程", MinWidth: 8, Weight: 2, value: textCell(connProcess), less: textLess(connProcess)},
+	{ID: ColumnSource, Title: "源地址", MinWidth: 12, Weight: 2,
+		value: textCell(func(c model.Connection) string { return joinHostPort(c.Metadata.SourceIP, c.Metadata.SourcePort) }),
+		less:  addrLess(func(c model.Connection) (string, string) { return c.Metadata.SourceIP, c.Metadata.SourcePort })},
+	{ID: ColumnSrcName, Title: "来源设备", MinWidth: 10, Weight: 1.5, value: textCell(connSourceName), less: textLess(connSourceName)},
+	{ID: ColumnDest, Title: "目标地址", MinWidth: 12, Weight: 2,
+		value: textCell(func(c model.Connection) string {
+			return joinHostPort(c.Metadata.DestinationIP, c.Metadata.DestinationPort)
+		}),
+		less: addrLess(func(c model.Connection) (string, string) { return c.Metadata.DestinationIP, c.Metadata.DestinationPort })},
+	{ID: ColumnCountry, Title: "国家", Width: 6, value: countryCell,
+		less: textLess(func(c model.Connection) string { return c.DestinationGeo().CountryCode })},
+	{ID: ColumnASN, Title: "ASN", MinWidth: 10, Weight: 1.5, value: textCell(connASN),
+		less: func(a, b model.Connection) bool { return a.DestinationGeo().ASN < b.DestinationGeo().ASN }},
+	{ID: ColumnChain, Title: "代理链", MinWidth: 8, Weight: 2.5, value: textCell(connChainPath), less: textLess(connChainPath)},
+	{ID: ColumnChainHead, Title: "策略组", MinWidth: 8, Weight: 1.5, value: textCell(connChainHead), less: textLess(connChainHead)},
+	{ID: ColumnChainTail, Title: "出口节点", MinWidth: 8, Weight: 1.5, value: textCell(connChainTail), less: textLess(connChainTail)},
+	{ID: ColumnRule, Title: "规则", MinWidth: 8, Weight: 2, value: ruleCell, less: textLess(func(c model.Connection) string { return c.Rule + ":" + c.RulePayload })},
+	{ID: ColumnType, Title: "类型", MinWidth: 8, Weight: 1.5, value: textCell(connTypeLabel), less: textLess(connTypeLabel)},
+	{ID: ColumnNetwork, Title: "网络", Width: 5, value: textCell(func(c model.Connection) string { return c.Metadata.Network }),
+		less: textLess(func(c model.Connection) string { return c.Metadata.Network })},
+	{ID: ColumnInbound, Title: "入站", Width: 12, value: textCell(func(c model.Connection) string { return c.Metadata.InboundName }),
+		less: textLess(func(c model.Connection) string { return c.Metadata.InboundName })},
+	{ID: ColumnUser, Title: "用户", Width: 8, value: textCell(func(c model.Connection) string { return c.Metadata.InboundUser }),
+		less: textLess(func(c model.Connection) string { return c.Metadata.InboundUser })},
+	{ID: ColumnUID, Title: "UID", Width: 6, align: columnAlignRight, value: uidCell,
+		less: func(a, b model.Connection) bool { return a.Metadata.UID < b.Metadata.UID }},
+	{ID: ColumnSniff, Title: "嗅探主机", MinWidth: 10, Weight: 2, value: textCell(func(c model.Connection) string { return c.Metadata.SniffHost }),
+		less: textLess(func(c model.Connection) string { return c.Metadata.SniffHost })},
+	{ID: ColumnDownSpeed, Title: "↓速率", Width: 11, align: columnAlignRight, DefaultDesc: true,
+		value: speedCell(func(c model.Connection) int64 { return c.DownloadSpeed }),
+		less:  func(a, b model.Connection) bool { return a.DownloadSpeed < b.DownloadSpeed }},
+	{ID: ColumnUpSpeed, Title: "↑速率", Width: 11, align: columnAlignRight, DefaultDesc: true,
+		value: speedCell(func(c model.Connection) int64 { return c.UploadSpeed }),
+		less:  func(a, b model.Connection) bool { return a.UploadSpeed < b.UploadSpeed }},
+	{ID: ColumnDownload, Title: "↓下载", Width: 10, align: columnAlignRight, DefaultDesc: true,
+		value: func(c model.Connection, _ int) string { return utils.FormatBytes(c.Download) },
+		less:  func(a, b model.Connection) bool { return a.Download < b.Download }},
+	{ID: ColumnUpload, Title: "↑上传", Width: 10, align: columnAlignRight, DefaultDesc: true,
+		value: func(c model.Connection, _ int) string { return utils.FormatBytes(c.Upload) },
+		less:  func(a, b model.Connection) bool { return a.Upload < b.Upload }},
+	{ID: ColumnAge, Title: "时长", Width: 8, align: columnAlignRight, DefaultDesc: true,
+		value: func(c model.Connection, _ int) string { return utils.FormatDuration(c.Start) },
+		// 开始时间越早时长越长
+		less: func(a, b model.Connection) bool { return connStart(a).After(connStart(b)) }},
+}
+
+// AllConnColumns 全部可选列
+func AllConnColumns() []ConnColumn {
+	return append([]ConnColumn(nil), connColumns...)
+}
+
+// LookupConnColumn 按 ID 查找列定义
+func LookupConnColumn(id string) (ConnColumn, bool) {
+	for _, col := range connColumns {
+		if col.ID == id {
+			return col, true
+		}
+	}
+	return ConnColumn{}, false
+}
+
+// NormalizeConnColumns 去掉未知和重复的列，为空时返回默认列
+func NormalizeConnColumns(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if _, ok := LookupConnColumn(id); !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	if len(result) == 0 {
+		return append([]string(nil), DefaultConnColumns...)
+	}
+	return result
+}
+
+// ToggleMetaConnColumns 显示或隐藏入站元数据列（任一已显示时全部隐藏，否则插入到流量列之前）
+func ToggleMetaConnColumns(ids []string) []string {
+	meta := make(map[string]bool, len(MetaConnColumns))
+	for _, id := range MetaConnColumns {
+		meta[id] = true
+	}
+	var kept []string
+	for _, id := range ids {
+		if !meta[id] {
+			kept = append(kept, id)
+		}
+	}
+	if len(kept) != len(ids) {
+		return NormalizeConnColumns(kept)
+	}
+
+	insertAt := len(kept)
+	for i, id := range kept {
+		if id == ColumnDownSpeed || id == ColumnUpSpeed || id == ColumnDownload || id == ColumnUpload || id == ColumnAge {
+			insertAt = i
+			break
+		}
+	}
+	result := append([]string(nil), kept[:insertAt]...)
+	result = append(result, MetaConnColumns...)
+	return append(result, kept[insertAt:]...)
+}
+
+// ParseConnSort 解析排序配置（列 ID，"-" 前缀表示降序），未知列返回空
+func ParseConnSort(s string) (by string, desc bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if rest, ok := strings.CutPrefix(s, "-"); ok {
+		s, desc = rest, true
+	}
+	if _, ok := LookupConnColumn(s); !ok {
+		return "", false
+	}
+	return s, desc
+}
+
+// FormatConnSort 生成排序配置字符串
+func FormatConnSort(by string, desc bool) string {
+	if by == "" {
+		return ""
+	}
+	if desc {
+		return "-" + by
+	}
+	return by
+}
+
+// SortConnections 按列排序（稳定排序），by 为空时保持原顺序
+func SortConnections(conns []model.Connection, by string, desc bool) []model.Connection {
+	col, ok := LookupConnColumn(by)
+	if !ok || len(conns) < 2 {
+		return conns
+	}
+	sorted := append([]model.Connection(nil), conns...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if desc {
+			return col.less(sorted[j], sorted[i])
+		}
+		return col.less(sorted[i], sorted[j])
+	})
+	return sorted
+}
+
+// resolvedColumn 计算好宽度的列
+type resolvedColumn struct {
+	ConnColumn
+	width int
+}
+
+// resolveColumns 根据页面宽度计算各列宽度：固定列优先，剩余空间按权重分给弹性列，
+// 最后一个弹性列吸收取整误差
+func resolveColumns(pageWidth int, ids []string) []resolvedColumn {
+	const prefixWidth = 2 // "► " 或 "  "
+
+	cols := make([]resolvedColumn, 0, len(ids))
+	fixedTotal, elasticMinTotal, totalWeight := ColWidthClose, 0, 0.0
+	lastElastic := -1
+	for _, id := range NormalizeConnColumns(ids) {
+		col, _ := LookupConnColumn(id)
+		if col.Width > 0 {
+			fixedTotal += col.Width
+		} else {
+			elasticMinTotal += col.MinWidth
+			totalWeight += col.Weight
+			lastElastic = len(cols)
+		}
+		cols = append(cols, resolvedColumn{ConnColumn: col, width: col.Width})
+	}
+
+	colGap := len(cols) // 关闭列与各列之间的空格
+	available := pageWidth - prefixWidth - fixedTotal - colGap
+	if available < elasticMinTotal {
+		available = elasticMinTotal
+	}
+
+	used := 0
+	for i := range cols {
+		if cols[i].Width > 0 || i == lastElastic {
+			continue
+		}
+		cols[i].width = max(cols[i].MinWidth, int(float64(available)*cols[i].Weight/totalWeight))
+		used += cols[i].width
+	}
+	if lastElastic >= 0 {
+		cols[lastElastic].width = max(cols[lastElastic].MinWidth, available-used)
+	}
+	return cols
+}
+
+// ResolveHeaderColumn 返回表头第 x 列（含行首 2 字符前缀）所在的列 ID，未命中返回空
+func ResolveHeaderColumn(pageWidth int, ids []string, x int) string {
+	pos := 2 + ColWidthClose + 1
+	for _, col := range resolveColumns(pageWidth, ids) {
+		if x >= pos && x < pos+col.width {
+			return col.ID
+		}
+		pos += col.width + 1
+	}
+	return ""
+}
+
+func (c resolvedColumn) render(s string) string {
+	if c.align == columnAlignRight {
+		return alignRight(s, c.width)
+	}
+	return alignLeft(s, c.width)
+}
+
+func textCell(get func(model.Connection) string) func(model.Connection, int) string {
+	return func(c model.Connection, _ int) string { return dashIfEmpty(get(c)) }
+}
+
+func textLess(get func(model.Connection) string) func(a, b model.Connection) bool {
+	return func(a, b model.Connection) bool {
+		return strings.ToLower(get(a)) < strings.ToLower(get(b))
+	}
+}
+
+// addrLess 地址按 IP 数值再按端口排序，无法解析的 IP 排在最后
+func addrLess(get func(model.Connection) (string, string)) func(a, b model.Connection) bool {
+	return func(a, b model.Connection) bool {
+		ipA, portA := get(a)
+		ipB, portB := get(b)
+		if ipA != ipB {
+			pa, pb := net.ParseIP(ipA), net.ParseIP(ipB)
+			if pa == nil || pb == nil {
+				return pb == nil && (pa != nil || ipA < ipB)
+			}
+			return string(pa.To16()) < string(pb.To16())
+		}
+		return portNumber(portA) < portNumber(portB)
+	}
+}
+
+func portNumber(port string) int {
+	n := 0
+	fmt.Sscanf(port, "%d", &n)
+	return n
+}
+
+func ruleCell(c model.Connection, width int) string {
+	rule := c.Rule
+	if c.RulePayload != "" && utils.DisplayWidth(rule)+utils.DisplayWidth(c.RulePayload)+1 <= width {
+		rule = fmt.Sprintf("%s:%s", rule, c.RulePayload)
+	}
+	return rule
+}
+
+func uidCell(c model.Connection, _ int) string {
+	if c.Metadata.UID > 0 || c.Metadata.Process != "" {
+		return fmt.Sprintf("%d", c.Metadata.UID)
+	}
+	return "-"
+}
+
+func speedCell(get func(model.Connection) int64) func(model.Connection, int) string {
+	return func(c model.Connection, _ int) string { return formatSpeed(get(c)) }
+}
+
+// countryCell 旗帜与国家代码，如 "🇺🇸 US"
+func countryCell(c model.Connection, _ int) string {
+	code := c.DestinationGeo().CountryCode
+	if flag := utils.CountryFlag(code); flag != "" {
+		return flag + " " + code
+	}
+	return dashIfEmpty(code)
+}
+
+// connSourceName 来源设备名，未识别时显示来源 IP
+func connSourceName(c model.Connection) string {
+	if c.SourceName != "" {
+		return c.SourceName
+	}
+	return c.Metadata.SourceIP
+}
+
+func connASN(c model.Connection) string {
+	tag := c.DestinationGeo()
+	if tag.ASN == 0 {
+		return tag.ASNOrg
+	}
+	return strings.TrimSpace(fmt.Sprintf("AS%d %s", tag.ASN, tag.ASNOrg))
+}
+
+func connHost(c model.Connection) string {
+	if c.Metadata.Host != "" {
+		return c.Metadata.Host
+	}
+	return c.Metadata.DestinationIP
+}
+
+func connProcess(c model.Connection) string {
+	if c.Metadata.Process != "" {
+		return c.Metadata.Process
+	}
+	return c.Metadata.ProcessPath
+}
+
+func connTypeLabel(c model.Connection) string {
+	return fmt.Sprintf("%s/%s", c.Metadata.Network, c.Metadata.Type)
+}
+
+// connChainPath 完整代理链（chains 为倒序，反转后用 → 拼接）
+func connChainPath(c model.Connection) string {
+	if len(c.Chains) == 0 {
+		return "DIRECT"
+	}
+	parts := make([]string, len(c.Chains))
+	for i, name := range c.Chains {
+		parts[len(c.Chains)-1-i] = name
+	}
+	return strings.Join(parts, " → ")
+}
+
+// connChainHead 链路起点（命中的策略组）
+func connChainHead(c model.Connection) string {
+	if len(c.Chains) == 0 {
+		return "DIRECT"
+	}
+	return c.Chains[len(c.Chains)-1]
+}
+
+// connChainTail 链路终点（实际出口节点）
+func connChainTail(c model.Connection) string {
+	if len(c.Chains) == 0 {
+		return "DIRECT"
+	}
+	return c.Chains[0]
+}
+
+func connStart(c model.Connection) time.Time {
+	t, _ := time.Parse(time.RFC3339, c.Start)
+	return t
+}
+
+func joinHostPort(ip, port string) string {
+	if ip == "" || port == "" {
+		return ip
+	}
+	return net.JoinHostPort(ip, port)
+}
@@ -0,0 +1,119 @@
+package components
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestTableMetaColumns(t *testing.T) {
+	conn := model.Connection{Metadata: model.Metadata{InboundName: "tun-in", InboundUser: "bob", SniffHost: "sniffed.test"}}
+	style := lipgloss.NewStyle()
+
+	if header := RenderTableHeader(style, 160, DefaultConnColumns, "", false); strings.Contains(header, "入站") {
+		t.Fatalf("meta columns should be hidden by default: %q", header)
+	}
+	columns := ToggleMetaConnColumns(DefaultConnColumns)
+	header := RenderTableHeader(style, 160, columns, "", false)
+	for _, col := range []string{"入站", "用户", "UID", "嗅探主机"} {
+		assertContains(t, header, col)
+	}
+	row := RenderConnectionRow(conn, style, "  ", 160, columns)
+	assertContains(t, row, "tun-in")
+	assertContains(t, row, "bob")
+	assertContains(t, row, "sniffed.test")
+	plain := RenderConnectionRow(conn, style, "  ", 160, DefaultConnColumns)
+	if lipgloss.Width(row) != lipgloss.Width(plain) {
+		t.Fatalf("meta columns changed row width: %d != %d", lipgloss.Width(row), lipgloss.Width(plain))
+	}
+
+	if got := ToggleMetaConnColumns(columns); strings.Join(got, ",") != strings.Join(DefaultConnColumns, ",") {
+		t.Fatalf("toggling twice should restore defaults, got %v", got)
+	}
+}
+
+func TestNormalizeConnColumns(t *testing.T) {
+	got := NormalizeConnColumns([]string{"Process", "bogus", "host", "process", " age "})
+	if strings.Join(got, ",") != "process,host,age" {
+		t.Fatalf("NormalizeConnColumns = %v", got)
+	}
+	if got := NormalizeConnColumns(nil); strings.Join(got, ",") != strings.Join(DefaultConnColumns, ",") {
+		t.Fatalf("empty columns should fall back to defaults, got %v", got)
+	}
+}
+
+func TestParseConnSort(t *testing.T) {
+	tests := []struct {
+		in   string
+		by   string
+		desc bool
+	}{
+		{"down_speed", "down_speed", false},
+		{"-age", "age", true},
+		{"bogus", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		by, desc := ParseConnSort(tt.in)
+		if by != tt.by || desc != tt.desc {
+			t.Errorf("ParseConnSort(%q) = %q,%v want %q,%v", tt.in, by, desc, tt.by, tt.desc)
+		}
+		if tt.by != "" && FormatConnSort(by, desc) != tt.in {
+			t.Errorf("FormatConnSort round trip of %q = %q", tt.in, FormatConnSort(by, desc))
+		}
+	}
+}
+
+func TestSortConnections(t *testing.T) {
+	conns := []model.Connection{
+		{ID: "a", Download: 10, Start: "2026-01-01T10:00:00Z", Metadata: model.Metadata{Host: "b.com", DestinationIP: "10.0.0.9", DestinationPort: "443"}},
+		{ID: "b", Download: 30, Start: "2026-01-01T09:00:00Z", Metadata: model.Metadata{Host: "A.com", DestinationIP: "10.0.0.10", DestinationPort: "80"}},
+		{ID: "c", Download: 20, Start: "2026-01-01T11:00:00Z", Metadata: model.Metadata{Host: "c.com", DestinationIP: "9.9.9.9", DestinationPort: "53"}},
+	}
+	ids := func(list []model.Connection) string {
+		var out []string
+		for _, c := range list {
+			out = append(out, c.ID)
+		}
+		return strings.Join(out, "")
+	}
+
+	tests := []struct {
+		by   string
+		desc bool
+		want string
+	}{
+		{"", false, "abc"},
+		{ColumnDownload, true, "bca"},
+		{ColumnHost, false, "bac"},
+		{ColumnAge, true, "bac"},
+		{ColumnDest, false, "cab"},
+	}
+	for _, tt := range tests {
+		if got := ids(SortConnections(conns, tt.by, tt.desc)); got != tt.want {
+			t.Errorf("SortConnections(%q, desc=%v) = %s, want %s", tt.by, tt.desc, got, tt.want)
+		}
+	}
+	if ids(conns) != "abc" {
+		t.Fatal("SortConnections must not reorder its input")
+	}
+}
+
+func TestResolveHeaderColumn(t *testing.T) {
+	columns := []string{ColumnProcess, ColumnHost, ColumnAge}
+	header := RenderTableHeader(lipgloss.NewStyle(), 100, columns, ColumnAge, true)
+	for _, id := range columns {
+		col, _ := LookupConnColumn(id)
+		x := strings.Index(header, col.Title)
+		x = lipgloss.Width(header[:x])
+		if got := ResolveHeaderColumn(100, columns, x); got != id {
+			t.Errorf("ResolveHeaderColumn at %q = %q, want %q", col.Title, got, id)
+		}
+	}
+	assertContains(t, header, "时长▼")
+	if got := ResolveHeaderColumn(100, columns, 1); got != "" {
+		t.Errorf("close column should not resolve to a sortable column, got %q", got)
+	}
+}
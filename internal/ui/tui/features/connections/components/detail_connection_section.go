@@ -11,6 +11,9 @@ import (
 func renderConnectionInfoSection(conn *model.Connection, s detailStyles) []string {
 	host := firstNonEmpty(conn.Metadata.Host, conn.Metadata.SniffHost, conn.Metadata.DestinationIP, "-")
 	source := formatEndpoint(conn.Metadata.SourceIP, conn.Metadata.SourcePort)
+	if conn.SourceName != "" {
+		source += " (" + conn.SourceName + ")"
+	}
 	target := formatEndpoint(conn.Metadata.DestinationIP, conn.Metadata.DestinationPort)
 
 	network := strings.ToUpper(firstNonEmpty(conn.Metadata.Network, "-"))
@@ -51,6 +54,9 @@ func renderConnectionInfoSection(conn *model.Connection, s detailStyles) []strin
 	if process != "" {
 		lines = append(lines, renderKVLine("进程", process, s))
 	}
+	for _, row := range getConnMetaRows(conn) {
+		lines = append(lines, renderKVLine(row[0], row[1], s))
+	}
 
 	return lines
 }
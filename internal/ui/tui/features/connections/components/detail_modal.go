@@ -10,13 +10,14 @@ import (
 func ResolveConnectionDetailModalBounds(
 	conn *model.Connection,
 	ipInfo *model.IPInfo,
+	speeds *SpeedHistory,
 	width, height, leftScroll, rightScroll, focusPanel int,
 ) (left, top, right, bottom int) {
 	if conn == nil || width <= 0 || height <= 0 {
 		return 0, 0, 0, 0
 	}
 
-	modal := buildConnectionDetailModal(conn, ipInfo, width, height, leftScroll, rightScroll, focusPanel)
+	modal := buildConnectionDetailModal(conn, ipInfo, speeds, width, height, leftScroll, rightScroll, focusPanel)
 	modalWidth := lipgloss.Width(modal)
 	modalHeight := lipgloss.Height(modal)
 
@@ -45,9 +46,10 @@ func ResolveConnectionDetailModalBounds(
 func RenderConnectionDetailModal(
 	conn *model.Connection,
 	ipInfo *model.IPInfo,
+	speeds *SpeedHistory,
 	width, height, leftScroll, rightScroll, focusPanel int,
 ) string {
-	modal := buildConnectionDetailModal(conn, ipInfo, width, height, leftScroll, rightScroll, focusPanel)
+	modal := buildConnectionDetailModal(conn, ipInfo, speeds, width, height, leftScroll, rightScroll, focusPanel)
 
 	helpText := common.DimStyle.Render("[←/→/h/l] 切换焦点  [↑/↓/k/j] 滚动  [q/Esc/Enter] 关闭")
 
@@ -66,6 +68,7 @@ func RenderConnectionDetailModal(
 func buildConnectionDetailModal(
 	conn *model.Connection,
 	ipInfo *model.IPInfo,
+	speeds *SpeedHistory,
 	width, height, leftScroll, rightScroll, focusPanel int,
 ) string {
 	// 模态框边框和标题样式
@@ -117,7 +120,7 @@ func buildConnectionDetailModal(
 
 		leftPanel := lipgloss.NewStyle().
 			Width(leftW).
-			Render(RenderDetailModalLeft(conn, ipInfo, leftW, innerH, leftScroll, focusPanel == 0))
+			Render(RenderDetailModalLeft(conn, ipInfo, speeds, leftW, innerH, leftScroll, focusPanel == 0))
 
 		rightPanel := lipgloss.NewStyle().
 			Width(rightW).
@@ -139,7 +142,7 @@ func buildConnectionDetailModal(
 
 		leftPanel := lipgloss.NewStyle().
 			Width(innerW).
-			Render(RenderDetailModalLeft(conn, ipInfo, innerW, topH, leftScroll, focusPanel == 0))
+			Render(RenderDetailModalLeft(conn, ipInfo, speeds, innerW, topH, leftScroll, focusPanel == 0))
 
 		rightPanel := lipgloss.NewStyle().
 			Width(innerW).
@@ -11,8 +11,8 @@ import (
 	"github.com/charmbracelet/lipgloss/table"
 )
 
-// RenderDetailModalLeft 渲染详情模态框的左侧（基础信息和地理信息）
-func RenderDetailModalLeft(conn *model.Connection, ipInfo *model.IPInfo, width, height, scrollTop int, isFocused bool) string {
+// RenderDetailModalLeft 渲染详情模态框的左侧（基础信息、实时速率和地理信息）
+func RenderDetailModalLeft(conn *model.Connection, ipInfo *model.IPInfo, speeds *SpeedHistory, width, height, scrollTop int, isFocused bool) string {
 	// 调整高度，为边框和标题留出空间
 	maxHeight := height - 4
 	if maxHeight < 5 {
@@ -27,8 +27,12 @@ func RenderDetailModalLeft(conn *model.Connection, ipInfo *model.IPInfo, width,
 	ipRows := getIPGeoInfoRows(ipInfo)
 	ipTable := renderInfoTable("目标 IP 地理信息", ipRows, width, isFocused)
 
-	// 合并表格
-	content := lipgloss.JoinVertical(lipgloss.Left, connTable, "", ipTable)
+	// 合并表格，实时速率图位于两表之间
+	sections := []string{connTable, ""}
+	if chart := renderSpeedHistory(speeds, width); chart != "" {
+		sections = append(sections, chart, "")
+	}
+	content := lipgloss.JoinVertical(lipgloss.Left, append(sections, ipTable)...)
 	lines := strings.Split(content, "\n")
 	totalLines := len(lines)
 
@@ -79,6 +83,9 @@ func RenderDetailModalLeft(conn *model.Connection, ipInfo *model.IPInfo, width,
 func getConnInfoRows(conn *model.Connection) [][]string {
 	host := firstNonEmpty(conn.Metadata.Host, conn.Metadata.SniffHost, conn.Metadata.DestinationIP, "-")
 	source := formatEndpoint(conn.Metadata.SourceIP, conn.Metadata.SourcePort)
+	if conn.SourceName != "" {
+		source += " (" + conn.SourceName + ")"
+	}
 	target := formatEndpoint(conn.Metadata.DestinationIP, conn.Metadata.DestinationPort)
 
 	network := strings.ToUpper(firstNonEmpty(conn.Metadata.Network, "-"))
@@ -117,6 +124,41 @@ func getConnInfoRows(conn *model.Connection) [][]string {
 		rows = append(rows, []string{"进程", process})
 	}
 
+	return append(rows, getConnMetaRows(conn)...)
+}
+
+// getConnMetaRows 入站、用户、嗅探等扩展元数据，仅输出非空字段
+func getConnMetaRows(conn *model.Connection) [][]string {
+	meta := conn.Metadata
+	var rows [][]string
+	if meta.InboundName != "" || meta.InboundIP != "" {
+		inbound := firstNonEmpty(meta.InboundName, "-")
+		if meta.InboundIP != "" {
+			inbound += " (" + formatEndpoint(meta.InboundIP, meta.InboundPort) + ")"
+		}
+		rows = append(rows, []string{"入站", inbound})
+	}
+	if meta.InboundUser != "" {
+		rows = append(rows, []string{"用户", meta.InboundUser})
+	}
+	if meta.UID > 0 || meta.Process != "" {
+		rows = append(rows, []string{"UID", fmt.Sprintf("%d", meta.UID)})
+	}
+	if meta.SniffHost != "" && meta.SniffHost != meta.Host {
+		rows = append(rows, []string{"嗅探主机", meta.SniffHost})
+	}
+	if meta.DNSMode != "" {
+		rows = append(rows, []string{"DNS 模式", meta.DNSMode})
+	}
+	if meta.SpecialProxy != "" {
+		rows = append(rows, []string{"特殊代理", meta.SpecialProxy})
+	}
+	if meta.RemoteDestination != "" {
+		rows = append(rows, []string{"远程目标", meta.RemoteDestination})
+	}
+	if meta.DSCP > 0 {
+		rows = append(rows, []string{"DSCP", fmt.Sprintf("%d", meta.DSCP)})
+	}
 	return rows
 }
 
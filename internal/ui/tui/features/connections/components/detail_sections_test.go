@@ -41,6 +41,31 @@ func TestRenderConnectionInfoSection(t *testing.T) {
 	assertContains(t, content, "实时速率： ↓1.5 KB/s  ↑512 B/s")
 }
 
+func TestConnMetaRows(t *testing.T) {
+	s := plainDetailStyles()
+	conn := &model.Connection{
+		Metadata: model.Metadata{
+			Host:        "example.com",
+			SniffHost:   "cdn.example.com",
+			InboundName: "DEFAULT-MIXED",
+			InboundIP:   "127.0.0.1",
+			InboundPort: "7890",
+			InboundUser: "alice",
+			Process:     "curl",
+			UID:         1000,
+		},
+	}
+
+	content := strings.Join(renderConnectionInfoSection(conn, s), "\n")
+	assertContains(t, content, "入站： DEFAULT-MIXED (127.0.0.1:7890)")
+	assertContains(t, content, "用户： alice")
+	assertContains(t, content, "UID： 1000")
+	assertContains(t, content, "嗅探主机： cdn.example.com")
+	if strings.Contains(content, "DNS 模式") {
+		t.Fatalf("empty DNS mode should be hidden:\n%s", content)
+	}
+}
+
 func TestRenderJSONDetailSection(t *testing.T) {
 	s := plainDetailStyles()
 	conn := &model.Connection{
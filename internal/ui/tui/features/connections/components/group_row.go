@@ -0,0 +1,67 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/pkg/utils"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// 聚合行固定列宽
+const (
+	groupColWidthCount = 8
+	groupColWidthSpeed = 11
+	groupColWidthBytes = 10
+)
+
+// groupKeyWidth 分组名称列宽度（占满固定列之外的空间）
+func groupKeyWidth(pageWidth int) int {
+	const prefixWidth = 2
+	fixed := ColWidthClose + groupColWidthCount + 2*groupColWidthSpeed + 2*groupColWidthBytes + 6
+	return max(12, pageWidth-prefixWidth-fixed)
+}
+
+// RenderGroupHeader 渲染聚合模式表头
+func RenderGroupHeader(style lipgloss.Style, pageWidth int, by service.ConnGroupKey) string {
+	header := []string{
+		alignCenter("", ColWidthClose),
+		alignLeft(by.Label(), groupKeyWidth(pageWidth)),
+		alignRight("连接数", groupColWidthCount),
+		alignRight("↓速率", groupColWidthSpeed),
+		alignRight("↑速率", groupColWidthSpeed),
+		alignRight("↓下载", groupColWidthBytes),
+		alignRight("↑上传", groupColWidthBytes),
+	}
+	return style.Render("  " + strings.Join(header, " "))
+}
+
+// RenderGroupRow 渲染一行分组汇总，detail 为分组名后的补充信息（如进程的 PID/用户），expanded 表示组内连接已展开
+func RenderGroupRow(group service.ConnGroup, detail string, style lipgloss.Style, prefix string, pageWidth int, expanded bool) string {
+	marker := "▸"
+	if expanded {
+		marker = "▾"
+	}
+	key := group.Key
+	if detail != "" {
+		key += "  (" + detail + ")"
+	}
+	cells := []string{
+		alignCenter(marker, ColWidthClose),
+		alignLeft(key, groupKeyWidth(pageWidth)),
+		alignRight(fmt.Sprintf("%d", group.Count()), groupColWidthCount),
+		alignRight(formatSpeed(group.DownloadSpeed), groupColWidthSpeed),
+		alignRight(formatSpeed(group.UploadSpeed), groupColWidthSpeed),
+		alignRight(utils.FormatBytes(group.Download), groupColWidthBytes),
+		alignRight(utils.FormatBytes(group.Upload), groupColWidthBytes),
+	}
+	return style.Render(prefix + strings.Join(cells, " "))
+}
+
+func formatSpeed(bytesPerSec int64) string {
+	if bytesPerSec <= 0 {
+		return "-"
+	}
+	return utils.FormatBytes(bytesPerSec) + "/s"
+}
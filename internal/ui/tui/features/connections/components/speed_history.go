@@ -0,0 +1,74 @@
+package components
+
+import (
+	"strings"
+
+	"github.com/aimony/mihosh/internal/ui/tui/components/common"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// SpeedHistory 单个连接的上传/下载速率历史
+type SpeedHistory struct {
+	Up     []int64
+	Down   []int64
+	Closed bool // 连接已关闭，不再采样
+}
+
+// Append 返回追加一次采样后的新历史（不修改原值），超过 limit 时丢弃最旧的点
+func (h *SpeedHistory) Append(up, down int64, limit int) *SpeedHistory {
+	next := &SpeedHistory{}
+	if h != nil {
+		next.Up = appendLimited(h.Up, up, limit)
+		next.Down = appendLimited(h.Down, down, limit)
+	} else {
+		next.Up = []int64{up}
+		next.Down = []int64{down}
+	}
+	return next
+}
+
+func appendLimited(values []int64, v int64, limit int) []int64 {
+	start := 0
+	if limit > 0 && len(values) >= limit {
+		start = len(values) - limit + 1
+	}
+	out := make([]int64, 0, len(values)-start+1)
+	out = append(out, values[start:]...)
+	return append(out, v)
+}
+
+// renderSpeedHistory 渲染连接的实时速率图
+func renderSpeedHistory(history *SpeedHistory, width int) string {
+	if history == nil {
+		return ""
+	}
+
+	title := "实时速率"
+	if history.Closed {
+		title += "（连接已关闭）"
+	}
+	if len(history.Up) == 0 {
+		hint := "等待采样..."
+		if history.Closed {
+			hint = "无速率数据"
+		}
+		return lipgloss.JoinVertical(lipgloss.Left,
+			common.TableHeaderStyle.Render(title),
+			common.DimStyle.Render(hint),
+		)
+	}
+
+	chart := common.RenderDualSparkline(history.Up, history.Down, common.SparklineConfig{
+		Title:      title,
+		Width:      max(20, width-2),
+		Height:     4,
+		Color1:     lipgloss.Color("#00BFFF"), // 蓝色 - 上传
+		Color2:     lipgloss.Color("#9370DB"), // 紫色 - 下载
+		Label1:     "上传速度",
+		Label2:     "下载速度",
+		ShowXAxis:  true,
+		MaxSeconds: common.ChartPoints,
+		FormatFunc: FormatSpeed,
+	})
+	return strings.TrimRight(chart, "\n")
+}
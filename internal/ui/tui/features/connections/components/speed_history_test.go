@@ -0,0 +1,37 @@
+package components
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+)
+
+func TestSpeedHistoryAppendLimit(t *testing.T) {
+	var h *SpeedHistory
+	for i := int64(1); i <= 5; i++ {
+		h = h.Append(i, i*10, 3)
+	}
+	if len(h.Up) != 3 || h.Up[0] != 3 || h.Down[2] != 50 {
+		t.Fatalf("unexpected history: %+v", h)
+	}
+}
+
+func TestDetailModalLeftShowsSpeedHistory(t *testing.T) {
+	conn := &model.Connection{ID: "a", Metadata: model.Metadata{Host: "example.com"}}
+	history := &SpeedHistory{Up: []int64{1, 2}, Down: []int64{3, 4}}
+
+	out := RenderDetailModalLeft(conn, nil, history, 60, 60, 0, true)
+	if !strings.Contains(out, "实时速率") {
+		t.Fatalf("expected speed chart in detail modal:\n%s", out)
+	}
+
+	history.Closed = true
+	if out := RenderDetailModalLeft(conn, nil, history, 60, 60, 0, true); !strings.Contains(out, "连接已关闭") {
+		t.Fatalf("expected closed marker:\n%s", out)
+	}
+
+	if out := RenderDetailModalLeft(conn, nil, nil, 60, 60, 0, true); strings.Contains(out, "实时速率") {
+		t.Fatalf("unexpected speed chart without history:\n%s", out)
+	}
+}
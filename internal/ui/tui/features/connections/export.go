@@ -0,0 +1,56 @@
+package connections
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// exportView 导出当前视图（活跃或历史）中满足过滤条件的连接
+func (s State) exportView(format service.ConnExportFormat) (State, tea.Cmd) {
+	now := time.Now()
+	var records []service.ConnExportRecord
+	if s.connViewMode == ConnViewActive {
+		if s.Connections != nil {
+			for _, c := range visibleConnections(s.focusConnections(s.Connections.Connections), s.connFilter, s.connSortBy, s.connSortDesc) {
+				records = append(records, service.NewConnExportRecord(c, time.Time{}, now))
+			}
+		}
+	} else {
+		closedAt := s.closedTimesByID()
+		for _, c := range visibleConnections(s.focusConnections(s.ClosedConnections()), s.connFilter, s.connSortBy, s.connSortDesc) {
+			records = append(records, service.NewConnExportRecord(c, closedAt[c.ID], now))
+		}
+	}
+
+	if len(records) == 0 {
+		s.connNotice = "当前视图没有可导出的连接"
+		return s, nil
+	}
+	s.connNotice = fmt.Sprintf("正在导出 %d 个连接...", len(records))
+	return s, ExportConnections(records, format)
+}
+
+// closedTimesByID 历史连接的关闭时间
+func (s State) closedTimesByID() map[string]time.Time {
+	times := make(map[string]time.Time, s.closedCount)
+	for i := 0; i < s.closedCount; i++ {
+		idx := (s.closedHead - 1 - i + len(s.closedConns)) % len(s.closedConns)
+		if _, ok := times[s.closedConns[idx].ID]; !ok {
+			times[s.closedConns[idx].ID] = s.closedTimes[idx]
+		}
+	}
+	return times
+}
+
+// ApplyConnectionsExported 显示导出结果
+func (s State) ApplyConnectionsExported(path string, count int, err error) State {
+	if err != nil {
+		s.connNotice = fmt.Sprintf("导出失败: %v", err)
+	} else {
+		s.connNotice = fmt.Sprintf("已导出 %d 个连接到 %s", count, path)
+	}
+	return s
+}
@@ -0,0 +1,45 @@
+package connections
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/infrastructure/api"
+)
+
+func TestExportViewUsesFilteredHistory(t *testing.T) {
+	s := State{}
+	s = s.ApplyWSConnections(api.ConnectionsData{Connections: []model.Connection{
+		{ID: "a", Metadata: model.Metadata{Host: "a.com"}},
+		{ID: "b", Metadata: model.Metadata{Host: "b.org"}},
+	}})
+	s = s.ApplyWSConnections(api.ConnectionsData{})
+	s.setConnViewMode(ConnViewHistory)
+	s.connFilter = "host:.com"
+
+	times := s.closedTimesByID()
+	if len(times) != 2 || times["a"].IsZero() || time.Since(times["a"]) > time.Minute {
+		t.Fatalf("unexpected closed times: %v", times)
+	}
+
+	next, cmd := s.exportView(service.ConnExportCSV)
+	if cmd == nil || next.connNotice != "正在导出 1 个连接..." {
+		t.Fatalf("expected export of one connection, notice=%q", next.connNotice)
+	}
+}
+
+func TestExportViewEmpty(t *testing.T) {
+	s := State{}
+	next, cmd := s.exportView(service.ConnExportJSONL)
+	if cmd != nil || next.connNotice == "" {
+		t.Fatalf("empty view should not export")
+	}
+
+	next = next.ApplyConnectionsExported("/tmp/x.jsonl", 3, nil)
+	if !strings.Contains(next.connNotice, "/tmp/x.jsonl") {
+		t.Fatalf("unexpected notice %q", next.connNotice)
+	}
+}
@@ -0,0 +1,73 @@
+package connections
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func filterTestConns() []model.Connection {
+	return []model.Connection{
+		{ID: "a", Chains: []string{"DIRECT"}, Metadata: model.Metadata{Network: "udp", Host: "dns.google", DestinationPort: "53"}},
+		{ID: "b", Chains: []string{"HK", "Proxy"}, Metadata: model.Metadata{Network: "tcp", Host: "www.google.com", DestinationPort: "443"}},
+	}
+}
+
+func TestFilterConnections_UsesQueryLanguage(t *testing.T) {
+	got := filterConnections(filterTestConns(), "google -net:udp")
+	if len(got) != 1 || got[0].ID != "b" {
+		t.Fatalf("expected only b, got %+v", got)
+	}
+}
+
+func TestFilterConnections_InvalidQueryFallsBackToLiteral(t *testing.T) {
+	// 括号未闭合，退回原文子串匹配
+	got := filterConnections(filterTestConns(), "(dns.google")
+	if len(got) != 0 {
+		t.Fatalf("literal fallback should not match, got %d", len(got))
+	}
+	got = filterConnections(filterTestConns(), "hots:x")
+	if len(got) != 0 {
+		t.Fatalf("unknown field should fall back to literal match, got %d", len(got))
+	}
+}
+
+func TestSelectedConnection_FollowsQueryFilter(t *testing.T) {
+	s := State{Connections: &model.ConnectionsResponse{Connections: filterTestConns()}, connFilter: "dport:443"}
+	if s.filteredConnCount() != 1 {
+		t.Fatalf("expected 1 filtered connection, got %d", s.filteredConnCount())
+	}
+	if conn := s.selectedConnection(); conn == nil || conn.ID != "b" {
+		t.Fatalf("expected b selected, got %+v", conn)
+	}
+}
+
+func TestRenderFilterLine_ShowsParseError(t *testing.T) {
+	state := PageState{
+		Connections: &model.ConnectionsResponse{Connections: filterTestConns()},
+		Width:       120,
+		Height:      30,
+		FilterText:  "hots:google",
+	}
+	view := RenderConnectionsPage(state)
+	if !strings.Contains(view, "未知字段") {
+		t.Fatalf("expected parse error in filter line")
+	}
+}
+
+func TestFilterMode_AcceptsDigitsAndUnicode(t *testing.T) {
+	s := State{}
+	s, _ = s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")}, nil, 0)
+	if !s.TextInputActive() {
+		t.Fatalf("expected filter input to be active")
+	}
+	for _, r := range "dport:443 进程" {
+		s, _ = s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}}, nil, 0)
+	}
+	s, _ = s.Update(tea.KeyMsg{Type: tea.KeyBackspace}, nil, 0)
+	if s.connFilter != "dport:443 进" {
+		t.Fatalf("unexpected filter %q", s.connFilter)
+	}
+}
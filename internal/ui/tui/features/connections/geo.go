@@ -0,0 +1,98 @@
+package connections
+
+import (
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/domain/model"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// geoBatchSize 每次补全最多查询的 IP 数，其余留到下一次推送
+const geoBatchSize = 64
+
+// RequestGeoTags 为尚未补全国家/ASN 的目标 IP 发起一次批量查询（同一时间只有一批在进行）
+func (s State) RequestGeoTags() (State, tea.Cmd) {
+	if s.geo == nil || s.geoResolving || s.Connections == nil {
+		return s, nil
+	}
+	var ips []string
+	seen := make(map[string]bool)
+	for _, c := range s.Connections.Connections {
+		ip := c.Metadata.DestinationIP
+		if seen[ip] || !service.IsPublicIP(ip) {
+			continue
+		}
+		seen[ip] = true
+		if _, done := s.geoTags[ip]; done {
+			continue
+		}
+		// mihomo 已提供国家与 ASN 时无需查询
+		if tag := c.DestinationGeo(); tag.CountryCode != "" && tag.ASN != 0 {
+			continue
+		}
+		ips = append(ips, ip)
+		if len(ips) == geoBatchSize {
+			break
+		}
+	}
+	if len(ips) == 0 {
+		return s, nil
+	}
+	s.geoResolving = true
+	return s, ResolveGeoTags(s.geo, ips, s.geoCachePath)
+}
+
+// ApplyGeoTags 合并查询结果并重新标注当前连接
+func (s State) ApplyGeoTags(tags map[string]model.GeoTag) State {
+	s.geoResolving = false
+	if len(tags) == 0 {
+		return s
+	}
+	merged := make(map[string]model.GeoTag, len(s.geoTags)+len(tags))
+	// 只保留仍在使用的 IP，避免长时间运行后无限增长
+	if s.Connections != nil {
+		for _, c := range s.Connections.Connections {
+			if tag, ok := s.geoTags[c.Metadata.DestinationIP]; ok {
+				merged[c.Metadata.DestinationIP] = tag
+			}
+		}
+	}
+	for ip, tag := range tags {
+		merged[ip] = tag
+	}
+	s.geoTags = merged
+	return s.reannotate()
+}
+
+// reannotate 用最新的补全结果重新标注当前连接
+func (s State) reannotate() State {
+	if s.Connections == nil {
+		return s
+	}
+	resp := *s.Connections
+	resp.Connections = s.annotate(resp.Connections)
+	s.Connections = &resp
+	prev := make(map[string]model.Connection, len(resp.Connections))
+	for _, c := range resp.Connections {
+		prev[c.ID] = c
+	}
+	s.PrevConnIDs = prev
+	return s
+}
+
+// annotate 为连接附加已补全的国家/ASN 与来源设备名（返回新切片，不修改推送数据）
+func (s State) annotate(conns []model.Connection) []model.Connection {
+	if (len(s.geoTags) == 0 && len(s.sourceNames) == 0) || len(conns) == 0 {
+		return conns
+	}
+	out := make([]model.Connection, len(conns))
+	copy(out, conns)
+	for i := range out {
+		if tag, ok := s.geoTags[out[i].Metadata.DestinationIP]; ok && !tag.Empty() {
+			out[i].Geo = &tag
+		}
+		if name := s.sourceNames[out[i].Metadata.SourceIP]; name != "" {
+			out[i].SourceName = name
+		}
+	}
+	return out
+}
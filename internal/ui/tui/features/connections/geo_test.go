@@ -0,0 +1,49 @@
+package connections
+
+import (
+	"testing"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/infrastructure/api"
+)
+
+func TestRequestAndApplyGeoTags(t *testing.T) {
+	conns := []model.Connection{
+		{ID: "a", Metadata: model.Metadata{DestinationIP: "1.1.1.1"}},
+		{ID: "b", Metadata: model.Metadata{DestinationIP: "1.1.1.1"}},
+		{ID: "c", Metadata: model.Metadata{DestinationIP: "192.168.1.10"}},
+	}
+	s := State{}.WithGeoCache(service.NewGeoCache(service.NewHTTPGeoProvider("http://127.0.0.1:0/{ip}"), 0, 0), "")
+	s = s.ApplyWSConnections(api.ConnectionsData{Connections: conns})
+
+	s, cmd := s.RequestGeoTags()
+	if cmd == nil || !s.geoResolving {
+		t.Fatalf("expected geo lookup to start")
+	}
+	if _, again := s.RequestGeoTags(); again != nil {
+		t.Fatalf("only one batch should be in flight")
+	}
+
+	s = s.ApplyGeoTags(map[string]model.GeoTag{"1.1.1.1": {CountryCode: "AU", ASN: 13335}})
+	if s.geoResolving {
+		t.Fatalf("resolving flag not cleared")
+	}
+	for _, c := range s.Connections.Connections[:2] {
+		if c.Geo == nil || c.Geo.CountryCode != "AU" {
+			t.Fatalf("connection %s not annotated: %+v", c.ID, c.Geo)
+		}
+	}
+	if conns[0].Geo != nil {
+		t.Fatalf("pushed data must not be modified")
+	}
+
+	// 后续推送沿用已补全的结果，不再重复查询
+	s = s.ApplyWSConnections(api.ConnectionsData{Connections: conns})
+	if s.Connections.Connections[0].Geo == nil {
+		t.Fatalf("geo tag lost on next push")
+	}
+	if _, cmd := s.RequestGeoTags(); cmd != nil {
+		t.Fatalf("resolved IPs should not be looked up again")
+	}
+}
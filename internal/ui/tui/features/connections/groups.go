@@ -0,0 +1,149 @@
+package connections
+
+import (
+	"fmt"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/domain/model"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// connRow 连接列表中的一行：聚合模式下为分组行或展开后的连接行，否则为连接行
+type connRow struct {
+	group    *service.ConnGroup
+	conn     *model.Connection
+	expanded bool
+}
+
+// buildConnRows 过滤、排序，并在聚合模式下按分组展开，返回列表的显示行
+func buildConnRows(conns []model.Connection, filter, sortBy string, sortDesc bool, groupBy service.ConnGroupKey, expanded map[string]bool) []connRow {
+	return groupRows(visibleConnections(conns, filter, sortBy, sortDesc), groupBy, expanded)
+}
+
+// groupRows 将已过滤排序的连接转换为显示行
+func groupRows(visible []model.Connection, groupBy service.ConnGroupKey, expanded map[string]bool) []connRow {
+	if groupBy == "" {
+		rows := make([]connRow, len(visible))
+		for i := range visible {
+			rows[i] = connRow{conn: &visible[i]}
+		}
+		return rows
+	}
+
+	groups := service.GroupConnections(visible, groupBy)
+	rows := make([]connRow, 0, len(groups))
+	for i := range groups {
+		g := &groups[i]
+		open := expanded[g.Key]
+		rows = append(rows, connRow{group: g, expanded: open})
+		if !open {
+			continue
+		}
+		for j := range g.Connections {
+			rows = append(rows, connRow{conn: &g.Connections[j]})
+		}
+	}
+	return rows
+}
+
+// pageRows PageState 对应的显示行
+func pageRows(state PageState) []connRow {
+	return buildConnRows(connectionsByViewMode(state), state.FilterText, state.SortBy, state.SortDesc, state.GroupBy, state.ExpandedGroups)
+}
+
+// viewConnections 当前视图（活跃或历史）的全部连接，跳转聚焦规则时只含该规则匹配的连接
+func (s State) viewConnections() []model.Connection {
+	if s.connViewMode == ConnViewActive {
+		if s.Connections == nil {
+			return nil
+		}
+		return s.focusConnections(s.Connections.Connections)
+	}
+	return s.focusConnections(s.ClosedConnections())
+}
+
+// rows 当前视图下的显示行
+func (s State) rows() []connRow {
+	return buildConnRows(s.viewConnections(), s.connFilter, s.connSortBy, s.connSortDesc, s.connGroupBy, s.connExpanded)
+}
+
+// RequestProcessDetails 按进程聚合时，通过 /proc 补充各进程的 PID、用户与 systemd 单元
+func (s State) RequestProcessDetails(resolver *service.IPResolver) (State, tea.Cmd) {
+	if resolver == nil || s.connGroupBy != service.GroupByProcess || s.procResolving {
+		return s, nil
+	}
+	conns := s.viewConnections()
+	if len(conns) == 0 {
+		return s, nil
+	}
+	s.procResolving = true
+	return s, ResolveProcessDetails(resolver, conns)
+}
+
+// ApplyProcessDetails 更新进程信息
+func (s State) ApplyProcessDetails(details map[string]string) State {
+	s.procResolving = false
+	s.procDetails = details
+	return s
+}
+
+// selectedRow 当前选中的行
+func (s State) selectedRow() (connRow, bool) {
+	rows := s.rows()
+	if s.selectedConn >= 0 && s.selectedConn < len(rows) {
+		return rows[s.selectedConn], true
+	}
+	return connRow{}, false
+}
+
+// cycleGroupBy 在平铺与各聚合维度之间切换
+func (s State) cycleGroupBy() State {
+	next := service.ConnGroupKey("")
+	if s.connGroupBy == "" {
+		next = service.ConnGroupKeys[0]
+	} else {
+		for i, key := range service.ConnGroupKeys {
+			if key == s.connGroupBy && i+1 < len(service.ConnGroupKeys) {
+				next = service.ConnGroupKeys[i+1]
+			}
+		}
+	}
+
+	s.connGroupBy = next
+	s.connExpanded = nil
+	s.procDetails = nil
+	s.selectedConn = 0
+	s.connScrollTop = 0
+	if next == "" {
+		s.connNotice = "已取消聚合"
+	} else {
+		s.connNotice = fmt.Sprintf("按%s聚合，Enter 展开/收起", next.Label())
+	}
+	return s
+}
+
+// toggleGroup 展开或收起分组
+func (s State) toggleGroup(key string) State {
+	expanded := make(map[string]bool, len(s.connExpanded)+1)
+	for k, v := range s.connExpanded {
+		expanded[k] = v
+	}
+	if expanded[key] {
+		delete(expanded, key)
+	} else {
+		expanded[key] = true
+	}
+	s.connExpanded = expanded
+	return s
+}
+
+// groupCount 聚合模式下的分组数
+func groupCount(rows []connRow) int {
+	n := 0
+	for _, row := range rows {
+		if row.group != nil {
+			n++
+		}
+	}
+	return n
+}
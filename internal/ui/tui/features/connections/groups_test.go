@@ -0,0 +1,119 @@
+package connections
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/domain/model"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func groupTestConns() []model.Connection {
+	return []model.Connection{
+		{ID: "a", Download: 100, DownloadSpeed: 2048, Metadata: model.Metadata{Process: "chrome", Host: "www.google.com"}},
+		{ID: "b", Download: 900, Metadata: model.Metadata{Process: "curl", Host: "example.com"}},
+		{ID: "c", Download: 300, DownloadSpeed: 1024, Metadata: model.Metadata{Process: "chrome", Host: "mail.google.com"}},
+	}
+}
+
+func TestCycleGroupBy(t *testing.T) {
+	s := State{Connections: &model.ConnectionsResponse{Connections: groupTestConns()}}
+	s.selectedConn = 2
+
+	s, _ = s.Update(runeKey("g"), nil, 0)
+	if s.connGroupBy != service.GroupByProcess || s.selectedConn != 0 {
+		t.Fatalf("expected process grouping with selection reset, got %q/%d", s.connGroupBy, s.selectedConn)
+	}
+	if got := s.filteredConnCount(); got != 2 {
+		t.Fatalf("expected 2 group rows, got %d", got)
+	}
+
+	for range service.ConnGroupKeys {
+		s, _ = s.Update(runeKey("g"), nil, 0)
+	}
+	if s.connGroupBy != "" || s.filteredConnCount() != 3 {
+		t.Fatalf("expected to cycle back to the flat list, got %q", s.connGroupBy)
+	}
+}
+
+func TestGroupExpandAndSelect(t *testing.T) {
+	s := State{Connections: &model.ConnectionsResponse{Connections: groupTestConns()}}
+	s, _ = s.Update(runeKey("g"), nil, 0)
+
+	// 组按总流量排序：curl(900) 在前，chrome(400) 在后
+	s, _ = s.Update(tea.KeyMsg{Type: tea.KeyDown}, nil, 0)
+	if row, _ := s.selectedRow(); row.group == nil || row.group.Key != "chrome" {
+		t.Fatalf("expected chrome group selected, got %+v", row)
+	}
+	if s.selectedConnection() != nil {
+		t.Fatal("a group row has no selected connection")
+	}
+
+	s, cmd := s.Update(tea.KeyMsg{Type: tea.KeyEnter}, nil, 0)
+	if cmd != nil || s.connDetailMode || s.filteredConnCount() != 4 {
+		t.Fatalf("enter on a group should expand it, rows=%d", s.filteredConnCount())
+	}
+
+	s, _ = s.Update(tea.KeyMsg{Type: tea.KeyDown}, nil, 0)
+	if conn := s.selectedConnection(); conn == nil || conn.ID != "a" {
+		t.Fatalf("expected first chrome connection, got %+v", conn)
+	}
+
+	// 实时更新后展开状态保留
+	s.Connections = &model.ConnectionsResponse{Connections: append(groupTestConns(), model.Connection{ID: "d", Metadata: model.Metadata{Process: "chrome"}})}
+	if got := s.filteredConnCount(); got != 5 {
+		t.Fatalf("expanded group should include new connections, rows=%d", got)
+	}
+
+	s.selectedConn = 1
+	s, _ = s.Update(tea.KeyMsg{Type: tea.KeyEnter}, nil, 0)
+	if s.filteredConnCount() != 2 {
+		t.Fatalf("second enter should collapse the group, rows=%d", s.filteredConnCount())
+	}
+}
+
+func TestGroupViewRendering(t *testing.T) {
+	s := State{Connections: &model.ConnectionsResponse{Connections: groupTestConns()}}
+	s, _ = s.Update(runeKey("g"), nil, 0)
+	s = s.toggleGroup("chrome")
+
+	out := RenderConnectionsPage(s.ToPageState(nil, 120, 40))
+	for _, want := range []string{"进程: 2 组", "连接数", "▾", "chrome", "3.0 KB/s", "▸", "curl", "mail.google.com"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in grouped page:\n%s", want, out)
+		}
+	}
+}
+
+func TestGroupFilterApplies(t *testing.T) {
+	s := State{Connections: &model.ConnectionsResponse{Connections: groupTestConns()}, connFilter: "host:google"}
+	s, _ = s.Update(runeKey("g"), nil, 0)
+	rows := s.rows()
+	if len(rows) != 1 || rows[0].group.Key != "chrome" || rows[0].group.Count() != 2 {
+		t.Fatalf("filter should apply before grouping, got %+v", rows)
+	}
+}
+
+func TestProcessDetailsInProcessGrouping(t *testing.T) {
+	resolver := service.NewIPResolver()
+	s := State{Connections: &model.ConnectionsResponse{Connections: groupTestConns()}}
+	if _, cmd := s.RequestProcessDetails(resolver); cmd != nil {
+		t.Fatal("process details are only resolved in process grouping")
+	}
+
+	s, _ = s.Update(runeKey("g"), nil, 0)
+	s, cmd := s.RequestProcessDetails(resolver)
+	if cmd == nil || !s.procResolving {
+		t.Fatal("expected a resolve command in process grouping")
+	}
+	if _, again := s.RequestProcessDetails(resolver); again != nil {
+		t.Fatal("should not resolve again while a request is pending")
+	}
+
+	s = s.ApplyProcessDetails(map[string]string{"chrome": "pid 42 · alice"})
+	view := RenderConnectionsPage(s.ToPageState(nil, 160, 40))
+	if s.procResolving || !strings.Contains(view, "chrome  (pid 42 · alice)") {
+		t.Fatalf("expected process detail in group row:\n%s", view)
+	}
+}
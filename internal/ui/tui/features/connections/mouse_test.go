@@ -407,6 +407,7 @@ func TestConnsHandleMouseLeft_ClickInsideDetailKeepsModal(t *testing.T) {
 	left, top, right, bottom := components.ResolveConnectionDetailModalBounds(
 		state.connDetailSnapshot,
 		state.connIPInfo,
+		state.detailSpeeds(),
 		width,
 		height,
 		state.connDetailLeftScroll,
@@ -0,0 +1,188 @@
+package connections
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/ui/tui/messages"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// FocusRule 只显示指定规则匹配的活跃与历史连接（label 为显示用的规则文本）
+func (s State) FocusRule(key service.RuleKey, label string) State {
+	s.resetForNavigation()
+	s.ruleFocus = &key
+	s.ruleFocusLabel = label
+	// 活跃连接中没有匹配而历史中有时直接显示历史
+	mode := ConnViewActive
+	if s.Connections != nil && len(s.focusConnections(s.Connections.Connections)) == 0 &&
+		len(s.focusConnections(s.ClosedConnections())) > 0 {
+		mode = ConnViewHistory
+	}
+	s.setConnViewMode(mode)
+	return s
+}
+
+// FocusConnection 选中来源与目标一致的连接并打开详情，先查活跃连接再查历史连接
+func (s State) FocusConnection(sourceIP, sourcePort, destHost, destPort string) (State, tea.Cmd) {
+	s.resetForNavigation()
+	s.ruleFocus = nil
+	s.ruleFocusLabel = ""
+
+	var active []model.Connection
+	if s.Connections != nil {
+		active = s.Connections.Connections
+	}
+	mode := ConnViewActive
+	conn := findByEndpoint(active, sourceIP, sourcePort, destHost, destPort)
+	if conn == nil {
+		mode = ConnViewHistory
+		conn = findByEndpoint(s.ClosedConnections(), sourceIP, sourcePort, destHost, destPort)
+	}
+	if conn == nil {
+		s.connNotice = fmt.Sprintf("未找到 %s:%s → %s:%s 的连接，可能已超出历史记录", sourceIP, sourcePort, destHost, destPort)
+		return s, nil
+	}
+
+	s.setConnViewMode(mode)
+	s.reselect(conn.ID)
+	snapshot := *conn
+	s.connDetailSnapshot = &snapshot
+	s.connDetailMode = true
+	s.navDetail = true
+	s.connIPInfo = nil
+	return s, FetchIPInfo(s.geo, conn.Metadata.DestinationIP)
+}
+
+// CanGoBack 没有需要 Esc 关闭的弹窗或过滤时返回 true（跳转打开的详情除外）
+func (s State) CanGoBack() bool {
+	if s.topNModalMode || s.TextInputActive() || s.connFilter != "" {
+		return false
+	}
+	return !s.connDetailMode || s.navDetail
+}
+
+// NavSnapshot 跳转前连接列表的过滤、选中、滚动位置与视图，返回时恢复
+type NavSnapshot struct {
+	filter         string
+	selected       int
+	scrollTop      int
+	viewMode       int
+	ruleFocus      *service.RuleKey
+	ruleFocusLabel string
+}
+
+// NavSnapshot 记录跳转会清除的列表状态
+func (s State) NavSnapshot() NavSnapshot {
+	return NavSnapshot{
+		filter:         s.connFilter,
+		selected:       s.selectedConn,
+		scrollTop:      s.connScrollTop,
+		viewMode:       s.connViewMode,
+		ruleFocus:      s.ruleFocus,
+		ruleFocusLabel: s.ruleFocusLabel,
+	}
+}
+
+// RestoreNav 关闭跳转打开的详情并恢复跳转前的列表状态
+func (s State) RestoreNav(snap NavSnapshot) State {
+	s.resetForNavigation()
+	s.setConnViewMode(snap.viewMode)
+	s.connFilter = snap.filter
+	s.ruleFocus = snap.ruleFocus
+	s.ruleFocusLabel = snap.ruleFocusLabel
+	s.selectedConn = snap.selected
+	s.connScrollTop = snap.scrollTop
+	return s
+}
+
+// resetForNavigation 关闭弹窗、详情与过滤，跳转后从干净的列表开始
+func (s *State) resetForNavigation() {
+	s.closeConnectionDetail()
+	s.closeTopNModal()
+	s.bulkCloseMode = false
+	s.columnPickerMode = false
+	s.connFilterMode = false
+	s.connFilter = ""
+}
+
+// clearRuleFocus 取消规则范围并回到列表顶部
+func (s *State) clearRuleFocus() {
+	s.ruleFocus = nil
+	s.ruleFocusLabel = ""
+	s.selectedConn = 0
+	s.connScrollTop = 0
+}
+
+// focusConnections 只保留聚焦规则匹配的连接，未聚焦时原样返回
+func (s State) focusConnections(conns []model.Connection) []model.Connection {
+	if s.ruleFocus == nil {
+		return conns
+	}
+	var matched []model.Connection
+	for _, c := range conns {
+		if service.NewRuleKey(c.Rule, c.RulePayload) == *s.ruleFocus {
+			matched = append(matched, c)
+		}
+	}
+	return matched
+}
+
+// activeResponse 规则聚焦后的活跃连接响应（总流量保持不变）
+func (s State) activeResponse() *model.ConnectionsResponse {
+	if s.ruleFocus == nil || s.Connections == nil {
+		return s.Connections
+	}
+	resp := *s.Connections
+	resp.Connections = s.focusConnections(resp.Connections)
+	return &resp
+}
+
+// focusLabel 规则聚焦时的提示文本
+func (s State) focusLabel() string {
+	if s.ruleFocus == nil {
+		return ""
+	}
+	active := 0
+	if s.Connections != nil {
+		active = len(s.focusConnections(s.Connections.Connections))
+	}
+	closed := len(s.focusConnections(s.ClosedConnections()))
+	return fmt.Sprintf("规则 %s: 活跃 %d · 历史 %d（Esc 返回）", s.ruleFocusLabel, active, closed)
+}
+
+// showConnectionRule 跳转到规则页选中连接匹配的规则（详情中为正在查看的连接）
+func (s State) showConnectionRule() tea.Cmd {
+	conn := s.selectedConnection()
+	if s.connDetailMode {
+		conn = s.connDetailSnapshot
+	}
+	if conn == nil || conn.Rule == "" {
+		return nil
+	}
+	label := conn.Rule
+	if conn.RulePayload != "" {
+		label += "," + conn.RulePayload
+	}
+	return messages.Navigate(messages.NavTarget{
+		Kind:  messages.NavToRule,
+		Rule:  service.NewRuleKey(conn.Rule, conn.RulePayload),
+		Label: label,
+	})
+}
+
+// findByEndpoint 在列表中查找来源与目标一致的连接，destHost 可为域名或 IP
+func findByEndpoint(conns []model.Connection, sourceIP, sourcePort, destHost, destPort string) *model.Connection {
+	for i := range conns {
+		m := conns[i].Metadata
+		if m.SourceIP != sourceIP || m.SourcePort != sourcePort || m.DestinationPort != destPort {
+			continue
+		}
+		if strings.EqualFold(m.Host, destHost) || strings.EqualFold(m.SniffHost, destHost) || m.DestinationIP == destHost {
+			return &conns[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package connections
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/infrastructure/api"
+	"github.com/aimony/mihosh/internal/ui/tui/messages"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func navConn(id, rule, payload, src, host string) model.Connection {
+	return model.Connection{ID: id, Rule: rule, RulePayload: payload, Metadata: model.Metadata{
+		SourceIP: "192.168.1.2", SourcePort: src, Host: host, DestinationIP: "1.1.1.1", DestinationPort: "443",
+	}}
+}
+
+func TestFocusRuleScopesActiveAndHistory(t *testing.T) {
+	s := State{}
+	s = s.ApplyWSConnections(api.ConnectionsData{Connections: []model.Connection{
+		navConn("a", "DomainSuffix", "google.com", "1000", "www.google.com"),
+		navConn("b", "Match", "", "1001", "example.com"),
+	}})
+	s = s.ApplyWSConnections(api.ConnectionsData{Connections: []model.Connection{
+		navConn("b", "Match", "", "1001", "example.com"),
+		navConn("c", "DomainSuffix", "google.com", "1002", "mail.google.com"),
+	}})
+
+	s = s.FocusRule(service.NewRuleKey("DOMAIN-SUFFIX", "google.com"), "DomainSuffix,google.com")
+	if conns := s.viewConnections(); len(conns) != 1 || conns[0].ID != "c" {
+		t.Fatalf("active view should only keep c, got %+v", conns)
+	}
+	page := s.ToPageState(nil, 120, 40)
+	if !strings.Contains(page.FocusLabel, "活跃 1 · 历史 1") {
+		t.Fatalf("unexpected focus label %q", page.FocusLabel)
+	}
+	s.setConnViewMode(ConnViewHistory)
+	if conns := s.viewConnections(); len(conns) != 1 || conns[0].ID != "a" {
+		t.Fatalf("history view should only keep a, got %+v", conns)
+	}
+	if !s.CanGoBack() {
+		t.Fatal("focused list should allow going back")
+	}
+
+	// 没有导航栈时 Esc 直接取消规则范围
+	s, _ = s.Update(tea.KeyMsg{Type: tea.KeyEsc}, nil, 0)
+	if s.ruleFocus != nil || len(s.viewConnections()) != 1 {
+		t.Fatalf("esc should clear the rule focus, got %+v", s.viewConnections())
+	}
+}
+
+func TestFocusConnectionOpensDetail(t *testing.T) {
+	s := State{}
+	s = s.ApplyWSConnections(api.ConnectionsData{Connections: []model.Connection{
+		navConn("a", "DomainSuffix", "google.com", "1000", "www.google.com"),
+	}})
+	s = s.ApplyWSConnections(api.ConnectionsData{})
+
+	before := s.NavSnapshot()
+	s, _ = s.FocusConnection("192.168.1.2", "1000", "www.google.com", "443")
+	if !s.connDetailMode || s.connDetailSnapshot.ID != "a" || s.connViewMode != ConnViewHistory {
+		t.Fatalf("expected detail of closed connection a, got mode=%v view=%d", s.connDetailMode, s.connViewMode)
+	}
+	if !s.CanGoBack() {
+		t.Fatal("detail opened by navigation should allow going back")
+	}
+
+	// 详情中按 R 跳到匹配的规则
+	_, cmd := s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'R'}}, nil, 0)
+	if cmd == nil {
+		t.Fatal("R should request navigation")
+	}
+	nav, ok := cmd().(messages.NavigateMsg)
+	if !ok || nav.Target.Kind != messages.NavToRule || nav.Target.Rule != service.NewRuleKey("DomainSuffix", "google.com") {
+		t.Fatalf("unexpected navigation %+v", nav)
+	}
+
+	s = s.RestoreNav(before)
+	if s.connDetailMode || s.connViewMode != ConnViewActive {
+		t.Fatalf("going back should close the navigation detail, got mode=%v view=%d", s.connDetailMode, s.connViewMode)
+	}
+
+	s, _ = s.FocusConnection("192.168.1.2", "9999", "www.google.com", "443")
+	if s.connDetailMode || !strings.Contains(s.connNotice, "未找到") {
+		t.Fatalf("missing connection should only set a notice, got %q", s.connNotice)
+	}
+}
+
+func TestRestoreNavKeepsListState(t *testing.T) {
+	s := State{}
+	s = s.ApplyWSConnections(api.ConnectionsData{Connections: []model.Connection{
+		navConn("a", "DomainSuffix", "google.com", "1000", "www.google.com"),
+		navConn("b", "Match", "", "1001", "example.com"),
+		navConn("c", "DomainSuffix", "google.com", "1002", "mail.google.com"),
+	}})
+	s = s.ApplyWSConnections(api.ConnectionsData{Connections: []model.Connection{
+		navConn("b", "Match", "", "1001", "example.com"),
+		navConn("c", "DomainSuffix", "google.com", "1002", "mail.google.com"),
+	}})
+	s.setConnViewMode(ConnViewHistory)
+	s.connFilter = "google"
+	s.selectedConn, s.connScrollTop = 1, 1
+
+	// 跳转会清除过滤与选中位置，返回时恢复
+	before := s.NavSnapshot()
+	s = s.FocusRule(service.NewRuleKey("Match", ""), "Match")
+	if s.connFilter != "" || s.connViewMode != ConnViewActive || s.selectedConn != 0 {
+		t.Fatalf("focus should start from a clean list, got %q %d %d", s.connFilter, s.connViewMode, s.selectedConn)
+	}
+	s = s.RestoreNav(before)
+	if s.connFilter != "google" || s.connViewMode != ConnViewHistory || s.selectedConn != 1 || s.connScrollTop != 1 {
+		t.Fatalf("list state not restored: %q %d %d %d", s.connFilter, s.connViewMode, s.selectedConn, s.connScrollTop)
+	}
+	if s.ruleFocus != nil {
+		t.Fatal("rule focus should be cleared on return")
+	}
+}
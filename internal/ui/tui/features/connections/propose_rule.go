@@ -0,0 +1,23 @@
+package connections
+
+import (
+	"github.com/aimony/mihosh/internal/ui/tui/messages"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// proposeRule 为选中连接（详情中为正在查看的连接）打开添加规则弹窗
+func (s State) proposeRule() tea.Cmd {
+	conn := s.selectedConnection()
+	if s.connDetailMode {
+		conn = s.connDetailSnapshot
+	}
+	if conn == nil {
+		return nil
+	}
+	host := conn.Metadata.Host
+	if host == "" {
+		host = conn.Metadata.SniffHost
+	}
+	msg := messages.ProposeRuleMsg{Host: host, IP: conn.Metadata.DestinationIP}
+	return func() tea.Msg { return msg }
+}
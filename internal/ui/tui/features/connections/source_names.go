@@ -0,0 +1,49 @@
+package connections
+
+import (
+	"github.com/aimony/mihosh/internal/app/service"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// RequestSourceNames 为尚未识别的内网来源 IP 查询设备名（同一时间只有一批在进行）
+func (s State) RequestSourceNames(resolver *service.IPResolver) (State, tea.Cmd) {
+	if resolver == nil || s.namesResolving || s.Connections == nil {
+		return s, nil
+	}
+	var ips []string
+	seen := make(map[string]bool)
+	for _, c := range s.Connections.Connections {
+		ip := c.Metadata.SourceIP
+		if seen[ip] || !service.IsPrivateIP(ip) {
+			continue
+		}
+		seen[ip] = true
+		if _, done := s.sourceNames[ip]; !done {
+			ips = append(ips, ip)
+		}
+	}
+	if len(ips) == 0 {
+		return s, nil
+	}
+	s.namesResolving = true
+	return s, ResolveSourceNames(resolver, ips)
+}
+
+// ApplySourceNames 合并设备名查询结果并重新标注当前连接
+func (s State) ApplySourceNames(names map[string]string) State {
+	s.namesResolving = false
+	merged := make(map[string]string, len(s.sourceNames)+len(names))
+	// 只保留仍在使用的 IP，设备离开后下次出现时重新识别
+	if s.Connections != nil {
+		for _, c := range s.Connections.Connections {
+			if name, ok := s.sourceNames[c.Metadata.SourceIP]; ok {
+				merged[c.Metadata.SourceIP] = name
+			}
+		}
+	}
+	for ip, name := range names {
+		merged[ip] = name
+	}
+	s.sourceNames = merged
+	return s.reannotate()
+}
@@ -0,0 +1,55 @@
+package connections
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/infrastructure/api"
+	"github.com/aimony/mihosh/internal/ui/tui/features/connections/components"
+	"github.com/aimony/mihosh/internal/ui/tui/messages"
+)
+
+func TestRequestAndApplySourceNames(t *testing.T) {
+	conns := []model.Connection{
+		{ID: "a", Metadata: model.Metadata{SourceIP: "192.168.1.5", Host: "a.example"}},
+		{ID: "b", Metadata: model.Metadata{SourceIP: "192.168.1.5", Host: "b.example"}},
+		{ID: "c", Metadata: model.Metadata{SourceIP: "8.8.8.8", Host: "c.example"}},
+	}
+	resolver := service.NewIPResolverWithSources(service.IPSourceEnv{}, []service.IPSource{service.StaticIPSource{{IP: "192.168.1.5", Name: "NAS"}}})
+
+	s := State{}.ApplyWSConnections(api.ConnectionsData{Connections: conns})
+	s, cmd := s.RequestSourceNames(resolver)
+	if cmd == nil {
+		t.Fatalf("expected source name lookup to start")
+	}
+	if _, again := s.RequestSourceNames(resolver); again != nil {
+		t.Fatalf("only one batch should be in flight")
+	}
+
+	msg, ok := cmd().(messages.SourceNamesMsg)
+	if !ok || len(msg.Names) != 1 || msg.Names["192.168.1.5"] != "NAS" {
+		t.Fatalf("unexpected lookup result: %+v", msg)
+	}
+	s = s.ApplySourceNames(msg.Names)
+	for _, c := range s.Connections.Connections[:2] {
+		if c.SourceName != "NAS" {
+			t.Fatalf("connection %s not annotated", c.ID)
+		}
+	}
+
+	// 后续推送沿用已识别的名称，不再重复查询
+	s = s.ApplyWSConnections(api.ConnectionsData{Connections: conns})
+	if s.Connections.Connections[0].SourceName != "NAS" {
+		t.Fatalf("source name lost on next push")
+	}
+	if _, cmd := s.RequestSourceNames(resolver); cmd != nil {
+		t.Fatalf("known IPs should not be looked up again")
+	}
+
+	s = s.WithTableLayout([]string{components.ColumnSrcName, components.ColumnHost}, "")
+	if view := RenderConnectionsPage(s.ToPageState(nil, 120, 40)); !strings.Contains(view, "NAS") || !strings.Contains(view, "来源设备") {
+		t.Fatalf("source name column not rendered:\n%s", view)
+	}
+}
@@ -0,0 +1,96 @@
+package connections
+
+import (
+	"sort"
+	"time"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/ui/tui/components/common"
+	"github.com/aimony/mihosh/internal/ui/tui/features/connections/components"
+)
+
+// speedHistoryTopK 除详情弹窗中的连接外，额外记录速率历史的连接数
+const speedHistoryTopK = 10
+
+// connSpeed 连接的当前速率；内核未提供时按与上次推送的流量差推算
+func connSpeed(conn model.Connection, prev map[string]model.Connection, elapsed time.Duration) (up, down int64) {
+	if conn.UploadSpeed > 0 || conn.DownloadSpeed > 0 {
+		return conn.UploadSpeed, conn.DownloadSpeed
+	}
+	old, ok := prev[conn.ID]
+	if !ok || elapsed <= 0 {
+		return 0, 0
+	}
+	return deltaRate(conn.Upload-old.Upload, elapsed), deltaRate(conn.Download-old.Download, elapsed)
+}
+
+func deltaRate(delta int64, elapsed time.Duration) int64 {
+	if delta <= 0 {
+		return 0
+	}
+	return int64(float64(delta) / elapsed.Seconds())
+}
+
+// trackSpeeds 为详情中的连接与速率前 K 的连接追加一次采样，并刷新详情快照
+func (s *State) trackSpeeds(conns []model.Connection, prev map[string]model.Connection, now time.Time) {
+	var elapsed time.Duration
+	if !s.lastWSAt.IsZero() {
+		elapsed = now.Sub(s.lastWSAt)
+	}
+	s.lastWSAt = now
+
+	type sample struct {
+		conn     model.Connection
+		up, down int64
+	}
+	samples := make([]sample, len(conns))
+	for i, conn := range conns {
+		up, down := connSpeed(conn, prev, elapsed)
+		samples[i] = sample{conn: conn, up: up, down: down}
+	}
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].up+samples[i].down > samples[j].up+samples[j].down
+	})
+
+	detailID := ""
+	if s.connDetailMode && s.connDetailSnapshot != nil {
+		detailID = s.connDetailSnapshot.ID
+	}
+
+	histories := make(map[string]*components.SpeedHistory, speedHistoryTopK+1)
+	detailAlive := false
+	for i, smp := range samples {
+		id := smp.conn.ID
+		if id == detailID {
+			detailAlive = true
+			// 详情随推送实时更新，而不是停留在打开时的数值
+			live := smp.conn
+			live.UploadSpeed, live.DownloadSpeed = smp.up, smp.down
+			s.connDetailSnapshot = &live
+		} else if i >= speedHistoryTopK {
+			continue
+		}
+		histories[id] = s.connSpeeds[id].Append(smp.up, smp.down, common.ChartPoints)
+	}
+
+	// 详情中的连接已关闭：保留历史并停止采样
+	if detailID != "" && !detailAlive {
+		closed := components.SpeedHistory{Closed: true}
+		if old := s.connSpeeds[detailID]; old != nil {
+			closed.Up, closed.Down = old.Up, old.Down
+		}
+		histories[detailID] = &closed
+	}
+	s.connSpeeds = histories
+}
+
+// detailSpeeds 详情弹窗中的连接的速率历史
+func (s State) detailSpeeds() *components.SpeedHistory {
+	if !s.connDetailMode || s.connDetailSnapshot == nil {
+		return nil
+	}
+	if h := s.connSpeeds[s.connDetailSnapshot.ID]; h != nil {
+		return h
+	}
+	return &components.SpeedHistory{}
+}
@@ -0,0 +1,65 @@
+package connections
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/infrastructure/api"
+)
+
+func TestTrackSpeedsDerivesRateFromTotals(t *testing.T) {
+	t0 := time.Unix(1000, 0)
+	s := State{}
+	s = s.applyWSConnectionsAt(api.ConnectionsData{Connections: []model.Connection{{ID: "a", Upload: 100, Download: 1000}}}, t0)
+	s = s.applyWSConnectionsAt(api.ConnectionsData{Connections: []model.Connection{{ID: "a", Upload: 300, Download: 5000}}}, t0.Add(2*time.Second))
+
+	h := s.connSpeeds["a"]
+	if h == nil || len(h.Up) != 2 {
+		t.Fatalf("expected two samples, got %+v", h)
+	}
+	if h.Up[1] != 100 || h.Down[1] != 2000 {
+		t.Fatalf("unexpected derived rate: up=%d down=%d", h.Up[1], h.Down[1])
+	}
+}
+
+func TestTrackSpeedsKeepsTopKAndDetail(t *testing.T) {
+	var conns []model.Connection
+	for i := 0; i < speedHistoryTopK+5; i++ {
+		conns = append(conns, model.Connection{ID: fmt.Sprintf("c%d", i), DownloadSpeed: int64(100 + i)})
+	}
+	// 速率最低的连接在详情中打开，也应被记录
+	s := State{connDetailMode: true, connDetailSnapshot: &model.Connection{ID: "c0"}}
+	s = s.ApplyWSConnections(api.ConnectionsData{Connections: conns})
+
+	if len(s.connSpeeds) != speedHistoryTopK+1 {
+		t.Fatalf("expected %d tracked connections, got %d", speedHistoryTopK+1, len(s.connSpeeds))
+	}
+	if s.connSpeeds["c0"] == nil || s.connSpeeds["c1"] != nil {
+		t.Fatalf("unexpected tracked set: %v", s.connSpeeds)
+	}
+}
+
+func TestDetailSnapshotFollowsStream(t *testing.T) {
+	s := State{connDetailMode: true, connDetailSnapshot: &model.Connection{ID: "a", Download: 10}}
+	s = s.ApplyWSConnections(api.ConnectionsData{Connections: []model.Connection{{ID: "a", Download: 500, DownloadSpeed: 50}}})
+	if s.connDetailSnapshot.Download != 500 || s.connDetailSnapshot.DownloadSpeed != 50 {
+		t.Fatalf("detail snapshot not refreshed: %+v", s.connDetailSnapshot)
+	}
+
+	s = s.ApplyWSConnections(api.ConnectionsData{})
+	h := s.detailSpeeds()
+	if !h.Closed || len(h.Down) != 1 {
+		t.Fatalf("closed connection should keep its history: %+v", h)
+	}
+	if s.connDetailSnapshot.Download != 500 {
+		t.Fatalf("closed connection should keep its final totals: %+v", s.connDetailSnapshot)
+	}
+
+	// 关闭后不再采样
+	s = s.ApplyWSConnections(api.ConnectionsData{})
+	if h := s.detailSpeeds(); !h.Closed || len(h.Down) != 1 {
+		t.Fatalf("closed connection should stop sampling: %+v", h)
+	}
+}
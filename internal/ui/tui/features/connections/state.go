@@ -1,9 +1,9 @@
 package connections
 
 import (
-	"strings"
 	"time"
 
+	"github.com/aimony/mihosh/internal/app/service"
 	"github.com/aimony/mihosh/internal/domain/model"
 	"github.com/aimony/mihosh/internal/infrastructure/api"
 	"github.com/aimony/mihosh/internal/ui/tui/components/common"
@@ -43,12 +43,49 @@ type State struct {
 	connDetailFocusPanel  int // 0=左侧(基础+地理), 1=右侧(JSON)
 	connViewMode          int // 0=活跃, 1=历史
 
+	// 跨页跳转：只显示某条规则匹配的连接，navDetail 表示详情由跳转打开
+	ruleFocus      *service.RuleKey
+	ruleFocusLabel string
+	navDetail      bool
+
 	siteTests        []model.SiteTest
 	selectedSiteTest int
 	proxyAddr        string
 	topNModalMode    bool
 	topNModalScroll  int
 
+	bulkCloseMode     bool
+	bulkCloseOptions  []BulkCloseOption
+	bulkCloseSelected int
+	connNotice        string
+
+	// 连接表列布局与排序（为空时使用默认列、按到达顺序）
+	connColumns          []string
+	connSortBy           string
+	connSortDesc         bool
+	columnPickerMode     bool
+	columnPickerItems    []ColumnPickerItem
+	columnPickerSelected int
+
+	// 聚合模式（为空时平铺显示）与已展开的分组
+	connGroupBy   service.ConnGroupKey
+	connExpanded  map[string]bool
+	procDetails   map[string]string // 进程名 -> PID/用户/单元摘要
+	procResolving bool
+
+	// 目标 IP 的国家/ASN 补全（IP -> 结果，空结果表示未收录）
+	geo          *service.GeoCache
+	geoCachePath string // 非空时每批查询后持久化缓存
+	geoTags      map[string]model.GeoTag
+	geoResolving bool
+	// 来源 IP -> 设备名（空字符串表示已查过但没有名称）
+	sourceNames    map[string]string
+	namesResolving bool
+
+	// 详情中的连接与速率前 K 的连接的速率历史
+	connSpeeds map[string]*components.SpeedHistory
+	lastWSAt   time.Time
+
 	lastMouseTarget MouseTarget
 	lastMouseIndex  int
 	lastMouseAt     time.Time
@@ -62,6 +99,13 @@ func NewState(proxyAddr string, siteTests []model.SiteTest) State {
 	}
 }
 
+// WithGeoCache 设置地理位置查询（详情与连接表补全共用），cachePath 非空时持久化缓存
+func (s State) WithGeoCache(geo *service.GeoCache, cachePath string) State {
+	s.geo = geo
+	s.geoCachePath = cachePath
+	return s
+}
+
 // CalculateTopN 计算 Top N 吞吐量
 func (s State) CalculateTopN(n int, within time.Duration) []components.TopNItem {
 	stats := make(map[string]int64)
@@ -153,7 +197,7 @@ func (s State) ToPageState(chartData *model.ChartData, width, height int) PageSt
 	}
 
 	return PageState{
-		Connections:        s.Connections,
+		Connections:        s.activeResponse(),
 		Width:              width,
 		Height:             height,
 		SelectedIndex:      s.selectedConn,
@@ -162,17 +206,32 @@ func (s State) ToPageState(chartData *model.ChartData, width, height int) PageSt
 		FilterMode:         s.connFilterMode,
 		DetailMode:         s.connDetailMode,
 		SelectedConnection: s.connDetailSnapshot,
+		DetailSpeeds:       s.detailSpeeds(),
 		IPInfo:             s.connIPInfo,
 		DetailLeftScroll:   s.connDetailLeftScroll,
 		DetailRightScroll:  s.connDetailRightScroll,
 		DetailFocusPanel:   s.connDetailFocusPanel,
 		ChartData:          chartData,
 		ViewMode:           s.connViewMode,
-		ClosedConnections:  s.ClosedConnections(),
+		ClosedConnections:  s.focusConnections(s.ClosedConnections()),
+		FocusLabel:         s.focusLabel(),
 		SiteTests:          s.siteTests,
 		SelectedSiteTest:   s.selectedSiteTest,
 		TopNItems:          topNItems,
 		TopNModalMode:      s.topNModalMode,
+		BulkCloseMode:      s.bulkCloseMode,
+		BulkCloseOptions:   s.bulkCloseOptions,
+		BulkCloseSelected:  s.bulkCloseSelected,
+		Notice:             s.connNotice,
+		Columns:            s.tableColumns(),
+		SortBy:             s.connSortBy,
+		SortDesc:           s.connSortDesc,
+		ColumnPickerMode:   s.columnPickerMode,
+		ColumnPickerItems:  s.columnPickerItems,
+		ColumnPickerIndex:  s.columnPickerSelected,
+		GroupBy:            s.connGroupBy,
+		ExpandedGroups:     s.connExpanded,
+		ProcessDetails:     s.procDetails,
 		TopNModalItems:     topNModalItems,
 		TopNModalScroll:    s.topNModalScroll,
 	}
@@ -223,10 +282,24 @@ func (s State) Update(msg tea.KeyMsg, client *api.Client, timeout int) (State, t
 			} else {
 				s.connDetailRightScroll++
 			}
+		case msg.String() == "R":
+			return s, s.showConnectionRule()
+		case msg.String() == "A":
+			return s, s.proposeRule()
 		}
 		return s, nil
 	}
 
+	// 批量关闭菜单
+	if s.bulkCloseMode {
+		return s.handleBulkClose(msg, client)
+	}
+
+	// 列设置
+	if s.columnPickerMode {
+		return s.handleColumnPicker(msg)
+	}
+
 	// 过滤输入模式
 	if s.connFilterMode {
 		return s.handleConnFilterMode(msg)
@@ -265,12 +338,43 @@ func (s State) Update(msg tea.KeyMsg, client *api.Client, timeout int) (State, t
 			FetchConnections(client),
 		)
 
+	case msg.String() == "c":
+		s = s.openBulkClose()
+
 	case msg.String() == "/":
 		s.connFilterMode = true
 
+	case msg.String() == "i":
+		s.connColumns = components.ToggleMetaConnColumns(s.tableColumns())
+		return s, s.saveTableLayout()
+
+	case msg.String() == "o":
+		return s.cycleSort()
+
+	case msg.String() == "O":
+		return s.reverseSort()
+
+	case msg.String() == "L":
+		s = s.openColumnPicker()
+
+	case msg.String() == "g":
+		s = s.cycleGroupBy()
+
+	case msg.String() == "e":
+		return s.exportView(service.ConnExportCSV)
+
+	case msg.String() == "E":
+		return s.exportView(service.ConnExportJSONL)
+
 	case msg.String() == "h":
 		s.setConnViewMode((s.connViewMode + 1) % 2)
 
+	case msg.String() == "R":
+		return s, s.showConnectionRule()
+
+	case msg.String() == "A":
+		return s, s.proposeRule()
+
 	case msg.String() == "s":
 		return s.triggerSiteTestByIndex(s.selectedSiteTest, timeout)
 
@@ -297,6 +401,8 @@ func (s State) Update(msg tea.KeyMsg, client *api.Client, timeout int) (State, t
 			s.connFilter = ""
 			s.selectedConn = 0
 			s.connScrollTop = 0
+		} else if s.ruleFocus != nil {
+			s.clearRuleFocus()
 		}
 	}
 
@@ -312,6 +418,9 @@ func (s State) HandleMouseLeft(
 	chartData *model.ChartData,
 	timeout int,
 ) (State, tea.Cmd) {
+	if s.bulkCloseMode || s.columnPickerMode {
+		return s, nil
+	}
 	if s.connDetailMode {
 		if s.connDetailSnapshot == nil {
 			s.closeConnectionDetail()
@@ -321,6 +430,7 @@ func (s State) HandleMouseLeft(
 		left, top, right, bottom := components.ResolveConnectionDetailModalBounds(
 			s.connDetailSnapshot,
 			s.connIPInfo,
+			s.detailSpeeds(),
 			pageWidth,
 			pageHeight,
 			s.connDetailLeftScroll,
@@ -353,7 +463,7 @@ func (s State) HandleMouseLeft(
 						s.connDetailRightScroll = 0
 						s.connDetailFocusPanel = 0
 						s.connIPInfo = nil
-						return s, FetchIPInfo(conn.Metadata.DestinationIP)
+						return s, FetchIPInfo(s.geo, conn.Metadata.DestinationIP)
 					}
 				}
 			}
@@ -385,6 +495,9 @@ func (s State) HandleMouseLeft(
 		}
 		return s, nil
 
+	case MouseTargetTableHeader:
+		return s.sortByColumn(hit.Column)
+
 	case MouseTargetViewActive:
 		s.setConnViewMode(ConnViewActive)
 		return s, nil
@@ -422,6 +535,9 @@ func (s State) HandleMouseLeft(
 
 // HandleMouseScroll 鼠标滚轮处理
 func (s State) HandleMouseScroll(up bool, mainX, mainY, mainWidth, mainHeight int) State {
+	if s.bulkCloseMode || s.columnPickerMode {
+		return s
+	}
 	if s.topNModalMode {
 		if up {
 			if s.topNModalScroll > 0 {
@@ -506,30 +622,14 @@ func (s *State) isMouseDoubleClickWithThreshold(target MouseTarget, idx int, now
 
 // ApplyWSConnections 处理 WebSocket 连接推送（含历史记录检测）
 func (s State) ApplyWSConnections(data api.ConnectionsData) State {
+	return s.applyWSConnectionsAt(data, time.Now())
+}
+
+func (s State) applyWSConnectionsAt(data api.ConnectionsData, now time.Time) State {
+	data.Connections = s.annotate(data.Connections)
 	currentIDs := make(map[string]model.Connection, len(data.Connections))
 	for _, conn := range data.Connections {
-		currentIDs[conn.ID] = model.Connection{
-			ID:            conn.ID,
-			Upload:        conn.Upload,
-			Download:      conn.Download,
-			Start:         conn.Start,
-			Chains:        conn.Chains,
-			Rule:          conn.Rule,
-			RulePayload:   conn.RulePayload,
-			DownloadSpeed: conn.DownloadSpeed,
-			UploadSpeed:   conn.UploadSpeed,
-			Metadata: model.Metadata{
-				Network:         conn.Metadata.Network,
-				Type:            conn.Metadata.Type,
-				SourceIP:        conn.Metadata.SourceIP,
-				DestinationIP:   conn.Metadata.DestinationIP,
-				SourcePort:      conn.Metadata.SourcePort,
-				DestinationPort: conn.Metadata.DestinationPort,
-				Host:            conn.Metadata.Host,
-				Process:         conn.Metadata.Process,
-				ProcessPath:     conn.Metadata.ProcessPath,
-			},
-		}
+		currentIDs[conn.ID] = conn
 	}
 
 	// 检测已关闭的连接写入 Ring Buffer
@@ -540,6 +640,7 @@ func (s State) ApplyWSConnections(data api.ConnectionsData) State {
 			}
 		}
 	}
+	s.trackSpeeds(data.Connections, s.PrevConnIDs, now)
 	s.PrevConnIDs = currentIDs
 	s.Connections = ConvertToConnectionsResponse(data)
 	return s
@@ -603,16 +704,20 @@ func (s State) UpdateProxyAddr(addr string) State {
 }
 
 func (s State) openSelectedConnectionDetail() (State, tea.Cmd) {
-	conn := s.selectedConnection()
-	if conn == nil {
+	row, ok := s.selectedRow()
+	if !ok {
 		return s, nil
 	}
+	if row.group != nil {
+		return s.toggleGroup(row.group.Key), nil
+	}
+	conn := row.conn
 
 	snapshot := *conn
 	s.connDetailSnapshot = &snapshot
 	s.connDetailMode = true
 	s.connIPInfo = nil
-	return s, FetchIPInfo(conn.Metadata.DestinationIP)
+	return s, FetchIPInfo(s.geo, conn.Metadata.DestinationIP)
 }
 
 func (s State) triggerSiteTestByIndex(idx int, timeout int) (State, tea.Cmd) {
@@ -626,6 +731,7 @@ func (s State) triggerSiteTestByIndex(idx int, timeout int) (State, tea.Cmd) {
 
 func (s *State) closeConnectionDetail() {
 	s.connDetailMode = false
+	s.navDetail = false
 	s.connDetailSnapshot = nil
 	s.connIPInfo = nil
 	s.connDetailLeftScroll = 0
@@ -647,6 +753,11 @@ func (s *State) setConnViewMode(mode int) {
 	s.connScrollTop = 0
 }
 
+// TextInputActive 是否处于过滤输入、批量关闭菜单或列设置（此时按键不应触发全局快捷键）
+func (s State) TextInputActive() bool {
+	return s.connFilterMode || s.bulkCloseMode || s.columnPickerMode
+}
+
 // handleConnFilterMode 连接过滤输入模式
 func (s State) handleConnFilterMode(msg tea.KeyMsg) (State, tea.Cmd) {
 	switch {
@@ -657,74 +768,27 @@ func (s State) handleConnFilterMode(msg tea.KeyMsg) (State, tea.Cmd) {
 		s.selectedConn = 0
 		s.connScrollTop = 0
 	case key.Matches(msg, common.Keys.Backspace):
-		if len(s.connFilter) > 0 {
-			s.connFilter = s.connFilter[:len(s.connFilter)-1]
+		if runes := []rune(s.connFilter); len(runes) > 0 {
+			s.connFilter = string(runes[:len(runes)-1])
 		}
 	default:
-		input := msg.String()
-		if len(input) == 1 && input[0] >= 32 && input[0] < 127 {
-			s.connFilter += input
+		runes := []rune(msg.String())
+		if len(runes) == 1 && runes[0] >= 32 && runes[0] != 127 {
+			s.connFilter += msg.String()
 		}
 	}
 	return s, nil
 }
 
-// filteredConnCount 过滤后的连接数量
+// filteredConnCount 过滤后列表的行数（聚合模式下含分组行）
 func (s State) filteredConnCount() int {
-	var conns []model.Connection
-	if s.connViewMode == ConnViewActive {
-		if s.Connections == nil {
-			return 0
-		}
-		conns = s.Connections.Connections
-	} else {
-		conns = s.ClosedConnections()
-	}
-	if s.connFilter == "" {
-		return len(conns)
-	}
-	count := 0
-	filter := strings.ToLower(s.connFilter)
-	for _, conn := range conns {
-		if connMatchesFilter(conn, filter) {
-			count++
-		}
-	}
-	return count
+	return len(s.rows())
 }
 
-// selectedConnection 获取当前选中的连接
+// selectedConnection 获取当前选中的连接（选中分组行时为 nil）
 func (s State) selectedConnection() *model.Connection {
-	var conns []model.Connection
-	if s.connViewMode == ConnViewActive {
-		if s.Connections == nil || len(s.Connections.Connections) == 0 {
-			return nil
-		}
-		conns = s.Connections.Connections
-	} else {
-		closed := s.ClosedConnections()
-		if len(closed) == 0 {
-			return nil
-		}
-		conns = closed
-	}
-
-	if s.connFilter == "" {
-		if s.selectedConn >= 0 && s.selectedConn < len(conns) {
-			return &conns[s.selectedConn]
-		}
-		return nil
-	}
-
-	filter := strings.ToLower(s.connFilter)
-	idx := 0
-	for i := range conns {
-		if connMatchesFilter(conns[i], filter) {
-			if idx == s.selectedConn {
-				return &conns[i]
-			}
-			idx++
-		}
+	if row, ok := s.selectedRow(); ok {
+		return row.conn
 	}
 	return nil
 }
@@ -756,25 +820,3 @@ func (s State) findConnectionByName(name string) *model.Connection {
 
 	return nil
 }
-
-// connMatchesFilter 检查连接是否匹配过滤词
-func connMatchesFilter(conn model.Connection, filter string) bool {
-	if filter == "" {
-		return true
-	}
-	if strings.Contains(strings.ToLower(conn.Metadata.Host), filter) {
-		return true
-	}
-	if strings.Contains(strings.ToLower(conn.Rule), filter) {
-		return true
-	}
-	if strings.Contains(strings.ToLower(conn.Metadata.DestinationIP), filter) {
-		return true
-	}
-	for _, chain := range conn.Chains {
-		if strings.Contains(strings.ToLower(chain), filter) {
-			return true
-		}
-	}
-	return false
-}
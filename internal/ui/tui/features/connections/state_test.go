@@ -0,0 +1,22 @@
+package connections
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/infrastructure/api"
+)
+
+func TestApplyWSConnectionsKeepsFullMetadataInHistory(t *testing.T) {
+	conn := model.Connection{ID: "a", Metadata: model.Metadata{Host: "example.com", InboundName: "tun-in", InboundUser: "bob", UID: 1000, SniffHost: "sniffed.test",
+		DestinationGeoIP: []interface{}{"US"}}}
+	s := State{}
+	s = s.ApplyWSConnections(api.ConnectionsData{Connections: []model.Connection{conn}})
+	s = s.ApplyWSConnections(api.ConnectionsData{})
+
+	closed := s.ClosedConnections()
+	if len(closed) != 1 || !reflect.DeepEqual(closed[0].Metadata, conn.Metadata) {
+		t.Fatalf("closed connection lost metadata: %+v", closed)
+	}
+}
@@ -0,0 +1,238 @@
+package connections
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aimony/mihosh/internal/ui/tui/components/common"
+	"github.com/aimony/mihosh/internal/ui/tui/features/connections/components"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ColumnPickerItem 列设置中的一项
+type ColumnPickerItem struct {
+	ID      string
+	Title   string
+	Visible bool
+}
+
+// WithTableLayout 应用配置中的列布局与排序
+func (s State) WithTableLayout(columns []string, sort string) State {
+	s.connColumns = components.NormalizeConnColumns(columns)
+	s.connSortBy, s.connSortDesc = components.ParseConnSort(sort)
+	return s
+}
+
+// tableColumns 当前显示的列
+func (s State) tableColumns() []string {
+	return components.NormalizeConnColumns(s.connColumns)
+}
+
+// saveTableLayout 持久化当前列布局与排序
+func (s State) saveTableLayout() tea.Cmd {
+	return SaveTableLayout(s.tableColumns(), components.FormatConnSort(s.connSortBy, s.connSortDesc))
+}
+
+// cycleSort 依次按显示的各列排序，最后回到到达顺序
+func (s State) cycleSort() (State, tea.Cmd) {
+	columns := s.tableColumns()
+	next := ""
+	for i, id := range columns {
+		if id == s.connSortBy {
+			if i+1 < len(columns) {
+				next = columns[i+1]
+			}
+			break
+		}
+	}
+	if s.connSortBy == "" {
+		next = columns[0]
+	}
+	return s.setSort(next, defaultSortDesc(next))
+}
+
+// reverseSort 反转当前排序方向
+func (s State) reverseSort() (State, tea.Cmd) {
+	if s.connSortBy == "" {
+		return s, nil
+	}
+	return s.setSort(s.connSortBy, !s.connSortDesc)
+}
+
+// sortByColumn 点击表头：切换到该列排序，再次点击同一列反转方向
+func (s State) sortByColumn(id string) (State, tea.Cmd) {
+	if id == s.connSortBy {
+		return s.reverseSort()
+	}
+	return s.setSort(id, defaultSortDesc(id))
+}
+
+// setSort 修改排序并保持原来选中的连接
+func (s State) setSort(by string, desc bool) (State, tea.Cmd) {
+	selected := s.selectedConnection()
+	s.connSortBy, s.connSortDesc = by, desc
+	if selected != nil {
+		s.reselect(selected.ID)
+	}
+
+	if col, ok := components.LookupConnColumn(by); ok {
+		direction := "升序"
+		if desc {
+			direction = "降序"
+		}
+		s.connNotice = fmt.Sprintf("按%s%s排列", col.Title, direction)
+	} else {
+		s.connNotice = "按到达顺序排列"
+	}
+	return s, s.saveTableLayout()
+}
+
+// reselect 让选中行跟随指定连接
+func (s *State) reselect(id string) {
+	for i, row := range s.rows() {
+		if row.conn != nil && row.conn.ID == id {
+			s.selectedConn = i
+			if s.selectedConn < s.connScrollTop {
+				s.connScrollTop = s.selectedConn
+			}
+			return
+		}
+	}
+}
+
+func defaultSortDesc(id string) bool {
+	col, ok := components.LookupConnColumn(id)
+	return ok && col.DefaultDesc
+}
+
+// openColumnPicker 打开列设置
+func (s State) openColumnPicker() State {
+	s.columnPickerMode = true
+	s.columnPickerItems = columnPickerItems(s.tableColumns())
+	s.columnPickerSelected = 0
+	s.connNotice = ""
+	return s
+}
+
+// handleColumnPicker 列设置按键处理
+func (s State) handleColumnPicker(msg tea.KeyMsg) (State, tea.Cmd) {
+	items := s.columnPickerItems
+	i := s.columnPickerSelected
+	switch {
+	case msg.String() == "K", msg.String() == "shift+up":
+		if i > 0 {
+			items[i], items[i-1] = items[i-1], items[i]
+			s.columnPickerSelected--
+		}
+	case msg.String() == "J", msg.String() == "shift+down":
+		if i < len(items)-1 {
+			items[i], items[i+1] = items[i+1], items[i]
+			s.columnPickerSelected++
+		}
+	case key.Matches(msg, common.Keys.Up), msg.String() == "k":
+		if i > 0 {
+			s.columnPickerSelected--
+		}
+	case key.Matches(msg, common.Keys.Down), msg.String() == "j":
+		if i < len(items)-1 {
+			s.columnPickerSelected++
+		}
+	case msg.String() == " ":
+		items[i].Visible = !items[i].Visible
+	case msg.String() == "d":
+		s.columnPickerItems = columnPickerItems(components.DefaultConnColumns)
+		s.columnPickerSelected = 0
+	case key.Matches(msg, common.Keys.Enter):
+		var columns []string
+		for _, item := range items {
+			if item.Visible {
+				columns = append(columns, item.ID)
+			}
+		}
+		if len(columns) == 0 {
+			s.connNotice = "至少保留一列"
+			return s, nil
+		}
+		s.columnPickerMode = false
+		s.connColumns = columns
+		s.connNotice = ""
+		// 隐藏的列不再作为排序依据
+		if s.connSortBy != "" && !containsString(columns, s.connSortBy) {
+			s.connSortBy, s.connSortDesc = "", false
+		}
+		return s, s.saveTableLayout()
+	case key.Matches(msg, common.Keys.Escape), msg.String() == "L":
+		s.columnPickerMode = false
+	}
+	return s, nil
+}
+
+// columnPickerItems 已显示的列按顺序在前，其余列按默认顺序在后
+func columnPickerItems(columns []string) []ColumnPickerItem {
+	visible := make(map[string]bool)
+	var items []ColumnPickerItem
+	for _, id := range columns {
+		col, _ := components.LookupConnColumn(id)
+		visible[id] = true
+		items = append(items, ColumnPickerItem{ID: id, Title: col.Title, Visible: true})
+	}
+	for _, col := range components.AllConnColumns() {
+		if !visible[col.ID] {
+			items = append(items, ColumnPickerItem{ID: col.ID, Title: col.Title})
+		}
+	}
+	return items
+}
+
+func containsString(list []string, target string) bool {
+	for _, item := range list {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
+
+// renderColumnPickerModal 渲染列设置弹窗
+func renderColumnPickerModal(state PageState) string {
+	modalWidth := state.Width - 10
+	if modalWidth < 40 {
+		modalWidth = 40
+	}
+	if modalWidth > 60 {
+		modalWidth = 60
+	}
+	innerWidth := modalWidth - 4
+
+	var bodyLines []string
+	for i, item := range state.ColumnPickerItems {
+		mark := "[ ]"
+		if item.Visible {
+			mark = "[✓]"
+		}
+		prefix, label := common.SymbolSelectInactive, fmt.Sprintf("%s %s", mark, item.Title)
+		if i == state.ColumnPickerIndex {
+			prefix, label = common.SymbolSelectActive, common.SelectedStyle.Render(label)
+		}
+		bodyLines = append(bodyLines, prefix+label+"  "+common.DimStyle.Render(item.ID))
+	}
+	bodyLines = append(bodyLines, "")
+	if state.Notice != "" {
+		bodyLines = append(bodyLines, common.WarningStyle.Render(state.Notice))
+	}
+	bodyLines = append(bodyLines, common.MutedStyle.Render("[空格] 显示/隐藏  [J/K] 移动  [d] 恢复默认  [Enter] 保存  [Esc] 取消"))
+
+	title := common.TableHeaderStyle.Render("连接表列设置")
+	separator := common.DimStyle.Render(strings.Repeat("─", innerWidth))
+	content := lipgloss.JoinVertical(lipgloss.Left, title, separator, strings.Join(bodyLines, "\n"))
+
+	modal := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(common.CPrimary).
+		Padding(0, 1).
+		Width(modalWidth).
+		Render(content)
+	return lipgloss.Place(state.Width, state.Height-2, lipgloss.Center, lipgloss.Center, modal)
+}
@@ -0,0 +1,136 @@
+package connections
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/ui/tui/features/connections/components"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func layoutTestConns() []model.Connection {
+	return []model.Connection{
+		{ID: "a", Download: 10, Metadata: model.Metadata{Host: "b.com"}},
+		{ID: "b", Download: 30, Metadata: model.Metadata{Host: "a.com"}},
+		{ID: "c", Download: 20, Metadata: model.Metadata{Host: "c.com"}},
+	}
+}
+
+func runeKey(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestWithTableLayout(t *testing.T) {
+	s := NewState("", nil).WithTableLayout([]string{"process", "nope", "down"}, "-down")
+	if got := strings.Join(s.tableColumns(), ","); got != "process,down" {
+		t.Fatalf("unexpected columns %s", got)
+	}
+	if s.connSortBy != "down" || !s.connSortDesc {
+		t.Fatalf("unexpected sort %q desc=%v", s.connSortBy, s.connSortDesc)
+	}
+
+	s = NewState("", nil).WithTableLayout(nil, "")
+	if got := strings.Join(s.tableColumns(), ","); got != strings.Join(components.DefaultConnColumns, ",") {
+		t.Fatalf("expected default columns, got %s", got)
+	}
+}
+
+func TestSortKeysKeepSelection(t *testing.T) {
+	s := State{Connections: &model.ConnectionsResponse{Connections: layoutTestConns()}}
+	s.selectedConn = 2 // c
+
+	// o 从第一列（主机）开始排序
+	s, cmd := s.Update(runeKey("o"), nil, 0)
+	if s.connSortBy != components.ColumnHost || s.connSortDesc || cmd == nil {
+		t.Fatalf("expected host ascending with save cmd, got %q desc=%v", s.connSortBy, s.connSortDesc)
+	}
+	if conn := s.selectedConnection(); conn == nil || conn.ID != "c" {
+		t.Fatalf("selection should follow connection c, got %+v", conn)
+	}
+
+	s, _ = s.Update(runeKey("O"), nil, 0)
+	if !s.connSortDesc || s.selectedConn != 0 {
+		t.Fatalf("expected host descending with c first, got desc=%v selected=%d", s.connSortDesc, s.selectedConn)
+	}
+	if !strings.Contains(s.connNotice, "主机降序") {
+		t.Fatalf("unexpected notice %q", s.connNotice)
+	}
+}
+
+func TestCycleSortReturnsToArrivalOrder(t *testing.T) {
+	s := State{Connections: &model.ConnectionsResponse{Connections: layoutTestConns()}}.WithTableLayout([]string{"host", "down"}, "")
+	s, _ = s.Update(runeKey("o"), nil, 0)
+	s, _ = s.Update(runeKey("o"), nil, 0)
+	if s.connSortBy != components.ColumnDownload || !s.connSortDesc {
+		t.Fatalf("numeric columns should default to descending, got %q desc=%v", s.connSortBy, s.connSortDesc)
+	}
+	s, _ = s.Update(runeKey("o"), nil, 0)
+	if s.connSortBy != "" || s.connNotice != "按到达顺序排列" {
+		t.Fatalf("expected arrival order, got %q (%q)", s.connSortBy, s.connNotice)
+	}
+}
+
+func TestHeaderClickSorts(t *testing.T) {
+	s := State{Connections: &model.ConnectionsResponse{Connections: layoutTestConns()}}
+	state := PageState{Connections: s.Connections, Width: 100, Height: 40, Columns: components.DefaultConnColumns}
+	header := components.RenderTableHeader(lipgloss.NewStyle(), state.Width, state.Columns, "", false)
+	x := lipgloss.Width(header[:strings.Index(header, "规则")])
+
+	hit := ResolveMouseHit(state, x, 3) // 标题 + 空行 + 统计行之后即表头
+	if hit.Target != MouseTargetTableHeader || hit.Column != components.ColumnRule {
+		t.Fatalf("unexpected header hit %+v", hit)
+	}
+
+	s, cmd := s.sortByColumn(hit.Column)
+	if s.connSortBy != components.ColumnRule || s.connSortDesc || cmd == nil {
+		t.Fatalf("expected rule ascending, got %q desc=%v", s.connSortBy, s.connSortDesc)
+	}
+	s, _ = s.sortByColumn(hit.Column)
+	if !s.connSortDesc {
+		t.Fatal("clicking the same header again should reverse the sort")
+	}
+}
+
+func TestColumnPicker(t *testing.T) {
+	s := State{Connections: &model.ConnectionsResponse{Connections: layoutTestConns()}}.WithTableLayout([]string{"host", "down"}, "down")
+	s, _ = s.Update(runeKey("L"), nil, 0)
+	if !s.columnPickerMode || !s.TextInputActive() {
+		t.Fatal("expected column picker to open")
+	}
+
+	s, _ = s.Update(runeKey("J"), nil, 0) // host 下移到 down 之后
+	s, _ = s.Update(runeKey(" "), nil, 0) // 隐藏 host
+	s, _ = s.Update(tea.KeyMsg{Type: tea.KeyUp}, nil, 0)
+	s, _ = s.Update(runeKey(" "), nil, 0) // 隐藏 down
+	s, cmd := s.Update(tea.KeyMsg{Type: tea.KeyEnter}, nil, 0)
+	if !s.columnPickerMode || cmd != nil || s.connNotice != "至少保留一列" {
+		t.Fatalf("saving with no columns should be refused, notice %q", s.connNotice)
+	}
+
+	s, _ = s.Update(runeKey(" "), nil, 0) // 重新显示 down
+	s, _ = s.Update(tea.KeyMsg{Type: tea.KeyDown}, nil, 0)
+	s, _ = s.Update(tea.KeyMsg{Type: tea.KeyDown}, nil, 0)
+	s, _ = s.Update(runeKey(" "), nil, 0) // 显示 process
+	s, cmd = s.Update(tea.KeyMsg{Type: tea.KeyEnter}, nil, 0)
+	if s.columnPickerMode || cmd == nil {
+		t.Fatal("expected picker to close with a save command")
+	}
+	if got := strings.Join(s.tableColumns(), ","); got != "down,process" {
+		t.Fatalf("unexpected columns %s", got)
+	}
+	if s.connSortBy != "down" {
+		t.Fatalf("sort on a still visible column should be kept, got %q", s.connSortBy)
+	}
+}
+
+func TestColumnPickerEscDiscardsChanges(t *testing.T) {
+	s := State{}.WithTableLayout([]string{"host"}, "host")
+	s, _ = s.Update(runeKey("L"), nil, 0)
+	s, _ = s.Update(runeKey("d"), nil, 0)
+	s, cmd := s.Update(tea.KeyMsg{Type: tea.KeyEsc}, nil, 0)
+	if s.columnPickerMode || cmd != nil || strings.Join(s.tableColumns(), ",") != "host" {
+		t.Fatalf("esc should keep the old layout, got %v", s.tableColumns())
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/aimony/mihosh/internal/app/service"
 	"github.com/aimony/mihosh/internal/domain/model"
 	"github.com/aimony/mihosh/internal/ui/tui/components/common"
 	"github.com/aimony/mihosh/internal/ui/tui/features/connections/components"
@@ -20,12 +21,13 @@ type PageState struct {
 	ScrollTop          int
 	FilterText         string
 	FilterMode         bool
-	DetailMode         bool              // 是否显示详情
-	SelectedConnection *model.Connection // 选中的连接
-	IPInfo             *model.IPInfo     // 目标IP地理信息
-	DetailLeftScroll   int               // 详情左侧页面滚动偏移
-	DetailRightScroll  int               // 详情右侧页面滚动偏移
-	DetailFocusPanel   int               // 详情当前焦点面板
+	DetailMode         bool                     // 是否显示详情
+	SelectedConnection *model.Connection        // 选中的连接
+	DetailSpeeds       *components.SpeedHistory // 选中连接的实时速率历史
+	IPInfo             *model.IPInfo            // 目标IP地理信息
+	DetailLeftScroll   int                      // 详情左侧页面滚动偏移
+	DetailRightScroll  int                      // 详情右侧页面滚动偏移
+	DetailFocusPanel   int                      // 详情当前焦点面板
 	// 图表数据
 	ChartData *model.ChartData
 	// 视图模式
@@ -39,6 +41,26 @@ type PageState struct {
 	TopNModalMode   bool
 	TopNModalItems  []components.TopNItem
 	TopNModalScroll int
+
+	BulkCloseMode     bool
+	BulkCloseOptions  []BulkCloseOption
+	BulkCloseSelected int
+	Notice            string
+
+	// 连接表列布局与排序
+	Columns           []string
+	SortBy            string
+	SortDesc          bool
+	ColumnPickerMode  bool
+	ColumnPickerItems []ColumnPickerItem
+	ColumnPickerIndex int
+
+	// 聚合模式
+	GroupBy        service.ConnGroupKey
+	ExpandedGroups map[string]bool
+	ProcessDetails map[string]string // 按进程聚合时各进程的 PID/用户/单元
+
+	FocusLabel string // 跨页跳转聚焦规则时的提示
 }
 
 // RenderConnectionsPage 渲染连接监控页面
@@ -48,6 +70,7 @@ func RenderConnectionsPage(state PageState) string {
 		return components.RenderConnectionDetailModal(
 			state.SelectedConnection,
 			state.IPInfo,
+			state.DetailSpeeds,
 			state.Width,
 			state.Height,
 			state.DetailLeftScroll,
@@ -58,6 +81,12 @@ func RenderConnectionsPage(state PageState) string {
 	if state.TopNModalMode {
 		return components.RenderTopNModal(state.TopNModalItems, state.Width, state.Height, state.TopNModalScroll)
 	}
+	if state.BulkCloseMode {
+		return renderBulkCloseModal(state)
+	}
+	if state.ColumnPickerMode {
+		return renderColumnPickerModal(state)
+	}
 
 	// 样式定义
 	headerStyle := common.BoldStyle.Foreground(common.CSecondary)
@@ -82,7 +111,8 @@ func RenderConnectionsPage(state PageState) string {
 	}
 
 	// 过滤连接
-	filteredConns := filterConnections(connList, state.FilterText)
+	filterQuery, filterErr := parseConnFilter(state.FilterText)
+	filteredConns := components.SortConnections(service.FilterConnections(connList, filterQuery), state.SortBy, state.SortDesc)
 
 	// 统计信息
 	var stats string
@@ -99,6 +129,12 @@ func RenderConnectionsPage(state PageState) string {
 			headerStyle.Render(fmt.Sprintf("%d", len(filteredConns))),
 		)
 	}
+	if state.FocusLabel != "" {
+		stats += "  " + common.BoldStyle.Foreground(common.CPrimary).Render(state.FocusLabel)
+	}
+	if state.Notice != "" {
+		stats += "  " + common.WarningStyle.Render(state.Notice)
+	}
 
 	// 过滤输入框
 	filterLine := ""
@@ -107,9 +143,21 @@ func RenderConnectionsPage(state PageState) string {
 	} else if state.FilterText != "" {
 		filterLine = dimStyle.Render(fmt.Sprintf("过滤: %s (按/编辑, Esc清除)", state.FilterText))
 	}
+	if filterLine != "" && filterErr != nil {
+		filterLine += "  " + common.ErrorStyle.Render("✗ "+filterErr.Error()+"（按原文匹配）")
+	}
+
+	// 聚合模式下的显示行
+	listRows := groupRows(filteredConns, state.GroupBy, state.ExpandedGroups)
+	if state.GroupBy != "" {
+		stats += fmt.Sprintf(" | %s: %s 组", state.GroupBy.Label(), headerStyle.Render(fmt.Sprintf("%d", groupCount(listRows))))
+	}
 
 	// 表头
-	tableHeader := components.RenderTableHeader(headerStyle, state.Width)
+	tableHeader := components.RenderTableHeader(headerStyle, state.Width, state.Columns, state.SortBy, state.SortDesc)
+	if state.GroupBy != "" {
+		tableHeader = components.RenderGroupHeader(headerStyle, state.Width, state.GroupBy)
+	}
 
 	// 计算使用的行数 (Header + Stats + Spacers + TableHeader + Divider + Footer)
 	usedLines := connectionsBaseUsedLines
@@ -159,13 +207,13 @@ func RenderConnectionsPage(state PageState) string {
 
 	// 连接列表
 	var rows []string
-	if len(filteredConns) == 0 {
+	if len(listRows) == 0 {
 		rows = append(rows, dimStyle.Render("  无活跃连接"))
 	} else {
 		// 确保选中索引在有效范围内
 		selectedIdx := state.SelectedIndex
-		if selectedIdx >= len(filteredConns) {
-			selectedIdx = len(filteredConns) - 1
+		if selectedIdx >= len(listRows) {
+			selectedIdx = len(listRows) - 1
 		}
 		if selectedIdx < 0 {
 			selectedIdx = 0
@@ -181,40 +229,46 @@ func RenderConnectionsPage(state PageState) string {
 		}
 
 		endIdx := scrollTop + maxDisplay
-		if endIdx > len(filteredConns) {
-			endIdx = len(filteredConns)
+		if endIdx > len(listRows) {
+			endIdx = len(listRows)
 		}
 
 		for i := scrollTop; i < endIdx; i++ {
-			conn := filteredConns[i]
+			listRow := listRows[i]
 			isSelected := i == selectedIdx
 
 			rowStyle := normalStyle
+			if listRow.group != nil {
+				rowStyle = common.BoldStyle
+			}
 			prefix := common.SymbolSelectInactive
 			if isSelected {
 				rowStyle = selectedStyle
 				prefix = common.SymbolSelectActive
 			}
 
-			row := components.RenderConnectionRow(conn, rowStyle, prefix, state.Width)
-			rows = append(rows, row)
+			if listRow.group != nil {
+				rows = append(rows, components.RenderGroupRow(*listRow.group, state.ProcessDetails[listRow.group.Key], rowStyle, prefix, state.Width, listRow.expanded))
+				continue
+			}
+			rows = append(rows, components.RenderConnectionRow(*listRow.conn, rowStyle, prefix, state.Width, state.Columns))
 		}
 
 		// 显示滚动提示
 		if scrollTop > 0 {
 			rows = append([]string{dimStyle.Render(fmt.Sprintf("  ↑ 还有 %d 条", scrollTop))}, rows...)
 		}
-		if endIdx < len(filteredConns) {
-			rows = append(rows, dimStyle.Render(fmt.Sprintf("  ↓ 还有 %d 条", len(filteredConns)-endIdx)))
+		if endIdx < len(listRows) {
+			rows = append(rows, dimStyle.Render(fmt.Sprintf("  ↓ 还有 %d 条", len(listRows)-endIdx)))
 		}
 	}
 
 	// 帮助提示
 	var helpText string
 	if state.ViewMode == 0 {
-		helpText = dimStyle.Render("[↑↓]选择 [x]关闭 [X]全部关闭 [/]搜索 [h]历史 [s]测速 [S]全测 [双击图表]排行 [r]刷新")
+		helpText = dimStyle.Render("[↑↓]选择 [x]关闭 [c]批量关闭 [X]全部关闭 [/]搜索 [o/O]排序 [L]列设置 [g]聚合 [e/E]导出 [h]历史 [s]测速 [S]全测 [双击图表]排行 [r]刷新")
 	} else {
-		helpText = dimStyle.Render("[↑↓]选择 [Enter]详情 [/]搜索 [h]活跃")
+		helpText = dimStyle.Render("[↑↓]选择 [Enter]详情 [/]搜索 [o/O]排序 [L]列设置 [g]聚合 [e/E]导出 [h]活跃")
 	}
 
 	// 组装页面
@@ -263,34 +317,24 @@ func RenderConnectionsPage(state PageState) string {
 	return mainContent + footer
 }
 
-// filterConnections 过滤连接
-func filterConnections(connections []model.Connection, filter string) []model.Connection {
-	if filter == "" {
-		return connections
+// parseConnFilter 解析过滤表达式，无效时退回整段文本子串匹配并返回解析错误
+func parseConnFilter(filter string) (service.ConnQuery, error) {
+	q, err := service.ParseConnQuery(filter)
+	if err != nil {
+		return service.LiteralConnQuery(filter), err
 	}
+	return q, nil
+}
 
-	filter = strings.ToLower(filter)
-	var filtered []model.Connection
-	for _, conn := range connections {
-		// 搜索主机、规则、代理链
-		if strings.Contains(strings.ToLower(conn.Metadata.Host), filter) ||
-			strings.Contains(strings.ToLower(conn.Rule), filter) ||
-			containsAnyLower(conn.Chains, filter) ||
-			strings.Contains(strings.ToLower(conn.Metadata.DestinationIP), filter) {
-			filtered = append(filtered, conn)
-		}
-	}
-	return filtered
+// filterConnections 按过滤表达式过滤连接
+func filterConnections(connections []model.Connection, filter string) []model.Connection {
+	q, _ := parseConnFilter(filter)
+	return service.FilterConnections(connections, q)
 }
 
-// containsAnyLower 检查字符串切片中是否有包含子串的元素
-func containsAnyLower(slice []string, sub string) bool {
-	for _, s := range slice {
-		if strings.Contains(strings.ToLower(s), sub) {
-			return true
-		}
-	}
-	return false
+// visibleConnections 过滤并排序后的连接，顺序与表格显示一致
+func visibleConnections(connections []model.Connection, filter, sortBy string, sortDesc bool) []model.Connection {
+	return components.SortConnections(filterConnections(connections, filter), sortBy, sortDesc)
 }
 
 func max(a, b int) int {
@@ -31,12 +31,14 @@ const (
 	MouseTargetChart
 	MouseTargetTopN
 	MouseTargetTopNModalItem
+	MouseTargetTableHeader
 )
 
 // MouseHit 是 connections 页面鼠标命中结果
 type MouseHit struct {
 	Target MouseTarget
 	Index  int
+	Column string // 点击表头时命中的列 ID
 }
 
 type connectionsListWindow struct {
@@ -126,14 +128,23 @@ func ResolveMouseHit(state PageState, pageX, pageY int) MouseHit {
 	if state.FilterMode || state.FilterText != "" {
 		line++ // 过滤行
 	}
+	if pageY == line {
+		if state.GroupBy != "" {
+			return MouseHit{Target: ConnectionsMouseTargetNone, Index: -1}
+		}
+		if column := components.ResolveHeaderColumn(state.Width, state.Columns, pageX); column != "" {
+			return MouseHit{Target: MouseTargetTableHeader, Index: -1, Column: column}
+		}
+		return MouseHit{Target: ConnectionsMouseTargetNone, Index: -1}
+	}
 	line += 2 // 表头 + 分隔线
 
-	filteredConns := filterConnections(connectionsByViewMode(state), state.FilterText)
-	if len(filteredConns) == 0 {
+	listRows := pageRows(state)
+	if len(listRows) == 0 {
 		return MouseHit{Target: ConnectionsMouseTargetNone, Index: -1}
 	}
 
-	window := resolveConnectionsListWindow(state, len(filteredConns))
+	window := resolveConnectionsListWindow(state, len(listRows))
 	dataStart := line
 	if window.ShowTopHint {
 		dataStart++
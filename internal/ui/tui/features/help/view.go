@@ -56,16 +56,31 @@ func RenderHelpPage(width, height int) string {
 		renderKey("Enter", "切换到选中节点"),
 		renderKey("t", "测速当前节点"),
 		renderKey("a", "测速当前组所有节点"),
+		renderKey("Esc", "取消批量测速"),
+		renderKey("s", "切换排序方式"),
+		renderKey("g", "切换分组方式"),
+		renderKey("*", "收藏/取消收藏节点（置顶）"),
+		renderKey("p", "收藏/取消收藏策略组"),
+		renderKey("P", "选择预设（保存/恢复所有策略组选择）"),
+		renderKey("/", "搜索（支持 region: type: udp: mult:）"),
 	)
 
 	// 连接监控卡片
 	connKeys := lipgloss.JoinVertical(lipgloss.Left,
 		sectionStyle.Render("🔗 连接监控 [2]"),
 		renderKey("↑/↓ k/j", "选择连接"),
-		renderKey("Enter", "查看连接详情"),
+		renderKey("Enter", "查看连接详情/展开分组"),
 		renderKey("x", "关闭选中连接"),
+		renderKey("c", "批量关闭（过滤/进程/主机/策略组）"),
 		renderKey("X", "关闭所有连接"),
-		renderKey("/", "搜索过滤"),
+		renderKey("/", "过滤（host: net:udp down>10MB OR …）"),
+		renderKey("i", "显示/隐藏入站、用户、UID、嗅探主机列"),
+		renderKey("o / O", "切换排序列 / 反转排序（也可点击表头）"),
+		renderKey("L", "列设置（显示、隐藏、调整顺序）"),
+		renderKey("g", "聚合（进程/主机/主域名/出口节点/规则/源 IP）"),
+		renderKey("e / E", "导出当前视图为 CSV / JSON Lines"),
+		renderKey("R", "跳到匹配的规则"),
+		renderKey("A", "为该主机/IP 添加规则"),
 		renderKey("Esc", "清除过滤/返回"),
 		renderKey("Tab", "切换活跃/历史"),
 	)
@@ -77,7 +92,9 @@ func RenderHelpPage(width, height int) string {
 		renderKey("[/]", "切换日志级别"),
 		renderKey("/", "搜索过滤"),
 		renderKey("c", "清空日志"),
-		renderKey("Esc", "清除搜索"),
+		renderKey("C", "打开对应的连接"),
+		renderKey("A", "为该主机/IP 添加规则"),
+		renderKey("Esc", "清除搜索/返回"),
 	)
 
 	// 规则页面卡片
@@ -85,7 +102,13 @@ func RenderHelpPage(width, height int) string {
 		sectionStyle.Render("📋 规则 [4]"),
 		renderKey("↑/↓ k/j", "选择规则"),
 		renderKey("/", "搜索过滤"),
-		renderKey("Esc", "清除搜索"),
+		renderKey("s", "按命中/流量排序"),
+		renderKey("d", "只看无命中规则"),
+		renderKey("x", "模拟匹配"),
+		renderKey("g", "按目标策略/规则类型分组"),
+		renderKey("Enter / z", "展开收起分组 / 全部展开收起"),
+		renderKey("C", "查看该规则匹配的连接"),
+		renderKey("Esc", "清除搜索/返回"),
 	)
 
 	// 设置页面卡片
@@ -1,6 +1,8 @@
 package logs
 
 import (
+	"net/netip"
+
 	"github.com/aimony/mihosh/internal/app/service"
 	"github.com/aimony/mihosh/internal/ui/tui/messages"
 	tea "github.com/charmbracelet/bubbletea"
@@ -16,3 +18,29 @@ func ResolveLogSourceIP(resolver *service.IPResolver, ip string) tea.Cmd {
 		}
 	}
 }
+
+// showLogConnection 跳转到连接页打开日志对应的连接，无法解析出来源与目标时忽略
+func showLogConnection(parsed *ParsedLog) tea.Cmd {
+	if parsed == nil || parsed.SourceIP == "" {
+		return nil
+	}
+	return messages.Navigate(messages.NavTarget{
+		Kind:       messages.NavToConnection,
+		SourceIP:   parsed.SourceIP,
+		SourcePort: parsed.SourcePort,
+		DestHost:   parsed.DestHost,
+		DestPort:   parsed.DestPort,
+	})
+}
+
+// proposeLogRule 为日志中的目标打开添加规则弹窗，目标为 IP 时只给出 IP 规则
+func proposeLogRule(parsed *ParsedLog) tea.Cmd {
+	if parsed == nil || parsed.DestHost == "" {
+		return nil
+	}
+	msg := messages.ProposeRuleMsg{Host: parsed.DestHost}
+	if _, err := netip.ParseAddr(parsed.DestHost); err == nil {
+		msg = messages.ProposeRuleMsg{IP: parsed.DestHost}
+	}
+	return func() tea.Msg { return msg }
+}
@@ -162,8 +162,22 @@ func renderSourceSection(resolved *model.ResolvedIP, width int) string {
 	switch resolved.NetworkType {
 	case "docker":
 		typeDisplay = "🐳 Docker"
+	case "podman":
+		typeDisplay = "🦭 Podman"
+	case "libvirt":
+		typeDisplay = "🖥 libvirt 虚拟机"
+	case "incus", "lxd":
+		typeDisplay = "📦 LXC/Incus"
+	case "kubernetes":
+		typeDisplay = "☸ Kubernetes"
 	case "tailscale":
 		typeDisplay = "🔗 Tailscale"
+	case "dhcp":
+		typeDisplay = "📡 DHCP 租约"
+	case "hosts":
+		typeDisplay = "📄 hosts"
+	case "static":
+		typeDisplay = "📌 自定义"
 	case "local":
 		typeDisplay = "💻 本机回环"
 	case "lan":
@@ -180,10 +194,19 @@ func renderSourceSection(resolved *model.ResolvedIP, width int) string {
 	if resolved.AppName != "" {
 		label := "应用"
 		switch resolved.NetworkType {
-		case "docker":
+		case "docker", "podman":
 			label = "容器"
-		case "tailscale":
+		case "libvirt", "incus", "lxd":
+			label = "实例"
+		case "kubernetes":
+			label = "Pod"
+		case "tailscale", "dhcp", "hosts", "static":
 			label = "设备"
+		case "lan":
+			// 反查到了设备名
+			if !resolved.Guessed {
+				label = "设备"
+			}
 		}
 		rows = append(rows, []string{label, resolved.AppName})
 	}
@@ -191,10 +214,20 @@ func renderSourceSection(resolved *model.ResolvedIP, width int) string {
 	if resolved.AppDetail != "" {
 		label := "详情"
 		switch resolved.NetworkType {
-		case "docker":
+		case "docker", "podman":
 			label = "镜像"
+		case "libvirt":
+			label = "网卡"
+		case "incus", "lxd":
+			label = "类型"
+		case "kubernetes":
+			label = "节点"
 		case "tailscale":
 			label = "系统"
+		case "hosts":
+			label = "别名"
+		case "lan":
+			label = "来源"
 		}
 		rows = append(rows, []string{label, resolved.AppDetail})
 	}
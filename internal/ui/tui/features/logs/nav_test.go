@@ -0,0 +1,47 @@
+package logs
+
+import (
+	"testing"
+
+	"github.com/aimony/mihosh/internal/ui/tui/messages"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestShowLogConnection(t *testing.T) {
+	s := NewState()
+	s = s.AppendLog("info", "[TCP] 172.18.0.6:39412 --> accounts.google.com:443 match DomainSuffix(google.com) using 节点选择 [新加坡 1]")
+	s, _ = s.Update(tea.KeyMsg{Type: tea.KeyEnter}, nil)
+	if s.CanGoBack() {
+		t.Fatal("open detail should be closed by Esc before going back")
+	}
+
+	_, cmd := s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'C'}}, nil)
+	if cmd == nil {
+		t.Fatal("C should request navigation")
+	}
+	want := messages.NavTarget{Kind: messages.NavToConnection, SourceIP: "172.18.0.6", SourcePort: "39412", DestHost: "accounts.google.com", DestPort: "443"}
+	if nav, ok := cmd().(messages.NavigateMsg); !ok || nav.Target != want {
+		t.Fatalf("unexpected navigation %+v", nav)
+	}
+
+	if showLogConnection(ParseLogPayload("DNS query failed")) != nil {
+		t.Fatal("unparsed log should not navigate")
+	}
+}
+
+func TestProposeLogRule(t *testing.T) {
+	s := NewState()
+	s = s.AppendLog("info", "[TCP] 172.18.0.6:39412 --> accounts.google.com:443 match DomainSuffix(google.com) using 节点选择 [新加坡 1]")
+	_, cmd := s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'A'}}, nil)
+	if cmd == nil {
+		t.Fatal("A should propose a rule")
+	}
+	if msg, ok := cmd().(messages.ProposeRuleMsg); !ok || msg.Host != "accounts.google.com" || msg.IP != "" {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+
+	cmd = proposeLogRule(ParseLogPayload("[UDP] 172.18.0.6:5353 --> 8.8.8.8:53 match Match using DIRECT"))
+	if msg, ok := cmd().(messages.ProposeRuleMsg); !ok || msg.IP != "8.8.8.8" || msg.Host != "" {
+		t.Fatalf("IP destination should only be proposed as IP, got %+v", msg)
+	}
+}
@@ -177,6 +177,16 @@ func (s State) Update(msg tea.KeyMsg, resolver *service.IPResolver) (State, tea.
 	case msg.String() == "/":
 		s.logFilterMode = true
 
+	case msg.String() == "C":
+		if entry := s.selectedLogEntry(); entry != nil {
+			return s, showLogConnection(ParseLogPayload(entry.Payload))
+		}
+
+	case msg.String() == "A":
+		if entry := s.selectedLogEntry(); entry != nil {
+			return s, proposeLogRule(ParseLogPayload(entry.Payload))
+		}
+
 	case key.Matches(msg, common.Keys.Clear):
 		s = s.ClearLogs()
 
@@ -192,6 +202,11 @@ func (s State) Update(msg tea.KeyMsg, resolver *service.IPResolver) (State, tea.
 	return s, nil
 }
 
+// CanGoBack 没有需要 Esc 关闭的详情或过滤时返回 true
+func (s State) CanGoBack() bool {
+	return !s.detailMode && !s.logFilterMode && s.logFilter == ""
+}
+
 // handleDetailMode 详情弹窗按键处理
 func (s State) handleDetailMode(msg tea.KeyMsg) (State, tea.Cmd) {
 	switch {
@@ -208,6 +223,10 @@ func (s State) handleDetailMode(msg tea.KeyMsg) (State, tea.Cmd) {
 		}
 	case key.Matches(msg, common.Keys.Down):
 		s.detailScroll++
+	case msg.String() == "C":
+		return s, showLogConnection(s.detailParsed)
+	case msg.String() == "A":
+		return s, proposeLogRule(s.detailParsed)
 	}
 	return s, nil
 }
@@ -1,6 +1,9 @@
 package nodes
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/aimony/mihosh/internal/domain/model"
 	"github.com/aimony/mihosh/internal/infrastructure/api"
 	"github.com/aimony/mihosh/internal/ui/tui/messages"
@@ -38,8 +41,16 @@ func SelectProxy(client *api.Client, group, proxy string) tea.Cmd {
 }
 
 func TestProxy(client *api.Client, name, testURL string, timeout int) tea.Cmd {
+	return TestProxyContext(context.Background(), client, name, testURL, timeout)
+}
+
+// TestProxyContext 可取消的单节点测速（批量测速共享同一 ctx）
+func TestProxyContext(ctx context.Context, client *api.Client, name, testURL string, timeout int) tea.Cmd {
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	return func() tea.Msg {
-		delay, err := client.TestProxyDelay(name, testURL, timeout)
+		delay, err := client.TestProxyDelayContext(ctx, name, testURL, timeout)
 		if err != nil {
 			return messages.TestDoneMsg{Name: name, Delay: -1, Err: err}
 		}
@@ -47,12 +58,11 @@ func TestProxy(client *api.Client, name, testURL string, timeout int) tea.Cmd {
 	}
 }
 
-func TestGroup(client *api.Client, group, testURL string, timeout int) tea.Cmd {
+// TestGroup 通过 /group/{name}/delay 一次请求测速整个策略组（可通过 ctx 取消）
+func TestGroup(ctx context.Context, proxySvc *service.ProxyService, group string) tea.Cmd {
 	return func() tea.Msg {
-		if err := client.TestGroupDelay(group, testURL, timeout); err != nil {
-			return messages.ErrMsg{Err: err}
-		}
-		return messages.TestDoneMsg{}
+		results, err := proxySvc.TestGroupDelayContext(ctx, group)
+		return messages.TestAllDoneMsg{Group: group, Results: results, Err: err}
 	}
 }
 
@@ -74,6 +84,74 @@ func LaunchBatchTests(client *api.Client, testURL string, timeout int, pending [
 	return tea.Batch(cmds...)
 }
 
+// SaveFavorites 将收藏的节点与策略组写入 mihosh 配置
+func SaveFavorites(proxies, groups []string) tea.Cmd {
+	proxies = append([]string(nil), proxies...)
+	groups = append([]string(nil), groups...)
+	return func() tea.Msg {
+		err := service.NewConfigService().SetFavorites(proxies, groups)
+		return messages.FavoritesSavedMsg{Proxies: proxies, Groups: groups, Err: err}
+	}
+}
+
+// LoadPresets 读取已保存的选择预设
+func LoadPresets() tea.Cmd {
+	return func() tea.Msg {
+		presets, err := service.NewPresetService(nil).List()
+		return messages.PresetsMsg{Presets: presets, Err: err}
+	}
+}
+
+// SavePreset 将当前所有 Selector 策略组的选择保存为预设
+func SavePreset(proxySvc *service.ProxyService, name string) tea.Cmd {
+	return func() tea.Msg {
+		presetSvc := service.NewPresetService(proxySvc)
+		preset, err := presetSvc.Save(name)
+		if err != nil {
+			return messages.PresetsMsg{Err: err}
+		}
+		presets, err := presetSvc.List()
+		return messages.PresetsMsg{
+			Presets: presets,
+			Notice:  fmt.Sprintf("已保存预设 %s（%d 个策略组）", preset.Name, len(preset.Selections)),
+			Err:     err,
+		}
+	}
+}
+
+// DeletePreset 删除预设
+func DeletePreset(name string) tea.Cmd {
+	return func() tea.Msg {
+		presetSvc := service.NewPresetService(nil)
+		if err := presetSvc.Delete(name); err != nil {
+			return messages.PresetsMsg{Err: err}
+		}
+		presets, err := presetSvc.List()
+		return messages.PresetsMsg{Presets: presets, Notice: fmt.Sprintf("已删除预设 %s", name), Err: err}
+	}
+}
+
+// ApplyPreset 应用预设，汇总已切换与失效的策略组
+func ApplyPreset(proxySvc *service.ProxyService, name string) tea.Cmd {
+	return func() tea.Msg {
+		entries, err := service.NewPresetService(proxySvc).Apply(name)
+		msg := messages.PresetAppliedMsg{Name: name, Err: err}
+		for _, e := range entries {
+			switch e.Status {
+			case service.PresetApplied:
+				msg.Applied++
+			case service.PresetGroupMissing:
+				msg.Missing = append(msg.Missing, e.Group)
+			case service.PresetProxyMissing:
+				msg.Missing = append(msg.Missing, e.Group+"/"+e.Target)
+			case service.PresetFailed:
+				msg.Failed = append(msg.Failed, e.Group)
+			}
+		}
+		return msg
+	}
+}
+
 func FetchConfigMode(client *api.Client) tea.Cmd {
 	return func() tea.Msg {
 		configs, err := client.GetConfigs()
@@ -0,0 +1,160 @@
+package nodes
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/ui/tui/components/common"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// OpenPresetPicker 打开预设选择器并加载预设列表
+func (s State) OpenPresetPicker() (State, tea.Cmd) {
+	s.ShowPresetPicker = true
+	s.PresetNaming = false
+	s.PresetNameInput = ""
+	return s, LoadPresets()
+}
+
+// handlePresetPicker 预设选择器按键处理
+func (s State) handlePresetPicker(msg tea.KeyMsg, proxySvc *service.ProxyService) (State, tea.Cmd) {
+	if s.PresetNaming {
+		return s.handlePresetNaming(msg, proxySvc)
+	}
+
+	switch {
+	case key.Matches(msg, common.Keys.Up):
+		if s.PresetSelected > 0 {
+			s.PresetSelected--
+		}
+	case key.Matches(msg, common.Keys.Down):
+		if s.PresetSelected < len(s.Presets)-1 {
+			s.PresetSelected++
+		}
+	case key.Matches(msg, common.Keys.Enter):
+		if s.PresetSelected < len(s.Presets) {
+			name := s.Presets[s.PresetSelected].Name
+			s.ShowPresetPicker = false
+			s.PresetNotice = fmt.Sprintf("正在应用预设 %s...", name)
+			return s, ApplyPreset(proxySvc, name)
+		}
+	case msg.String() == "n":
+		s.PresetNaming = true
+		s.PresetNameInput = ""
+	case msg.String() == "d":
+		if s.PresetSelected < len(s.Presets) {
+			return s, DeletePreset(s.Presets[s.PresetSelected].Name)
+		}
+	case msg.String() == "P", key.Matches(msg, common.Keys.Escape):
+		s.ShowPresetPicker = false
+	}
+	return s, nil
+}
+
+// handlePresetNaming 新建预设时的名称输入
+func (s State) handlePresetNaming(msg tea.KeyMsg, proxySvc *service.ProxyService) (State, tea.Cmd) {
+	switch {
+	case key.Matches(msg, common.Keys.Escape):
+		s.PresetNaming = false
+		s.PresetNameInput = ""
+	case key.Matches(msg, common.Keys.Enter):
+		name := strings.TrimSpace(s.PresetNameInput)
+		if name == "" {
+			return s, nil
+		}
+		s.PresetNaming = false
+		s.PresetNameInput = ""
+		return s, SavePreset(proxySvc, name)
+	case key.Matches(msg, common.Keys.Backspace):
+		if runes := []rune(s.PresetNameInput); len(runes) > 0 {
+			s.PresetNameInput = string(runes[:len(runes)-1])
+		}
+	default:
+		runes := []rune(msg.String())
+		if len(runes) == 1 && runes[0] >= 32 {
+			s.PresetNameInput += msg.String()
+		}
+	}
+	return s, nil
+}
+
+// ApplyPresets 应用预设列表加载结果
+func (s State) ApplyPresets(presets []model.SelectionPreset, notice string, err error) State {
+	if err != nil {
+		s.PresetNotice = "预设操作失败: " + err.Error()
+		return s
+	}
+	s.Presets = presets
+	if s.PresetSelected >= len(presets) {
+		s.PresetSelected = max(len(presets)-1, 0)
+	}
+	if notice != "" {
+		s.PresetNotice = notice
+	}
+	return s
+}
+
+// ApplyPresetResult 记录预设应用结果
+func (s State) ApplyPresetResult(name string, applied int, missing, failed []string, err error) State {
+	if err != nil {
+		s.PresetNotice = fmt.Sprintf("应用预设 %s 失败: %s", name, err.Error())
+		return s
+	}
+	notice := fmt.Sprintf("已应用预设 %s：切换 %d 个策略组", name, applied)
+	if len(missing) > 0 {
+		notice += fmt.Sprintf("，%d 项已失效（%s）", len(missing), strings.Join(missing, "、"))
+	}
+	if len(failed) > 0 {
+		notice += fmt.Sprintf("，%d 个切换失败（%s）", len(failed), strings.Join(failed, "、"))
+	}
+	s.PresetNotice = notice
+	return s
+}
+
+// buildPresetModal 构建预设选择器弹窗
+func buildPresetModal(state PageState) string {
+	modalWidth := state.Width - 10
+	if modalWidth < 40 {
+		modalWidth = 40
+	}
+	if modalWidth > 70 {
+		modalWidth = 70
+	}
+	innerWidth := modalWidth - 4
+
+	var bodyLines []string
+	if len(state.Presets) == 0 {
+		bodyLines = append(bodyLines, common.DimStyle.Render("尚未保存预设，按 [n] 保存当前选择"))
+	}
+	for i, p := range state.Presets {
+		prefix, name := common.SymbolSelectInactive, p.Name
+		if i == state.PresetSelected {
+			prefix, name = common.SymbolSelectActive, common.SelectedStyle.Render(p.Name)
+		}
+		meta := common.DimStyle.Render(fmt.Sprintf("%d 个策略组 · %s", len(p.Selections), p.CreatedAt.Format("01-02 15:04")))
+		bodyLines = append(bodyLines, prefix+name+"  "+meta)
+	}
+
+	bodyLines = append(bodyLines, "")
+	if state.PresetNaming {
+		bodyLines = append(bodyLines, common.TableHeaderStyle.Render(fmt.Sprintf("新预设名称: %s▌", state.PresetNameInput)))
+		bodyLines = append(bodyLines, common.MutedStyle.Render("[Enter] 保存当前选择  [Esc] 取消"))
+	} else {
+		bodyLines = append(bodyLines, common.MutedStyle.Render("[Enter] 应用  [n] 保存当前选择  [d] 删除  [Esc] 关闭"))
+	}
+
+	title := common.TableHeaderStyle.Render("选择预设")
+	separator := common.DimStyle.Render(strings.Repeat("─", innerWidth))
+	content := lipgloss.JoinVertical(lipgloss.Left, title, separator, strings.Join(bodyLines, "\n"))
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#007BFF")).
+		Padding(0, 1).
+		Width(modalWidth).
+		Render(content)
+}
@@ -0,0 +1,54 @@
+package nodes
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/domain/model"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPresetPicker_NamingCapturesInput(t *testing.T) {
+	state := State{ShowPresetPicker: true}
+
+	state, _ = state.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}}, nil, nil, "", 0)
+	if !state.PresetNaming || !state.TextInputActive() {
+		t.Fatalf("expected naming mode after pressing n")
+	}
+	for _, r := range "work" {
+		state, _ = state.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}}, nil, nil, "", 0)
+	}
+	if state.PresetNameInput != "work" {
+		t.Fatalf("expected typed name, got %q", state.PresetNameInput)
+	}
+
+	state, cmd := state.Update(tea.KeyMsg{Type: tea.KeyEnter}, nil, nil, "", 0)
+	if cmd == nil || state.PresetNaming {
+		t.Fatalf("expected save command and naming mode closed")
+	}
+}
+
+func TestPresetPicker_EnterAppliesSelected(t *testing.T) {
+	state := State{
+		ShowPresetPicker: true,
+		Presets:          []model.SelectionPreset{{Name: "gaming"}, {Name: "work"}},
+	}
+
+	state, _ = state.Update(tea.KeyMsg{Type: tea.KeyDown}, nil, nil, "", 0)
+	state, cmd := state.Update(tea.KeyMsg{Type: tea.KeyEnter}, nil, nil, "", 0)
+
+	if cmd == nil || state.ShowPresetPicker {
+		t.Fatalf("expected apply command and picker closed")
+	}
+	if !strings.Contains(state.PresetNotice, "work") {
+		t.Fatalf("expected notice mentions applied preset, got %q", state.PresetNotice)
+	}
+}
+
+func TestApplyPresetResult_ReportsMissing(t *testing.T) {
+	state := State{}.ApplyPresetResult("work", 2, []string{"Streaming/SG"}, nil, nil)
+
+	if !strings.Contains(state.PresetNotice, "切换 2 个") || !strings.Contains(state.PresetNotice, "Streaming/SG") {
+		t.Fatalf("unexpected notice %q", state.PresetNotice)
+	}
+}
@@ -2,6 +2,8 @@ package nodes
 
 import (
 	"github.com/aimony/mihosh/internal/ui/tui/components/common"
+	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
@@ -24,9 +26,27 @@ const (
 	SortOrderNameAsc                        // A-Z 升序
 	SortOrderDelayAsc                       // 延迟升序
 	SortOrderAvailable                      // 可用性过滤
+	SortOrderRegion                         // 按地区
+	SortOrderProtocol                       // 按协议
+	SortOrderUDP                            // UDP 优先
+	SortOrderMultiplier                     // 倍率升序
 )
 
-var sortOrderLabels = []string{"默认顺序", "按名称排序", "按延迟排序", "仅可用节点"}
+var sortOrderLabels = []string{"默认顺序", "按名称排序", "按延迟排序", "仅可用节点", "按地区排序", "按协议排序", "UDP优先", "按倍率排序"}
+
+// groupByOrder 节点列表分组方式（g 键循环切换）
+var groupByOrder = []service.NodeGroupKey{
+	service.NodeGroupNone,
+	service.NodeGroupRegion,
+	service.NodeGroupProtocol,
+	service.NodeGroupUDP,
+	service.NodeGroupMultiplier,
+}
+
+var groupByLabels = []string{"不分组", "按地区", "按协议", "按UDP", "按倍率"}
+
+// defaultClassifier 未注入分类器时使用的内置规则
+var defaultClassifier = service.DefaultNodeClassifier()
 
 type nodesMouseFocus int
 
@@ -55,15 +75,35 @@ type State struct {
 	TestAllRunning []string
 	TestAllTotal   int
 	TestAllDone    int
+	// 批量测速并发上限（<=0 时使用默认值），及用于 Esc 取消的上下文
+	TestConcurrency int
+	batchCtx        context.Context
+	batchCancel     context.CancelFunc
+	// 整组测速：TestAllGroup 为正在测速的策略组，batchViaGroup 为 false 时退回逐节点队列
+	TestAllGroup  string
+	batchViaGroup bool
 	// Ring Buffer for test failures
 	TestFailuresArr      [testFailureCap]string
 	failHead          int // 写入位置
 	failCount         int // 已写入总数（上限 testFailureCap）
 	ShowFailureDetail bool
 	FailureScrollTop  int
-	// 排序
+	// 排序与分组
 	ProxySortOrder  ProxySortOrder
-	OriginalProxies []string // 记录原始顺序，便于恢复
+	GroupBy         int                     // groupByOrder 下标
+	Classifier      *service.NodeClassifier // 地区/倍率识别规则，为 nil 时使用内置规则
+	OriginalProxies []string                // 记录原始顺序，便于恢复
+	// 收藏：收藏的节点置顶显示，收藏的策略组排在策略组列表最前
+	FavoriteProxies   []string
+	FavoriteGroups    []string
+	orderedGroupNames []string // 核心返回的策略组原始顺序
+	// 选择预设
+	ShowPresetPicker bool
+	Presets          []model.SelectionPreset
+	PresetSelected   int
+	PresetNaming     bool   // 是否正在输入新预设名称
+	PresetNameInput  string
+	PresetNotice     string // 最近一次预设操作结果
 	// 搜索
 	NodeFilter           string
 	NodeFilterMode       bool
@@ -165,18 +205,32 @@ func (s State) ToPageState(width, height int) PageState {
 		ProxyScrollTop:    s.ProxyScrollTop,
 		FilterText:        s.NodeFilter,
 		FilterMode:        s.NodeFilterMode,
+		BatchActive:       s.TestAllActive,
+		NodeMetas:         s.nodeMetas(),
+		GroupBy:           s.groupByKey(),
+		GroupByLabel:      groupByLabels[s.GroupBy%len(groupByLabels)],
+		FavoriteProxies:   favoriteSet(s.FavoriteProxies),
+		FavoriteGroups:    favoriteSet(s.FavoriteGroups),
+		ShowPresetPicker:  s.ShowPresetPicker,
+		Presets:           s.Presets,
+		PresetSelected:    s.PresetSelected,
+		PresetNaming:      s.PresetNaming,
+		PresetNameInput:   s.PresetNameInput,
+		PresetNotice:      s.PresetNotice,
 	}
 }
 
 // Update 处理节点页面按键
 func (s State) Update(msg tea.KeyMsg, client *api.Client, proxySvc *service.ProxyService, testURL string, timeout int) (State, tea.Cmd) {
-	_ = proxySvc // 保留签名以兼容调用方（批量测速由页面状态控制并发）
-
 	// 搜索输入模式：拦截所有按键用于输入
 	if s.NodeFilterMode {
 		return s.handleNodeFilterMode(msg)
 	}
 
+	if s.ShowPresetPicker {
+		return s.handlePresetPicker(msg, proxySvc)
+	}
+
 	// 失败详情弹窗打开时，↑/↓ 控制弹窗滚动，f/Esc 关闭弹窗
 	if s.ShowFailureDetail {
 		switch {
@@ -248,27 +302,30 @@ func (s State) Update(msg tea.KeyMsg, client *api.Client, proxySvc *service.Prox
 	case key.Matches(msg, common.Keys.Test):
 		if len(display) > 0 && s.SelectedProxy < len(display) {
 			proxyName := display[s.SelectedProxy]
+			s = s.CancelBatchTests()
 			s.Testing = true
 			s.TestingTarget = proxyName
-			s.TestAllActive = false
-			s.TestAllPending = nil
-			s.TestAllRunning = nil
-			s.TestAllTotal = 0
-			s.TestAllDone = 0
 			return s, TestProxy(client, proxyName, testURL, timeout)
 		}
 
 	case key.Matches(msg, common.Keys.TestAll):
-		if len(s.CurrentProxies) > 0 {
+		if len(s.CurrentProxies) > 0 && s.SelectedGroup < len(s.GroupNames) {
+			groupName := s.GroupNames[s.SelectedGroup]
+			s = s.CancelBatchTests()
+			s.batchCtx, s.batchCancel = context.WithCancel(context.Background())
 			s.Testing = true
 			s.clearTestFailures()
 			s.ShowFailureDetail = false
 			s.TestAllActive = true
+			s.TestAllGroup = groupName
+			s.batchViaGroup = true
+			// 保留节点队列，核心不支持整组测速时退回逐节点测速
 			s.TestAllPending = append([]string(nil), s.CurrentProxies...)
 			s.TestAllRunning = nil
 			s.TestAllTotal = len(s.CurrentProxies)
 			s.TestAllDone = 0
-			return s.LaunchBatchTests(client, testURL, timeout)
+			s.TestingTarget = fmt.Sprintf("策略组 %s（%d 个节点）", groupName, s.TestAllTotal)
+			return s, TestGroup(s.batchCtx, proxySvc, groupName)
 		}
 
 	case msg.String() == "f":
@@ -299,10 +356,39 @@ func (s State) Update(msg tea.KeyMsg, client *api.Client, proxySvc *service.Prox
 		s.SelectedProxy = 0
 		s.ProxyScrollTop = 0
 
+	case msg.String() == "g":
+		s.GroupBy = (s.GroupBy + 1) % len(groupByOrder)
+		s.applySortOrder()
+		s.updateFilteredProxies()
+		s.SelectedProxy = 0
+		s.ProxyScrollTop = 0
+
+	case msg.String() == "*":
+		if len(display) > 0 && s.SelectedProxy < len(display) {
+			s.FavoriteProxies, _ = service.ToggleFavorite(s.FavoriteProxies, display[s.SelectedProxy])
+			s.refreshFavoriteProxies(display[s.SelectedProxy])
+			return s, SaveFavorites(s.FavoriteProxies, s.FavoriteGroups)
+		}
+
+	case msg.String() == "p":
+		if len(s.GroupNames) > 0 && s.SelectedGroup < len(s.GroupNames) {
+			groupName := s.GroupNames[s.SelectedGroup]
+			s.FavoriteGroups, _ = service.ToggleFavorite(s.FavoriteGroups, groupName)
+			s.refreshFavoriteGroups(groupName)
+			return s, SaveFavorites(s.FavoriteProxies, s.FavoriteGroups)
+		}
+
+	case msg.String() == "P":
+		return s.OpenPresetPicker()
+
 	case msg.String() == "/":
 		s.NodeFilterMode = true
 
 	case key.Matches(msg, common.Keys.Escape):
+		if s.TestAllActive {
+			// 批量测速进行中：优先取消排队与进行中的测速
+			return s.CancelBatchTests(), nil
+		}
 		if s.NodeFilter != "" {
 			s.NodeFilter = ""
 			s.SelectedProxy = 0
@@ -314,6 +400,11 @@ func (s State) Update(msg tea.KeyMsg, client *api.Client, proxySvc *service.Prox
 	return s, nil
 }
 
+// TextInputActive 是否处于文本输入状态（此时按键不应触发全局快捷键）
+func (s State) TextInputActive() bool {
+	return s.NodeFilterMode || (s.ShowPresetPicker && s.PresetNaming)
+}
+
 // handleNodeFilterMode 搜索输入模式处理
 func (s State) handleNodeFilterMode(msg tea.KeyMsg) (State, tea.Cmd) {
 	switch {
@@ -360,17 +451,40 @@ func (s *State) updateFilteredProxies() {
 	if s.NodeFilter == "" {
 		return
 	}
-	filter := strings.ToLower(s.NodeFilter)
+	query := service.ParseNodeQuery(s.NodeFilter)
+	classifier := s.classifier()
 	for i, name := range s.CurrentProxies {
-		if strings.Contains(strings.ToLower(name), filter) {
+		if query.Match(name, classifier.Classify(s.Proxies[name], name)) {
 			s.FilteredProxyIndices = append(s.FilteredProxyIndices, i)
 		}
 	}
 }
 
+func (s State) classifier() *service.NodeClassifier {
+	if s.Classifier != nil {
+		return s.Classifier
+	}
+	return defaultClassifier
+}
+
+func (s State) groupByKey() service.NodeGroupKey {
+	return groupByOrder[s.GroupBy%len(groupByOrder)]
+}
+
+// nodeMetas 计算当前显示节点的元数据
+func (s State) nodeMetas() map[string]model.NodeMeta {
+	display := s.displayProxies()
+	metas := make(map[string]model.NodeMeta, len(display))
+	classifier := s.classifier()
+	for _, name := range display {
+		metas[name] = classifier.Classify(s.Proxies[name], name)
+	}
+	return metas
+}
+
 // HandleMouseLeft 处理 nodes 页面左键单击/双击
 func (s State) HandleMouseLeft(pageX, pageY, pageWidth, pageHeight int, client *api.Client) (State, tea.Cmd) {
-	if s.ShowFailureDetail {
+	if s.ShowFailureDetail || s.ShowPresetPicker {
 		return s, nil
 	}
 
@@ -458,6 +572,9 @@ func (s *State) isMouseDoubleClick(target MouseTarget, idx int, now time.Time) b
 
 // HandleMouseScroll 处理鼠标滚轮（弹窗打开时控制弹窗滚动，否则根据鼠标位置或焦点控制列表滚动）
 func (s State) HandleMouseScroll(up bool, pageX, pageY, pageWidth, pageHeight int) State {
+	if s.ShowPresetPicker {
+		return s
+	}
 	if s.ShowFailureDetail {
 		if up {
 			if s.FailureScrollTop > 0 {
@@ -530,7 +647,8 @@ func (s State) ApplyGroups(Groups map[string]model.Group, orderedNames []string)
 	}
 
 	s.Groups = Groups
-	s.GroupNames = orderedNames
+	s.orderedGroupNames = orderedNames
+	s.GroupNames, _ = service.PinFavorites(orderedNames, favoriteSet(s.FavoriteGroups))
 
 	if selectedGroupName != "" {
 		for i, name := range s.GroupNames {
@@ -560,21 +678,21 @@ func (s State) ApplyProxies(Proxies map[string]model.Proxy) State {
 
 // ApplyTestDone 单节点测速完成
 func (s State) ApplyTestDone(name string, delay int, err error) State {
+	if errors.Is(err, context.Canceled) {
+		// 被 Esc 取消的测速不计为失败
+		return s
+	}
 	if err != nil {
 		s.appendTestFailure(fmt.Sprintf("%s: %s", name, err.Error()))
 	}
 	if s.TestAllActive {
-		s.removeRunningTest(name)
+		if !s.removeRunningTest(name) {
+			// 已取消批次的迟到结果
+			return s
+		}
 		s.TestAllDone++
 		if s.TestAllDone >= s.TestAllTotal {
-			s.Testing = false
-			s.TestingTarget = ""
-			s.TestAllActive = false
-			s.TestAllPending = nil
-			s.TestAllRunning = nil
-			s.TestAllTotal = 0
-			s.TestAllDone = 0
-			return s
+			return s.CancelBatchTests()
 		}
 		s.Testing = true
 		s.updateBatchTestingTarget()
@@ -593,30 +711,35 @@ func (s State) ApplyTestDone(name string, delay int, err error) State {
 	return s
 }
 
-// ApplyTestAllDone 批量测速完成
-func (s State) ApplyTestAllDone(results map[string]int) State {
+// ApplyTestAllDone 整组测速完成；核心返回 404 时保留批次并退回逐节点队列（由调用方 LaunchBatchTests）
+func (s State) ApplyTestAllDone(group string, results map[string]int, err error) State {
+	if !s.TestAllActive || !s.batchViaGroup || group != s.TestAllGroup || errors.Is(err, context.Canceled) {
+		// 已取消或过期批次的结果
+		return s
+	}
+	if api.IsNotFound(err) {
+		s.batchViaGroup = false
+		s.updateBatchTestingTarget()
+		return s
+	}
+	if err != nil {
+		s.appendTestFailure(fmt.Sprintf("%s: %s", group, err.Error()))
+	}
 	for name, delay := range results {
 		if delay == -1 {
 			s.appendTestFailure(fmt.Sprintf("%s: timeout or error", name))
 		}
 	}
-	s.Testing = false
-	s.TestingTarget = ""
-	s.TestAllActive = false
-	s.TestAllPending = nil
-	s.TestAllRunning = nil
-	s.TestAllTotal = 0
-	s.TestAllDone = 0
-	return s
+	return s.CancelBatchTests()
 }
 
-// launchBatchTests 启动/补位批量测速任务（受并发上限控制）
+// LaunchBatchTests 启动/补位批量测速任务（受并发上限控制）
 func (s State) LaunchBatchTests(client *api.Client, testURL string, timeout int) (State, tea.Cmd) {
-	if !s.TestAllActive || s.TestAllTotal == 0 {
+	if !s.TestAllActive || s.batchViaGroup || s.TestAllTotal == 0 {
 		return s, nil
 	}
 
-	slots := model.TestConcurrency - len(s.TestAllRunning)
+	slots := service.ResolveTestConcurrency(s.TestConcurrency) - len(s.TestAllRunning)
 	if slots <= 0 || len(s.TestAllPending) == 0 {
 		s.updateBatchTestingTarget()
 		return s, nil
@@ -627,7 +750,7 @@ func (s State) LaunchBatchTests(client *api.Client, testURL string, timeout int)
 		name := s.TestAllPending[0]
 		s.TestAllPending = s.TestAllPending[1:]
 		s.TestAllRunning = append(s.TestAllRunning, name)
-		cmds = append(cmds, TestProxy(client, name, testURL, timeout))
+		cmds = append(cmds, TestProxyContext(s.batchCtx, client, name, testURL, timeout))
 	}
 
 	s.Testing = true
@@ -650,15 +773,35 @@ func (s *State) updateBatchTestingTarget() {
 	s.TestingTarget = fmt.Sprintf("%s（已完成 %d/%d）", s.TestAllRunning[0], s.TestAllDone, s.TestAllTotal)
 }
 
-func (s *State) removeRunningTest(name string) {
+// CancelBatchTests 取消批量测速：中止进行中的请求并清空排队队列
+func (s State) CancelBatchTests() State {
+	if s.batchCancel != nil {
+		s.batchCancel()
+	}
+	s.batchCtx = nil
+	s.batchCancel = nil
+	s.Testing = false
+	s.TestingTarget = ""
+	s.TestAllActive = false
+	s.TestAllPending = nil
+	s.TestAllRunning = nil
+	s.TestAllTotal = 0
+	s.TestAllDone = 0
+	s.TestAllGroup = ""
+	s.batchViaGroup = false
+	return s
+}
+
+func (s *State) removeRunningTest(name string) bool {
 	for i, running := range s.TestAllRunning {
 		if running == name {
 			last := len(s.TestAllRunning) - 1
 			s.TestAllRunning[i] = s.TestAllRunning[last]
 			s.TestAllRunning = s.TestAllRunning[:last]
-			return
+			return true
 		}
 	}
+	return false
 }
 
 // updateCurrentProxies 更新当前策略组的节点列表（指针接收者，修改自身）
@@ -724,7 +867,59 @@ func (s *State) applySortOrder() {
 			return s.getProxyDelayUnsafe(filtered[i]) < s.getProxyDelayUnsafe(filtered[j])
 		})
 		s.CurrentProxies = filtered
+	case SortOrderRegion, SortOrderProtocol, SortOrderUDP, SortOrderMultiplier:
+		keys := map[ProxySortOrder]service.NodeSortKey{
+			SortOrderRegion:     service.NodeSortRegion,
+			SortOrderProtocol:   service.NodeSortProtocol,
+			SortOrderUDP:        service.NodeSortUDP,
+			SortOrderMultiplier: service.NodeSortMultiplier,
+		}
+		copied := append([]string(nil), s.OriginalProxies...)
+		s.classifier().SortNodes(copied, s.Proxies, keys[s.ProxySortOrder])
+		s.CurrentProxies = copied
+	}
+
+	// 分组：同组节点相邻，组内保持上面的排序结果
+	s.classifier().GroupNodes(s.CurrentProxies, s.Proxies, s.groupByKey())
+	// 收藏节点置顶
+	s.CurrentProxies, _ = service.PinFavorites(s.CurrentProxies, favoriteSet(s.FavoriteProxies))
+}
+
+// refreshFavoriteProxies 收藏变化后重新排列节点列表，并保持选中 name
+func (s *State) refreshFavoriteProxies(name string) {
+	s.applySortOrder()
+	s.updateFilteredProxies()
+	for i, n := range s.displayProxies() {
+		if n == name {
+			s.SelectedProxy = i
+			break
+		}
+	}
+	if s.SelectedProxy < s.ProxyScrollTop {
+		s.ProxyScrollTop = s.SelectedProxy
+	}
+}
+
+// refreshFavoriteGroups 收藏变化后重新排列策略组列表，并保持选中 name
+func (s *State) refreshFavoriteGroups(name string) {
+	s.GroupNames, _ = service.PinFavorites(s.orderedGroupNames, favoriteSet(s.FavoriteGroups))
+	for i, n := range s.GroupNames {
+		if n == name {
+			s.SelectedGroup = i
+			break
+		}
+	}
+	if s.SelectedGroup < s.GroupScrollTop {
+		s.GroupScrollTop = s.SelectedGroup
+	}
+}
+
+func favoriteSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
 	}
+	return set
 }
 
 // getProxyDelayUnsafe 获取节点的最新延迟值（未测速为0，超时/失败为-1或0）
@@ -1,24 +1,56 @@
 package nodes
 
 import (
+	"context"
 	"strings"
 	"testing"
 
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/infrastructure/api"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-func TestNodesState_TestAllStartsWithFirstProxyTarget(t *testing.T) {
+func TestNodesState_TestAllStartsGroupDelayTest(t *testing.T) {
 	state := State{
 		GroupNames:     []string{"Auto"},
 		SelectedGroup:  0,
 		CurrentProxies: []string{"HK-01", "JP-01"},
 	}
 
-	next, _ := state.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}}, nil, nil, "", 0)
+	next, cmd := state.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}}, nil, nil, "", 0)
+
+	if !next.Testing || cmd == nil {
+		t.Fatalf("expected Testing=true and a group test command after pressing a")
+	}
+	if !strings.Contains(next.TestingTarget, "Auto") {
+		t.Fatalf("expected TestingTarget mentions the group, got %q", next.TestingTarget)
+	}
+	if !next.TestAllActive || next.TestAllGroup != "Auto" || next.TestAllTotal != 2 || len(next.TestAllRunning) != 0 {
+		t.Fatalf("expected group batch state initialized, active=%v group=%q total=%d running=%d",
+			next.TestAllActive, next.TestAllGroup, next.TestAllTotal, len(next.TestAllRunning))
+	}
 
-	if !next.Testing {
-		t.Fatalf("expected Testing=true after pressing a")
+	// 整组结果返回后记录失败节点并结束批次
+	next = next.ApplyTestAllDone("Auto", map[string]int{"HK-01": 80, "JP-01": -1}, nil)
+	if next.Testing || next.TestAllActive {
+		t.Fatalf("expected batch finished, testing=%v active=%v", next.Testing, next.TestAllActive)
 	}
+	if failures := next.TestFailures(); len(failures) != 1 || !strings.HasPrefix(failures[0], "JP-01") {
+		t.Fatalf("expected JP-01 recorded as failure, got %v", failures)
+	}
+}
+
+func TestNodesState_TestAllFallsBackToQueueOn404(t *testing.T) {
+	state := State{
+		GroupNames:     []string{"Auto"},
+		CurrentProxies: []string{"HK-01", "JP-01"},
+	}
+
+	next, _ := state.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}}, nil, nil, "", 0)
+	next = next.ApplyTestAllDone("Auto", nil, &api.StatusError{StatusCode: 404, Status: "404 Not Found"})
+	next, _ = next.LaunchBatchTests(nil, "", 0)
+
 	if !strings.Contains(next.TestingTarget, "HK-01") {
 		t.Fatalf("expected TestingTarget contains first proxy, got %q", next.TestingTarget)
 	}
@@ -66,3 +98,142 @@ func TestNodesState_FailureModalSupportsHomeAndEnd(t *testing.T) {
 		t.Fatalf("expected end to move scroll near bottom, got %d", state.FailureScrollTop)
 	}
 }
+
+func TestNodesState_TestAllRespectsConcurrency(t *testing.T) {
+	state := State{
+		GroupNames:      []string{"Auto"},
+		CurrentProxies:  []string{"HK-01", "JP-01", "US-01"},
+		TestConcurrency: 1,
+	}
+
+	next, _ := state.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}}, nil, nil, "", 0)
+	next = next.ApplyTestAllDone("Auto", nil, &api.StatusError{StatusCode: 404, Status: "404 Not Found"})
+	next, _ = next.LaunchBatchTests(nil, "", 0)
+	if len(next.TestAllRunning) != 1 || len(next.TestAllPending) != 2 {
+		t.Fatalf("expected 1 running and 2 pending, got running=%d pending=%d", len(next.TestAllRunning), len(next.TestAllPending))
+	}
+
+	next = next.ApplyTestDone("HK-01", 80, nil)
+	next, _ = next.LaunchBatchTests(nil, "", 0)
+	if len(next.TestAllRunning) != 1 || next.TestAllRunning[0] != "JP-01" {
+		t.Fatalf("expected JP-01 to fill the freed slot, got %v", next.TestAllRunning)
+	}
+}
+
+func TestNodesState_EscCancelsBatch(t *testing.T) {
+	state := State{
+		GroupNames:      []string{"Auto"},
+		CurrentProxies:  []string{"HK-01", "JP-01", "US-01"},
+		TestConcurrency: 1,
+		NodeFilter:      "01",
+	}
+	state.updateFilteredProxies()
+
+	next, _ := state.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}}, nil, nil, "", 0)
+	ctx := next.batchCtx
+
+	next, _ = next.Update(tea.KeyMsg{Type: tea.KeyEsc}, nil, nil, "", 0)
+	if next.TestAllActive || next.Testing || len(next.TestAllPending) != 0 || len(next.TestAllRunning) != 0 {
+		t.Fatalf("expected batch cleared after Esc, active=%v testing=%v pending=%d running=%d",
+			next.TestAllActive, next.Testing, len(next.TestAllPending), len(next.TestAllRunning))
+	}
+	if ctx == nil || ctx.Err() == nil {
+		t.Fatalf("expected in-flight context to be cancelled")
+	}
+	if next.NodeFilter != "01" {
+		t.Fatalf("expected first Esc to cancel batch only, filter=%q", next.NodeFilter)
+	}
+
+	// 被取消的在途结果不应记为失败
+	next = next.ApplyTestDone("HK-01", -1, context.Canceled)
+	if len(next.TestFailures()) != 0 {
+		t.Fatalf("expected cancelled result ignored, got failures %v", next.TestFailures())
+	}
+}
+
+func TestNodesState_GroupByRegionAndFieldFilter(t *testing.T) {
+	state := State{
+		GroupNames: []string{"Auto"},
+		Groups: map[string]model.Group{
+			"Auto": {Name: "Auto", All: []string{"JP 01", "HK 01", "US 01", "HK 02 0.5x"}},
+		},
+		Proxies: map[string]model.Proxy{
+			"JP 01":      {Type: "Trojan"},
+			"HK 01":      {Type: "Vmess", UDP: true},
+			"US 01":      {Type: "Vmess"},
+			"HK 02 0.5x": {Type: "Vmess", UDP: true},
+		},
+	}
+	state.updateCurrentProxies()
+
+	next, _ := state.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'g'}}, nil, nil, "", 0)
+	want := []string{"HK 01", "HK 02 0.5x", "JP 01", "US 01"}
+	if strings.Join(next.CurrentProxies, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected nodes grouped by region %v, got %v", want, next.CurrentProxies)
+	}
+	if page := next.ToPageState(120, 40); page.GroupBy != service.NodeGroupRegion || page.NodeMetas["HK 02 0.5x"].Multiplier != 0.5 {
+		t.Fatalf("expected page state carries region grouping and metas, got %q %+v", page.GroupBy, page.NodeMetas["HK 02 0.5x"])
+	}
+
+	next.NodeFilter = "region:hk mult:<1"
+	next.updateFilteredProxies()
+	if display := next.displayProxies(); len(display) != 1 || display[0] != "HK 02 0.5x" {
+		t.Fatalf("expected field filter to keep only HK 02 0.5x, got %v", display)
+	}
+
+	next.NodeFilter = "udp:yes"
+	next.updateFilteredProxies()
+	if display := next.displayProxies(); len(display) != 2 {
+		t.Fatalf("expected 2 UDP nodes, got %v", display)
+	}
+}
+
+func TestNodesState_StarPinsProxyAndKeepsSelection(t *testing.T) {
+	state := State{
+		GroupNames: []string{"Proxy"},
+		Groups: map[string]model.Group{
+			"Proxy": {Name: "Proxy", All: []string{"HK-01", "JP-01", "US-01"}},
+		},
+	}
+	state.updateCurrentProxies()
+	state.SelectedProxy = 2
+
+	next, cmd := state.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'*'}}, nil, nil, "", 0)
+
+	if cmd == nil {
+		t.Fatalf("expected a command persisting favorites")
+	}
+	if got := strings.Join(next.CurrentProxies, ","); got != "US-01,HK-01,JP-01" {
+		t.Fatalf("expected starred proxy pinned to top, got %s", got)
+	}
+	if next.SelectedProxy != 0 {
+		t.Fatalf("expected selection to follow starred proxy, got %d", next.SelectedProxy)
+	}
+
+	next, _ = next.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'*'}}, nil, nil, "", 0)
+	if got := strings.Join(next.CurrentProxies, ","); got != "HK-01,JP-01,US-01" || len(next.FavoriteProxies) != 0 {
+		t.Fatalf("expected original order after unstar, got %s favorites=%v", got, next.FavoriteProxies)
+	}
+}
+
+func TestNodesState_FavoriteGroupsPinnedOnRefresh(t *testing.T) {
+	groups := map[string]model.Group{
+		"GLOBAL": {Name: "GLOBAL"},
+		"Proxy":  {Name: "Proxy"},
+		"Auto":   {Name: "Auto"},
+	}
+	state := State{FavoriteGroups: []string{"Auto"}}.ApplyGroups(groups, []string{"GLOBAL", "Proxy", "Auto"})
+
+	if got := strings.Join(state.GroupNames, ","); got != "Auto,GLOBAL,Proxy" {
+		t.Fatalf("expected favorite group first, got %s", got)
+	}
+
+	state.SelectedGroup = 2
+	next, cmd := state.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}}, nil, nil, "", 0)
+	if cmd == nil || strings.Join(next.GroupNames, ",") != "Proxy,Auto,GLOBAL" {
+		t.Fatalf("expected pinned groups keep core order, got %v", next.GroupNames)
+	}
+	if next.GroupNames[next.SelectedGroup] != "Proxy" {
+		t.Fatalf("expected selection kept on Proxy, got %s", next.GroupNames[next.SelectedGroup])
+	}
+}
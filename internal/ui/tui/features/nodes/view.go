@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/aimony/mihosh/internal/app/service"
 	"github.com/aimony/mihosh/internal/domain/model"
 	"github.com/aimony/mihosh/internal/ui/tui/components/common"
 	"github.com/charmbracelet/lipgloss"
@@ -38,6 +39,18 @@ type PageState struct {
 	ProxyScrollTop    int    // 节点列表滚动偏移
 	FilterText        string // 节点搜索关键词
 	FilterMode        bool   // 是否处于搜索输入模式
+	BatchActive       bool   // 是否正在批量测速
+	NodeMetas         map[string]model.NodeMeta
+	GroupBy           service.NodeGroupKey // 分组字段（为空表示不分组）
+	GroupByLabel      string
+	FavoriteProxies   map[string]bool // 收藏（置顶）的节点
+	FavoriteGroups    map[string]bool // 收藏的策略组
+	ShowPresetPicker  bool            // 是否显示预设选择器
+	Presets           []model.SelectionPreset
+	PresetSelected    int
+	PresetNaming      bool
+	PresetNameInput   string
+	PresetNotice      string // 最近一次预设操作结果
 }
 
 // displayWidth 计算字符串的显示宽度（使用 runewidth 库精确计算）
@@ -69,12 +82,16 @@ func RenderNodesPage(state PageState) string {
 	// 搜索状态提示行
 	var searchLine string
 	if state.FilterMode {
-		searchLine = common.TableHeaderStyle.Render(fmt.Sprintf("搜索: %s▌", state.FilterText))
+		searchLine = common.TableHeaderStyle.Render(fmt.Sprintf("搜索: %s▌", state.FilterText)) +
+			" " + common.MutedStyle.Render("支持 region:hk,jp type:vmess udp:yes mult:<1 provider:名称")
 	} else if state.FilterText != "" {
 		searchLine = common.MutedStyle.Render(fmt.Sprintf("搜索: %s  [Esc]清除", state.FilterText))
 	}
 
-	helpText := common.MutedStyle.Render(fmt.Sprintf("[↑/↓]选择 [←/→]切组 [Enter]切换 [t]测速 [m]模式 [s]排序:%s [/]搜索 [r]刷新", sortLabel))
+	helpText := common.MutedStyle.Render(fmt.Sprintf("[↑/↓]选择 [←/→]切组 [Enter]切换 [t]测速 [m]模式 [s]排序:%s [g]分组:%s [*]收藏 [p]收藏组 [P]预设 [/]搜索 [r]刷新", sortLabel, state.GroupByLabel))
+	if state.BatchActive {
+		helpText = common.MutedStyle.Render(fmt.Sprintf("[↑/↓]选择 [←/→]切组 [t]测速 [Esc]取消批量测速 [s]排序:%s [r]刷新", sortLabel))
+	}
 
 	var failureBadge string
 	if len(state.TestFailures) > 0 {
@@ -82,6 +99,11 @@ func RenderNodesPage(state PageState) string {
 			" " + common.MutedStyle.Render("[f]查看详情")
 	}
 
+	var presetLine string
+	if state.PresetNotice != "" {
+		presetLine = common.MutedStyle.Render(state.PresetNotice)
+	}
+
 	mainContent := lipgloss.JoinVertical(
 		lipgloss.Left,
 		modeSwitch,
@@ -92,6 +114,7 @@ func RenderNodesPage(state PageState) string {
 		proxyList,
 		searchLine,
 		failureBadge,
+		presetLine,
 	)
 
 	contentLines := strings.Count(mainContent, "\n") + 1
@@ -102,6 +125,9 @@ func RenderNodesPage(state PageState) string {
 		modal := buildFailureModal(state)
 		return overlayCenter(fullPage, modal, state.Width, state.Height)
 	}
+	if state.ShowPresetPicker {
+		return overlayCenter(fullPage, buildPresetModal(state), state.Width, state.Height)
+	}
 	return fullPage
 }
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/aimony/mihosh/internal/app/service"
 	"github.com/aimony/mihosh/internal/ui/tui/components/common"
 	"github.com/aimony/mihosh/pkg/utils"
 	"github.com/charmbracelet/lipgloss"
@@ -184,7 +185,7 @@ func RenderGroupListComponent(state PageState, groupMaxLines int) string {
 	maxTypeLen := nodesDefaultNameLen
 	maxNowLen := nodesDefaultNameLen
 	for _, name := range state.GroupNames {
-		if w := displayWidth(name); w > maxNameLen {
+		if w := displayWidth(favoriteLabel(name, state.FavoriteGroups[name])); w > maxNameLen {
 			maxNameLen = w
 		}
 		group := state.Groups[name]
@@ -215,7 +216,7 @@ func RenderGroupListComponent(state PageState, groupMaxLines int) string {
 
 		content := fmt.Sprintf("%s%s │ %s │ %s",
 			prefix,
-			padString(name, maxNameLen),
+			padString(favoriteLabel(name, state.FavoriteGroups[name]), maxNameLen),
 			padString(group.Type, maxTypeLen),
 			padString(group.Now, maxNowLen),
 		)
@@ -251,17 +252,43 @@ func RenderProxyListComponent(state PageState, proxyMaxLines int) string {
 
 	maxNameLen := nodesDefaultNameLen
 	for _, name := range state.CurrentProxies {
-		if w := displayWidth(name); w > maxNameLen {
+		if w := displayWidth(favoriteLabel(name, state.FavoriteProxies[name])); w > maxNameLen {
 			maxNameLen = w
 		}
 	}
 
 	delayColWidth := 6
 	statusColWidth := 2
+	regionColWidth, protoColWidth, multColWidth := 4, 4, 4
+	groupColWidth := 4
+	for _, name := range state.CurrentProxies {
+		meta := state.NodeMetas[name]
+		if w := displayWidth(meta.Region); w > regionColWidth {
+			regionColWidth = w
+		}
+		if w := displayWidth(meta.Protocol); w > protoColWidth {
+			protoColWidth = w
+		}
+		if w := displayWidth(service.FormatMultiplier(meta.Multiplier)); w > multColWidth {
+			multColWidth = w
+		}
+		if w := displayWidth(proxySectionLabel(state, name)); w > groupColWidth {
+			groupColWidth = w
+		}
+	}
 	window := resolveListWindow(state.SelectedProxy, state.ProxyScrollTop, proxyMaxLines, len(state.CurrentProxies))
 
-	header := fmt.Sprintf("  %s │ %s │ %s",
+	groupHeader := ""
+	if state.GroupBy != service.NodeGroupNone {
+		groupHeader = padString("分组", groupColWidth) + " │ "
+	}
+	header := fmt.Sprintf("  %s%s │ %s │ %s │ %s │ %s │ %s │ %s",
+		groupHeader,
 		padString("名称", maxNameLen),
+		padString("地区", regionColWidth),
+		padString("协议", protoColWidth),
+		"UDP",
+		padString("倍率", multColWidth),
 		padString("延迟", delayColWidth),
 		padString("状态", statusColWidth),
 	)
@@ -276,7 +303,7 @@ func RenderProxyListComponent(state PageState, proxyMaxLines int) string {
 			prefix = common.SelectedStyle.Render(common.SymbolSelectActive)
 		}
 
-		namePart := padString(name, maxNameLen)
+		namePart := padString(favoriteLabel(name, state.FavoriteProxies[name]), maxNameLen)
 		if i == state.SelectedProxy {
 			namePart = common.SelectedStyle.Render(namePart)
 		} else if name == currentNode {
@@ -319,7 +346,26 @@ func RenderProxyListComponent(state PageState, proxyMaxLines int) string {
 			status = common.InactiveStyle.Render(status)
 		}
 
-		line := prefix + namePart + " │ " + delayStr + " │ " + status
+		meta := state.NodeMetas[name]
+		udp := " - "
+		if meta.UDP {
+			udp = " ✓ "
+		}
+		metaPart := common.MutedStyle.Render(padString(meta.Region, regionColWidth) + " │ " +
+			padString(meta.Protocol, protoColWidth) + " │ " + udp + " │ " +
+			padString(service.FormatMultiplier(meta.Multiplier), multColWidth))
+
+		groupPart := ""
+		if state.GroupBy != service.NodeGroupNone {
+			// 仅在每组第一行（或窗口首行）显示分组名
+			label := proxySectionLabel(state, name)
+			if i > window.ScrollTop && proxySectionLabel(state, state.CurrentProxies[i-1]) == label {
+				label = ""
+			}
+			groupPart = common.TableHeaderStyle.Render(padString(label, groupColWidth)) + " │ "
+		}
+
+		line := prefix + groupPart + namePart + " │ " + metaPart + " │ " + delayStr + " │ " + status
 		bar := renderScrollbar(proxyMaxLines, len(state.CurrentProxies), window.ScrollTop, i-window.ScrollTop)
 		lines = append(lines, line+" "+common.DimStyle.Render(bar))
 	}
@@ -327,6 +373,22 @@ func RenderProxyListComponent(state PageState, proxyMaxLines int) string {
 	return common.TableHeaderStyle.Render(header) + "\n" + strings.Join(lines, "\n")
 }
 
+// favoriteLabel 为收藏项加上 ★ 标记
+func favoriteLabel(name string, favorite bool) string {
+	if favorite {
+		return "★ " + name
+	}
+	return name
+}
+
+// proxySectionLabel 返回节点所在分组名（收藏节点统一归入置顶分组）
+func proxySectionLabel(state PageState, name string) string {
+	if state.FavoriteProxies[name] {
+		return "★ 收藏"
+	}
+	return service.NodeGroupLabel(state.NodeMetas[name], state.GroupBy)
+}
+
 // RenderModeSwitchComponent 渲染模式切换按钮
 func RenderModeSwitchComponent(currentMode string) string {
 	modes := []struct {
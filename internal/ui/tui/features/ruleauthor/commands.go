@@ -0,0 +1,57 @@
+package ruleauthor
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/infrastructure/api"
+	"github.com/aimony/mihosh/internal/infrastructure/config"
+	"github.com/aimony/mihosh/internal/ui/tui/messages"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// builtinTargets 除策略组外总是可选的目标
+var builtinTargets = []string{"DIRECT", "REJECT"}
+
+// FetchTargets 获取可作为规则目标的策略组
+func FetchTargets(id int, client *api.Client) tea.Cmd {
+	return func() tea.Msg {
+		_, names, err := client.GetGroups()
+		if err != nil {
+			return messages.RuleTargetsMsg{RequestID: id, Err: err}
+		}
+		names = slices.Clone(names)
+		for _, t := range builtinTargets {
+			if !slices.Contains(names, t) {
+				names = append(names, t)
+			}
+		}
+		return messages.RuleTargetsMsg{RequestID: id, Names: names}
+	}
+}
+
+// PlanEdit 读取 mihomo 配置文件并生成添加规则的预览
+func PlanEdit(id int, draft service.RuleDraft, position string) tea.Cmd {
+	return func() tea.Msg {
+		path, err := config.GetMihomoConfigPath()
+		if err != nil {
+			return messages.RuleEditPlannedMsg{RequestID: id, Err: err}
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return messages.RuleEditPlannedMsg{RequestID: id, Err: fmt.Errorf("读取配置文件失败: %w", err)}
+		}
+		edit, err := service.PlanRuleInsert(path, content, draft, position)
+		return messages.RuleEditPlannedMsg{RequestID: id, Edit: edit, Err: err}
+	}
+}
+
+// ApplyEdit 备份并写入配置后重载 mihomo
+func ApplyEdit(id int, client *api.Client, edit *service.RuleEdit) tea.Cmd {
+	return func() tea.Msg {
+		backup, err := service.ApplyRuleEdit(edit, client.ReloadConfig)
+		return messages.RuleEditAppliedMsg{RequestID: id, Edit: edit, Backup: backup, Err: err}
+	}
+}
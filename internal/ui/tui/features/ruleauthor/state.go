@@ -0,0 +1,223 @@
+package ruleauthor
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/infrastructure/api"
+	"github.com/aimony/mihosh/internal/ui/tui/components/common"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// step 添加规则的步骤
+type step int
+
+const (
+	stepRule     step = iota // 选择候选规则
+	stepTarget               // 选择目标策略
+	stepPosition             // 选择插入位置
+	stepPreview              // 预览 diff，等待确认
+	stepDone                 // 显示结果
+)
+
+// positionLabels 插入位置说明
+var positionLabels = map[string]string{
+	service.RuleInsertManaged:     "mihosh 管理块（列表顶部，优先匹配）",
+	service.RuleInsertTop:         "规则列表顶部",
+	service.RuleInsertBeforeMatch: "MATCH 兜底规则之前",
+}
+
+// State 添加规则弹窗状态（连接页与日志页共用，由 tui.Model 持有）
+type State struct {
+	active bool
+	step   step
+	source string // 来源主机或 IP
+
+	drafts   []service.RuleDraft
+	targets  []string
+	cursor   int
+	draft    service.RuleDraft
+	position string
+
+	edit    *service.RuleEdit
+	busy    bool // 正在加载目标、生成预览或写入
+	request int  // 正在等待的请求编号，编号不一致的结果来自已放弃的请求
+	scroll  int  // diff 滚动偏移
+	result  string
+	err     string
+}
+
+// requestSeq 请求编号，跨弹窗递增，重新打开弹窗后旧请求的结果也能被识别
+var requestSeq atomic.Int64
+
+// startRequest 标记进入等待并分配新的请求编号
+func (s *State) startRequest() int {
+	s.busy = true
+	s.request = int(requestSeq.Add(1))
+	return s.request
+}
+
+// accepts 结果是否属于正在等待的请求
+func (s State) accepts(id int) bool {
+	return s.active && s.busy && id == s.request
+}
+
+// Open 打开弹窗，按主机与 IP 给出候选规则，并加载目标策略
+func Open(host, ip string, client *api.Client) (State, tea.Cmd) {
+	s := State{active: true, source: host}
+	if s.source == "" {
+		s.source = ip
+	}
+	s.drafts = service.SuggestRuleDrafts(host, ip)
+	if len(s.drafts) == 0 {
+		s.step = stepDone
+		s.err = "无法从该条目生成规则（缺少主机与 IP）"
+		return s, nil
+	}
+	return s, FetchTargets(s.startRequest(), client)
+}
+
+// Active 弹窗是否打开
+func (s State) Active() bool {
+	return s.active
+}
+
+// ApplyTargets 更新可选的目标策略，id 为请求编号
+func (s State) ApplyTargets(id int, names []string, err error) State {
+	if !s.accepts(id) {
+		return s
+	}
+	s.busy = false
+	if err != nil {
+		s.err = fmt.Sprintf("获取策略组失败: %v", err)
+		return s
+	}
+	s.targets = names
+	return s
+}
+
+// ApplyPlan 显示修改预览，id 为请求编号
+func (s State) ApplyPlan(id int, edit *service.RuleEdit, err error) State {
+	if !s.accepts(id) {
+		return s
+	}
+	s.busy = false
+	if err != nil {
+		s.err = err.Error()
+		return s
+	}
+	s.err = ""
+	s.edit = edit
+	s.step = stepPreview
+	s.scroll = 0
+	return s
+}
+
+// ApplyResult 显示写入与重载结果，id 为请求编号
+func (s State) ApplyResult(id int, backup string, err error) State {
+	if !s.accepts(id) {
+		return s
+	}
+	s.busy = false
+	s.step = stepDone
+	if err != nil {
+		s.err = err.Error()
+		if backup != "" {
+			s.result = "原配置备份: " + backup
+		}
+		return s
+	}
+	s.err = ""
+	s.result = fmt.Sprintf("已添加 %s（第 %d 行）\n原配置备份: %s", s.draft, s.edit.Line, backup)
+	return s
+}
+
+// Update 处理弹窗按键
+func (s State) Update(msg tea.KeyMsg, client *api.Client) (State, tea.Cmd) {
+	if s.busy {
+		if key.Matches(msg, common.Keys.Escape) && s.step != stepPreview {
+			s.active = false
+		}
+		return s, nil
+	}
+
+	switch {
+	case key.Matches(msg, common.Keys.Escape):
+		s = s.back()
+	case key.Matches(msg, common.Keys.Up):
+		if s.step == stepPreview {
+			s.scroll = max(s.scroll-1, 0)
+		} else if s.cursor > 0 {
+			s.cursor--
+		}
+	case key.Matches(msg, common.Keys.Down):
+		if s.step == stepPreview {
+			if s.edit != nil && s.scroll < strings.Count(s.edit.Diff, "\n")-1 {
+				s.scroll++
+			}
+		} else if s.cursor < s.optionCount()-1 {
+			s.cursor++
+		}
+	case key.Matches(msg, common.Keys.Enter), s.step == stepPreview && msg.String() == "y":
+		return s.next(client)
+	}
+	return s, nil
+}
+
+// optionCount 当前步骤的选项数
+func (s State) optionCount() int {
+	switch s.step {
+	case stepRule:
+		return len(s.drafts)
+	case stepTarget:
+		return len(s.targets)
+	case stepPosition:
+		return len(service.RuleInsertPositions)
+	}
+	return 0
+}
+
+// next 确认当前步骤
+func (s State) next(client *api.Client) (State, tea.Cmd) {
+	switch s.step {
+	case stepRule:
+		if s.cursor < len(s.drafts) {
+			s.draft = s.drafts[s.cursor]
+			s.step, s.cursor, s.err = stepTarget, 0, ""
+		}
+	case stepTarget:
+		if s.cursor < len(s.targets) {
+			s.draft.Target = s.targets[s.cursor]
+			s.step, s.cursor = stepPosition, 0
+		}
+	case stepPosition:
+		s.position = service.RuleInsertPositions[s.cursor]
+		s.err = ""
+		return s, PlanEdit(s.startRequest(), s.draft, s.position)
+	case stepPreview:
+		return s, ApplyEdit(s.startRequest(), client, s.edit)
+	case stepDone:
+		s.active = false
+	}
+	return s, nil
+}
+
+// back 返回上一步，第一步与结果页时关闭弹窗
+func (s State) back() State {
+	s.err = ""
+	switch s.step {
+	case stepTarget:
+		s.step, s.cursor = stepRule, 0
+	case stepPosition:
+		s.step, s.cursor = stepTarget, 0
+	case stepPreview:
+		s.step, s.cursor = stepPosition, 0
+		s.edit = nil
+	default:
+		s.active = false
+	}
+	return s
+}
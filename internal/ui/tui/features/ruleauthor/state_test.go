@@ -0,0 +1,148 @@
+package ruleauthor
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var (
+	keyEnter = tea.KeyMsg{Type: tea.KeyEnter}
+	keyEsc   = tea.KeyMsg{Type: tea.KeyEsc}
+	keyDown  = tea.KeyMsg{Type: tea.KeyDown}
+)
+
+func TestOpenWithoutHostOrIP(t *testing.T) {
+	s, cmd := Open("", "", nil)
+	if cmd != nil || s.step != stepDone || s.err == "" {
+		t.Fatalf("expected error state without command, got step=%d err=%q", s.step, s.err)
+	}
+	s, _ = s.Update(keyEnter, nil)
+	if s.Active() {
+		t.Fatal("Enter on result should close the modal")
+	}
+}
+
+func TestStepFlow(t *testing.T) {
+	s, cmd := Open("api.github.com", "140.82.112.6", nil)
+	if !s.Active() || !s.busy || cmd == nil {
+		t.Fatal("Open should start loading targets")
+	}
+	if s.source != "api.github.com" || len(s.drafts) != 4 {
+		t.Fatalf("unexpected drafts %v", s.drafts)
+	}
+
+	// 加载中的按键被忽略
+	s, _ = s.Update(keyEnter, nil)
+	if s.step != stepRule {
+		t.Fatalf("Enter while busy should be ignored, step=%d", s.step)
+	}
+
+	s = s.ApplyTargets(s.request, []string{"Proxy", "DIRECT"}, nil)
+	s, _ = s.Update(keyDown, nil)
+	s, _ = s.Update(keyEnter, nil)
+	if s.step != stepTarget || s.draft.Type != "DOMAIN" || s.draft.Payload != "api.github.com" {
+		t.Fatalf("unexpected draft %+v at step %d", s.draft, s.step)
+	}
+
+	s, _ = s.Update(keyDown, nil)
+	s, _ = s.Update(keyEnter, nil)
+	if s.step != stepPosition || s.draft.Target != "DIRECT" {
+		t.Fatalf("unexpected target %q at step %d", s.draft.Target, s.step)
+	}
+
+	s, cmd = s.Update(keyEnter, nil)
+	if !s.busy || cmd == nil || s.position != service.RuleInsertManaged {
+		t.Fatalf("Enter on position should plan the edit, position=%q", s.position)
+	}
+
+	edit, err := service.PlanRuleInsert("config.yaml", []byte("rules:\n  - MATCH,DIRECT\n"), s.draft, s.position)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s = s.ApplyPlan(s.request, edit, nil)
+	if s.step != stepPreview || s.busy {
+		t.Fatalf("expected preview, step=%d busy=%v", s.step, s.busy)
+	}
+	if view := Render(s, 100, 30); !strings.Contains(view, "+  - DOMAIN,api.github.com,DIRECT") {
+		t.Fatalf("preview should show the added line:\n%s", view)
+	}
+
+	// Esc 在预览中返回位置选择，并丢弃预览
+	back, _ := s.Update(keyEsc, nil)
+	if back.step != stepPosition || back.edit != nil || !back.Active() {
+		t.Fatalf("Esc in preview should go back, step=%d", back.step)
+	}
+
+	s, cmd = s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}}, nil)
+	if !s.busy || cmd == nil {
+		t.Fatal("y in preview should apply the edit")
+	}
+	// 写入中不能用 Esc 关闭
+	s, _ = s.Update(keyEsc, nil)
+	if !s.Active() {
+		t.Fatal("Esc while applying should not close the modal")
+	}
+
+	s = s.ApplyResult(s.request, "config.yaml.bak", nil)
+	if s.step != stepDone || !strings.Contains(s.result, "config.yaml.bak") {
+		t.Fatalf("unexpected result %q", s.result)
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	s, _ := Open("", "1.2.3.4", nil)
+	s = s.ApplyTargets(s.request, nil, errors.New("timeout"))
+	if s.busy || !strings.Contains(s.err, "timeout") {
+		t.Fatalf("unexpected state busy=%v err=%q", s.busy, s.err)
+	}
+
+	// 关闭后到达的消息不会重新打开弹窗
+	s, _ = s.Update(keyEsc, nil)
+	if s.Active() {
+		t.Fatal("Esc on first step should close the modal")
+	}
+	if s = s.ApplyPlan(s.request, &service.RuleEdit{}, nil); s.step == stepPreview {
+		t.Fatal("plan result after close should be ignored")
+	}
+
+	s, _ = Open("example.com", "", nil)
+	s.step = stepPosition
+	s = s.ApplyPlan(s.request, nil, errors.New("规则已存在（第 3 行）"))
+	if s.step != stepPosition || s.err == "" {
+		t.Fatalf("plan error should stay on position step, step=%d", s.step)
+	}
+}
+
+func TestStaleResultsIgnored(t *testing.T) {
+	s, _ := Open("example.com", "", nil)
+	s = s.ApplyTargets(s.request, []string{"Proxy"}, nil)
+	s, _ = s.Update(keyEnter, nil)
+	s, _ = s.Update(keyEnter, nil)
+	s, _ = s.Update(keyEnter, nil)
+	stale := s.request
+	if !s.busy || s.step != stepPosition {
+		t.Fatalf("expected planning, step=%d busy=%v", s.step, s.busy)
+	}
+
+	// 生成预览时按 Esc 关闭，重新打开后迟到的预览不应套用到新的弹窗上
+	s, _ = s.Update(keyEsc, nil)
+	s, _ = Open("other.example", "", nil)
+	s = s.ApplyTargets(stale, []string{"DIRECT"}, nil)
+	if !s.busy || s.targets != nil {
+		t.Fatal("targets from an abandoned request should be ignored")
+	}
+	s = s.ApplyTargets(s.request, []string{"Proxy"}, nil)
+	s, _ = s.Update(keyEnter, nil)
+	s, _ = s.Update(keyEnter, nil)
+	s, _ = s.Update(keyEnter, nil)
+	if s = s.ApplyPlan(stale, &service.RuleEdit{Diff: "old"}, nil); s.step == stepPreview || !s.busy {
+		t.Fatal("plan from an abandoned request should be ignored")
+	}
+	if s = s.ApplyResult(stale, "old.bak", nil); s.step == stepDone {
+		t.Fatal("apply result from an abandoned request should be ignored")
+	}
+}
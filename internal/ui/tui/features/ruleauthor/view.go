@@ -0,0 +1,135 @@
+package ruleauthor
+
+import (
+	"strings"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/ui/tui/components/common"
+	"github.com/aimony/mihosh/pkg/utils"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Render 渲染添加规则弹窗
+func Render(s State, width, height int) string {
+	modalWidth := min(max(width-10, 50), 90)
+	innerWidth := modalWidth - 4
+	// 边框、标题、分隔线、空行与底部提示之外可用于列表的行数
+	listHeight := max(height-10, 3)
+
+	var body []string
+	switch s.step {
+	case stepRule:
+		body = append(body, common.MutedStyle.Render("选择要添加的规则:"))
+		for i, d := range s.drafts {
+			body = append(body, renderOption(d.Type+","+d.Payload, i == s.cursor))
+		}
+	case stepTarget:
+		body = append(body, common.MutedStyle.Render("规则 "+s.draft.Type+","+s.draft.Payload+" 的目标策略:"))
+		start, end := visibleRange(len(s.targets), s.cursor, listHeight-1)
+		for i := start; i < end; i++ {
+			body = append(body, renderOption(s.targets[i], i == s.cursor))
+		}
+	case stepPosition:
+		body = append(body, common.MutedStyle.Render("插入位置（"+s.draft.String()+"）:"))
+		for i, p := range service.RuleInsertPositions {
+			body = append(body, renderOption(positionLabels[p], i == s.cursor))
+		}
+	case stepPreview:
+		if s.edit != nil {
+			body = append(body, common.MutedStyle.Render(s.edit.Path))
+			body = append(body, renderDiff(s.edit.Diff, s.scroll, listHeight-1, innerWidth)...)
+		}
+	case stepDone:
+		if s.err == "" {
+			for _, line := range strings.Split(s.result, "\n") {
+				body = append(body, common.SuccessStyle.Render(line))
+			}
+		} else if s.result != "" {
+			body = append(body, common.MutedStyle.Render(s.result))
+		}
+	}
+
+	switch {
+	case s.busy:
+		body = append(body, "", common.WarningStyle.Render(busyText(s.step)))
+	case s.err != "":
+		body = append(body, "", common.ErrorStyle.Width(innerWidth).Render("✗ "+s.err))
+	}
+	body = append(body, "", common.MutedStyle.Render(footerText(s.step)))
+
+	title := common.TableHeaderStyle.Render("添加规则 · " + s.source)
+	separator := common.DimStyle.Render(strings.Repeat("─", innerWidth))
+	content := lipgloss.JoinVertical(lipgloss.Left, title, separator, strings.Join(body, "\n"))
+
+	modal := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(common.CPrimary).
+		Padding(0, 1).
+		Width(modalWidth).
+		Render(content)
+	return lipgloss.Place(width, height-2, lipgloss.Center, lipgloss.Center, modal)
+}
+
+// renderOption 渲染一个可选项
+func renderOption(label string, selected bool) string {
+	if selected {
+		return common.SymbolSelectActive + common.SelectedStyle.Render(label)
+	}
+	return common.SymbolSelectInactive + label
+}
+
+// visibleRange 让光标保持在可见范围内
+func visibleRange(total, cursor, height int) (int, int) {
+	if total <= height {
+		return 0, total
+	}
+	start := min(max(cursor-height/2, 0), total-height)
+	return start, start + height
+}
+
+// renderDiff 按行着色 diff，并截取滚动窗口
+func renderDiff(diff string, scroll, height, width int) []string {
+	lines := strings.Split(strings.TrimRight(diff, "\n"), "\n")
+	scroll = min(scroll, max(len(lines)-height, 0))
+	end := min(scroll+height, len(lines))
+
+	var out []string
+	for _, line := range lines[scroll:end] {
+		line = utils.TruncateString(line, width)
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"), strings.HasPrefix(line, "@@"):
+			out = append(out, common.DimStyle.Render(line))
+		case strings.HasPrefix(line, "+"):
+			out = append(out, common.SuccessStyle.Render(line))
+		case strings.HasPrefix(line, "-"):
+			out = append(out, common.ErrorStyle.Render(line))
+		default:
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+// busyText 等待中的提示
+func busyText(st step) string {
+	switch st {
+	case stepPosition:
+		return "正在生成预览..."
+	case stepPreview:
+		return "正在写入配置并重载 mihomo..."
+	}
+	return "正在获取策略组..."
+}
+
+// footerText 各步骤的按键提示
+func footerText(st step) string {
+	switch st {
+	case stepPreview:
+		return "[Enter/y] 备份、写入并重载  [↑/↓] 滚动  [Esc] 返回"
+	case stepDone:
+		return "[Enter/Esc] 关闭"
+	case stepRule:
+		return "[Enter] 下一步  [Esc] 取消"
+	}
+	return "[Enter] 下一步  [Esc] 返回"
+}
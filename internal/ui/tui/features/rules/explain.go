@@ -0,0 +1,81 @@
+package rules
+
+import (
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/infrastructure/api"
+	"github.com/aimony/mihosh/internal/ui/tui/components/common"
+	"github.com/aimony/mihosh/internal/ui/tui/messages"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// FetchRuleExplanation 模拟目标的规则匹配并追踪最终节点
+func FetchRuleExplanation(client *api.Client, target service.RuleTarget) tea.Cmd {
+	return func() tea.Msg {
+		exp, err := service.NewProxyService(client, "", 0).ExplainRule(target)
+		return messages.RuleExplainMsg{Explanation: exp, Err: err}
+	}
+}
+
+// TextInputActive 是否处于搜索、类型筛选或模拟匹配输入（此时按键不应触发全局快捷键）
+func (s State) TextInputActive() bool {
+	return s.ruleFilterMode || s.showTypeFilter || s.explainMode
+}
+
+// handleExplainMode 模拟匹配目标输入模式
+func (s State) handleExplainMode(msg tea.KeyMsg, client *api.Client) (State, tea.Cmd) {
+	switch {
+	case key.Matches(msg, common.Keys.Escape):
+		s.explainMode = false
+	case key.Matches(msg, common.Keys.Enter):
+		target, err := service.ParseRuleTarget(s.explainInput)
+		if err != nil {
+			s.explainErr = err.Error()
+			return s, nil
+		}
+		s.explainMode = false
+		s.explainLoading = true
+		s.explainErr = ""
+		return s, FetchRuleExplanation(client, target)
+	case key.Matches(msg, common.Keys.Backspace):
+		if len(s.explainInput) > 0 {
+			s.explainInput = s.explainInput[:len(s.explainInput)-1]
+		}
+	default:
+		input := msg.String()
+		if len(input) == 1 && input[0] >= 32 && input[0] < 127 {
+			s.explainInput += input
+		}
+	}
+	return s, nil
+}
+
+// ApplyExplanation 显示模拟结果，并清除过滤、选中命中的规则
+func (s State) ApplyExplanation(exp *service.RuleExplanation, err error) State {
+	s.explainLoading = false
+	if err != nil {
+		s.explanation = nil
+		s.explainErr = err.Error()
+		return s
+	}
+	s.explanation = exp
+	s.explainErr = ""
+	if exp == nil || exp.Matched == nil {
+		return s
+	}
+	s.ruleFilter = ""
+	s.selectedTypes = nil
+	s.updateFilteredRules()
+	s.selectRule(exp.Matched.Index - 1)
+	return s
+}
+
+// clearExplanation 关闭模拟结果
+func (s *State) clearExplanation() bool {
+	if s.explanation == nil && s.explainErr == "" {
+		return false
+	}
+	s.explanation = nil
+	s.explainErr = ""
+	return true
+}
@@ -0,0 +1,61 @@
+package rules
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/domain/model"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func typeKeys(s State, text string) State {
+	for _, r := range text {
+		s, _ = s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}}, nil)
+	}
+	return s
+}
+
+func TestExplainPrompt(t *testing.T) {
+	s := State{}.ApplyRules([]model.Rule{
+		{Type: "DomainSuffix", Payload: "google.com", Proxy: "Proxy"},
+		{Type: "DomainSuffix", Payload: "github.com", Proxy: "Dev"},
+		{Type: "Match", Proxy: "DIRECT"},
+	})
+	s = typeKeys(s, "/goo")
+	s, _ = s.Update(tea.KeyMsg{Type: tea.KeyEnter}, nil)
+
+	s = typeKeys(s, "x")
+	if !s.explainMode || !s.TextInputActive() {
+		t.Fatal("x should open the explain prompt")
+	}
+	s = typeKeys(s, "api.github.com:443")
+	s, cmd := s.Update(tea.KeyMsg{Type: tea.KeyEnter}, nil)
+	if cmd == nil || !s.explainLoading || s.explainMode {
+		t.Fatal("enter should start the explanation")
+	}
+
+	s = s.ApplyExplanation(&service.RuleExplanation{
+		Target:  service.RuleTarget{Host: "api.github.com", Port: 443},
+		Matched: &service.RuleStep{Index: 2, Rule: model.Rule{Type: "DomainSuffix", Payload: "github.com", Proxy: "Dev"}},
+		Chain:   []string{"Dev", "JP"},
+	}, nil)
+	if s.ruleFilter != "" || s.selectedRule != 1 {
+		t.Fatalf("matched rule should be selected with filters cleared, got filter %q selected %d", s.ruleFilter, s.selectedRule)
+	}
+	view := RenderRulesPage(s.ToPageState(120, 30))
+	if !strings.Contains(view, "#2 DomainSuffix github.com → Dev") || !strings.Contains(view, "Dev → JP") {
+		t.Fatalf("explanation not rendered:\n%s", view)
+	}
+
+	s, _ = s.Update(tea.KeyMsg{Type: tea.KeyEsc}, nil)
+	if s.explanation != nil {
+		t.Fatal("esc should close the explanation")
+	}
+
+	s = s.ApplyExplanation(nil, errors.New("boom"))
+	if !strings.Contains(RenderRulesPage(s.ToPageState(120, 30)), "模拟失败: boom") {
+		t.Fatal("error not rendered")
+	}
+}
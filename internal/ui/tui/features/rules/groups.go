@@ -0,0 +1,262 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/domain/model"
+)
+
+// ruleGroupBy 规则分组维度
+type ruleGroupBy string
+
+const (
+	groupByTarget ruleGroupBy = "target" // 按目标策略（Rule.Proxy）
+	groupByType   ruleGroupBy = "type"   // 按规则类型（RULE-SET 等）
+)
+
+// ruleGroupOrder 分组维度切换顺序（之后回到平铺）
+var ruleGroupOrder = []ruleGroupBy{groupByTarget, groupByType}
+
+// Label 分组维度的中文名称
+func (g ruleGroupBy) Label() string {
+	switch g {
+	case groupByTarget:
+		return "目标策略"
+	case groupByType:
+		return "规则类型"
+	}
+	return "平铺"
+}
+
+// key 规则在该维度下的分组名
+func (g ruleGroupBy) key(rule model.Rule) string {
+	v := rule.Proxy
+	if g == groupByType {
+		v = rule.Type
+	}
+	if v == "" {
+		return "(空)"
+	}
+	return v
+}
+
+// ruleGroup 一个分组：包含的规则索引与合计命中
+type ruleGroup struct {
+	Key     string
+	Indices []int // 按过滤结果顺序
+	Hits    int
+	Bytes   int64
+	Dead    int  // 无命中的规则数
+	HasHits bool // 是否已有命中统计
+}
+
+// groupRules 按维度将过滤结果分组；按规则数（命中/流量排序时按合计命中/流量）降序排列
+func groupRules(rules []model.Rule, hitRows []service.RuleHitRow, indices []int, groupBy ruleGroupBy, hitSort string) []ruleGroup {
+	pos := make(map[string]int)
+	var groups []ruleGroup
+	for _, idx := range indices {
+		k := groupBy.key(rules[idx])
+		i, ok := pos[k]
+		if !ok {
+			i = len(groups)
+			pos[k] = i
+			groups = append(groups, ruleGroup{Key: k})
+		}
+		g := &groups[i]
+		g.Indices = append(g.Indices, idx)
+		if idx < len(hitRows) {
+			g.HasHits = true
+			g.Hits += hitRows[idx].Hits
+			g.Bytes += hitRows[idx].Bytes
+			if hitRows[idx].Dead {
+				g.Dead++
+			}
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		switch {
+		case hitSort == service.RuleSortHits && len(hitRows) > 0:
+			return a.Hits > b.Hits
+		case hitSort == service.RuleSortBytes && len(hitRows) > 0:
+			return a.Bytes > b.Bytes
+		}
+		return len(a.Indices) > len(b.Indices)
+	})
+	return groups
+}
+
+// buildRuleRows 生成列表的显示行：平铺时每条规则一行，分组时为分组行及展开后的规则行
+func buildRuleRows(rules []model.Rule, hitRows []service.RuleHitRow, indices []int, groupBy ruleGroupBy, hitSort string, expanded map[string]bool) []filteredRule {
+	row := func(idx int) filteredRule {
+		fr := filteredRule{Index: idx, Rule: rules[idx]}
+		if idx < len(hitRows) {
+			fr.Hit = &hitRows[idx]
+		}
+		return fr
+	}
+
+	if groupBy == "" {
+		rows := make([]filteredRule, 0, len(indices))
+		for _, idx := range indices {
+			if idx >= 0 && idx < len(rules) {
+				rows = append(rows, row(idx))
+			}
+		}
+		return rows
+	}
+
+	groups := groupRules(rules, hitRows, indices, groupBy, hitSort)
+	rows := make([]filteredRule, 0, len(groups))
+	for i := range groups {
+		g := &groups[i]
+		open := expanded[g.Key]
+		rows = append(rows, filteredRule{Index: -1, Group: g, Expanded: open})
+		if !open {
+			continue
+		}
+		for _, idx := range g.Indices {
+			rows = append(rows, row(idx))
+		}
+	}
+	return rows
+}
+
+// rows 当前的显示行
+func (s State) rows() []filteredRule {
+	return buildRuleRows(s.rules, s.hitRows, s.filteredRuleIndices, s.groupBy, s.hitSort, s.expandedGroups)
+}
+
+// rowCount 显示行数
+func (s State) rowCount() int {
+	if s.groupBy == "" {
+		return len(s.filteredRuleIndices)
+	}
+	return len(s.rows())
+}
+
+// selectedRow 当前选中的行
+func (s State) selectedRow() (filteredRule, bool) {
+	if s.groupBy == "" {
+		if s.selectedRule >= 0 && s.selectedRule < len(s.filteredRuleIndices) {
+			idx := s.filteredRuleIndices[s.selectedRule]
+			return filteredRule{Index: idx, Rule: s.rules[idx]}, true
+		}
+		return filteredRule{}, false
+	}
+	rows := s.rows()
+	if s.selectedRule >= 0 && s.selectedRule < len(rows) {
+		return rows[s.selectedRule], true
+	}
+	return filteredRule{}, false
+}
+
+// selectRule 选中指定规则，分组时先展开其所在分组；规则不在过滤结果中时返回 false
+func (s *State) selectRule(idx int) bool {
+	if s.groupBy != "" {
+		for _, i := range s.filteredRuleIndices {
+			if i == idx {
+				if k := s.groupBy.key(s.rules[idx]); !s.expandedGroups[k] {
+					s.toggleGroup(k)
+				}
+				break
+			}
+		}
+	}
+	for i, row := range s.rows() {
+		if row.Group == nil && row.Index == idx {
+			s.selectedRule = i
+			return true
+		}
+	}
+	return false
+}
+
+// selectGroup 选中指定分组行
+func (s *State) selectGroup(key string) bool {
+	for i, row := range s.rows() {
+		if row.Group != nil && row.Group.Key == key {
+			s.selectedRule = i
+			return true
+		}
+	}
+	return false
+}
+
+// cycleGroupBy 在平铺与各分组维度之间切换
+func (s *State) cycleGroupBy() {
+	next := ruleGroupBy("")
+	if s.groupBy == "" {
+		next = ruleGroupOrder[0]
+	} else {
+		for i, g := range ruleGroupOrder {
+			if g == s.groupBy && i+1 < len(ruleGroupOrder) {
+				next = ruleGroupOrder[i+1]
+			}
+		}
+	}
+	s.groupBy = next
+	s.expandedGroups = nil
+	s.selectedRule = 0
+	s.ruleScrollTop = 0
+}
+
+// toggleGroup 展开或收起分组
+func (s *State) toggleGroup(key string) {
+	expanded := make(map[string]bool, len(s.expandedGroups)+1)
+	for k, v := range s.expandedGroups {
+		expanded[k] = v
+	}
+	if expanded[key] {
+		delete(expanded, key)
+	} else {
+		expanded[key] = true
+	}
+	s.expandedGroups = expanded
+}
+
+// setAllGroupsExpanded 展开或收起全部分组，光标停留在原来的分组上
+func (s *State) setAllGroupsExpanded(open bool) {
+	row, ok := s.selectedRow()
+	key := ""
+	if ok && row.Group != nil {
+		key = row.Group.Key
+	} else if ok {
+		key = s.groupBy.key(row.Rule)
+	}
+
+	s.expandedGroups = nil
+	if open {
+		s.expandedGroups = make(map[string]bool)
+		for _, g := range groupRules(s.rules, s.hitRows, s.filteredRuleIndices, s.groupBy, s.hitSort) {
+			s.expandedGroups[g.Key] = true
+		}
+	}
+	switch {
+	case !ok:
+		s.selectedRule = 0
+	case !open || row.Group != nil:
+		s.selectGroup(key)
+	default:
+		s.selectRule(row.Index)
+	}
+}
+
+// groupSummary 各分组规则数的概况，例如 "Proxy 120 · DIRECT 80"
+func groupSummary(groups []ruleGroup, limit int) string {
+	var out string
+	for i, g := range groups {
+		if i == limit {
+			out += fmt.Sprintf(" · 其他 %d 组", len(groups)-limit)
+			break
+		}
+		if i > 0 {
+			out += " · "
+		}
+		out += fmt.Sprintf("%s %d", g.Key, len(g.Indices))
+	}
+	return out
+}
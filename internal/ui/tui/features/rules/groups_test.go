@@ -0,0 +1,88 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/ui/tui/messages"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var groupedRules = []model.Rule{
+	{Type: "DomainSuffix", Payload: "google.com", Proxy: "Proxy"},
+	{Type: "RuleSet", Payload: "ads", Proxy: "REJECT"},
+	{Type: "DomainSuffix", Payload: "github.com", Proxy: "Proxy"},
+	{Type: "RuleSet", Payload: "cn", Proxy: "DIRECT"},
+	{Type: "Match", Proxy: "Proxy"},
+}
+
+func TestGroupByTarget(t *testing.T) {
+	s := State{}.ApplyRules(groupedRules)
+	s = typeKeys(s, "g")
+	if s.groupBy != groupByTarget || s.rowCount() != 3 {
+		t.Fatalf("expected 3 target groups, got %q %d", s.groupBy, s.rowCount())
+	}
+
+	// 规则最多的分组排在最前，默认收起
+	rows := s.rows()
+	if rows[0].Group.Key != "Proxy" || len(rows[0].Group.Indices) != 3 || rows[0].Expanded {
+		t.Fatalf("unexpected first group %+v", rows[0].Group)
+	}
+	view := RenderRulesPage(s.ToPageState(120, 30))
+	if !strings.Contains(view, "按目标策略分组 3 组: Proxy 3 · REJECT 1 · DIRECT 1") {
+		t.Fatalf("summary not rendered:\n%s", view)
+	}
+
+	// Enter 展开分组，C 跳转到组内规则的连接
+	s, _ = s.Update(tea.KeyMsg{Type: tea.KeyEnter}, nil)
+	if s.rowCount() != 6 {
+		t.Fatalf("expanded group should add 3 rows, got %d", s.rowCount())
+	}
+	if _, cmd := s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'C'}}, nil); cmd != nil {
+		t.Fatal("C on a group row should do nothing")
+	}
+	s, _ = s.Update(tea.KeyMsg{Type: tea.KeyDown}, nil)
+	s, _ = s.Update(tea.KeyMsg{Type: tea.KeyDown}, nil)
+	_, cmd := s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'C'}}, nil)
+	if nav, ok := cmd().(messages.NavigateMsg); !ok || nav.Target.Label != "DomainSuffix,github.com" {
+		t.Fatalf("unexpected navigation %+v", nav)
+	}
+
+	// 过滤后分组与光标跟随
+	s = s.ApplyHitStats(map[service.RuleKey]service.RuleHitStats{}, s.hitsSince)
+	if row, _ := s.selectedRow(); row.Index != 2 {
+		t.Fatalf("selection should stay on github.com, got %d", row.Index)
+	}
+
+	s = typeKeys(s, "g")
+	if s.groupBy != groupByType || s.rowCount() != 3 || s.expandedGroups != nil {
+		t.Fatalf("expected 3 collapsed type groups, got %q %d", s.groupBy, s.rowCount())
+	}
+	s = typeKeys(s, "g")
+	if s.groupBy != "" || s.rowCount() != len(groupedRules) {
+		t.Fatal("third g should return to the flat list")
+	}
+}
+
+func TestGroupExpandAllAndFocus(t *testing.T) {
+	s := State{}.ApplyRules(groupedRules)
+	s = typeKeys(s, "gg")
+
+	s = typeKeys(s, "z")
+	if s.rowCount() != 3+len(groupedRules) {
+		t.Fatalf("z should expand every group, got %d rows", s.rowCount())
+	}
+	s = typeKeys(s, "z")
+	if s.rowCount() != 3 {
+		t.Fatalf("second z should collapse every group, got %d rows", s.rowCount())
+	}
+
+	// 跳转聚焦时展开规则所在的分组
+	s = s.FocusRule(service.NewRuleKey("RULE-SET", "cn"))
+	row, ok := s.selectedRow()
+	if !ok || row.Group != nil || row.Rule.Payload != "cn" || !s.expandedGroups["RuleSet"] {
+		t.Fatalf("focused rule should be selected inside its group, got %+v", row)
+	}
+}
@@ -0,0 +1,105 @@
+package rules
+
+import (
+	"sort"
+	"time"
+
+	"github.com/aimony/mihosh/internal/app/service"
+)
+
+// 规则页排序方式循环顺序
+var hitSortOrder = []string{service.RuleSortIndex, service.RuleSortHits, service.RuleSortBytes}
+
+// WithHitWindow 设置判定无命中规则的时间窗口
+func (s State) WithHitWindow(window time.Duration) State {
+	s.hitWindow = window
+	return s
+}
+
+// HitWindow 判定无命中规则的时间窗口
+func (s State) HitWindow() time.Duration {
+	if s.hitWindow <= 0 {
+		return service.DefaultRuleStatsWindow
+	}
+	return s.hitWindow
+}
+
+// ApplyHitStats 更新命中统计（trackingSince 为开始统计的时间），保持当前选中的规则不变
+func (s State) ApplyHitStats(stats map[service.RuleKey]service.RuleHitStats, trackingSince time.Time) State {
+	s.hitStats = stats
+	s.hitsSince = trackingSince
+	s.rebuildHitRows()
+	s.refilterKeepSelection()
+	return s
+}
+
+// rebuildHitRows 将命中统计对应到规则列表，统计尚未覆盖整个时间窗口时不标记无命中规则
+func (s *State) rebuildHitRows() {
+	if s.hitsSince.IsZero() {
+		s.hitRows = nil
+		return
+	}
+	s.hitRows = service.BuildRuleHitRows(s.rules, s.hitStats)
+	if time.Since(s.hitsSince) < s.HitWindow() {
+		for i := range s.hitRows {
+			s.hitRows[i].Dead = false
+		}
+	}
+}
+
+// refilterKeepSelection 重建过滤结果，并让光标停留在原来选中的规则或分组上
+func (s *State) refilterKeepSelection() {
+	row, ok := s.selectedRow()
+	s.updateFilteredRules()
+	switch {
+	case ok && row.Group != nil:
+		if s.selectGroup(row.Group.Key) {
+			return
+		}
+	case ok:
+		if s.selectRule(row.Index) {
+			return
+		}
+	}
+	if count := s.rowCount(); s.selectedRule >= count {
+		s.selectedRule = max(count-1, 0)
+	}
+}
+
+// cycleHitSort 切换排序方式：规则顺序 → 命中次数 → 流量
+func (s *State) cycleHitSort() {
+	current := s.hitSort
+	if current == "" {
+		current = service.RuleSortIndex
+	}
+	next := 0
+	for i, by := range hitSortOrder {
+		if by == current {
+			next = (i + 1) % len(hitSortOrder)
+		}
+	}
+	s.hitSort = hitSortOrder[next]
+	s.refilterKeepSelection()
+}
+
+// isDeadRule 统计已覆盖整个时间窗口且该规则在窗口内无命中
+func (s State) isDeadRule(idx int) bool {
+	return idx < len(s.hitRows) && s.hitRows[idx].Dead
+}
+
+// sortFilteredByHits 按命中次数或流量降序排列过滤结果
+func (s *State) sortFilteredByHits() {
+	if len(s.hitRows) != len(s.rules) {
+		return
+	}
+	switch s.hitSort {
+	case service.RuleSortHits:
+		sort.SliceStable(s.filteredRuleIndices, func(i, j int) bool {
+			return s.hitRows[s.filteredRuleIndices[i]].Hits > s.hitRows[s.filteredRuleIndices[j]].Hits
+		})
+	case service.RuleSortBytes:
+		sort.SliceStable(s.filteredRuleIndices, func(i, j int) bool {
+			return s.hitRows[s.filteredRuleIndices[i]].Bytes > s.hitRows[s.filteredRuleIndices[j]].Bytes
+		})
+	}
+}
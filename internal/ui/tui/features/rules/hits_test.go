@@ -0,0 +1,82 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/domain/model"
+)
+
+func TestHitStatsSortAndDeadFilter(t *testing.T) {
+	s := State{}.WithHitWindow(time.Hour).ApplyRules([]model.Rule{
+		{Type: "DomainSuffix", Payload: "google.com", Proxy: "Proxy"},
+		{Type: "DomainKeyword", Payload: "ads", Proxy: "REJECT"},
+		{Type: "Match", Proxy: "Final"},
+	})
+	stats := map[service.RuleKey]service.RuleHitStats{
+		service.NewRuleKey("DomainSuffix", "google.com"): {Hits: 2, Bytes: 100},
+		service.NewRuleKey("Match", ""):                  {Hits: 1, Bytes: 9000},
+	}
+	s = s.ApplyHitStats(stats, time.Now().Add(-2*time.Hour))
+
+	// 选中 Match 后切换排序，光标跟随规则
+	s.selectedRule = 2
+	s = typeKeys(s, "s")
+	if s.hitSort != service.RuleSortHits || s.filteredRuleIndices[0] != 0 {
+		t.Fatalf("expected hits sort, got %q %v", s.hitSort, s.filteredRuleIndices)
+	}
+	if s.filteredRuleIndices[s.selectedRule] != 2 {
+		t.Fatalf("selection should stay on Match, got %d", s.filteredRuleIndices[s.selectedRule])
+	}
+	s = typeKeys(s, "s")
+	if s.hitSort != service.RuleSortBytes || s.filteredRuleIndices[0] != 2 {
+		t.Fatalf("expected bytes sort, got %q %v", s.hitSort, s.filteredRuleIndices)
+	}
+
+	view := RenderRulesPage(s.ToPageState(140, 30))
+	if !strings.Contains(view, "最近 1h 无命中 1 条") || !strings.Contains(view, "8.8 KB") {
+		t.Fatalf("hit summary not rendered:\n%s", view)
+	}
+
+	s = typeKeys(s, "d")
+	if len(s.filteredRuleIndices) != 1 || s.filteredRuleIndices[0] != 1 {
+		t.Fatalf("dead filter should keep only the ads rule, got %v", s.filteredRuleIndices)
+	}
+}
+
+func TestHitStatsNoDeadRulesBeforeWindowCovered(t *testing.T) {
+	s := State{}.WithHitWindow(24 * time.Hour).ApplyRules([]model.Rule{
+		{Type: "DomainSuffix", Payload: "google.com", Proxy: "Proxy"},
+		{Type: "Match", Proxy: "DIRECT"},
+	})
+	stats := map[service.RuleKey]service.RuleHitStats{
+		service.NewRuleKey("DomainSuffix", "google.com"): {Hits: 1, Bytes: 10},
+	}
+
+	// 刚开始统计时没有命中不代表规则无用
+	s = s.ApplyHitStats(stats, time.Now().Add(-time.Hour))
+	if s.isDeadRule(1) {
+		t.Fatal("rules should not be flagged before tracking covers the window")
+	}
+	if view := RenderRulesPage(s.ToPageState(140, 20)); !strings.Contains(view, "满 1d 后标记无命中规则") {
+		t.Fatalf("coverage hint not rendered:\n%s", view)
+	}
+
+	s = s.ApplyHitStats(stats, time.Now().Add(-25*time.Hour))
+	if !s.isDeadRule(1) || s.isDeadRule(0) {
+		t.Fatal("Match should be flagged once the window is covered")
+	}
+}
+
+func TestHitStatsPendingWithoutData(t *testing.T) {
+	s := State{}.ApplyRules([]model.Rule{{Type: "Match", Proxy: "DIRECT"}})
+	s = s.ApplyHitStats(nil, time.Time{})
+	if s.isDeadRule(0) {
+		t.Fatal("rules should not be flagged before any connection is observed")
+	}
+	if !strings.Contains(RenderRulesPage(s.ToPageState(120, 20)), "等待连接数据") {
+		t.Fatal("pending hint not rendered")
+	}
+}
@@ -0,0 +1,105 @@
+package rules
+
+import (
+	"slices"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/ui/tui/messages"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// FocusRule 关闭输入与过滤并选中指定规则，规则尚未加载时在加载后选中
+func (s State) FocusRule(key service.RuleKey) State {
+	s.ruleFilterMode = false
+	s.showTypeFilter = false
+	s.explainMode = false
+	s.ruleFilter = ""
+	s.selectedTypes = nil
+	s.deadOnly = false
+	s.clearExplanation()
+	s.focusRule = &key
+	s.updateFilteredRules()
+	s.applyRuleFocus()
+	return s
+}
+
+// NavSnapshot 跳转前规则列表的过滤、选中、滚动位置与分组展开状态，返回时恢复
+type NavSnapshot struct {
+	filter        string
+	selectedTypes []string
+	deadOnly      bool
+	selected      int
+	scrollTop     int
+	groupBy       ruleGroupBy
+	expanded      map[string]bool
+}
+
+// NavSnapshot 记录跳转会清除的列表状态
+func (s State) NavSnapshot() NavSnapshot {
+	return NavSnapshot{
+		filter:        s.ruleFilter,
+		selectedTypes: slices.Clone(s.selectedTypes),
+		deadOnly:      s.deadOnly,
+		selected:      s.selectedRule,
+		scrollTop:     s.ruleScrollTop,
+		groupBy:       s.groupBy,
+		expanded:      s.expandedGroups, // 展开状态按写时复制更新，可直接引用
+	}
+}
+
+// RestoreNav 放弃未完成的聚焦并恢复跳转前的列表状态
+func (s State) RestoreNav(snap NavSnapshot) State {
+	s.focusRule = nil
+	s.ruleFilter = snap.filter
+	s.selectedTypes = snap.selectedTypes
+	s.deadOnly = snap.deadOnly
+	s.groupBy = snap.groupBy
+	s.expandedGroups = snap.expanded
+	s.updateFilteredRules()
+	s.selectedRule = max(0, min(snap.selected, s.rowCount()-1))
+	s.ruleScrollTop = max(0, min(snap.scrollTop, s.selectedRule))
+	return s
+}
+
+// CanGoBack 没有弹窗、过滤或模拟结果需要 Esc 关闭时返回 true
+func (s State) CanGoBack() bool {
+	return !s.TextInputActive() && s.explanation == nil && s.explainErr == "" &&
+		s.ruleFilter == "" && len(s.selectedTypes) == 0 && !s.deadOnly
+}
+
+// applyRuleFocus 在过滤结果中选中待聚焦的规则
+func (s *State) applyRuleFocus() {
+	if s.focusRule == nil {
+		return
+	}
+	for _, idx := range s.filteredRuleIndices {
+		rule := s.rules[idx]
+		if service.NewRuleKey(rule.Type, rule.Payload) == *s.focusRule && s.selectRule(idx) {
+			s.ruleScrollTop = s.selectedRule
+			s.focusRule = nil
+			return
+		}
+	}
+}
+
+// showRuleConnections 跳转到连接页查看选中规则匹配的连接
+func (s State) showRuleConnections() tea.Cmd {
+	row, ok := s.selectedRow()
+	if !ok || row.Group != nil {
+		return nil
+	}
+	rule := row.Rule
+	return messages.Navigate(messages.NavTarget{
+		Kind:  messages.NavToRuleConnections,
+		Rule:  service.NewRuleKey(rule.Type, rule.Payload),
+		Label: ruleLabel(rule.Type, rule.Payload),
+	})
+}
+
+// ruleLabel 规则的显示文本（MATCH 等无内容的规则只显示类型）
+func ruleLabel(ruleType, payload string) string {
+	if payload == "" {
+		return ruleType
+	}
+	return ruleType + "," + payload
+}
@@ -0,0 +1,78 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/ui/tui/messages"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFocusRule(t *testing.T) {
+	rules := []model.Rule{
+		{Type: "DomainSuffix", Payload: "google.com", Proxy: "Proxy"},
+		{Type: "DomainKeyword", Payload: "ads", Proxy: "REJECT"},
+		{Type: "Match", Proxy: "DIRECT"},
+	}
+
+	// 规则尚未加载时，加载后选中
+	s := State{}.FocusRule(service.NewRuleKey("DOMAIN-KEYWORD", "ads"))
+	s = s.ApplyRules(rules)
+	if s.filteredRuleIndices[s.selectedRule] != 1 || s.focusRule != nil {
+		t.Fatalf("expected ads rule selected, got %d", s.selectedRule)
+	}
+
+	// 聚焦时清除过滤
+	s = typeKeys(s, "/goo")
+	s = s.FocusRule(service.NewRuleKey("Match", ""))
+	if s.ruleFilter != "" || s.filteredRuleIndices[s.selectedRule] != 2 {
+		t.Fatalf("expected Match selected without filter, got %q %d", s.ruleFilter, s.selectedRule)
+	}
+	if !s.CanGoBack() {
+		t.Fatal("focused rule list should allow going back")
+	}
+
+	_, cmd := s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'C'}}, nil)
+	nav, ok := cmd().(messages.NavigateMsg)
+	if !ok || nav.Target.Kind != messages.NavToRuleConnections || nav.Target.Label != "Match" {
+		t.Fatalf("unexpected navigation %+v", nav)
+	}
+}
+
+func TestRestoreNavKeepsListState(t *testing.T) {
+	rules := []model.Rule{
+		{Type: "DomainSuffix", Payload: "google.com", Proxy: "Proxy"},
+		{Type: "DomainSuffix", Payload: "youtube.com", Proxy: "Proxy"},
+		{Type: "DomainKeyword", Payload: "ads", Proxy: "REJECT"},
+		{Type: "Match", Proxy: "DIRECT"},
+	}
+	s := State{}.ApplyRules(rules)
+	s = typeKeys(s, "/.com")
+	s.ruleFilterMode = false
+	s.selectedRule, s.ruleScrollTop = 1, 1
+
+	// 跳转会清除过滤，返回时恢复过滤与选中位置
+	before := s.NavSnapshot()
+	s = s.FocusRule(service.NewRuleKey("Match", ""))
+	if s.ruleFilter != "" || s.filteredRuleIndices[s.selectedRule] != 3 {
+		t.Fatalf("expected Match selected without filter, got %q %d", s.ruleFilter, s.selectedRule)
+	}
+	s = s.RestoreNav(before)
+	if s.ruleFilter != ".com" || len(s.filteredRuleIndices) != 2 || s.selectedRule != 1 || s.ruleScrollTop != 1 {
+		t.Fatalf("list state not restored: %q %v %d %d", s.ruleFilter, s.filteredRuleIndices, s.selectedRule, s.ruleScrollTop)
+	}
+
+	// 聚焦展开的分组在返回后恢复原来的展开状态
+	s = s.RestoreNav(NavSnapshot{})
+	s.groupBy = groupByTarget
+	before = s.NavSnapshot()
+	s = s.FocusRule(service.NewRuleKey("Match", ""))
+	if len(s.expandedGroups) == 0 {
+		t.Fatal("focusing a grouped rule should expand its group")
+	}
+	s = s.RestoreNav(before)
+	if len(s.expandedGroups) != 0 || s.groupBy != groupByTarget || s.selectedRule != 0 {
+		t.Fatalf("group state not restored: %v %q %d", s.expandedGroups, s.groupBy, s.selectedRule)
+	}
+}
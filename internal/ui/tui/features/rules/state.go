@@ -3,7 +3,9 @@ package rules
 import (
 	"github.com/aimony/mihosh/internal/ui/tui/components/common"
 	"strings"
+	"time"
 
+	"github.com/aimony/mihosh/internal/app/service"
 	"github.com/aimony/mihosh/internal/domain/model"
 	"github.com/aimony/mihosh/internal/infrastructure/api"
 	"github.com/charmbracelet/bubbles/key"
@@ -26,6 +28,28 @@ type State struct {
 	availableTypes   []string // 可用规则类型列表（从规则中提取）
 	typeFilterCursor int      // 光标位置（在availableTypes中的索引）
 
+	// 命中统计
+	hitStats  map[service.RuleKey]service.RuleHitStats
+	hitsSince time.Time            // 开始统计的时间，零值表示尚无统计
+	hitRows   []service.RuleHitRow // 与 rules 一一对应
+	hitWindow time.Duration        // 判定无命中的时间窗口
+	hitSort   string               // 排序方式（index/hits/bytes）
+	deadOnly  bool                 // 只显示无命中的规则
+
+	// 规则匹配模拟
+	explainMode    bool
+	explainInput   string
+	explainLoading bool
+	explanation    *service.RuleExplanation
+	explainErr     string
+
+	// 分组显示（groupBy 为空时平铺，否则 selectedRule 为显示行的索引）
+	groupBy        ruleGroupBy
+	expandedGroups map[string]bool
+
+	// 跨页跳转时待选中的规则（规则加载后选中）
+	focusRule *service.RuleKey
+
 	ColorAdjustLight float64 // 0.2-0.4 建议明度增加比例
 	ColorAdjustDark  float64 // 0.15-0.25 建议明度降低比例
 }
@@ -49,6 +73,22 @@ func (s State) ToPageState(width, height int) PageState {
 		SelectedTypes:    s.selectedTypes,
 		AvailableTypes:   s.availableTypes,
 		TypeFilterCursor: s.typeFilterCursor,
+		// 命中统计
+		HitRows:   s.hitRows,
+		HitSort:   s.hitSort,
+		HitWindow: s.HitWindow(),
+		HitsSince: s.hitsSince,
+		DeadOnly:  s.deadOnly,
+		// 规则匹配模拟
+		ExplainMode:    s.explainMode,
+		ExplainInput:   s.explainInput,
+		ExplainLoading: s.explainLoading,
+		Explanation:    s.explanation,
+		ExplainErr:     s.explainErr,
+		// 分组
+		GroupBy:        string(s.groupBy),
+		GroupLabel:     s.groupBy.Label(),
+		ExpandedGroups: s.expandedGroups,
 	}
 }
 
@@ -63,6 +103,10 @@ func (s State) Update(msg tea.KeyMsg, client *api.Client) (State, tea.Cmd) {
 		return s.handleRuleFilterMode(msg)
 	}
 
+	if s.explainMode {
+		return s.handleExplainMode(msg, client)
+	}
+
 	switch {
 	case key.Matches(msg, common.Keys.Up):
 		if s.selectedRule > 0 {
@@ -73,13 +117,43 @@ func (s State) Update(msg tea.KeyMsg, client *api.Client) (State, tea.Cmd) {
 		}
 
 	case key.Matches(msg, common.Keys.Down):
-		if s.selectedRule < len(s.filteredRuleIndices)-1 {
+		if s.selectedRule < s.rowCount()-1 {
 			s.selectedRule++
 		}
 
+	case key.Matches(msg, common.Keys.Enter):
+		if row, ok := s.selectedRow(); ok && row.Group != nil {
+			s.toggleGroup(row.Group.Key)
+		}
+
+	case msg.String() == "g":
+		s.cycleGroupBy()
+
+	case msg.String() == "z":
+		if s.groupBy != "" {
+			groups := groupRules(s.rules, s.hitRows, s.filteredRuleIndices, s.groupBy, s.hitSort)
+			s.setAllGroupsExpanded(len(s.expandedGroups) < len(groups))
+		}
+
 	case msg.String() == "/":
 		s.ruleFilterMode = true
 
+	case msg.String() == "s":
+		s.cycleHitSort()
+
+	case msg.String() == "d":
+		s.deadOnly = !s.deadOnly
+		s.selectedRule = 0
+		s.ruleScrollTop = 0
+		s.updateFilteredRules()
+
+	case msg.String() == "C":
+		return s, s.showRuleConnections()
+
+	case msg.String() == "x":
+		s.explainMode = true
+		s.explainErr = ""
+
 	case msg.String() == "t":
 		s.showTypeFilter = true
 		s.typeFilterSearch = ""
@@ -91,9 +165,13 @@ func (s State) Update(msg tea.KeyMsg, client *api.Client) (State, tea.Cmd) {
 		return s, FetchRules(client)
 
 	case key.Matches(msg, common.Keys.Escape):
-		if s.ruleFilter != "" || len(s.selectedTypes) > 0 {
+		if s.clearExplanation() {
+			break
+		}
+		if s.ruleFilter != "" || len(s.selectedTypes) > 0 || s.deadOnly {
 			s.ruleFilter = ""
 			s.selectedTypes = nil
+			s.deadOnly = false
 			s.selectedRule = 0
 			s.ruleScrollTop = 0
 			s.updateFilteredRules()
@@ -105,7 +183,7 @@ func (s State) Update(msg tea.KeyMsg, client *api.Client) (State, tea.Cmd) {
 
 // HandleMouseScroll 鼠标滚轮处理
 func (s State) HandleMouseScroll(up bool) State {
-	count := len(s.filteredRuleIndices)
+	count := s.rowCount()
 	if up {
 		if s.selectedRule > 0 {
 			s.selectedRule--
@@ -128,7 +206,9 @@ func (s State) ApplyRules(rules []model.Rule) State {
 	if cap(s.filteredRuleIndices) < len(rules) {
 		s.filteredRuleIndices = make([]int, 0, len(rules))
 	}
+	s.rebuildHitRows()
 	s.updateFilteredRules()
+	s.applyRuleFocus()
 	return s
 }
 
@@ -169,10 +249,11 @@ func (s *State) updateFilteredRules() {
 	hasTypeFilter := len(s.selectedTypes) > 0
 
 	// 无过滤条件时显示全部
-	if !hasTextFilter && !hasTypeFilter {
+	if !hasTextFilter && !hasTypeFilter && !s.deadOnly {
 		for i := range s.rules {
 			s.filteredRuleIndices = append(s.filteredRuleIndices, i)
 		}
+		s.sortFilteredByHits()
 		return
 	}
 
@@ -183,6 +264,10 @@ func (s *State) updateFilteredRules() {
 	}
 
 	for i, rule := range s.rules {
+		if s.deadOnly && !s.isDeadRule(i) {
+			continue
+		}
+
 		// 类型过滤检查
 		if hasTypeFilter {
 			matched := false
@@ -215,6 +300,7 @@ func (s *State) updateFilteredRules() {
 
 		s.filteredRuleIndices = append(s.filteredRuleIndices, i)
 	}
+	s.sortFilteredByHits()
 }
 
 // handleTypeFilterMode 处理类型筛选弹窗的按键
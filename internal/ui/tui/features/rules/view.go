@@ -3,7 +3,9 @@ package rules
 import (
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/aimony/mihosh/internal/app/service"
 	"github.com/aimony/mihosh/internal/domain/model"
 	"github.com/aimony/mihosh/internal/ui/tui/components/common"
 	"github.com/aimony/mihosh/pkg/utils"
@@ -17,6 +19,9 @@ const (
 	colorAnimationMs   = 250
 )
 
+// ruleHitColumnsWidth 命中次数与流量两列的宽度
+const ruleHitColumnsWidth = 21
+
 var (
 	domainColorKey         = "Domain"
 	domainSuffixColorKey  = "DomainSuffix"
@@ -47,10 +52,14 @@ var ruleTypeColors = map[string]lipgloss.Color{
 	"Match":         common.CWarning,
 }
 
-// filteredRule 带原始索引的规则
+// filteredRule 带原始索引的规则；分组显示时 Group 非空的为分组行
 type filteredRule struct {
-	Index int        // 原始索引
-	Rule  model.Rule // 规则数据
+	Index int                 // 原始索引
+	Rule  model.Rule          // 规则数据
+	Hit   *service.RuleHitRow // 命中统计，尚无统计时为 nil
+
+	Group    *ruleGroup // 分组行
+	Expanded bool       // 分组是否展开
 }
 
 // PageState 规则页面状态
@@ -72,45 +81,88 @@ type PageState struct {
 	SelectedTypes    []string // 已选择的规则类型
 	AvailableTypes   []string // 可用规则类型列表
 	TypeFilterCursor int      // 光标位置
+
+	// 命中统计
+	HitRows   []service.RuleHitRow // 与 Rules 一一对应，尚无统计时为空
+	HitSort   string               // 排序方式
+	HitWindow time.Duration        // 判定无命中的时间窗口
+	HitsSince time.Time            // 开始统计的时间
+	DeadOnly  bool                 // 只显示无命中的规则
+
+	// 规则匹配模拟
+	ExplainMode    bool                     // 是否处于模拟目标输入模式
+	ExplainInput   string                   // 模拟目标
+	ExplainLoading bool                     // 是否正在模拟
+	Explanation    *service.RuleExplanation // 模拟结果
+	ExplainErr     string                   // 模拟失败原因
+
+	// 分组
+	GroupBy        string          // 分组维度，空为平铺
+	GroupLabel     string          // 分组维度名称
+	ExpandedGroups map[string]bool // 已展开的分组
 }
 
 // RenderRulesPage 渲染规则页面
 func RenderRulesPage(state PageState) string {
 	var sections []string
 
-	// 渲染搜索框
-	searchBox := renderRuleSearchBox(state.FilterText, state.FilterMode, state.SelectedTypes)
-	sections = append(sections, searchBox)
+	// 渲染搜索框（模拟匹配时替换为目标输入框）
+	if state.ExplainMode {
+		sections = append(sections, renderExplainInput(state.ExplainInput))
+	} else {
+		sections = append(sections, renderRuleSearchBox(state.FilterText, state.FilterMode, state.SelectedTypes))
+	}
 	sections = append(sections, "")
 
-	// 过滤规则 (使用缓存的索引)
-	var filteredRules []filteredRule
-	for _, idx := range state.FilteredRuleIndices {
-		if idx >= 0 && idx < len(state.Rules) {
-			filteredRules = append(filteredRules, filteredRule{Index: idx, Rule: state.Rules[idx]})
-		}
-	}
+	// 过滤规则 (使用缓存的索引)，分组时转换为分组行
+	groupBy := ruleGroupBy(state.GroupBy)
+	rows := buildRuleRows(state.Rules, state.HitRows, state.FilteredRuleIndices, groupBy, state.HitSort, state.ExpandedGroups)
 
 	// 渲染统计信息
-	stats := fmt.Sprintf("共 %d 条规则", len(filteredRules))
-	if state.FilterText != "" || len(state.SelectedTypes) > 0 {
+	stats := fmt.Sprintf("共 %d 条规则", len(state.FilteredRuleIndices))
+	if state.FilterText != "" || len(state.SelectedTypes) > 0 || state.DeadOnly {
 		stats += fmt.Sprintf(" (过滤自 %d 条)", len(state.Rules))
 	}
-	sections = append(sections, common.MutedStyle.Render(stats))
+	sections = append(sections, common.MutedStyle.Render(stats)+renderHitSummary(state))
+
+	// 分组概况：各分组的规则数
+	summaryLines := 0
+	if groupBy != "" {
+		groups := groupRules(state.Rules, state.HitRows, state.FilteredRuleIndices, groupBy, service.RuleSortIndex)
+		summary := fmt.Sprintf("按%s分组 %d 组: %s", state.GroupLabel, len(groups), groupSummary(groups, 8))
+		sections = append(sections, common.MutedStyle.Render(utils.TruncateString(summary, state.Width)))
+		summaryLines = 1
+	}
 	sections = append(sections, "")
 
-	// 计算可显示的规则行数 (搜索框 + 统计 + 间隔)
-	availableHeight := state.Height - rulesFixedLines
+	// 模拟结果
+	explainLines := renderExplanation(state)
+	if len(explainLines) > 0 {
+		sections = append(sections, explainLines...)
+		sections = append(sections, "")
+	}
+
+	// 计算可显示的规则行数 (搜索框 + 统计 + 模拟结果 + 间隔)
+	availableHeight := state.Height - rulesFixedLines - summaryLines
+	if len(explainLines) > 0 {
+		availableHeight -= len(explainLines) + 1
+	}
 	if availableHeight < rulesMinHeight {
 		availableHeight = rulesMinHeight
 	}
 
 	// 渲染规则列表（传入颜色调整参数）
-	ruleList := renderRuleList(filteredRules, state.SelectedRule, state.ScrollTop, availableHeight, state.Width, state.ColorAdjustLight, state.ColorAdjustDark)
+	ruleList := renderRuleList(rows, state.SelectedRule, state.ScrollTop, availableHeight, state.Width, state.ColorAdjustLight, state.ColorAdjustDark)
 	sections = append(sections, ruleList)
 
 	// 统一底部的提示信息
-	helpText := "[↑/↓]选择 [/]搜索 [t]类型筛选 [Esc]清除 [r]刷新"
+	helpText := "[↑/↓]选择 [/]搜索 [t]类型筛选 [g]分组 [s]排序 [d]无命中 [x]模拟匹配 [Esc]清除 [r]刷新"
+	if groupBy != "" {
+		helpText = "[↑/↓]选择 [Enter]展开/收起 [z]全部展开/收起 [g]切换分组 [/]搜索 [t]类型筛选 [s]排序 [Esc]清除"
+	}
+	if state.ExplainMode {
+		helpText = "[Enter]模拟 [Esc]取消  格式: 域名、域名:端口 或 IP"
+	}
 	mainContent := strings.Join(sections, "\n")
 	contentLines := strings.Count(mainContent, "\n") + 1
 
@@ -151,6 +203,90 @@ func renderRuleSearchBox(filterText string, filterMode bool, selectedTypes []str
 	return label + input + hint
 }
 
+// renderHitSummary 渲染命中统计概况：无命中规则数、统计覆盖时长与排序方式
+func renderHitSummary(state PageState) string {
+	if state.HitsSince.IsZero() {
+		return common.MutedStyle.Render(" · 等待连接数据以统计命中")
+	}
+	dead := 0
+	for _, row := range state.HitRows {
+		if row.Dead {
+			dead++
+		}
+	}
+	summary := fmt.Sprintf(" · 最近 %s 无命中 %d 条", formatWindow(state.HitWindow), dead)
+	if covered := time.Since(state.HitsSince); covered < state.HitWindow {
+		summary = fmt.Sprintf(" · 统计仅覆盖 %s，满 %s 后标记无命中规则", formatWindow(covered), formatWindow(state.HitWindow))
+	}
+	switch state.HitSort {
+	case service.RuleSortHits:
+		summary += " · 按命中次数排序"
+	case service.RuleSortBytes:
+		summary += " · 按流量排序"
+	}
+	if state.DeadOnly {
+		summary += " · 只看无命中"
+	}
+	return common.MutedStyle.Render(summary)
+}
+
+// formatWindow 以天/小时/分钟显示时长
+func formatWindow(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	default:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+}
+
+// renderExplainInput 渲染模拟匹配目标输入框
+func renderExplainInput(input string) string {
+	inputStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF")).Background(common.CHighlight)
+	return common.MutedStyle.Render("模拟匹配: ") + inputStyle.Render(input+"█")
+}
+
+// renderExplanation 渲染模拟结果：命中规则、节点链路与无法判断的规则数
+func renderExplanation(state PageState) []string {
+	switch {
+	case state.ExplainErr != "":
+		return []string{lipgloss.NewStyle().Foreground(common.CDanger).Render("模拟失败: " + state.ExplainErr)}
+	case state.ExplainLoading:
+		return []string{common.MutedStyle.Render("正在模拟匹配...")}
+	case state.Explanation == nil:
+		return nil
+	}
+
+	exp := state.Explanation
+	target := exp.Target.Host
+	if target == "" {
+		target = exp.Target.IP
+	}
+	if exp.Target.Port > 0 {
+		target += fmt.Sprintf(":%d", exp.Target.Port)
+	}
+	matched := "无规则命中（默认直连）"
+	if m := exp.Matched; m != nil {
+		matched = fmt.Sprintf("#%d %s %s → %s", m.Index, m.Rule.Type, m.Rule.Payload, m.Rule.Proxy)
+	}
+	highlight := lipgloss.NewStyle().Foreground(common.CSuccess).Bold(true)
+	lines := []string{
+		common.MutedStyle.Render("模拟 "+target+": ") + highlight.Render(matched),
+		common.MutedStyle.Render("节点链路: ") + strings.Join(exp.Chain, " → "),
+	}
+	if len(exp.Undecided) > 0 {
+		first := exp.Undecided[0]
+		lines = append(lines, lipgloss.NewStyle().Foreground(common.CWarning).Render(
+			fmt.Sprintf("⚠ 之前有 %d 条规则无法判断，实际可能先命中（如 #%d %s: %s）", len(exp.Undecided), first.Index, first.Rule.Type, first.Reason)))
+	}
+	if exp.Mode != "" && !strings.EqualFold(exp.Mode, "rule") {
+		lines = append(lines, lipgloss.NewStyle().Foreground(common.CWarning).Render("当前为 "+exp.Mode+" 模式，规则不生效"))
+	}
+	return lines
+}
+
 // renderRuleList 渲染规则列表（含整体垂直滚动条）
 func renderRuleList(rules []filteredRule, selectedIdx, scrollTop, maxLines, width int, colorAdjustLight, colorAdjustDark float64) string {
 	if len(rules) == 0 {
@@ -178,7 +314,12 @@ func renderRuleList(rules []filteredRule, selectedIdx, scrollTop, maxLines, widt
 	var lines []string
 	for i := scrollTop; i < endIdx; i++ {
 		fr := rules[i]
-		line := renderRuleEntry(fr.Rule, fr.Index, i == selectedIdx, listWidth, adjustedColors)
+		var line string
+		if fr.Group != nil {
+			line = renderGroupEntry(fr, i == selectedIdx, listWidth, adjustedColors)
+		} else {
+			line = renderRuleEntry(fr, i == selectedIdx, listWidth, adjustedColors)
+		}
 		lines = append(lines, line)
 	}
 	listStr := strings.Join(lines, "\n")
@@ -244,10 +385,18 @@ func calcThumbRange(viewHeight, total, scrollTop int) (start, end int) {
 	return
 }
 
-// renderRuleEntry 渲染单条规则
-func renderRuleEntry(rule model.Rule, index int, selected bool, width int, adjustedColors map[string]lipgloss.Color) string {
+// renderRuleEntry 渲染单条规则（有统计时附带命中次数与流量，无命中的规则变暗）
+func renderRuleEntry(fr filteredRule, selected bool, width int, adjustedColors map[string]lipgloss.Color) string {
+	rule, index := fr.Rule, fr.Index
+	dead := fr.Hit != nil && fr.Hit.Dead
+
 	// 获取规则类型颜色（可能已被调整）
 	color := getAdjustedRuleTypeColor(rule.Type, adjustedColors)
+	payloadColor := common.CSecondary
+	proxyColor := lipgloss.Color("#FFFFFF")
+	if dead {
+		color, payloadColor, proxyColor = common.CGray, common.CGray, common.CGray
+	}
 
 	// 序号
 	indexStyle := lipgloss.NewStyle().Foreground(common.CSuccess).Width(6)
@@ -258,8 +407,11 @@ func renderRuleEntry(rule model.Rule, index int, selected bool, width int, adjus
 	typeStr := typeStyle.Render(rule.Type)
 
 	// Payload
-	payloadStyle := lipgloss.NewStyle().Foreground(common.CSecondary)
+	payloadStyle := lipgloss.NewStyle().Foreground(payloadColor)
 	payloadWidth := width - 50
+	if fr.Hit != nil {
+		payloadWidth -= ruleHitColumnsWidth
+	}
 	if payloadWidth < 20 {
 		payloadWidth = 20
 	}
@@ -269,12 +421,24 @@ func renderRuleEntry(rule model.Rule, index int, selected bool, width int, adjus
 	}
 	payloadStr := payloadStyle.Width(payloadWidth).Render(payload)
 
+	// 命中次数与流量
+	hitStr := ""
+	if hit := fr.Hit; hit != nil {
+		hitStyle := lipgloss.NewStyle().Foreground(common.CInfo).Width(8).Align(lipgloss.Right)
+		bytesStyle := lipgloss.NewStyle().Foreground(common.CInfo).Width(11).Align(lipgloss.Right)
+		if dead {
+			hitStr = hitStyle.Foreground(common.CGray).Render("✗") + " " + bytesStyle.Foreground(common.CGray).Render("-") + " "
+		} else {
+			hitStr = hitStyle.Render(fmt.Sprintf("%d", hit.Hits)) + " " + bytesStyle.Render(utils.FormatBytes(hit.Bytes)) + " "
+		}
+	}
+
 	// 代理
-	proxyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
+	proxyStyle := lipgloss.NewStyle().Foreground(proxyColor)
 	proxyStr := proxyStyle.Render(rule.Proxy)
 
 	// 构建行
-	line := fmt.Sprintf("%s %s %s %s", indexStr, typeStr, payloadStr, proxyStr)
+	line := fmt.Sprintf("%s %s %s %s%s", indexStr, typeStr, payloadStr, hitStr, proxyStr)
 
 	// 选中样式
 	if selected {
@@ -288,6 +452,44 @@ func renderRuleEntry(rule model.Rule, index int, selected bool, width int, adjus
 	return line
 }
 
+// renderGroupEntry 渲染分组行：展开标记、分组名、规则数与合计命中
+func renderGroupEntry(fr filteredRule, selected bool, width int, adjustedColors map[string]lipgloss.Color) string {
+	g := fr.Group
+	marker := "▸"
+	if fr.Expanded {
+		marker = "▾"
+	}
+
+	// 按类型分组时沿用类型颜色
+	nameColor := lipgloss.Color("#FFFFFF")
+	if color, ok := adjustedColors[g.Key]; ok {
+		nameColor = color
+	} else if color, ok := ruleTypeColors[g.Key]; ok {
+		nameColor = color
+	}
+	nameWidth := max(width-50, 20)
+	name := lipgloss.NewStyle().Foreground(nameColor).Bold(true).Width(nameWidth).Render(utils.TruncateString(g.Key, nameWidth))
+	count := lipgloss.NewStyle().Foreground(common.CSuccess).Width(12).Align(lipgloss.Right).Render(fmt.Sprintf("%d 条", len(g.Indices)))
+
+	hitStr := ""
+	if g.HasHits {
+		hitStyle := lipgloss.NewStyle().Foreground(common.CInfo).Width(8).Align(lipgloss.Right)
+		bytesStyle := lipgloss.NewStyle().Foreground(common.CInfo).Width(11).Align(lipgloss.Right)
+		hitStr = " " + hitStyle.Render(fmt.Sprintf("%d", g.Hits)) + " " + bytesStyle.Render(utils.FormatBytes(g.Bytes))
+		if g.Dead > 0 {
+			hitStr += common.DimStyle.Render(fmt.Sprintf("  ✗%d", g.Dead))
+		}
+	}
+
+	line := fmt.Sprintf("%s %s%s%s", common.MutedStyle.Render(marker), name, count, hitStr)
+	if selected {
+		return lipgloss.NewStyle().
+			Background(common.CHighlight).
+			Render(common.SymbolSelectActive + line)
+	}
+	return common.SymbolSelectInactive + line
+}
+
 // adjustedRuleColors 存储调整后的规则类型颜色缓存
 var adjustedRuleColors = make(map[string]lipgloss.Color)
 
@@ -15,8 +15,8 @@ const (
 	settingsMinRowWidth = 40
 )
 
-var SettingKeys = []string{"api-address", "secret", "test-url", "timeout", "proxy-address"}
-var SettingLabels = []string{"API 地址", "密钥", "测速URL", "超时(ms)", "代理地址"}
+var SettingKeys = []string{"api-address", "secret", "test-url", "timeout", "proxy-address", "test-concurrency"}
+var SettingLabels = []string{"API 地址", "密钥", "测速URL", "超时(ms)", "代理地址", "测速并发"}
 
 // PageState 设置页面状态
 type PageState struct {
@@ -44,6 +44,8 @@ func GetSettingValue(cfg *config.Config, index int) string {
 		return fmt.Sprintf("%d", cfg.Timeout)
 	case 4:
 		return cfg.ProxyAddress
+	case 5:
+		return fmt.Sprintf("%d", cfg.TestConcurrency)
 	}
 	return ""
 }
@@ -3,8 +3,10 @@ package messages
 import (
 	"time"
 
+	"github.com/aimony/mihosh/internal/app/service"
 	"github.com/aimony/mihosh/internal/domain/model"
 	"github.com/aimony/mihosh/internal/infrastructure/api"
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 // ========= Global Lifecycle & API Messages =========
@@ -22,6 +24,12 @@ func (e ErrMsg) Error() string { return e.Err.Error() }
 
 type ConfigSavedMsg struct{}
 
+type FavoritesSavedMsg struct {
+	Proxies []string
+	Groups  []string
+	Err     error
+}
+
 type ConfigModeMsg struct {
 	Mode string
 }
@@ -35,7 +43,23 @@ type TestDoneMsg struct {
 }
 
 type TestAllDoneMsg struct {
+	Group   string
 	Results map[string]int
+	Err     error
+}
+
+type PresetsMsg struct {
+	Presets []model.SelectionPreset
+	Notice  string
+	Err     error
+}
+
+type PresetAppliedMsg struct {
+	Name    string
+	Applied int
+	Missing []string // 已不存在的策略组/节点
+	Failed  []string
+	Err     error
 }
 
 // ========= Connections Messages =========
@@ -50,6 +74,47 @@ type ConnectionClosedMsg struct {
 
 type AllConnectionsClosedMsg struct{}
 
+// AutoKillMsg 自动关闭策略命中的连接
+type AutoKillMsg struct {
+	Records []service.KillRecord
+}
+
+// BulkConnectionsClosedMsg 批量关闭连接完成
+type BulkConnectionsClosedMsg struct {
+	Label  string
+	Closed int
+	Failed int
+}
+
+// ConnectionLayoutSavedMsg 连接表列布局已保存
+type ConnectionLayoutSavedMsg struct {
+	Columns []string
+	Sort    string
+	Err     error
+}
+
+// ConnectionsExportedMsg 连接导出完成
+type ConnectionsExportedMsg struct {
+	Path  string
+	Count int
+	Err   error
+}
+
+// ProcessDetailsMsg 按进程聚合时的进程信息（进程名 -> 摘要）
+type ProcessDetailsMsg struct {
+	Details map[string]string
+}
+
+// GeoTagsMsg 连接表目标 IP 的国家/ASN 补全结果
+type GeoTagsMsg struct {
+	Tags map[string]model.GeoTag
+}
+
+// SourceNamesMsg 连接表来源 IP 的设备名识别结果
+type SourceNamesMsg struct {
+	Names map[string]string
+}
+
 type IPInfoMsg struct {
 	Info *model.IPInfo
 	Err  error
@@ -65,6 +130,76 @@ type SiteTestMsg struct {
 
 type RulesMsg []model.Rule
 
+// RuleExplainMsg 规则匹配模拟结果
+type RuleExplainMsg struct {
+	Explanation *service.RuleExplanation
+	Err         error
+}
+
+// ========= Rule Authoring Messages =========
+
+// ProposeRuleMsg 请求为连接或日志条目添加规则（Host、IP 至少一个非空）
+type ProposeRuleMsg struct {
+	Host string
+	IP   string
+}
+
+// RuleTargetsMsg 添加规则时可选的目标策略
+type RuleTargetsMsg struct {
+	RequestID int
+	Names     []string
+	Err       error
+}
+
+// RuleEditPlannedMsg 添加规则的修改预览
+type RuleEditPlannedMsg struct {
+	RequestID int
+	Edit      *service.RuleEdit
+	Err       error
+}
+
+// RuleEditAppliedMsg 规则已写入 mihomo 配置并重载
+type RuleEditAppliedMsg struct {
+	RequestID int
+	Edit      *service.RuleEdit
+	Backup    string
+	Err       error
+}
+
+// ========= Cross-page Navigation Messages =========
+
+// NavKind 跨页跳转类型
+type NavKind int
+
+const (
+	NavToRule            NavKind = iota // 规则页选中规则
+	NavToRuleConnections                // 连接页只显示该规则匹配的活跃与历史连接
+	NavToConnection                     // 连接页打开对应连接的详情
+)
+
+// NavTarget 跨页跳转的聚焦目标
+type NavTarget struct {
+	Kind  NavKind
+	Rule  service.RuleKey // NavToRule、NavToRuleConnections
+	Label string          // 显示用的目标描述，如 DomainSuffix,google.com
+
+	// NavToConnection：按来源与目标定位连接（DestHost 可为域名或 IP）
+	SourceIP   string
+	SourcePort string
+	DestHost   string
+	DestPort   string
+}
+
+// NavigateMsg 请求跳转到其他页面并聚焦目标，Esc 可返回来源页面
+type NavigateMsg struct {
+	Target NavTarget
+}
+
+// Navigate 返回发出跳转请求的命令
+func Navigate(target NavTarget) tea.Cmd {
+	return func() tea.Msg { return NavigateMsg{Target: target} }
+}
+
 // ========= WebSocket Streaming Messages =========
 
 type MemoryWSMsg struct {
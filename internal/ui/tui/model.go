@@ -1,12 +1,15 @@
 package tui
 
 import (
+	"fmt"
 	"github.com/aimony/mihosh/internal/ui/tui/features/connections"
 	"github.com/aimony/mihosh/internal/ui/tui/features/nodes"
 	"github.com/aimony/mihosh/internal/ui/tui/features/logs"
+	"github.com/aimony/mihosh/internal/ui/tui/features/ruleauthor"
 	"github.com/aimony/mihosh/internal/ui/tui/features/rules"
 	"github.com/aimony/mihosh/internal/ui/tui/features/settings"
 	"context"
+	"time"
 
 	"github.com/aimony/mihosh/internal/ui/tui/components/layout"
 
@@ -51,6 +54,20 @@ type Model struct {
 	// IP 解析器
 	ipResolver *service.IPResolver
 
+	// 自动关闭连接策略（未配置时为空）
+	autoKiller *service.AutoKiller
+
+	// 规则命中统计（ruleHitsPath 为空时不保存）
+	ruleHits        *service.RuleHitCounter
+	ruleHitsPath    string
+	ruleHitsSaved   time.Time
+
+	// 跨页跳转返回栈（Esc 返回跳转前的页面）
+	navStack []navEntry
+
+	// 添加规则弹窗（连接页与日志页共用）
+	ruleAuthor ruleauthor.State
+
 	// 五个页面子状态
 	nodesState    nodes.State
 	connsState    connections.State
@@ -69,12 +86,65 @@ func NewModel(client *api.Client, testURL string, timeout int) Model {
 	}
 
 	proxySvc := service.NewProxyService(client, testURL, timeout)
+	proxySvc.SetConcurrency(cfg.TestConcurrency)
+	classifier, err := service.NewNodeClassifierFromConfig(cfg)
+	if err != nil {
+		classifier = service.DefaultNodeClassifier()
+	}
 	configSvc := service.NewConfigService()
 	connSvc := service.NewConnectionService(client)
 
 	wsClient := api.NewWSClient(cfg.APIAddress, cfg.Secret)
 	wsCtx, wsCancel := context.WithCancel(context.Background())
-	ipResolver := service.NewIPResolver()
+	ipResolver, resolverErr := service.NewIPResolverFromConfig(cfg)
+	if resolverErr != nil {
+		ipResolver = service.NewIPResolver()
+		resolverErr = fmt.Errorf("内网 IP 解析源配置无效: %w", resolverErr)
+	}
+	ptrLookups, ptrErr := service.NewPTRLookupsFromConfig(cfg, client)
+	if ptrErr != nil && resolverErr == nil {
+		resolverErr = fmt.Errorf("设备名反查配置无效: %w", ptrErr)
+	}
+	ipResolver.SetHostnameResolver(service.NewHostnameResolver(ptrLookups...))
+	autoKiller, killerErr := service.NewAutoKiller(cfg.KillPolicies, connSvc.CloseConnection, false)
+	if killerErr != nil {
+		killerErr = fmt.Errorf("自动关闭策略未启用: %w", killerErr)
+	}
+	geo, geoErr := service.NewGeoProviderFromConfig(cfg)
+	if geoErr != nil {
+		geo = service.NewHTTPGeoProvider(cfg.GeoHTTPURL)
+		geoErr = fmt.Errorf("离线地理位置库未启用: %w", geoErr)
+	}
+	startErr := killerErr
+	if startErr == nil {
+		startErr = geoErr
+	}
+	if startErr == nil {
+		startErr = resolverErr
+	}
+	ruleHits, ruleHitsPath, ruleHitsErr := newRuleHitCounter()
+	ruleWindow, windowErr := service.ParseStatsWindow(cfg.RuleStatsWindow)
+	if windowErr != nil {
+		ruleWindow = service.DefaultRuleStatsWindow
+		ruleHitsErr = windowErr
+	}
+	if startErr == nil {
+		startErr = ruleHitsErr
+	}
+	geoCache := service.NewGeoCache(geo, 0, 0)
+	var geoCachePath string
+	if cfg.GeoCachePersist {
+		if path, err := service.DefaultGeoCachePath(); err == nil && geoCache.Load(path) == nil {
+			geoCachePath = path
+		}
+	}
+	proxySvc.SetGeoProvider(geoCache)
+	nodesState := nodes.State{
+		TestConcurrency: cfg.TestConcurrency,
+		Classifier:      classifier,
+		FavoriteProxies: cfg.FavoriteProxies,
+		FavoriteGroups:  cfg.FavoriteGroups,
+	}
 
 	return Model{
 		client:        client,
@@ -91,10 +161,15 @@ func NewModel(client *api.Client, testURL string, timeout int) Model {
 		wsCtx:         wsCtx,
 		wsCancel:      wsCancel,
 		ipResolver:    ipResolver,
-		nodesState:    nodes.State{},
-		connsState:    connections.NewState(cfg.ProxyAddress, model.DefaultSiteTests()),
+		autoKiller:    autoKiller,
+		ruleHits:      ruleHits,
+		ruleHitsPath:  ruleHitsPath,
+		ruleHitsSaved: time.Now(),
+		err:           startErr,
+		nodesState:    nodesState,
+		connsState:    connections.NewState(cfg.ProxyAddress, model.DefaultSiteTests()).WithTableLayout(cfg.ConnectionColumns, cfg.ConnectionSort).WithGeoCache(geoCache, geoCachePath),
 		logsState:     logs.NewState(),
-		rulesState:    rules.State{},
+		rulesState:    rules.State{}.WithHitWindow(ruleWindow),
 		settingsState: settings.State{},
 	}
 }
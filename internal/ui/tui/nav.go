@@ -0,0 +1,93 @@
+package tui
+
+import (
+	"github.com/aimony/mihosh/internal/ui/tui/components/layout"
+	"github.com/aimony/mihosh/internal/ui/tui/features/connections"
+	"github.com/aimony/mihosh/internal/ui/tui/features/rules"
+	"github.com/aimony/mihosh/internal/ui/tui/messages"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// navStackLimit 返回栈最多保留的跳转记录
+const navStackLimit = 20
+
+// navEntry 一次跨页跳转：从 from 页跳到 to 页，并记录 to 页被跳转清除前的列表状态
+type navEntry struct {
+	from  layout.PageType
+	to    layout.PageType
+	conns connections.NavSnapshot
+	rules rules.NavSnapshot
+}
+
+// navTargetPage 跳转目标所在的页面
+func navTargetPage(target messages.NavTarget) layout.PageType {
+	if target.Kind == messages.NavToRule {
+		return layout.PageRules
+	}
+	return layout.PageConnections
+}
+
+// navigate 切换到目标页面并聚焦目标，同时记录返回位置
+func (m Model) navigate(target messages.NavTarget) (Model, tea.Cmd) {
+	to := navTargetPage(target)
+	m.navStack = append(m.navStack, navEntry{
+		from:  m.currentPage,
+		to:    to,
+		conns: m.connsState.NavSnapshot(),
+		rules: m.rulesState.NavSnapshot(),
+	})
+	if len(m.navStack) > navStackLimit {
+		m.navStack = m.navStack[len(m.navStack)-navStackLimit:]
+	}
+
+	var focusCmd tea.Cmd
+	switch target.Kind {
+	case messages.NavToRule:
+		m.rulesState = m.rulesState.FocusRule(target.Rule)
+	case messages.NavToRuleConnections:
+		m.connsState = m.connsState.FocusRule(target.Rule, target.Label)
+	case messages.NavToConnection:
+		m.connsState, focusCmd = m.connsState.FocusConnection(target.SourceIP, target.SourcePort, target.DestHost, target.DestPort)
+	}
+
+	if m.currentPage == to {
+		return m, focusCmd
+	}
+	m.currentPage = to
+	return m, tea.Batch(m.onPageChange(), focusCmd)
+}
+
+// canGoBack 当前页面是最近一次跳转的目标，且没有弹窗或过滤需要 Esc 关闭
+func (m Model) canGoBack() bool {
+	if len(m.navStack) == 0 || m.navStack[len(m.navStack)-1].to != m.currentPage {
+		return false
+	}
+	switch m.currentPage {
+	case layout.PageConnections:
+		return m.connsState.CanGoBack()
+	case layout.PageRules:
+		return m.rulesState.CanGoBack()
+	case layout.PageLogs:
+		return m.logsState.CanGoBack()
+	}
+	return true
+}
+
+// goBack 恢复当前页面跳转前的列表状态并返回跳转前的页面
+func (m Model) goBack() (Model, tea.Cmd) {
+	entry := m.navStack[len(m.navStack)-1]
+	m.navStack = m.navStack[:len(m.navStack)-1]
+
+	switch entry.to {
+	case layout.PageConnections:
+		m.connsState = m.connsState.RestoreNav(entry.conns)
+	case layout.PageRules:
+		m.rulesState = m.rulesState.RestoreNav(entry.rules)
+	}
+
+	if m.currentPage == entry.from {
+		return m, nil
+	}
+	m.currentPage = entry.from
+	return m, m.onPageChange()
+}
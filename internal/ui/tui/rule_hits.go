@@ -0,0 +1,61 @@
+package tui
+
+import (
+	"time"
+
+	"github.com/aimony/mihosh/internal/app/service"
+	"github.com/aimony/mihosh/internal/domain/model"
+	"github.com/aimony/mihosh/internal/ui/tui/components/layout"
+	"github.com/aimony/mihosh/internal/ui/tui/features/rules"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ruleHitsSaveInterval 规则命中统计的保存间隔
+const ruleHitsSaveInterval = 5 * time.Minute
+
+// newRuleHitCounter 创建规则命中计数器并载入 ~/.mihosh/rule-hits.json，无法确定路径时只统计不保存
+func newRuleHitCounter() (*service.RuleHitCounter, string, error) {
+	counter := service.NewRuleHitCounter()
+	path, err := service.DefaultRuleHitsPath()
+	if err != nil {
+		return counter, "", err
+	}
+	if err := counter.Load(path); err != nil {
+		return counter, "", err
+	}
+	return counter, path, nil
+}
+
+// observeRuleHits 记录连接推送，在规则页时刷新命中统计，并定期保存
+func (m Model) observeRuleHits(conns []model.Connection) (Model, tea.Cmd) {
+	if m.ruleHits == nil {
+		return m, nil
+	}
+	m.ruleHits.Observe(conns)
+	if m.currentPage == layout.PageRules {
+		m.rulesState = m.refreshRuleHitStats()
+	}
+	if m.ruleHitsPath == "" || time.Since(m.ruleHitsSaved) < ruleHitsSaveInterval {
+		return m, nil
+	}
+	m.ruleHitsSaved = time.Now()
+	counter, path := m.ruleHits, m.ruleHitsPath
+	return m, func() tea.Msg {
+		_ = counter.Save(path)
+		return nil
+	}
+}
+
+// refreshRuleHitStats 用时间窗口内的命中统计更新规则页
+func (m Model) refreshRuleHitStats() rules.State {
+	since := time.Now().Add(-m.rulesState.HitWindow())
+	return m.rulesState.ApplyHitStats(m.ruleHits.Stats(since), m.ruleHits.Since())
+}
+
+// Close 退出前保存规则命中统计
+func (m Model) Close() error {
+	if m.ruleHits == nil || m.ruleHitsPath == "" {
+		return nil
+	}
+	return m.ruleHits.Save(m.ruleHitsPath)
+}
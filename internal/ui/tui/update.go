@@ -1,8 +1,10 @@
 package tui
 
 import (
+	"fmt"
 	"github.com/aimony/mihosh/internal/ui/tui/features/connections"
 	"github.com/aimony/mihosh/internal/ui/tui/features/nodes"
+	"github.com/aimony/mihosh/internal/ui/tui/features/ruleauthor"
 	"github.com/aimony/mihosh/internal/ui/tui/features/rules"
 	"time"
 
@@ -56,6 +58,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	// ── 全局：鼠标事件 ──
 	case tea.MouseMsg:
+		// 添加规则弹窗打开时忽略鼠标
+		if m.ruleAuthor.Active() {
+			return m, nil
+		}
 		switch {
 		case isMouseLeftPress(msg):
 			statusBarHeight := common.StatusBarHeight
@@ -105,12 +111,35 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		// 添加规则弹窗拦截
+		if m.ruleAuthor.Active() && msg.String() != "ctrl+c" {
+			var cmd tea.Cmd
+			m.ruleAuthor, cmd = m.ruleAuthor.Update(msg, m.client)
+			return m, cmd
+		}
+
 		// 全局帮助
 		if msg.String() == "?" {
 			m.showHelp = true
 			return m, nil
 		}
 
+		// 节点页/连接页/规则页文本输入（搜索、预设命名、过滤、模拟匹配）时，除 Ctrl+C 外的按键都交给页面处理
+		if m.currentPage == layout.PageNodes && m.nodesState.TextInputActive() && msg.String() != "ctrl+c" {
+			return m.dispatchKeyToPage(msg)
+		}
+		if m.currentPage == layout.PageConnections && m.connsState.TextInputActive() && msg.String() != "ctrl+c" {
+			return m.dispatchKeyToPage(msg)
+		}
+		if m.currentPage == layout.PageRules && m.rulesState.TextInputActive() && msg.String() != "ctrl+c" {
+			return m.dispatchKeyToPage(msg)
+		}
+
+		// 跨页跳转后，页面没有需要关闭的内容时 Esc 返回来源页面
+		if key.Matches(msg, common.Keys.Escape) && m.canGoBack() {
+			return m.goBack()
+		}
+
 		// 全局快捷键
 		switch {
 		case key.Matches(msg, common.Keys.Quit):
@@ -162,6 +191,29 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case messages.ConfigModeMsg:
 		m.nodesState = m.nodesState.ApplyConfigMode(msg.Mode)
 
+	case messages.PresetsMsg:
+		m.nodesState = m.nodesState.ApplyPresets(msg.Presets, msg.Notice, msg.Err)
+
+	case messages.PresetAppliedMsg:
+		m.nodesState = m.nodesState.ApplyPresetResult(msg.Name, msg.Applied, msg.Missing, msg.Failed, msg.Err)
+		return m, tea.Batch(nodes.FetchGroups(m.client), nodes.FetchProxies(m.client))
+
+	case messages.FavoritesSavedMsg:
+		if msg.Err != nil {
+			m.err = fmt.Errorf("保存收藏失败: %w", msg.Err)
+		} else if m.config != nil {
+			m.config.FavoriteProxies = msg.Proxies
+			m.config.FavoriteGroups = msg.Groups
+		}
+
+	case messages.ConnectionLayoutSavedMsg:
+		if msg.Err != nil {
+			m.err = fmt.Errorf("保存连接列设置失败: %w", msg.Err)
+		} else if m.config != nil {
+			m.config.ConnectionColumns = msg.Columns
+			m.config.ConnectionSort = msg.Sort
+		}
+
 	case messages.ConnectionsMsg:
 		m.connsState = m.connsState.ApplyConnections(msg.Resp)
 		if msg.Resp != nil && m.chartData != nil {
@@ -189,9 +241,39 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.chartData != nil {
 			m.chartData.AddConnCountData(len(msg.Data.Connections))
 		}
+		var cmds []tea.Cmd
+		var hitsCmd tea.Cmd
+		if m, hitsCmd = m.observeRuleHits(msg.Data.Connections); hitsCmd != nil {
+			cmds = append(cmds, hitsCmd)
+		}
+		if !m.autoKiller.Empty() {
+			cmds = append(cmds, connections.AutoKill(m.autoKiller, msg.Data))
+		}
+		if m.currentPage == layout.PageConnections {
+			var cmd tea.Cmd
+			m.connsState, cmd = m.connsState.RequestProcessDetails(m.ipResolver)
+			if cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+			m.connsState, cmd = m.connsState.RequestGeoTags()
+			if cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+			m.connsState, cmd = m.connsState.RequestSourceNames(m.ipResolver)
+			if cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+		}
 		if m.wsMsgChan != nil {
-			return m, listenWSMessages(m.wsCtx, m.wsMsgChan)
+			cmds = append(cmds, listenWSMessages(m.wsCtx, m.wsMsgChan))
+		}
+		return m, tea.Batch(cmds...)
+
+	case messages.AutoKillMsg:
+		for _, record := range msg.Records {
+			m.logsState = m.logsState.AppendLog("warning", "[autokill] "+record.String())
 		}
+		m.connsState = m.connsState.ApplyAutoKill(msg.Records)
 
 	case messages.LogsWSMsg:
 		m.logsState = m.logsState.AppendLog(msg.LogType, msg.Payload)
@@ -204,6 +286,32 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case messages.RulesMsg:
 		m.rulesState = m.rulesState.ApplyRules(msg)
+		if m.ruleHits != nil {
+			m.rulesState = m.refreshRuleHitStats()
+		}
+
+	case messages.NavigateMsg:
+		return m.navigate(msg.Target)
+
+	case messages.ProposeRuleMsg:
+		var cmd tea.Cmd
+		m.ruleAuthor, cmd = ruleauthor.Open(msg.Host, msg.IP, m.client)
+		return m, cmd
+
+	case messages.RuleTargetsMsg:
+		m.ruleAuthor = m.ruleAuthor.ApplyTargets(msg.RequestID, msg.Names, msg.Err)
+
+	case messages.RuleEditPlannedMsg:
+		m.ruleAuthor = m.ruleAuthor.ApplyPlan(msg.RequestID, msg.Edit, msg.Err)
+
+	case messages.RuleEditAppliedMsg:
+		m.ruleAuthor = m.ruleAuthor.ApplyResult(msg.RequestID, msg.Backup, msg.Err)
+		if msg.Err == nil {
+			return m, rules.FetchRules(m.client)
+		}
+
+	case messages.RuleExplainMsg:
+		m.rulesState = m.rulesState.ApplyExplanation(msg.Explanation, msg.Err)
 
 	case messages.SiteTestMsg:
 		m.connsState = m.connsState.ApplySiteTestResult(msg.Name, msg.Delay, msg.Err)
@@ -219,7 +327,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nodes.FetchProxies(m.client)
 
 	case messages.TestAllDoneMsg:
-		m.nodesState = m.nodesState.ApplyTestAllDone(msg.Results)
+		m.nodesState = m.nodesState.ApplyTestAllDone(msg.Group, msg.Results, msg.Err)
+		// 核心不支持整组测速接口时退回逐节点队列
+		if m.nodesState.TestAllActive {
+			var batchCmd tea.Cmd
+			m.nodesState, batchCmd = m.nodesState.LaunchBatchTests(m.client, m.testURL, m.timeout)
+			return m, batchCmd
+		}
 		return m, nodes.FetchProxies(m.client)
 
 	case messages.IPInfoMsg:
@@ -233,6 +347,22 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case messages.AllConnectionsClosedMsg:
 		m.connsState = m.connsState.ApplyAllConnectionsClosed()
 
+	case messages.ProcessDetailsMsg:
+		m.connsState = m.connsState.ApplyProcessDetails(msg.Details)
+
+	case messages.GeoTagsMsg:
+		m.connsState = m.connsState.ApplyGeoTags(msg.Tags)
+
+	case messages.SourceNamesMsg:
+		m.connsState = m.connsState.ApplySourceNames(msg.Names)
+
+	case messages.ConnectionsExportedMsg:
+		m.connsState = m.connsState.ApplyConnectionsExported(msg.Path, msg.Count, msg.Err)
+
+	case messages.BulkConnectionsClosedMsg:
+		m.connsState = m.connsState.ApplyBulkConnectionsClosed(msg.Label, msg.Closed, msg.Failed)
+		return m, connections.FetchConnections(m.client)
+
 	case messages.ConnTickMsg:
 		if m.currentPage == layout.PageConnections {
 			return m, connTick()
@@ -245,13 +375,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case messages.ErrMsg:
 		m.err = msg
-		m.nodesState.Testing = false
-		m.nodesState.TestingTarget = ""
-		m.nodesState.TestAllActive = false
-		m.nodesState.TestAllPending = nil
-		m.nodesState.TestAllRunning = nil
-		m.nodesState.TestAllTotal = 0
-		m.nodesState.TestAllDone = 0
+		m.nodesState = m.nodesState.CancelBatchTests()
 		m.nodesState.TestPending = 0
 	}
 
@@ -278,6 +402,10 @@ func (m Model) dispatchKeyToPage(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		var newCfg, proxyAddr = m.config, ""
 		m.settingsState, newCfg, proxyAddr, cmd = m.settingsState.Update(msg, m.config, m.configSvc)
 		m.config = newCfg
+		if newCfg != nil {
+			m.nodesState.TestConcurrency = newCfg.TestConcurrency
+			m.proxySvc.SetConcurrency(newCfg.TestConcurrency)
+		}
 		if proxyAddr != "" {
 			m.connsState = m.connsState.UpdateProxyAddr(proxyAddr)
 		}
@@ -295,6 +423,9 @@ func (m *Model) onPageChange() tea.Cmd {
 	case layout.PageLogs:
 		return logsTick()
 	case layout.PageRules:
+		if m.ruleHits != nil {
+			m.rulesState = m.refreshRuleHitStats()
+		}
 		return rules.FetchRules(m.client)
 	}
 	return nil
@@ -4,6 +4,7 @@ import (
 	"github.com/aimony/mihosh/internal/ui/styles"
 	"github.com/aimony/mihosh/internal/ui/tui/components/common"
 	"github.com/aimony/mihosh/internal/ui/tui/components/layout"
+	"github.com/aimony/mihosh/internal/ui/tui/features/ruleauthor"
 	"github.com/charmbracelet/lipgloss"
 )
 
@@ -48,6 +49,9 @@ func (m Model) View() string {
 	case layout.PageRules:
 		pageContent = m.renderRulesPage()
 	}
+	if m.ruleAuthor.Active() {
+		pageContent = ruleauthor.Render(m.ruleAuthor, mainWidth-4, contentHeight-2)
+	}
 
 	// ── 主面板 ──
 	pageTitle := layout.GetPageTitle(m.currentPage)
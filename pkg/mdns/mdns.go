@@ -0,0 +1,186 @@
+// Package mdns 通过组播 DNS 反查局域网设备名（RFC 6762 传统单播查询，只发送一次查询）
+package mdns
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	typePTR = 12
+	classIN = 1
+	// unicastResponse QU 位：要求应答方直接单播回复
+	unicastResponse = 0x8000
+
+	defaultTimeout = time.Second
+)
+
+// ErrNotFound 超时前没有设备应答
+var ErrNotFound = errors.New("mDNS 无应答")
+
+// ErrMalformed 报文格式错误
+var ErrMalformed = errors.New("mDNS 报文格式错误")
+
+var ipv4Group = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// ReverseName 返回 IP 的反向解析域名（x.x.x.x.in-addr.arpa. 或 ip6.arpa.）
+func ReverseName(ip net.IP) string {
+	if v4 := ip.To4(); v4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa.", v4[3], v4[2], v4[1], v4[0])
+	}
+	const hexDigits = "0123456789abcdef"
+	var b strings.Builder
+	for i := len(ip) - 1; i >= 0; i-- {
+		b.WriteByte(hexDigits[ip[i]&0x0f])
+		b.WriteByte('.')
+		b.WriteByte(hexDigits[ip[i]>>4])
+		b.WriteByte('.')
+	}
+	b.WriteString("ip6.arpa.")
+	return b.String()
+}
+
+// LookupAddr 向局域网组播查询 IPv4 地址的 PTR 记录，返回去掉末尾点的主机名；
+// ctx 没有截止时间时最多等待 1 秒
+func LookupAddr(ctx context.Context, addr string) (string, error) {
+	ip := net.ParseIP(addr).To4()
+	if ip == nil {
+		return "", fmt.Errorf("仅支持 IPv4: %s", addr)
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultTimeout)
+	}
+
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return "", err
+	}
+
+	var idBuf [2]byte
+	_, _ = rand.Read(idBuf[:])
+	id := binary.BigEndian.Uint16(idBuf[:])
+	name := ReverseName(ip)
+	if _, err := conn.WriteTo(BuildQuery(id, name), ipv4Group); err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, 9000)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return "", ErrNotFound
+			}
+			return "", err
+		}
+		if host, err := ParsePTRAnswer(buf[:n], name); err == nil {
+			return host, nil
+		}
+	}
+}
+
+// BuildQuery 构造一个 PTR 查询报文
+func BuildQuery(id uint16, name string) []byte {
+	msg := make([]byte, 12, 12+len(name)+6)
+	binary.BigEndian.PutUint16(msg[0:], id)
+	binary.BigEndian.PutUint16(msg[4:], 1) // QDCOUNT
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	msg = append(msg, 0)
+	msg = binary.BigEndian.AppendUint16(msg, typePTR)
+	msg = binary.BigEndian.AppendUint16(msg, classIN|unicastResponse)
+	return msg
+}
+
+// ParsePTRAnswer 从应答报文中取出 name 的 PTR 记录
+func ParsePTRAnswer(msg []byte, name string) (string, error) {
+	if len(msg) < 12 || msg[2]&0x80 == 0 {
+		return "", ErrMalformed
+	}
+	qdCount := int(binary.BigEndian.Uint16(msg[4:]))
+	rrCount := int(binary.BigEndian.Uint16(msg[6:])) + int(binary.BigEndian.Uint16(msg[8:])) + int(binary.BigEndian.Uint16(msg[10:]))
+
+	off := 12
+	for i := 0; i < qdCount; i++ {
+		_, next, err := readName(msg, off)
+		if err != nil {
+			return "", err
+		}
+		off = next + 4
+	}
+	want := strings.TrimSuffix(name, ".")
+	for i := 0; i < rrCount; i++ {
+		owner, next, err := readName(msg, off)
+		if err != nil {
+			return "", err
+		}
+		if next+10 > len(msg) {
+			return "", ErrMalformed
+		}
+		rrType := binary.BigEndian.Uint16(msg[next:])
+		rdLen := int(binary.BigEndian.Uint16(msg[next+8:]))
+		rdata := next + 10
+		if rdata+rdLen > len(msg) {
+			return "", ErrMalformed
+		}
+		if rrType == typePTR && strings.EqualFold(owner, want) {
+			host, _, err := readName(msg, rdata)
+			if err != nil {
+				return "", err
+			}
+			return host, nil
+		}
+		off = rdata + rdLen
+	}
+	return "", ErrNotFound
+}
+
+// readName 读取（可能压缩的）域名，返回不带末尾点的名称与名称之后的偏移
+func readName(msg []byte, off int) (string, int, error) {
+	var labels []string
+	next := -1
+	for jumps := 0; ; {
+		if off >= len(msg) {
+			return "", 0, ErrMalformed
+		}
+		l := int(msg[off])
+		switch {
+		case l == 0:
+			if next < 0 {
+				next = off + 1
+			}
+			return strings.Join(labels, "."), next, nil
+		case l&0xc0 == 0xc0:
+			if off+1 >= len(msg) || jumps > 16 {
+				return "", 0, ErrMalformed
+			}
+			if next < 0 {
+				next = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3fff)
+			jumps++
+		default:
+			if off+1+l > len(msg) {
+				return "", 0, ErrMalformed
+			}
+			labels = append(labels, string(msg[off+1:off+1+l]))
+			off += 1 + l
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package mdns
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+)
+
+// buildResponse 构造对 BuildQuery 的应答，PTR 数据使用名称压缩指向问题中的 "arpa"
+func buildResponse(t *testing.T, query []byte, host string) []byte {
+	t.Helper()
+	msg := append([]byte(nil), query...)
+	msg[2] |= 0x84                         // QR + AA
+	binary.BigEndian.PutUint16(msg[6:], 1) // ANCOUNT
+	msg = append(msg, 0xc0, 12)            // 所有者名称指向问题
+	msg = binary.BigEndian.AppendUint16(msg, typePTR)
+	msg = binary.BigEndian.AppendUint16(msg, classIN)
+	msg = binary.BigEndian.AppendUint32(msg, 120)
+	rdata := []byte{byte(len(host))}
+	rdata = append(rdata, host...)
+	rdata = append(rdata, 5)
+	rdata = append(rdata, "local"...)
+	rdata = append(rdata, 0)
+	msg = binary.BigEndian.AppendUint16(msg, uint16(len(rdata)))
+	return append(msg, rdata...)
+}
+
+func TestReverseName(t *testing.T) {
+	if got := ReverseName(net.ParseIP("192.168.1.20")); got != "20.1.168.192.in-addr.arpa." {
+		t.Fatalf("unexpected v4 reverse name: %s", got)
+	}
+	got := ReverseName(net.ParseIP("2001:db8::1"))
+	want := "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa."
+	if got != want {
+		t.Fatalf("unexpected v6 reverse name: %s", got)
+	}
+}
+
+func TestParsePTRAnswer(t *testing.T) {
+	name := ReverseName(net.ParseIP("192.168.1.20"))
+	query := BuildQuery(0x1234, name)
+	if len(query) != 12+len(name)+1+4 {
+		t.Fatalf("unexpected query length %d", len(query))
+	}
+
+	host, err := ParsePTRAnswer(buildResponse(t, query, "Alices-iPhone"), name)
+	if err != nil || host != "Alices-iPhone.local" {
+		t.Fatalf("got %q, %v", host, err)
+	}
+
+	if _, err := ParsePTRAnswer(buildResponse(t, query, "x"), "21.1.168.192.in-addr.arpa."); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound for other name, got %v", err)
+	}
+	if _, err := ParsePTRAnswer(query, name); err != ErrMalformed {
+		t.Fatalf("queries must be rejected, got %v", err)
+	}
+	resp := buildResponse(t, query, "host")
+	if _, err := ParsePTRAnswer(resp[:len(resp)-3], name); err == nil {
+		t.Fatalf("truncated response must fail")
+	}
+}
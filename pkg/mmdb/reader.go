@@ -0,0 +1,363 @@
+// Package mmdb 只读的 MaxMind DB（.mmdb）解析器，支持 GeoLite2/DB-IP 及 mihomo 的 Country.mmdb、geoip.metadb
+package mmdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+)
+
+var metadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// ErrInvalidDatabase 文件不是有效的 MMDB 数据库
+var ErrInvalidDatabase = errors.New("无效的 MMDB 数据库")
+
+// Metadata 数据库元数据
+type Metadata struct {
+	DatabaseType string
+	IPVersion    int
+	NodeCount    int
+	RecordSize   int
+	BuildEpoch   uint64
+	Languages    []string
+}
+
+// Reader MMDB 数据库
+type Reader struct {
+	buf       []byte
+	data      []byte // 数据段
+	meta      Metadata
+	nodeSize  int
+	ipv4Start int // IPv6 树中 ::/96 子树的起点
+}
+
+// Open 读取 MMDB 文件
+func Open(path string) (*Reader, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := FromBytes(buf)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// FromBytes 从内存中的数据库内容创建 Reader
+func FromBytes(buf []byte) (*Reader, error) {
+	idx := bytes.LastIndex(buf, metadataMarker)
+	if idx < 0 {
+		return nil, ErrInvalidDatabase
+	}
+	metaStart := idx + len(metadataMarker)
+	d := decoder{buf: buf[metaStart:]}
+	raw, _, err := d.decode(0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 元数据: %v", ErrInvalidDatabase, err)
+	}
+	m, ok := raw.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: 元数据不是 map", ErrInvalidDatabase)
+	}
+
+	meta := Metadata{
+		DatabaseType: asString(m["database_type"]),
+		IPVersion:    int(asUint(m["ip_version"])),
+		NodeCount:    int(asUint(m["node_count"])),
+		RecordSize:   int(asUint(m["record_size"])),
+		BuildEpoch:   asUint(m["build_epoch"]),
+	}
+	if langs, ok := m["languages"].([]any); ok {
+		for _, l := range langs {
+			meta.Languages = append(meta.Languages, asString(l))
+		}
+	}
+	switch meta.RecordSize {
+	case 24, 28, 32:
+	default:
+		return nil, fmt.Errorf("%w: 不支持的 record_size %d", ErrInvalidDatabase, meta.RecordSize)
+	}
+	if meta.IPVersion != 4 && meta.IPVersion != 6 {
+		return nil, fmt.Errorf("%w: 不支持的 ip_version %d", ErrInvalidDatabase, meta.IPVersion)
+	}
+
+	nodeSize := meta.RecordSize / 4
+	treeSize := meta.NodeCount * nodeSize
+	if treeSize+16 > idx {
+		return nil, fmt.Errorf("%w: 搜索树越界", ErrInvalidDatabase)
+	}
+	r := &Reader{
+		buf:      buf,
+		data:     buf[treeSize+16 : idx],
+		meta:     meta,
+		nodeSize: nodeSize,
+	}
+	// 在创建时算好，Lookup 可在多个 goroutine 中并发调用
+	if meta.IPVersion == 6 {
+		r.ipv4Start = r.ipv4StartNode()
+	}
+	return r, nil
+}
+
+// Metadata 数据库元数据
+func (r *Reader) Metadata() Metadata {
+	return r.meta
+}
+
+// Lookup 查找 IP 对应的记录，未收录时 found 为 false
+func (r *Reader) Lookup(ip net.IP) (record any, found bool, err error) {
+	bits := ip.To4()
+	start := 0
+	if bits == nil {
+		bits = ip.To16()
+		if bits == nil {
+			return nil, false, fmt.Errorf("无效的 IP 地址: %v", ip)
+		}
+		if r.meta.IPVersion == 4 {
+			return nil, false, nil
+		}
+	} else if r.meta.IPVersion == 6 {
+		start = r.ipv4Start
+	}
+
+	node := start
+	for i := 0; i < len(bits)*8 && node < r.meta.NodeCount; i++ {
+		bit := (bits[i/8] >> (7 - uint(i%8))) & 1
+		node = r.readRecord(node, int(bit))
+	}
+
+	switch {
+	case node == r.meta.NodeCount:
+		return nil, false, nil
+	case node < r.meta.NodeCount:
+		return nil, false, fmt.Errorf("%w: 搜索树未在叶子结束", ErrInvalidDatabase)
+	}
+	offset := node - r.meta.NodeCount - 16
+	if offset < 0 || offset >= len(r.data) {
+		return nil, false, fmt.Errorf("%w: 数据指针越界", ErrInvalidDatabase)
+	}
+	d := decoder{buf: r.data}
+	record, _, err = d.decode(offset)
+	if err != nil {
+		return nil, false, err
+	}
+	return record, true, nil
+}
+
+// ipv4StartNode 沿 96 个 0 位走到 IPv6 树中 ::/96 子树的起点
+func (r *Reader) ipv4StartNode() int {
+	node := 0
+	for i := 0; i < 96 && node < r.meta.NodeCount; i++ {
+		node = r.readRecord(node, 0)
+	}
+	return node
+}
+
+func (r *Reader) readRecord(node, bit int) int {
+	b := r.buf[node*r.nodeSize : (node+1)*r.nodeSize]
+	switch r.meta.RecordSize {
+	case 24:
+		b = b[bit*3:]
+		return int(b[0])<<16 | int(b[1])<<8 | int(b[2])
+	case 28:
+		if bit == 0 {
+			return int(b[3]&0xF0)<<20 | int(b[0])<<16 | int(b[1])<<8 | int(b[2])
+		}
+		return int(b[3]&0x0F)<<24 | int(b[4])<<16 | int(b[5])<<8 | int(b[6])
+	default:
+		return int(binary.BigEndian.Uint32(b[bit*4:]))
+	}
+}
+
+// 数据段字段类型
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+// decoder 数据段解码器
+type decoder struct {
+	buf   []byte
+	depth int
+}
+
+// decode 解码 offset 处的值，返回值与下一个字段的偏移
+func (d *decoder) decode(offset int) (any, int, error) {
+	if d.depth > 64 {
+		return nil, 0, fmt.Errorf("%w: 数据嵌套过深", ErrInvalidDatabase)
+	}
+	typ, size, offset, err := d.header(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if typ == typePointer {
+		d.depth++
+		v, _, err := d.decode(size)
+		d.depth--
+		return v, offset, err
+	}
+
+	if typ != typeMap && typ != typeArray && typ != typeBool && offset+size > len(d.buf) {
+		return nil, 0, fmt.Errorf("%w: 数据越界", ErrInvalidDatabase)
+	}
+	switch typ {
+	case typeString:
+		return string(d.buf[offset : offset+size]), offset + size, nil
+	case typeBytes:
+		return append([]byte(nil), d.buf[offset:offset+size]...), offset + size, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, fmt.Errorf("%w: double 长度 %d", ErrInvalidDatabase, size)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(d.buf[offset:])), offset + 8, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, fmt.Errorf("%w: float 长度 %d", ErrInvalidDatabase, size)
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(d.buf[offset:]))), offset + 4, nil
+	case typeUint16, typeUint32, typeUint64:
+		if size > 8 {
+			return nil, 0, fmt.Errorf("%w: 整数长度 %d", ErrInvalidDatabase, size)
+		}
+		var v uint64
+		for _, b := range d.buf[offset : offset+size] {
+			v = v<<8 | uint64(b)
+		}
+		return v, offset + size, nil
+	case typeInt32:
+		var v uint32
+		for _, b := range d.buf[offset : offset+size] {
+			v = v<<8 | uint32(b)
+		}
+		return int64(int32(v)), offset + size, nil
+	case typeUint128:
+		return append([]byte(nil), d.buf[offset:offset+size]...), offset + size, nil
+	case typeBool:
+		return size != 0, offset, nil
+	case typeMap:
+		m := make(map[string]any, size)
+		d.depth++
+		defer func() { d.depth-- }()
+		for i := 0; i < size; i++ {
+			k, next, err := d.decode(offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, 0, fmt.Errorf("%w: map 的键不是字符串", ErrInvalidDatabase)
+			}
+			v, next, err := d.decode(next)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[key] = v
+			offset = next
+		}
+		return m, offset, nil
+	case typeArray:
+		arr := make([]any, 0, size)
+		d.depth++
+		defer func() { d.depth-- }()
+		for i := 0; i < size; i++ {
+			v, next, err := d.decode(offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			arr = append(arr, v)
+			offset = next
+		}
+		return arr, offset, nil
+	}
+	return nil, 0, fmt.Errorf("%w: 未知的数据类型 %d", ErrInvalidDatabase, typ)
+}
+
+// header 解析控制字节，指针类型时 size 为目标偏移
+func (d *decoder) header(offset int) (typ, size, next int, err error) {
+	if offset >= len(d.buf) {
+		return 0, 0, 0, fmt.Errorf("%w: 数据越界", ErrInvalidDatabase)
+	}
+	ctrl := d.buf[offset]
+	offset++
+	typ = int(ctrl >> 5)
+
+	if typ == typePointer {
+		ss := int(ctrl>>3) & 0x3
+		need := ss + 1
+		if offset+need > len(d.buf) {
+			return 0, 0, 0, fmt.Errorf("%w: 指针越界", ErrInvalidDatabase)
+		}
+		b := d.buf[offset : offset+need]
+		vvv := int(ctrl & 0x7)
+		var ptr int
+		switch ss {
+		case 0:
+			ptr = vvv<<8 | int(b[0])
+		case 1:
+			ptr = (vvv<<16 | int(b[0])<<8 | int(b[1])) + 2048
+		case 2:
+			ptr = (vvv<<24 | int(b[0])<<16 | int(b[1])<<8 | int(b[2])) + 526336
+		default:
+			ptr = int(binary.BigEndian.Uint32(b))
+		}
+		return typ, ptr, offset + need, nil
+	}
+
+	if typ == typeExtended {
+		if offset >= len(d.buf) {
+			return 0, 0, 0, fmt.Errorf("%w: 数据越界", ErrInvalidDatabase)
+		}
+		typ = 7 + int(d.buf[offset])
+		offset++
+	}
+
+	size = int(ctrl & 0x1f)
+	if size >= 29 {
+		n := size - 28
+		if offset+n > len(d.buf) {
+			return 0, 0, 0, fmt.Errorf("%w: 数据越界", ErrInvalidDatabase)
+		}
+		var v int
+		for _, b := range d.buf[offset : offset+n] {
+			v = v<<8 | int(b)
+		}
+		size = [...]int{29, 285, 65821}[n-1] + v
+		offset += n
+	}
+	return typ, size, offset, nil
+}
+
+func asString(v any) string {
+	s, _ := v.(string)
+	return s
+}
+
+func asUint(v any) uint64 {
+	switch n := v.(type) {
+	case uint64:
+		return n
+	case int64:
+		return uint64(n)
+	}
+	return 0
+}
@@ -0,0 +1,115 @@
+package mmdb
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aimony/mihosh/internal/testutil/mmdbtest"
+)
+
+func buildTestDB(t *testing.T, ipVersion int) *Reader {
+	t.Helper()
+	b := mmdbtest.NewBuilder("GeoLite2-Country", ipVersion)
+	records := map[string]any{
+		"1.1.1.0/24": map[string]any{
+			"country": map[string]any{
+				"iso_code": "AU",
+				"names":    map[string]any{"en": "Australia"},
+			},
+			"location":                 map[string]any{"latitude": -33.49, "longitude": 143.21},
+			"autonomous_system_number": uint32(13335),
+		},
+		"8.8.0.0/16": map[string]any{"tags": []any{"google", true, 42}},
+	}
+	if ipVersion == 6 {
+		records["2001:db8::/32"] = "docs-" + strings.Repeat("x", 300)
+	}
+	for cidr, rec := range records {
+		if err := b.Insert(cidr, rec); err != nil {
+			t.Fatalf("Insert(%s): %v", cidr, err)
+		}
+	}
+	buf, err := b.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	r, err := FromBytes(buf)
+	if err != nil {
+		t.Fatalf("FromBytes: %v", err)
+	}
+	return r
+}
+
+func TestLookup(t *testing.T) {
+	for _, version := range []int{4, 6} {
+		r := buildTestDB(t, version)
+		if got := r.Metadata(); got.DatabaseType != "GeoLite2-Country" || got.IPVersion != version || got.RecordSize != 32 {
+			t.Fatalf("v%d metadata = %+v", version, got)
+		}
+
+		rec, found, err := r.Lookup(net.ParseIP("1.1.1.1"))
+		if err != nil || !found {
+			t.Fatalf("v%d Lookup(1.1.1.1) found=%v err=%v", version, found, err)
+		}
+		m := rec.(map[string]any)
+		country := m["country"].(map[string]any)
+		if country["iso_code"] != "AU" || country["names"].(map[string]any)["en"] != "Australia" {
+			t.Errorf("v%d country = %v", version, country)
+		}
+		if m["autonomous_system_number"] != uint64(13335) {
+			t.Errorf("v%d asn = %v", version, m["autonomous_system_number"])
+		}
+		if lat := m["location"].(map[string]any)["latitude"]; lat != -33.49 {
+			t.Errorf("v%d latitude = %v", version, lat)
+		}
+
+		rec, found, _ = r.Lookup(net.ParseIP("8.8.4.4"))
+		tags := rec.(map[string]any)["tags"].([]any)
+		if !found || tags[0] != "google" || tags[1] != true || tags[2] != int64(42) {
+			t.Errorf("v%d tags = %v", version, tags)
+		}
+
+		if _, found, err := r.Lookup(net.ParseIP("9.9.9.9")); found || err != nil {
+			t.Errorf("v%d Lookup(9.9.9.9) found=%v err=%v", version, found, err)
+		}
+	}
+}
+
+func TestLookupIPv6(t *testing.T) {
+	r := buildTestDB(t, 6)
+	rec, found, err := r.Lookup(net.ParseIP("2001:db8::1"))
+	if err != nil || !found || !strings.HasPrefix(rec.(string), "docs-xxx") || len(rec.(string)) != 305 {
+		t.Fatalf("Lookup(2001:db8::1) = %v, %v, %v", rec, found, err)
+	}
+
+	// IPv4 数据库查询 IPv6 地址视为未收录
+	r4 := buildTestDB(t, 4)
+	if _, found, err := r4.Lookup(net.ParseIP("2001:db8::1")); found || err != nil {
+		t.Errorf("v4 db Lookup(v6) found=%v err=%v", found, err)
+	}
+}
+
+func TestLookupConcurrent(t *testing.T) {
+	// GeoCache 会在多个 goroutine 中同时查询同一个 Reader
+	r := buildTestDB(t, 6)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, found, err := r.Lookup(net.ParseIP("1.1.1.1")); err != nil || !found {
+				t.Errorf("Lookup(1.1.1.1) found=%v err=%v", found, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestFromBytesInvalid(t *testing.T) {
+	if _, err := FromBytes([]byte("not a database")); !errors.Is(err, ErrInvalidDatabase) {
+		t.Errorf("err = %v, want ErrInvalidDatabase", err)
+	}
+}
@@ -6,15 +6,22 @@ import "strings"
 func DisplayWidth(s string) int {
 	width := 0
 	for _, r := range s {
-		if r > 127 {
-			width += 2
-		} else {
-			width++
-		}
+		width += runeWidth(r)
 	}
 	return width
 }
 
+// runeWidth 单个字符的显示宽度；旗帜由两个区域指示符组成，整体占 2 个单位
+func runeWidth(r rune) int {
+	switch {
+	case r >= 0x1F1E6 && r <= 0x1F1FF:
+		return 1
+	case r > 127:
+		return 2
+	}
+	return 1
+}
+
 // PadString 将字符串填充到指定显示宽度
 func PadString(s string, targetWidth int) string {
 	currentWidth := DisplayWidth(s)
@@ -33,12 +40,7 @@ func TruncateString(s string, maxWidth int) string {
 	result := ""
 	currentWidth := 0
 	for _, r := range s {
-		var rw int
-		if r > 127 {
-			rw = 2
-		} else {
-			rw = 1
-		}
+		rw := runeWidth(r)
 		if currentWidth+rw > maxWidth-2 {
 			break
 		}
@@ -47,3 +49,18 @@ func TruncateString(s string, maxWidth int) string {
 	}
 	return result + ".."
 }
+
+// CountryFlag 将两位国家代码转换为旗帜 emoji，无效代码返回空
+func CountryFlag(code string) string {
+	if len(code) != 2 {
+		return ""
+	}
+	var b strings.Builder
+	for _, c := range strings.ToUpper(code) {
+		if c < 'A' || c > 'Z' {
+			return ""
+		}
+		b.WriteRune(0x1F1E6 + c - 'A')
+	}
+	return b.String()
+}